@@ -1,18 +1,50 @@
 package main
 
 import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/approval"
+	"github.com/aadityansha/autogit/internal/backup"
+	"github.com/aadityansha/autogit/internal/breaker"
 	"github.com/aadityansha/autogit/internal/config"
 	"github.com/aadityansha/autogit/internal/daemon"
+	"github.com/aadityansha/autogit/internal/fleet"
 	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/gogit"
+	"github.com/aadityansha/autogit/internal/hg"
+	"github.com/aadityansha/autogit/internal/history"
+	"github.com/aadityansha/autogit/internal/jj"
+	"github.com/aadityansha/autogit/internal/ignore"
+	"github.com/aadityansha/autogit/internal/pathutil"
+	"github.com/aadityansha/autogit/internal/proc"
+	"github.com/aadityansha/autogit/internal/server"
+	"github.com/aadityansha/autogit/internal/service"
+	"github.com/aadityansha/autogit/internal/spend"
+	"github.com/aadityansha/autogit/internal/stats"
 	"github.com/aadityansha/autogit/internal/tui"
+	"github.com/aadityansha/autogit/internal/vcs"
+	"github.com/aadityansha/autogit/internal/wsl"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 const Version = "1.0.0"
@@ -27,23 +59,67 @@ var rootCmd = &cobra.Command{
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize autogit daemon for the current repository",
-	Long:  "Detects the Git root directory and starts a background daemon that monitors for changes.",
+	Long:  "Detects the Git root directory and starts a background daemon that monitors for changes. Outside a git repository, a Mercurial root is registered with vcs \"hg\" (experimental).\n\nFor bare repositories such as dotfiles, pass --git-dir and --work-tree explicitly.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Detect Git root
-		rootPath, err := git.GetRootPath()
-		if err != nil {
-			return fmt.Errorf("failed to detect Git root: %w", err)
+		gitDir, _ := cmd.Flags().GetString("git-dir")
+		workTree, _ := cmd.Flags().GetString("work-tree")
+		
+		var rootPath string
+		var repo vcs.Repo
+		if gitDir != "" {
+			if workTree == "" {
+				return fmt.Errorf("--work-tree is required with --git-dir")
+			}
+			var err error
+			if gitDir, err = filepath.Abs(gitDir); err != nil {
+				return fmt.Errorf("failed to resolve git dir: %w", err)
+			}
+			if rootPath, err = filepath.Abs(workTree); err != nil {
+				return fmt.Errorf("failed to resolve work tree: %w", err)
+			}
+			
+			// Make sure the pair actually forms a repository
+			repo = git.OpenLocation(git.Location{GitDir: gitDir, WorkTree: rootPath})
+			if _, err := repo.HasChanges(); err != nil {
+				return fmt.Errorf("invalid git dir / work tree: %w", err)
+			}
+			
+			fmt.Printf("Using git dir: %s\n", gitDir)
+			fmt.Printf("Using work tree: %s\n", rootPath)
+		} else {
+			// Detect Git root, or else a Mercurial one
+			var err error
+			rootPath, err = getRootPath()
+			if hgRoot, hgErr := getHgRootPath(); err != nil && hgErr == nil {
+				rootPath, repo = hgRoot, hg.Open(hgRoot)
+				fmt.Printf("Detected Mercurial root: %s (experimental)\n", rootPath)
+			} else if err != nil {
+				return fmt.Errorf("failed to detect Git root: %w", err)
+			} else {
+				repo = openGit(rootPath)
+				fmt.Printf("Detected Git root: %s\n", rootPath)
+			}
+		}
+		
+		// The first cycle would commit whatever state the repository is in
+		if err := repo.CheckState(); err != nil {
+			return fmt.Errorf("not starting autogit: %w\nThen run 'autogit init' again", err)
 		}
 		
-		fmt.Printf("Detected Git root: %s\n", rootPath)
+		if boundary := wsl.Detect(rootPath); boundary.CrossBoundary {
+			fmt.Printf("⚠ Warning: %s\n", boundary.Describe())
+			fmt.Printf("  File watching is unreliable and git is slow across this boundary.\n")
+			fmt.Printf("  The daemon will poll every %s or longer instead of watching files.\n", config.MinCrossBoundaryInterval)
+			fmt.Printf("  For best results keep the repository on the same side as autogit.\n")
+		}
 		
 		// Check if daemon already exists for this repo
-		daemonInfo, _ := config.LoadDaemonInfo()
-		if daemonInfo != nil && daemonInfo.RepoPath == rootPath {
-			// Check if process is still running
-			if isProcessRunning(daemonInfo.PID) {
-				return fmt.Errorf("daemon is already running for this repository (PID: %d)", daemonInfo.PID)
-			}
+		daemonInfo, stale, _ := config.LoadLiveDaemonInfo(config.StateName(rootPath))
+		if stale != nil {
+			fmt.Printf("Removed stale record of daemon PID %d, which no longer runs\n", stale.PID)
+		}
+		if daemonInfo != nil {
+			return fmt.Errorf("daemon is already running for this repository (PID: %d)", daemonInfo.PID)
 		}
 		
 		// Load config
@@ -52,15 +128,57 @@ var initCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 		
-		// Validate API key before starting daemon
-		if err := ai.ValidateAPIKey(cfg.AIProvider, cfg.APIKey, cfg.BaseURL); err != nil {
-			return fmt.Errorf("API key validation failed: %w\nPlease configure your API key using 'autogit --menu'", err)
+		// Validate API key before starting daemon, unless messages are
+		// generated locally
+		if rc := cfg.ForRepo(rootPath); rc.MessageSource != config.MessageHeuristic && !rc.AIDisabled() && ai.NeedsAPIKey(cfg.AIProvider) {
+			if err := ai.ValidateAPIKey(cfg.AIProvider, cfg.APIKey, cfg.BaseURL); err != nil {
+				if cfg.APIKeyEnvOnly {
+					return fmt.Errorf("API key validation failed: %w\nPlease set %s", err, strings.Join(config.APIKeyVars(cfg.AIProvider), " or "))
+				}
+				return fmt.Errorf("API key validation failed: %w\nPlease configure your API key using 'autogit setup'", err)
+			}
+			
+			fmt.Printf("✓ API key validated successfully\n")
+			if err := checkModel(cfg); err != nil {
+				return fmt.Errorf("model validation failed: %w\nPick one of the provider's models in 'autogit menu'", err)
+			}
 		}
 		
-		fmt.Printf("✓ API key validated successfully\n")
-		
 		// Update root path in config
 		cfg.RootPath = rootPath
+		if gitDir != "" {
+			rc, _ := cfg.FindRepo(rootPath)
+			rc.Path = rootPath
+			rc.GitDir = gitDir
+			rc.WorkTree = rootPath
+			// A work tree such as $HOME is full of files that must never be added
+			rc.TrackedOnly = true
+			cfg.SetRepo(rc)
+		}
+		if _, ok := repo.(*hg.Repo); ok {
+			rc, _ := cfg.FindRepo(rootPath)
+			rc.Path = rootPath
+			rc.VCS = config.VCSHg
+			cfg.SetRepo(rc)
+		}
+		if err := identify(cfg, repo); err != nil {
+			return err
+		}
+		
+		// --set reaches the daemon as AUTOGIT_REPO_ variables, leaving the
+		// config file as it is
+		sets, _ := cmd.Flags().GetStringArray("set")
+		for _, set := range sets {
+			key, value, ok := strings.Cut(set, "=")
+			if !ok {
+				return fmt.Errorf("--set %s: expected key=value", set)
+			}
+			os.Setenv(config.RepoEnvName(cfg.ForRepo(rootPath), key), value)
+		}
+		if _, problems := config.RepoEnvOverrides(cfg.ForRepo(rootPath)); len(problems) > 0 {
+			return fmt.Errorf("invalid settings in the environment:\n  %s", strings.Join(problems, "\n  "))
+		}
+		
 		if err := config.SaveConfig(cfg); err != nil {
 			return fmt.Errorf("failed to save config: %w", err)
 		}
@@ -81,11 +199,31 @@ var initCmd = &cobra.Command{
 var menuCmd = &cobra.Command{
 	Use:   "menu",
 	Short: "Open interactive TUI dashboard",
-	Long:  "Opens a terminal UI with dashboard, logs, and settings tabs.",
+	Long:  "Opens a terminal UI with dashboard, logs, and settings tabs.\n\nWith --basic, the same functions are offered as plain sequential prompts without full-screen rendering, for screen readers and limited terminals.\n\nWith --demo, the dashboard shows synthetic repositories, logs, statistics and approvals from a temporary directory, to explore it without a repository or API key. The real config and repositories are not touched.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		m, err := tui.NewModel()
-		if err != nil {
-			return fmt.Errorf("failed to initialize TUI: %w", err)
+		basic, _ := cmd.Flags().GetBool("basic")
+		demo, _ := cmd.Flags().GetBool("demo")
+		if basic && demo {
+			return fmt.Errorf("--demo works with the full-screen dashboard only")
+		}
+		if basic {
+			return tui.RunBasic(os.Stdin, os.Stdout, repoRootPath())
+		}
+		
+		var m tea.Model
+		if demo {
+			demoModel, cleanup, err := tui.NewDemoModel()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			m = demoModel
+		} else {
+			model, err := tui.NewModel(repoRootPath())
+			if err != nil {
+				return fmt.Errorf("failed to initialize TUI: %w", err)
+			}
+			m = model
 		}
 		
 		p := tea.NewProgram(m, tea.WithAltScreen())
@@ -97,6 +235,37 @@ var menuCmd = &cobra.Command{
 	},
 }
 
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Walk through the settings autogit needs, step by step",
+	Long:  "Asks for the AI provider, checks the API key with it, and asks for the check interval and which changes to commit, then saves the config. Run in a repository where autogit is not running yet, it offers to start autogit there as 'autogit init' does.\n\nThe model, base URL and every other setting can be changed later with 'autogit menu' or 'autogit config'.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Offer to initialize the current repository, unless it already runs
+		rootPath, _ := getRootPath()
+		if rootPath != "" {
+			if daemonInfo, _, _ := config.LoadLiveDaemonInfo(config.StateName(rootPath)); daemonInfo != nil {
+				rootPath = ""
+			}
+		}
+		
+		result, err := tui.RunSetup(rootPath)
+		if err != nil {
+			return err
+		}
+		if !result.Saved {
+			fmt.Println("Setup cancelled, nothing was saved")
+			return nil
+		}
+		fmt.Printf("✓ Settings saved to %s\n", config.GetConfigPath())
+		
+		if !result.Init {
+			fmt.Println("Run 'autogit init' in a repository to start committing")
+			return nil
+		}
+		return initCmd.RunE(initCmd, nil)
+	},
+}
+
 var startDaemonCmd = &cobra.Command{
 	Use:    "start-daemon",
 	Short:  "Internal command to start daemon (do not call directly)",
@@ -120,6 +289,11 @@ var startDaemonCmd = &cobra.Command{
 			return fmt.Errorf("failed to create daemon: %w", err)
 		}
 		
+		// Record our own PID so daemons launched by a service manager
+		// are visible to status and pause
+		name := cfg.ForRepo(rootPath).StateName()
+		config.SaveDaemonInfo(config.NewDaemonInfo(os.Getpid(), name, rootPath, daemon.StatusRunning))
+		
 		// Setup signal handling
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -134,43 +308,179 @@ var startDaemonCmd = &cobra.Command{
 		d.Stop()
 		
 		// Clean up daemon info
-		config.DeleteDaemonInfo()
+		config.DeleteDaemonInfo(name)
+		config.DeleteHealth(name)
 		
 		return nil
 	},
 }
 
+var superviseCmd = &cobra.Command{
+	Use:    "supervise",
+	Short:  "Internal command to run the daemon under a watchdog (do not call directly)",
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("root path required")
+		}
+		
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		return daemon.Supervise(args[0], sigChan)
+	},
+}
+
 var pauseCmd = &cobra.Command{
 	Use:   "pause",
 	Short: "Pause the running daemon",
-	Long:  "Stops the background daemon for the current repository.",
+	Long:  "Pauses the background daemon for the current repository, or with --group for any repository in the group. The daemon keeps running but checks nothing until 'autogit resume', also across restarts. With --stop the daemon process is stopped instead.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		daemonInfo, err := config.LoadDaemonInfo()
-		if err != nil || daemonInfo == nil {
-			return fmt.Errorf("no daemon is running")
+		stop, _ := cmd.Flags().GetBool("stop")
+		if group, _ := cmd.Flags().GetString("group"); group != "" {
+			return pauseGroup(group, stop)
+		}
+		
+		daemonInfo, err := repoDaemon()
+		if err != nil {
+			return err
+		}
+		
+		if stop {
+			if err := daemon.StopDaemonProcess(daemonInfo); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Daemon stopped successfully\n")
+			return nil
 		}
 		
-		// Check if process is running
-		if !isProcessRunning(daemonInfo.PID) {
-			config.DeleteDaemonInfo()
-			return fmt.Errorf("daemon process not found (may have crashed)")
+		if err := pauseDaemon(daemonInfo); err != nil {
+			return err
+		}
+		fmt.Println("✓ Daemon paused; run 'autogit resume' to continue")
+		return nil
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume a paused daemon",
+	Long:  "Ends a pause of the background daemon for the current repository, or with --group for any repository in the group, and checks for changes right away. A daemon that stopped checking after a failed push starts again too.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if group, _ := cmd.Flags().GetString("group"); group != "" {
+			return resumeGroup(group)
 		}
 		
-		// Kill the process
-		process, err := os.FindProcess(daemonInfo.PID)
+		daemonInfo, err := repoDaemon()
 		if err != nil {
-			return fmt.Errorf("failed to find process: %w", err)
+			return err
 		}
 		
-		if err := process.Signal(syscall.SIGTERM); err != nil {
-			return fmt.Errorf("failed to stop daemon: %w", err)
+		if err := resumeDaemon(daemonInfo); err != nil {
+			return err
+		}
+		fmt.Println("✓ Daemon resumed")
+		return nil
+	},
+}
+
+var triggerCmd = &cobra.Command{
+	Use:   "trigger [file]",
+	Short: "Ask the running daemon to check for changes now",
+	Long:  "Asks the daemon to check for changes right away instead of waiting for the interval, for editors to run after saving. The file, or the current directory without one, must lie in the daemon's repository. A paused daemon ignores the request.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "."
+		if len(args) == 1 {
+			path = args[0]
+		}
+		path, err := absPath(path)
+		if err != nil {
+			return err
 		}
 		
-		// Clean up daemon info
-		config.DeleteDaemonInfo()
+		daemons, _, _ := config.ListLiveDaemonInfo()
+		if len(daemons) == 0 {
+			return fmt.Errorf("no daemon is running")
+		}
+		var daemonInfo *config.DaemonInfo
+		for _, info := range daemons {
+			if pathutil.Within(path, info.RepoPath) && (daemonInfo == nil || len(info.RepoPath) > len(daemonInfo.RepoPath)) {
+				daemonInfo = info
+			}
+		}
+		if daemonInfo == nil {
+			return fmt.Errorf("%s is not in a repository a daemon runs for", path)
+		}
+		
+		trigger := &config.Trigger{RequestedAt: time.Now()}
+		if len(args) == 1 {
+			trigger.Path = path
+		}
+		if err := config.SaveTrigger(daemonInfo.Name, trigger); err != nil {
+			return err
+		}
+		if err := proc.Wake(daemonInfo.PID); err != nil {
+			return fmt.Errorf("failed to signal daemon: %w", err)
+		}
+		return nil
+	},
+}
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Let editors trigger a check when a file is saved",
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the on-save script for editors",
+	Long:  "Writes a script that runs 'autogit trigger' in the background with the file it is given, and shows how to call it from common editors after every save.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to get executable path: %w", err)
+		}
+		if exe, err = filepath.Abs(exe); err != nil {
+			return fmt.Errorf("failed to resolve executable path: %w", err)
+		}
 		
-		fmt.Printf("✓ Daemon stopped successfully\n")
+		script := "#!/bin/sh\n# Installed by 'autogit hook install'; asks the daemon to check now\n\"" + exe + "\" trigger \"$@\" >/dev/null 2>&1 &\n"
+		if runtime.GOOS == "windows" {
+			script = "@rem Installed by 'autogit hook install'; asks the daemon to check now\r\n@start \"\" /b " + pathutil.QuoteArg(exe) + " trigger %* >nul 2>&1\r\n"
+		}
+		path := config.GetHookPath()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create hooks directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
 		
+		fmt.Printf("✓ Installed %s\n\n", path)
+		fmt.Println("Run it with the saved file from your editor:")
+		fmt.Printf("  Vim/Neovim:  autocmd BufWritePost * silent! call system(shellescape('%s') . ' ' . shellescape(expand('%%:p')))\n", path)
+		fmt.Printf("  Emacs:       (add-hook 'after-save-hook (lambda () (call-process \"%s\" nil 0 nil buffer-file-name)))\n", path)
+		fmt.Printf("  VS Code:     \"emeraldwalk.runonsave\": {\"commands\": [{\"match\": \".*\", \"cmd\": \"%s ${file}\"}]} (Run on Save extension)\n", path)
+		fmt.Printf("  JetBrains:   a File Watcher running %s with arguments $FilePath$\n", path)
+		return nil
+	},
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the on-save script",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := config.GetHookPath()
+		if err := os.Remove(path); err != nil {
+			if os.IsNotExist(err) {
+				fmt.Println("No on-save script is installed")
+				return nil
+			}
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		fmt.Printf("✓ Removed %s; remove the call from your editor as well\n", path)
 		return nil
 	},
 }
@@ -178,56 +488,2158 @@ var pauseCmd = &cobra.Command{
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show daemon status",
+	Long:  "Shows the state of the daemon for the current repository, or outside of a repository of every running daemon.\n\nWith --serve-ssh, serves the dashboard of 'autogit menu' over SSH instead, read-only and without the settings tab, to the keys in --authorized-keys. Guests connect with 'ssh -p 2222 <host>'.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		daemonInfo, err := config.LoadDaemonInfo()
-		if err != nil || daemonInfo == nil {
-			fmt.Println("Status: Not running")
-			return nil
+		if group, _ := cmd.Flags().GetString("group"); group != "" {
+			return groupStatus(group)
+		}
+		if serveSSH, _ := cmd.Flags().GetBool("serve-ssh"); serveSSH {
+			addr, _ := cmd.Flags().GetString("ssh-addr")
+			keys, _ := cmd.Flags().GetString("authorized-keys")
+			return serveGuestView(addr, keys)
 		}
 		
-		running := isProcessRunning(daemonInfo.PID)
-		if !running {
-			fmt.Println("Status: Process not found (may have crashed)")
+		daemons, stale, _ := config.ListLiveDaemonInfo()
+		rootPath, err := getAnyRootPath()
+		if err != nil {
+			// Outside of a repository, show every daemon
+			for _, info := range stale {
+				if err := restartStale(info); err != nil {
+					return err
+				}
+			}
+			if len(daemons) == 0 {
+				if len(stale) == 0 {
+					fmt.Println("Status: Not running")
+				}
+				return nil
+			}
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			for i, info := range daemons {
+				if i > 0 {
+					fmt.Println()
+				}
+				printDaemonStatus(cfg, info)
+			}
 			return nil
 		}
 		
-		fmt.Printf("Status: %s\n", daemonInfo.Status)
-		fmt.Printf("PID: %d\n", daemonInfo.PID)
-		fmt.Printf("Repository: %s\n", daemonInfo.RepoPath)
+		if info := config.DaemonFor(stale, rootPath); info != nil {
+			return restartStale(info)
+		}
+		daemonInfo := config.DaemonFor(daemons, rootPath)
+		if daemonInfo == nil {
+			fmt.Println("Status: Not running")
+			return nil
+		}
 		
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		printDaemonStatus(cfg, daemonInfo)
 		return nil
 	},
 }
 
-func isProcessRunning(pid int) bool {
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false
+// printDaemonStatus prints the state of the daemon info describes
+func printDaemonStatus(cfg *config.Config, daemonInfo *config.DaemonInfo) {
+	clock, now := cfg.Clock(), time.Now()
+	health := config.HealthFor(daemonInfo)
+	
+	status := daemonInfo.Status
+	if health != nil {
+		status = health.Status
+		if health.Stale(now) {
+			status = "unresponsive (no heartbeat since " + clock.Both(health.Heartbeat, now) + ")"
+		}
 	}
 	
-	// On Unix, sending signal 0 checks if process exists
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
-}
-
-func init() {
-	rootCmd.AddCommand(initCmd)
-	rootCmd.AddCommand(menuCmd)
-	rootCmd.AddCommand(startDaemonCmd)
-	rootCmd.AddCommand(pauseCmd)
-	rootCmd.AddCommand(statusCmd)
+	if status == daemon.StatusPaused {
+		status += " (run 'autogit resume' to continue)"
+	}
+	if status == daemon.StatusAuthExpired {
+		status += " (API key rejected, using local messages; run 'autogit reauth')"
+	}
+	fmt.Printf("Status: %s\n", status)
+	fmt.Printf("PID: %d\n", daemonInfo.PID)
+	fmt.Printf("Repository: %s\n", daemonInfo.RepoPath)
+	if id := cfg.ForRepo(daemonInfo.RepoPath).ID; id != "" {
+		fmt.Printf("ID: %s\n", id)
+	}
 	
-	// Enable version flag
-	rootCmd.SetVersionTemplate("autogit version {{.Version}}\n")
+	if !daemonInfo.StartedAt.IsZero() {
+		fmt.Printf("Started: %s\n", clock.Both(daemonInfo.StartedAt, now))
+	}
 	
-	// Alias --menu for menu command
-	rootCmd.PersistentFlags().BoolP("menu", "m", false, "Open interactive TUI dashboard")
-	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
-		if menu, _ := cmd.Flags().GetBool("menu"); menu {
-			// Execute menu command
-			menuCmd.RunE(cmd, args)
-			os.Exit(0)
+	if health == nil {
+		if !daemonInfo.StartedAt.IsZero() {
+			timing := cfg.ForRepo(daemonInfo.RepoPath).GetCheckTiming()
+			fmt.Printf("Next check: %s\n", clock.Both(timing.Next(daemonInfo.StartedAt, now), now))
+		}
+		return
+	}
+	
+	if !health.LastCheck.IsZero() {
+		fmt.Printf("Last check: %s\n", clock.Both(health.LastCheck, now))
+	}
+	if !health.LastCommit.IsZero() {
+		fmt.Printf("Last commit: %s\n", clock.Both(health.LastCommit, now))
+		fmt.Printf("  %s\n", strings.ReplaceAll(health.LastCommitMsg, "\n", "\n  "))
+	}
+	if health.LastError != "" {
+		fmt.Printf("Last error: %s\n  %s\n", clock.Both(health.LastErrorAt, now), health.LastError)
+	}
+	if health.ModelFallback != "" {
+		fmt.Printf("⚠ Warning: %s\n", health.ModelFallback)
+	}
+	if cfg.ForRepo(daemonInfo.RepoPath).AIDisabled() {
+		fmt.Println("AI provider: disabled for this repository")
+	} else if state := breaker.Get(cfg.AIProvider); state.Open(now) {
+		fmt.Printf("AI provider: %s skipped until %s after repeated failures\n  %s\n", cfg.AIProvider, clock.Both(state.OpenUntil, now), state.LastError)
+	} else if state.Failures > 0 {
+		fmt.Printf("AI provider: %s failed %d time(s) in a row\n  %s\n", cfg.AIProvider, state.Failures, state.LastError)
+	}
+	if mute, _ := config.LoadMute(); mute != nil {
+		fmt.Printf("Notifications: muted until %s\n", clock.Both(mute.Until, now))
+	}
+	if len(health.PendingApproval) > 0 {
+		fmt.Printf("Awaiting approval: %s (run 'autogit approve')\n", strings.Join(health.PendingApproval, ", "))
+	}
+	if len(health.HeldLFS) > 0 {
+		fmt.Printf("Held for Git LFS: %s (install git-lfs and run 'git lfs install')\n", strings.Join(health.HeldLFS, ", "))
+	}
+	if state, _ := ignore.Load(cfg.ForRepo(daemonInfo.RepoPath).StateName()); state != nil && len(state.Suggested) > 0 {
+		fmt.Printf("Suggested for .gitignore: %s (add them in 'autogit menu')\n", strings.Join(state.Suggested, ", "))
+	}
+	if health.NextRun.IsZero() {
+		fmt.Println("Next check: not scheduled")
+		return
+	}
+	rc := cfg.ForRepo(daemonInfo.RepoPath)
+	timing := rc.GetCheckTiming()
+	fmt.Printf("Next checks (%s):\n", timing)
+	sched, _ := rc.Schedule.Parse()
+	for _, t := range timing.Upcoming(sched, health.NextRun, 3) {
+		fmt.Printf("  %s\n", clock.Both(t.In(sched.Location()), now))
+	}
+}
+
+
+var installServiceCmd = &cobra.Command{
+	Use:   "install-service",
+	Short: "Run the daemon as an OS-supervised service",
+	Long:  "Registers a systemd user unit (Linux), launchd agent (macOS), or Task Scheduler logon task (Windows) so the daemon for the current repository survives reboots.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		
+		// Stop a manually started daemon so the service manager owns it
+		if daemonInfo, _, _ := config.LoadLiveDaemonInfo(config.StateName(rootPath)); daemonInfo != nil {
+			proc.Terminate(daemonInfo.PID)
+		}
+		
+		location, err := service.Install(rootPath)
+		if err != nil {
+			return fmt.Errorf("failed to install service: %w", err)
+		}
+		
+		fmt.Printf("✓ Service installed: %s\n", location)
+		fmt.Printf("Repository: %s\n", rootPath)
+		
+		if cfg, err := config.LoadConfig(); err == nil && cfg.APIKeyEnv != "" {
+			fmt.Printf("⚠ Warning: the API key comes from $%s, which the service manager does not inherit.\n", cfg.APIKeyEnv)
+			fmt.Printf("  Make the variable available to user services (e.g. 'systemctl --user set-environment' or launchctl setenv).\n")
+		}
+		
+		return nil
+	},
+}
+
+var uninstallServiceCmd = &cobra.Command{
+	Use:   "uninstall-service",
+	Short: "Remove the OS service for the current repository",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		
+		if err := service.Uninstall(rootPath); err != nil {
+			return fmt.Errorf("failed to uninstall service: %w", err)
+		}
+		
+		fmt.Printf("✓ Service removed\n")
+		
+		return nil
+	},
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show what the daemon has committed for the current repository",
+	Long:  "Shows commits made, lines changed, AI tokens used and failures for today, the last 7 and 30 days and all time, followed by the AI usage and estimated cost of all repositories against ai_budget.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		
+		s, err := stats.Load(config.StateName(rootPath))
+		if err != nil {
+			return err
+		}
+		
+		days, _ := cmd.Flags().GetInt("days")
+		fmt.Printf("Repository: %s\n\n", rootPath)
+		fmt.Println(s.Report(time.Now(), days))
+		
+		// AI usage is shared by all repositories, as is ai_budget
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		ledger, err := spend.Load()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n%s\n", ledger.Report(cfg.AIBudget, time.Now()))
+		
+		return nil
+	},
+}
+
+var whyCmd = &cobra.Command{
+	Use:   "why <file>",
+	Short: "Show which autogit commits changed a file",
+	Long:  "Lists the commits autogit made to a file or directory, newest first, with the message, what triggered the cycle and the AI provider and model that wrote the message.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		
+		abs, err := absPath(args[0])
+		if err != nil {
+			return err
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved // git reports the root with symlinks resolved
+		}
+		rel, err := filepath.Rel(rootPath, abs)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("%s is outside the repository %s", args[0], rootPath)
+		}
+		
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		store, err := history.OpenReadOnly(cfg.Storage)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		
+		entries, err := store.Load(cfg.ForRepo(rootPath).StateName())
+		if err != nil {
+			return err
+		}
+		matches := history.Touching(entries, rel)
+		if len(matches) == 0 {
+			fmt.Printf("No autogit commits recorded for %s\n", filepath.ToSlash(rel))
+			return nil
+		}
+		
+		clock, now := cfg.Clock(), time.Now()
+		
+		for i := len(matches) - 1; i >= 0; i-- {
+			e := matches[i]
+			commit := e.Commit
+			if len(commit) > 7 {
+				commit = commit[:7]
+			}
+			origin := e.Source
+			if e.Provider != "" {
+				origin = fmt.Sprintf("%s, %s/%s", e.Source, e.Provider, e.Model)
+			}
+			fmt.Printf("%s  %s  %s (%s)\n", commit, clock.Both(e.Time, now), e.Trigger, origin)
+			fmt.Printf("    %s\n", strings.ReplaceAll(e.Message, "\n", "\n    "))
+			if e.Approval != "" {
+				fmt.Printf("    Sensitive changes approved by %s\n", e.Approval)
+			}
 		}
+		
+		return nil
+	},
+}
+
+var uninitCmd = &cobra.Command{
+	Use:   "uninit",
+	Short: "Stop autogit for the current repository and remove its files",
+	Long:  "Removes the service registered for the current repository, stops its daemon, and deletes its daemon info, log, interrupted cycle, pending approval and .gitignore suggestions. With --purge, its commit history, statistics, backups and entry under repos in the config are removed as well. The repository itself is not touched.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		purge, _ := cmd.Flags().GetBool("purge")
+		
+		if service.Installed(rootPath) {
+			if err := service.Uninstall(rootPath); err != nil {
+				return fmt.Errorf("failed to uninstall service: %w", err)
+			}
+			fmt.Println("✓ Service removed")
+		}
+		
+		if daemonInfo, _, _ := config.LoadLiveDaemonInfo(config.StateName(rootPath)); daemonInfo != nil {
+			if err := daemon.StopDaemonProcess(daemonInfo); err != nil {
+				return err
+			}
+			fmt.Println("✓ Daemon stopped")
+		}
+		
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		repoName := cfg.ForRepo(rootPath).StateName()
+		
+		files := []string{config.GetCyclePath(repoName), config.GetApprovalPath(repoName), config.GetIgnorePath(repoName), config.GetSharedBranchPath(repoName)}
+		if repoConfig := cfg.ForRepo(rootPath); repoConfig.GetLogDestination() == config.LogToFile {
+			files = append(files, repoConfig.LogPath())
+		}
+		if purge {
+			files = append(files, config.GetStatsPath(repoName))
+			store, err := history.Open(cfg.Storage)
+			if err != nil {
+				return err
+			}
+			err = store.Remove(repoName)
+			store.Close()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Removed the commit history of %s\n", git.GetRepoName(rootPath))
+		}
+		for _, path := range files {
+			if err := os.Remove(path); err == nil {
+				fmt.Printf("✓ Removed %s\n", path)
+			} else if !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+		if dir := config.GetBackupDir(repoName); purge {
+			if _, err := os.Stat(dir); err == nil {
+				if err := os.RemoveAll(dir); err != nil {
+					return fmt.Errorf("failed to remove %s: %w", dir, err)
+				}
+				fmt.Printf("✓ Removed %s\n", dir)
+			}
+		}
+		
+		changed := false
+		if pathutil.Same(cfg.RootPath, rootPath) {
+			cfg.RootPath = ""
+			changed = true
+		}
+		if purge {
+			repos := cfg.Repos[:0]
+			for _, r := range cfg.Repos {
+				if pathutil.Same(r.Path, rootPath) {
+					changed = true
+					fmt.Println("✓ Removed per-repository settings")
+					continue
+				}
+				repos = append(repos, r)
+			}
+			cfg.Repos = repos
+		}
+		if changed {
+			if err := config.SaveConfig(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+		}
+		
+		fmt.Printf("autogit is no longer set up for %s\n", rootPath)
+		return nil
+	},
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a read-only status page",
+	Long:  "Serves an HTML status page listing the configured repositories, their daemon state, errors, recent commits and activity, and the same data as JSON at /status.json. Clicks on Slack approval buttons are received at /slack/actions and verified with the Slack signing secret. Other requests must carry the token as \"Authorization: Bearer <token>\" or ?token=<token>. Without --token or AUTOGIT_SERVE_TOKEN a random token is generated and printed.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			token = os.Getenv("AUTOGIT_SERVE_TOKEN")
+		}
+		if token == "" {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				return fmt.Errorf("failed to generate token: %w", err)
+			}
+			token = hex.EncodeToString(b)
+		}
+		
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		fmt.Printf("Serving status on http://%s/?token=%s\n", listener.Addr(), token)
+		
+		srv := &http.Server{Handler: server.New(token), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			<-sigChan
+			srv.Close()
+		}()
+		
+		if err := srv.Serve(listener); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	},
+}
+
+// serveGuestView serves the read-only dashboard over SSH until interrupted
+func serveGuestView(addr, authorizedKeys string) error {
+	if authorizedKeys == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to find home directory: %w", err)
+		}
+		authorizedKeys = filepath.Join(home, ".ssh", "authorized_keys")
+	}
+	srv, err := tui.NewSSHServer(addr, authorizedKeys)
+	if err != nil {
+		return err
+	}
+	
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	fmt.Printf("Serving the read-only dashboard on ssh://%s to the keys in %s\n", listener.Addr(), authorizedKeys)
+	
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		<-sigChan
+		srv.Close()
+	}()
+	
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+var muteCmd = &cobra.Command{
+	Use:   "mute [duration]",
+	Short: "Silence desktop notifications for a while",
+	Long:  "Silences desktop notifications from every autogit daemon for the given duration, e.g. 30m or 2h, or for notifications.mute_duration (default 1h). Commits continue as usual.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if off, _ := cmd.Flags().GetBool("off"); off {
+			if err := config.DeleteMute(); err != nil {
+				return fmt.Errorf("failed to unmute: %w", err)
+			}
+			fmt.Println("✓ Notifications unmuted")
+			return nil
+		}
+		
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		duration := cfg.Notifications.GetMuteDuration()
+		if len(args) == 1 {
+			if duration, err = time.ParseDuration(args[0]); err != nil || duration <= 0 {
+				return fmt.Errorf("invalid duration %q, expected e.g. 30m or 2h", args[0])
+			}
+		}
+		
+		until := time.Now().Add(duration)
+		if err := config.SaveMute(&config.Mute{Until: until}); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Notifications muted until %s\n", cfg.Clock().Both(until, time.Now()))
+		return nil
+	},
+}
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "List and restore commits saved before history rewrites",
+	Long:  "Before the daemon rewrites history, such as for squash_daily, it saves the commits it replaces as a git bundle in backups/ under the config directory. The last 20 per repository are kept.",
+}
+
+var backupsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the backups of the current repository",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		repoName := config.StateName(rootPath)
+		
+		backups, err := backup.List(repoName)
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			fmt.Printf("No backups for %s\n", rootPath)
+			return nil
+		}
+		
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		clock, now := cfg.Clock(), time.Now()
+		for _, b := range backups {
+			fmt.Printf("%s  %s  %s: %d commits on %s up to %.7s\n", b.ID, clock.Both(b.Created, now), b.Reason, b.Commits, b.Branch, b.Tip)
+		}
+		return nil
+	},
+}
+
+var backupsRestoreCmd = &cobra.Command{
+	Use:   "restore <id|latest>",
+	Short: "Restore the commits of a backup",
+	Long:  "Puts the commits of a backup on the branch autogit-backup/<id>, from where they can be inspected, merged or reset to. With --reset the current branch is moved back to them as well, which is only done when nothing was committed since the rewrite; uncommitted changes are kept.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		repoName := config.StateName(rootPath)
+		
+		b, err := backup.Find(repoName, args[0])
+		if err != nil {
+			return err
+		}
+		reset, _ := cmd.Flags().GetBool("reset")
+		branch, err := backup.Restore(openGit(rootPath), repoName, b, reset)
+		if branch != "" {
+			fmt.Printf("✓ Restored %d commits to branch %s\n", b.Commits, branch)
+		}
+		if err != nil {
+			return err
+		}
+		if reset {
+			fmt.Printf("✓ %s is back at %.7s; the remote still has the rewritten history, so push with --force-with-lease if you want it there too\n", b.Branch, b.Tip)
+		}
+		return nil
+	},
+}
+
+var storageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Manage where the commit history is kept",
+}
+
+var storageMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy the history files into the configured storage backend",
+	Long:  "Copies the commit history of every registered repository from its file in history/ under the config directory into the backend set in storage.backend, so that 'autogit why' and 'autogit serve' keep showing it. Repositories that already have history in the backend are skipped, so the command can be run again safely. The files are left in place.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if cfg.Storage.GetBackend() == config.StorageFile {
+			return fmt.Errorf("storage.backend is %q, which already reads the history files; set it to %q first", config.StorageFile, config.StorageSQLite)
+		}
+		store, err := history.Open(cfg.Storage)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		
+		repos := make([]config.RepoConfig, 0, len(cfg.Repos)+1)
+		if cfg.RootPath != "" {
+			repos = append(repos, cfg.ForRepo(cfg.RootPath))
+		}
+		for _, r := range cfg.Repos {
+			repos = append(repos, cfg.ForRepo(r.Path))
+		}
+		names := make(map[string]bool)
+		for _, rc := range repos {
+			// History files of daemons not restarted since they were
+			// named after the directory alone
+			if _, err := rc.MigrateState(); err != nil {
+				return err
+			}
+			names[rc.StateName()] = true
+		}
+		for name := range names {
+			entries, err := history.Files{}.Load(name)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				continue
+			}
+			if existing, err := store.Load(name); err != nil {
+				return err
+			} else if len(existing) > 0 {
+				fmt.Printf("Skipped %s, which already has %d entries in %s\n", name, len(existing), cfg.Storage.GetBackend())
+				continue
+			}
+			for _, e := range entries {
+				if err := store.Append(name, e); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("✓ Copied %d entries of %s\n", len(entries), name)
+		}
+		return nil
+	},
+}
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Keep settings and repositories alike across machines",
+}
+
+var fleetApplyCmd = &cobra.Command{
+	Use:   "apply <file>",
+	Short: "Bring this machine in line with a fleet file",
+	Long:  "Applies a fleet file, in YAML or JSON, that a team keeps for all its machines: its settings are written to the config, missing repositories are cloned and registered with the settings listed for them, and each gets a service that runs its daemon. What cannot be fixed, such as a repository whose origin points elsewhere, is reported as drift and makes the command fail. Repositories registered here but not in the fleet are listed and left alone. With --dry-run nothing is changed.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		noServices, _ := cmd.Flags().GetBool("no-services")
+		
+		f, err := fleet.Load(args[0])
+		if err != nil {
+			return err
+		}
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		
+		steps, drifted := 0, 0
+		step := func(done, planned string) {
+			steps++
+			if dryRun {
+				fmt.Printf("~ %s\n", planned)
+			} else {
+				fmt.Printf("✓ %s\n", done)
+			}
+		}
+		drift := func(format string, a ...interface{}) {
+			drifted++
+			fmt.Printf("⚠ "+format+"\n", a...)
+		}
+		
+		if keys, err := fleet.Apply(cfg, f.Settings); err != nil {
+			return err
+		} else if len(keys) > 0 {
+			list := strings.Join(keys, ", ")
+			step("Updated "+list, "Would update "+list)
+		}
+		
+		daemons, _, _ := config.ListLiveDaemonInfo()
+		var paths, services []string
+		for _, r := range f.Repos {
+			path, err := f.PathOf(r)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, path)
+			
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				if dryRun {
+					step("", "Would clone "+r.URL+" to "+path)
+					continue
+				}
+				if err := git.Clone(r.URL, path); err != nil {
+					drift("%v", err)
+					continue
+				}
+				step("Cloned "+r.URL+" to "+path, "")
+			}
+			repo := git.Open(path)
+			if _, err := repo.HasChanges(); err != nil {
+				drift("%s exists but is not a git repository", path)
+				continue
+			}
+			if origin, err := repo.RemoteURL("origin"); err != nil || !fleet.SameURL(origin, r.URL) {
+				if err != nil {
+					origin = "missing"
+				}
+				drift("%s: origin is %s, the fleet has %s", path, origin, r.URL)
+				continue
+			}
+			
+			if _, registered := cfg.FindRepo(path); !registered {
+				step("Registered "+path, "Would register "+path)
+			}
+			if !dryRun {
+				if err := identify(cfg, repo); err != nil {
+					return err
+				}
+			}
+			rc, _ := cfg.FindRepo(path)
+			rc.Path = path
+			if keys, err := fleet.Apply(&rc, r.Settings); err != nil {
+				return err
+			} else if len(keys) > 0 {
+				list := strings.Join(keys, ", ")
+				step("Updated "+list+" of "+path, "Would update "+list+" of "+path)
+			}
+			cfg.SetRepo(rc)
+			
+			if !noServices && !service.Installed(path) {
+				step("Installed a service for "+path, "Would install a service for "+path)
+				services = append(services, path)
+			} else if config.DaemonFor(daemons, path) == nil {
+				fmt.Printf("  %s has no running daemon\n", path)
+			}
+		}
+		
+		for _, rc := range cfg.Repos {
+			listed := false
+			for _, path := range paths {
+				listed = listed || pathutil.Same(rc.Path, path)
+			}
+			if !listed {
+				fmt.Printf("  %s is not in the fleet and was left alone\n", rc.Path)
+			}
+		}
+		
+		if problems := cfg.Validate(); len(problems) > 0 {
+			return &config.ValidationError{Path: args[0], Problems: problems}
+		}
+		if !dryRun {
+			if err := config.SaveConfig(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			// Services start their daemons, which read the saved config
+			for _, path := range services {
+				if _, err := service.Install(path); err != nil {
+					drift("failed to install a service for %s: %v", path, err)
+				}
+			}
+		}
+		
+		switch {
+		case drifted > 0:
+			return fmt.Errorf("this machine drifted from the fleet in %d place(s), which need fixing by hand", drifted)
+		case steps == 0:
+			fmt.Println("✓ This machine matches the fleet")
+		case noServices && !dryRun:
+			fmt.Println("Run 'autogit init' in a repository to start its daemon")
+		}
+		return nil
+	},
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Review and approve held changes to sensitive paths",
+	Long:  "Shows the changes to sensitive paths such as .gitignore and CI files that the daemon is holding back and, once approved, lets the next cycle commit them. Editing the files again requires a new approval.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		repo := openGit(rootPath)
+		
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		repoCfg := cfg.ForRepo(rootPath)
+		
+		files, err := repo.ChangedFiles(repoCfg.Exclude...)
+		if err != nil {
+			return err
+		}
+		sensitive, _ := approval.Split(files, cfg.Approval.SensitivePaths())
+		if len(sensitive) == 0 {
+			fmt.Println("No changes to sensitive paths are waiting for approval")
+			return nil
+		}
+		
+		diff, err := repo.FullDiffPaths(sensitive)
+		if err != nil {
+			return err
+		}
+		repoName := repoCfg.StateName()
+		hash := approval.Hash(diff)
+		if approval.Approved(repoName, hash) {
+			fmt.Println("These changes are already approved and will be committed on the next cycle")
+			return nil
+		}
+		
+		fmt.Printf("Changes to sensitive paths in %s:\n\n%s\n", rootPath, diff)
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+			fmt.Print("Commit these changes? [y/N] ")
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				fmt.Println("Not approved")
+				return nil
+			}
+		}
+		
+		if err := approval.Approve(repoName, sensitive, hash, "autogit approve"); err != nil {
+			return err
+		}
+		fmt.Printf("Approved %d changes; they will be committed on the next cycle\n", len(sensitive))
+		return nil
+	},
+}
+
+var reauthCmd = &cobra.Command{
+	Use:   "reauth",
+	Short: "Replace a rejected or expired API key",
+	Long:  "Checks a new API key with the provider and saves it. Daemons that paused AI messages after the old key was rejected pick up the new key on their next cycle, without a restart.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !ai.NeedsAPIKey(cfg.AIProvider) {
+			return fmt.Errorf("provider %s does not use an API key", cfg.AIProvider)
+		}
+		transport, err := ai.TransportFor(cfg.Network)
+		if err != nil {
+			return err
+		}
+		if cfg.APIKeyEnvOnly || cfg.APIKeyEnv == "AUTOGIT_API_KEY" {
+			return fmt.Errorf("the API key is read from the environment (%s); update it there and restart the daemons", cfg.KeyStorage())
+		}
+		
+		key, _ := cmd.Flags().GetString("key")
+		if key == "" {
+			if key, err = readKey(cfg.AIProvider); err != nil {
+				return err
+			}
+		}
+		if err := ai.ValidateAPIKey(cfg.AIProvider, key, cfg.BaseURL); err != nil {
+			return err
+		}
+		if _, err := ai.Ping(cfg.AIProvider, key, cfg.BaseURL, transport); errors.Is(err, ai.ErrUnauthorized) {
+			return fmt.Errorf("%s rejected the new key too: %w", cfg.AIProvider, err)
+		} else if err != nil {
+			fmt.Printf("⚠ Could not check the key with %s: %v\n", cfg.AIProvider, err)
+		}
+		
+		cfg.APIKey = key
+		cfg.APIKeyEnv = ""
+		if err := config.SaveConfig(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("✓ API key saved (%s); running daemons resume AI messages on their next cycle\n", cfg.KeyStorage())
+		return nil
+	},
+}
+
+// checkModel checks the model configured for the current provider against
+// the provider's model list. When the list cannot be fetched, as while
+// offline, it only warns.
+func checkModel(cfg *config.Config) error {
+	model := cfg.GetAIModel()
+	transport, err := ai.TransportFor(cfg.Network)
+	if err != nil {
+		return err
+	}
+	err = ai.ValidateModel(cfg.AIProvider, cfg.APIKey, cfg.BaseURL, model, transport)
+	if errors.Is(err, ai.ErrModelsUnavailable) {
+		fmt.Printf("⚠ Could not check model %s: %v\n", model, err)
+		return nil
+	}
+	return err
+}
+
+// readKey prompts for an API key, without echoing it when stdin is a terminal
+func readKey(provider string) (string, error) {
+	fmt.Printf("New API key for %s: ", provider)
+	var key string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		data, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key: %w", err)
+		}
+		key = string(data)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read API key: %w", err)
+		}
+		key = line
+	}
+	
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("no API key entered")
+	}
+	return key, nil
+}
+
+// networkSummary describes the proxy and certificates AI requests use, or
+// returns "" when nothing is configured. Passwords in proxy URLs are masked.
+func networkSummary(n config.Network) string {
+	var parts []string
+	if n.Proxy != "" {
+		proxy := n.Proxy
+		if u, err := url.Parse(n.Proxy); err == nil {
+			proxy = u.Redacted()
+		}
+		parts = append(parts, "proxy "+proxy)
+	} else {
+		for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+			if os.Getenv(name) != "" {
+				parts = append(parts, "proxy from "+name)
+				break
+			}
+		}
+	}
+	if n.CABundle != "" {
+		parts = append(parts, "CA bundle "+n.CABundlePath())
+	}
+	if n.InsecureSkipVerify {
+		parts = append(parts, "certificates not verified")
+	}
+	return strings.Join(parts, ", ")
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment for common problems",
+	Long:  "Checks git, the repository's remote, credentials and Git LFS, the config, the API key and provider, the daemon and the log directory, and prints how to fix each problem found.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		problems := 0
+		fail := func(label, format string, a ...interface{}) {
+			problems++
+			fmt.Printf("%-10s✗ %s\n", label, fmt.Sprintf(format, a...))
+		}
+		fix := func(format string, a ...interface{}) {
+			fmt.Printf("          → %s\n", fmt.Sprintf(format, a...))
+		}
+		
+		fmt.Printf("autogit:  %s (%s/%s)\n", Version, runtime.GOOS, runtime.GOARCH)
+		
+		if version, err := git.Version(); err != nil {
+			fail("git:", "%v", err)
+			fix("install git from https://git-scm.com and make sure it is on PATH; until then the daemon commits through go-git, without backups, mirrors, LFS or signing")
+		} else {
+			fmt.Printf("git:      %s\n", version)
+			// Old git works, with the features it lacks worked around
+			missing := git.Missing()
+			for _, f := range missing {
+				fmt.Printf("          ⚠ %s needs git %s: %s\n", f.Name, f.Since, f.Fallback)
+			}
+			if len(missing) > 0 {
+				fix("upgrade git to %s or later to enable them", missing[len(missing)-1].Since)
+			}
+		}
+		
+		fmt.Printf("config:   %s\n", config.GetConfigPath())
+		
+		cfg, err := config.LoadConfig()
+		var transport http.RoundTripper
+		if err == nil {
+			n := cfg.Network
+			var nerr error
+			if transport, nerr = ai.TransportFor(n); nerr != nil {
+				fail("network:", "%v", nerr)
+				fix("correct network.proxy or network.ca_bundle in the config file")
+			} else if summary := networkSummary(n); summary != "" {
+				fmt.Printf("network:  %s\n", summary)
+				if n.InsecureSkipVerify && n.CABundle == "" {
+					fmt.Printf("          ⚠ certificates of AI providers are not verified; set network.ca_bundle instead\n")
+				}
+			}
+		}
+		switch {
+		case err != nil:
+			problems++
+			fmt.Printf("          ✗ %s\n", strings.ReplaceAll(err.Error(), "\n", "\n          "))
+			fix("correct the settings above in the config file, or run 'autogit --menu'")
+		case !ai.NeedsAPIKey(cfg.AIProvider):
+			fmt.Printf("api key:  not needed (provider %s)\n", cfg.AIProvider)
+		case cfg.APIKey == "":
+			fail("api key:", "%s", cfg.KeyStorage())
+			if cfg.APIKeyEnvOnly {
+				fix("set %s", strings.Join(config.APIKeyVars(cfg.AIProvider), " or "))
+			} else {
+				fix("run 'autogit reauth' to set it")
+			}
+		default:
+			fmt.Printf("api key:  %s\n", cfg.KeyStorage())
+			
+			if latency, err := ai.Ping(cfg.AIProvider, cfg.APIKey, cfg.BaseURL, transport); err == nil {
+				fmt.Printf("provider: ✓ %s answered in %s\n", cfg.AIProvider, latency.Round(time.Millisecond))
+			} else if errors.Is(err, ai.ErrUnauthorized) {
+				fail("provider:", "%s: %v", cfg.AIProvider, err)
+				fix("the key may have expired or been revoked; run 'autogit reauth'")
+			} else {
+				fail("provider:", "%s: %v", cfg.AIProvider, err)
+				if cfg.BaseURL != "" {
+					fix("check your network connection and base_url (%s)", cfg.BaseURL)
+				} else {
+					fix("check your network connection or proxy settings")
+				}
+			}
+		}
+		if err == nil && cfg.Email.Enabled() {
+			fmt.Printf("email:    %s via %s:%d\n", strings.Join(cfg.Email.To, ", "), cfg.Email.Host, cfg.Email.GetPort())
+		}
+		
+		rootPath, err := getRootPath()
+		inRepo := err == nil
+		if hgRoot, hgErr := getHgRootPath(); !inRepo && hgErr == nil {
+			// Mercurial repositories are only committed; the git checks do not apply
+			rootPath = hgRoot
+			fmt.Printf("repo:     %s (Mercurial)\n", rootPath)
+			if version, err := hg.Version(); err != nil {
+				fail("hg:", "%v", err)
+				fix("install Mercurial from https://www.mercurial-scm.org and make sure it is on PATH")
+			} else {
+				fmt.Printf("hg:       %s, committing through Mercurial (experimental)\n", version)
+			}
+			if cfg != nil {
+				rc := cfg.ForRepo(rootPath)
+				if rc.GetVCS() != config.VCSHg {
+					fail("hg:", "the repository is not registered with vcs %q", config.VCSHg)
+					fix("run 'autogit init' in it")
+				}
+				for _, p := range rc.VCSProblems() {
+					fail("hg:", "%s", p)
+				}
+			}
+		} else if !inRepo {
+			fmt.Printf("repo:     ✗ not inside a git repository\n")
+			rootPath, _ = os.Getwd()
+		} else {
+			fmt.Printf("repo:     %s\n", rootPath)
+		}
+		
+		if inRepo {
+			repo := openGit(rootPath)
+			name, url, err := repo.PushRemote()
+			switch {
+			case err != nil:
+				fail("remote:", "%v", err)
+				fix("add one with 'git remote add origin <url>' and push once with 'git push -u origin HEAD'")
+			default:
+				fmt.Printf("remote:   %s %s\n", name, url)
+				if latency, err := repo.PingRemote(15*time.Second); err != nil {
+					fail("", "%v", err)
+					fix("check that 'git push' works from a terminal without asking for a password")
+				} else {
+					fmt.Printf("          ✓ reachable in %s\n", latency.Round(time.Millisecond))
+				}
+				
+				// The daemon cannot answer a password prompt, so HTTPS
+				// remotes need a credential helper
+				if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
+					if helper := repo.CredentialHelper(); helper != "" {
+						fmt.Printf("creds:    credential helper %s\n", helper)
+					} else {
+						fail("creds:", "no credential helper for an HTTPS remote")
+						fix("configure one, e.g. 'git config --global credential.helper manager' (or 'osxkeychain', 'libsecret', 'store')")
+					}
+				} else {
+					fmt.Printf("creds:    SSH keys or agent\n")
+				}
+			}
+			
+			// Files tracked by LFS are held while LFS is unusable
+			if data, err := os.ReadFile(filepath.Join(rootPath, ".gitattributes")); err == nil && strings.Contains(string(data), "filter=lfs") {
+				if err := repo.LFSReady(); err != nil {
+					fail("lfs:", "%v", err)
+					fix("install git-lfs from https://git-lfs.com and run 'git lfs install'; until then changes to LFS files are not committed")
+				} else {
+					fmt.Printf("lfs:      ✓ ready\n")
+				}
+			}
+			
+			if cfg != nil {
+				if signing := daemon.OpenRepo(cfg.ForRepo(rootPath)); !signing.Signs() {
+					fmt.Printf("signing:  off\n")
+				} else {
+					format, key := signing.SigningKey()
+					switch {
+					case key != "":
+						fmt.Printf("signing:  %s key %s\n", format, key)
+					case format == "ssh":
+						fail("signing:", "ssh signing is on but no key is set")
+						fix("set signing.key in the config, or run 'git config user.signingkey <public key file>'")
+					default:
+						fmt.Printf("signing:  %s, default key for the committer\n", format)
+					}
+					fmt.Printf("          the daemon cannot enter a passphrase; keep the key unlocked in gpg-agent or ssh-agent\n")
+				}
+				
+				if rc := cfg.ForRepo(rootPath); rc.GetVCS() == config.VCSGoGit {
+					fmt.Printf("vcs:      committing through go-git (experimental), without backups, mirrors, LFS or signing\n")
+					for _, p := range rc.VCSProblems() {
+						fail("vcs:", "%s", p)
+					}
+				}
+				if rc := cfg.ForRepo(rootPath); rc.GetVCS() == config.VCSJJ {
+					version, err := jj.Version()
+					switch {
+					case err != nil:
+						fail("jj:", "%v", err)
+						fix("install jj from https://jj-vcs.github.io/jj and make sure it is on PATH")
+					case !jj.Colocated(rootPath):
+						fail("jj:", "%s is not colocated with git", rootPath)
+						fix("run 'jj git init --colocate' in the repository")
+					default:
+						fmt.Printf("jj:       %s, committing through Jujutsu (experimental)\n", version)
+					}
+					for _, p := range rc.VCSProblems() {
+						fail("jj:", "%s", p)
+					}
+				} else if jj.Detect(rootPath) {
+					fmt.Printf("jj:       ⚠ Jujutsu repository committed with git; set vcs to %q for jj support\n", config.VCSJJ)
+				}
+				
+				// A daemon started from this shell takes these over the config
+				overrides, problems := config.RepoEnvOverrides(cfg.ForRepo(rootPath))
+				for _, o := range overrides {
+					fmt.Printf("env:      %s=%s (%s)\n", o.Key, o.Value, o.Name)
+				}
+				for _, p := range problems {
+					fail("env:", "%s", p)
+				}
+				if len(problems) > 0 {
+					fix("correct or unset these variables; the daemon does not start with them")
+				}
+			}
+		}
+		
+		// Read-only: 'autogit status' removes and restarts a stale daemon
+		if infos, err := config.ListDaemonInfo(); err != nil {
+			fail("daemon:", "%v", err)
+			fix("remove the unreadable file in %s", config.GetDaemonDir())
+		} else if len(infos) == 0 {
+			fmt.Printf("daemon:   not running\n")
+		} else {
+			for _, info := range infos {
+				if !info.Alive() {
+					fail("daemon:", "recorded PID %d for %s no longer runs", info.PID, info.RepoPath)
+					fix("run 'autogit status' in %s to clean up and restart it", info.RepoPath)
+				} else if health := config.HealthFor(info); health != nil && health.Stale(time.Now()) {
+					fail("daemon:", "PID %d for %s has not written a heartbeat since %s", info.PID, info.RepoPath, health.Heartbeat.Format(time.RFC3339))
+					fix("check its log, then restart it with 'autogit pause --stop' and 'autogit init'")
+				} else {
+					fmt.Printf("daemon:   ✓ PID %d for %s\n", info.PID, info.RepoPath)
+				}
+			}
+		}
+		
+		logDir, destination := config.GetLogDir(), config.LogToFile
+		if cfg != nil {
+			repoConfig := cfg.ForRepo(rootPath)
+			logDir, destination = filepath.Dir(repoConfig.LogPath()), repoConfig.GetLogDestination()
+		}
+		if destination != config.LogToFile {
+			fmt.Printf("logs:     sent to %s\n", destination)
+		} else if err := checkWritable(logDir); err != nil {
+			fail("logs:", "%s is not writable: %v", logDir, err)
+			fix("check the permissions and free space of %s", logDir)
+		} else {
+			fmt.Printf("logs:     %s\n", logDir)
+		}
+		
+		// Crash reports stay local; point at them for a bug report
+		if reports, _ := config.ListCrashReports(); len(reports) > 0 {
+			fmt.Printf("crashes:  ⚠ %d crash report(s), the latest %s\n", len(reports), reports[len(reports)-1])
+			fmt.Printf("          attach it to a bug report after checking it for private paths\n")
+		} else if cfg != nil && cfg.CrashReports {
+			fmt.Printf("crashes:  none, reports go to %s\n", config.GetCrashDir())
+		}
+		
+		// WSL detection: a repository on /mnt/c used from WSL, or on
+		// \\wsl$ used from Windows, cannot be watched reliably
+		boundary := wsl.Detect(rootPath)
+		mark := "✓"
+		if boundary.CrossBoundary {
+			mark = "⚠"
+		}
+		fmt.Printf("wsl:      %s %s\n", mark, boundary.Describe())
+		if boundary.InWSL && boundary.Distro != "" {
+			fmt.Printf("          distribution: %s\n", boundary.Distro)
+		}
+		if boundary.CrossBoundary {
+			fmt.Printf("          immediate mode is disabled and polling is at least every %s\n", config.MinCrossBoundaryInterval)
+		}
+		
+		fmt.Println()
+		if problems == 0 {
+			fmt.Println("No problems found")
+		} else {
+			fmt.Printf("%d problem(s) found\n", problems)
+		}
+		return nil
+	},
+}
+
+// checkWritable creates and removes a file in dir
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// pauseDaemon holds the checks of the daemon without stopping its process
+func pauseDaemon(daemonInfo *config.DaemonInfo) error {
+	if err := config.SavePause(daemonInfo.Name, &config.Pause{Since: time.Now()}); err != nil {
+		return err
+	}
+	if err := proc.Wake(daemonInfo.PID); err != nil {
+		return fmt.Errorf("failed to signal daemon: %w", err)
+	}
+	return nil
+}
+
+// resumeDaemon ends a pause, letting the daemon check right away
+func resumeDaemon(daemonInfo *config.DaemonInfo) error {
+	if err := config.DeletePause(daemonInfo.Name); err != nil {
+		return fmt.Errorf("failed to resume: %w", err)
+	}
+	if err := proc.Wake(daemonInfo.PID); err != nil {
+		return fmt.Errorf("failed to signal daemon: %w", err)
+	}
+	return nil
+}
+
+// loadGroup returns the configuration and the repositories tagged with group
+func loadGroup(group string) (*config.Config, []string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	paths := cfg.GroupRepos(group)
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("no repositories in group %q; add one with 'autogit group add %s'", group, group)
+	}
+	return cfg, paths, nil
+}
+
+// pauseGroup pauses, or with stop stops, the daemons that run for the
+// repositories in group
+func pauseGroup(group string, stop bool) error {
+	_, paths, err := loadGroup(group)
+	if err != nil {
+		return err
+	}
+	
+	daemons, _, _ := config.ListLiveDaemonInfo()
+	for _, path := range paths {
+		daemonInfo := config.DaemonFor(daemons, path)
+		if daemonInfo == nil {
+			fmt.Printf("  %s: not running\n", git.GetRepoName(path))
+			continue
+		}
+		if !stop {
+			if err := pauseDaemon(daemonInfo); err != nil {
+				return err
+			}
+			fmt.Printf("✓ %s: daemon paused\n", git.GetRepoName(path))
+			continue
+		}
+		if err := daemon.StopDaemonProcess(daemonInfo); err != nil {
+			return err
+		}
+		fmt.Printf("✓ %s: daemon stopped\n", git.GetRepoName(path))
+	}
+	return nil
+}
+
+// resumeGroup resumes the daemons that run for the repositories in group
+func resumeGroup(group string) error {
+	_, paths, err := loadGroup(group)
+	if err != nil {
+		return err
+	}
+	
+	daemons, _, _ := config.ListLiveDaemonInfo()
+	for _, path := range paths {
+		daemonInfo := config.DaemonFor(daemons, path)
+		if daemonInfo == nil {
+			fmt.Printf("  %s: not running\n", git.GetRepoName(path))
+			continue
+		}
+		if err := resumeDaemon(daemonInfo); err != nil {
+			return err
+		}
+		fmt.Printf("✓ %s: daemon resumed\n", git.GetRepoName(path))
+	}
+	return nil
+}
+
+// groupStatus lists the repositories in group with the state of their daemon
+func groupStatus(group string) error {
+	cfg, paths, err := loadGroup(group)
+	if err != nil {
+		return err
+	}
+	
+	now := time.Now()
+	daemons, _, _ := config.ListLiveDaemonInfo()
+	
+	fmt.Printf("Group %s: %d repositories\n", group, len(paths))
+	for _, path := range paths {
+		status := "stopped"
+		if daemonInfo := config.DaemonFor(daemons, path); daemonInfo != nil {
+			health := config.HealthFor(daemonInfo)
+			status = daemonInfo.Status
+			if health != nil {
+				status = health.Status
+				if health.Stale(now) {
+					status = "unresponsive"
+				}
+			}
+		}
+		line := fmt.Sprintf("  %-20s %-12s %s", git.GetRepoName(path), status, path)
+		if cfg.ForRepo(path).AIDisabled() {
+			line += " (AI disabled)"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// repoFlag is the repository --repo names, which commands run for instead
+// of the one in the working directory
+var repoFlag *config.RepoConfig
+
+// resolveRepo turns the value of --repo, a path or the name or ID of a
+// repository under repos in the config, into the repository's settings. A
+// path that is not registered is looked up as a git repository, and then as
+// a Mercurial one.
+func resolveRepo(value string) (*config.RepoConfig, error) {
+	abs, _ := filepath.Abs(value)
+	cfg, err := config.LoadConfig()
+	if err == nil {
+		var matches []string
+		for _, r := range cfg.Repos {
+			if r.Path != "" && (r.ID == value || git.GetRepoName(r.Path) == value || pathutil.Same(r.Path, abs)) {
+				matches = append(matches, r.Path)
+			}
+		}
+		if len(matches) > 1 {
+			return nil, fmt.Errorf("%q names several repositories, pass the path instead:\n  %s", value, strings.Join(matches, "\n  "))
+		}
+		if len(matches) == 1 {
+			rc := cfg.ForRepo(matches[0])
+			return &rc, nil
+		}
+	} else {
+		cfg = &config.Config{}
+	}
+	
+	info, err := os.Stat(value)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is neither a directory nor the name of a repository in the config", value)
+	}
+	rootPath, err := git.FindRoot(value)
+	if errors.Is(err, git.ErrNotInstalled) {
+		rootPath, err = gogit.FindRoot(value)
+	}
+	if err != nil {
+		hgRoot, hgErr := hg.FindRoot(value)
+		if hgErr != nil {
+			return nil, fmt.Errorf("%s: %w", value, err)
+		}
+		rc := cfg.ForRepo(hgRoot)
+		rc.VCS = config.VCSHg
+		return &rc, nil
+	}
+	rc := cfg.ForRepo(rootPath)
+	return &rc, nil
+}
+
+// getRootPath returns the root of the git repository the command runs for:
+// the one --repo names, or else the one of the working directory
+func getRootPath() (string, error) {
+	if repoFlag == nil {
+		rootPath, err := git.GetRootPath()
+		if errors.Is(err, git.ErrNotInstalled) {
+			return gogit.FindRoot("")
+		}
+		return rootPath, err
+	}
+	if repoFlag.GetVCS() == config.VCSHg {
+		return "", fmt.Errorf("%s is a Mercurial repository", repoFlag.Path)
+	}
+	return repoFlag.Path, nil
+}
+
+// getHgRootPath returns the root of the Mercurial repository the command
+// runs for, like getRootPath
+func getHgRootPath() (string, error) {
+	if repoFlag == nil {
+		return hg.GetRootPath()
+	}
+	if repoFlag.GetVCS() != config.VCSHg {
+		return "", fmt.Errorf("%s is not a Mercurial repository", repoFlag.Path)
+	}
+	return repoFlag.Path, nil
+}
+
+// getAnyRootPath returns the root of the git or else Mercurial repository
+// the command runs for
+func getAnyRootPath() (string, error) {
+	rootPath, err := getRootPath()
+	if err != nil {
+		if hgRoot, hgErr := getHgRootPath(); hgErr == nil {
+			return hgRoot, nil
+		}
+	}
+	return rootPath, err
+}
+
+// openGit opens the git repository at rootPath, with the git dir it is
+// registered with
+func openGit(rootPath string) *git.Repo {
+	if cfg, err := config.LoadConfig(); err == nil {
+		if rc := cfg.ForRepo(rootPath); rc.GitDir != "" {
+			return git.OpenLocation(git.Location{GitDir: rc.GitDir, WorkTree: rootPath})
+		}
+	}
+	return git.Open(rootPath)
+}
+
+// repoRootPath returns the root of the repository --repo names, or "" to
+// use the one of the working directory
+func repoRootPath() string {
+	if repoFlag == nil {
+		return ""
+	}
+	return repoFlag.Path
+}
+
+// absPath resolves path like filepath.Abs, but relative to the repository
+// --repo names when given
+func absPath(path string) (string, error) {
+	if repoFlag != nil && !filepath.IsAbs(path) {
+		return filepath.Join(repoFlag.Path, path), nil
+	}
+	return filepath.Abs(path)
+}
+
+// completeRepos offers the names of the repositories in the config for
+// --repo, described by their path
+func completeRepos(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	var names []string
+	for _, r := range cfg.Repos {
+		if name := git.GetRepoName(r.Path); r.Path != "" && strings.HasPrefix(name, toComplete) {
+			names = append(names, name+"\t"+r.Path)
+		}
+	}
+	if len(names) == 0 {
+		return nil, cobra.ShellCompDirectiveFilterDirs // Fall back to paths
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// repoDaemon returns the daemon of the current repository, or outside of a
+// repository the one daemon that runs
+func repoDaemon() (*config.DaemonInfo, error) {
+	daemons, stale, err := config.ListLiveDaemonInfo()
+	if err != nil {
+		return nil, err
+	}
+	rootPath, err := getAnyRootPath()
+	if err != nil {
+		switch len(daemons) {
+		case 0:
+			return nil, fmt.Errorf("no daemon is running; start one with 'autogit init'")
+		case 1:
+			return daemons[0], nil
+		}
+		return nil, fmt.Errorf("%d daemons are running; run this in a repository or pass --repo", len(daemons))
+	}
+	if daemonInfo := config.DaemonFor(daemons, rootPath); daemonInfo != nil {
+		return daemonInfo, nil
+	}
+	if config.DaemonFor(stale, rootPath) != nil {
+		return nil, fmt.Errorf("daemon process not found (may have crashed)")
+	}
+	return nil, fmt.Errorf("no daemon is running for %s; start one with 'autogit init'", rootPath)
+}
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Tag repositories with groups for bulk commands and shared settings",
+	Long:  "Groups such as \"work\" or \"notes\" select repositories for 'autogit pause --group' and 'autogit status --group', and apply the settings under repo_groups in the config to every repository tagged with them.",
+}
+
+var groupAddCmd = &cobra.Command{
+	Use:   "add <group>...",
+	Short: "Add the current repository to groups",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editGroups(func(rc *config.RepoConfig) {
+			for _, group := range args {
+				if !rc.InGroup(group) {
+					rc.Groups = append(rc.Groups, group)
+				}
+			}
+		})
+	},
+}
+
+var groupRemoveCmd = &cobra.Command{
+	Use:   "remove <group>...",
+	Short: "Remove the current repository from groups",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editGroups(func(rc *config.RepoConfig) {
+			groups := rc.Groups[:0]
+			for _, g := range rc.Groups {
+				keep := true
+				for _, group := range args {
+					keep = keep && g != group
+				}
+				if keep {
+					groups = append(groups, g)
+				}
+			}
+			rc.Groups = groups
+		})
+	},
+}
+
+var groupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List groups and their repositories",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		
+		var groups []string
+		seen := make(map[string]bool)
+		for _, r := range cfg.Repos {
+			for _, g := range r.Groups {
+				if !seen[g] {
+					seen[g] = true
+					groups = append(groups, g)
+				}
+			}
+		}
+		if len(groups) == 0 {
+			fmt.Println("No groups; add the current repository to one with 'autogit group add <group>'")
+			return nil
+		}
+		
+		sort.Strings(groups)
+		for _, g := range groups {
+			fmt.Printf("%s:\n", g)
+			for _, path := range cfg.GroupRepos(g) {
+				fmt.Printf("  %s\n", path)
+			}
+		}
+		return nil
+	},
+}
+
+// editGroups changes the groups of the current repository's entry
+func editGroups(change func(rc *config.RepoConfig)) error {
+	rootPath, err := getRootPath()
+	if err != nil {
+		return fmt.Errorf("failed to detect Git root: %w", err)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	
+	rc, ok := cfg.FindRepo(rootPath)
+	if !ok {
+		rc.Path = rootPath
+	}
+	change(&rc)
+	cfg.SetRepo(rc)
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	
+	if len(rc.Groups) == 0 {
+		fmt.Printf("%s is in no groups\n", git.GetRepoName(rootPath))
+	} else {
+		fmt.Printf("%s is in groups: %s\n", git.GetRepoName(rootPath), strings.Join(rc.Groups, ", "))
+	}
+	return nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change settings without the TUI",
+	Long:  "Reads and changes settings in the config file by name, e.g. 'autogit config set ai_provider openai'. Nested settings are named with dots, such as notifications.on_success. With --local, the settings of the current repository's entry under repos are used instead of the global ones.",
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		cfg, settings, _, err := loadSettings(cmd)
+		if err != nil {
+			return err
+		}
+		if key == "api_key" {
+			return fmt.Errorf("the API key is not printed; it is kept in: %s", cfg.KeyStorage())
+		}
+		local, _ := cmd.Flags().GetBool("local")
+		if err := config.CheckKey(key, local); err != nil {
+			return err
+		}
+		
+		value, ok := config.Lookup(settings, key)
+		if !ok {
+			return fmt.Errorf("%s is not set", key)
+		}
+		if obj, isObj := value.(map[string]interface{}); isObj {
+			for _, line := range config.Flatten(obj) {
+				fmt.Printf("%s.%s=%s\n", key, line[0], line[1])
+			}
+			return nil
+		}
+		fmt.Println(config.FormatValue(value))
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting",
+	Long:  "Changes a setting and saves the config once it is valid. Numbers, true, false, lists such as '[\"dist/\"]' and objects are read as JSON; anything else is a string.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+		local, _ := cmd.Flags().GetBool("local")
+		switch {
+		case key == "api_key":
+			return fmt.Errorf("the API key is not set on the command line, where it would stay in the shell history; run 'autogit reauth'")
+		case !local && key == "repos":
+			return fmt.Errorf("repos is changed by 'autogit init' and 'autogit uninit'; use --local for the current repository's settings")
+		case local && (key == "path" || key == "id"):
+			return fmt.Errorf("%s is set by 'autogit init' and 'autogit repair'", key)
+		}
+		settings, err := config.ParseSetting(key, value, local)
+		if err != nil {
+			return err
+		}
+		
+		cfg, _, rc, err := loadSettings(cmd)
+		if err != nil {
+			return err
+		}
+		if local {
+			err = config.ApplySettings(&rc, settings)
+			cfg.SetRepo(rc)
+		} else {
+			err = config.ApplySettings(cfg, settings)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		if problems := cfg.Validate(); len(problems) > 0 {
+			return &config.ValidationError{Path: config.GetConfigPath(), Problems: problems}
+		}
+		if !local && (key == "ai_models" || key == "ai_models."+strings.ToLower(cfg.AIProvider)) {
+			if err := checkModel(cfg); err != nil {
+				return err
+			}
+		}
+		if err := config.SaveConfig(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		
+		fmt.Printf("✓ %s = %s\n", key, value)
+		if daemons, _, _ := config.ListLiveDaemonInfo(); len(daemons) > 0 {
+			fmt.Println("  Running daemons use it once restarted ('autogit pause --stop', then 'autogit init')")
+		}
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print every setting that is set",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, settings, _, err := loadSettings(cmd)
+		if err != nil {
+			return err
+		}
+		local, _ := cmd.Flags().GetBool("local")
+		
+		// Repository entries are listed with --local in each repository
+		delete(settings, "repos")
+		delete(settings, "api_key")
+		for _, line := range config.Flatten(settings) {
+			fmt.Printf("%s=%s\n", line[0], line[1])
+		}
+		if !local {
+			fmt.Printf("api_key: %s\n", cfg.KeyStorage())
+		}
+		return nil
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in $EDITOR",
+	Long:  "Opens the config file in $VISUAL or $EDITOR (vi, or notepad on Windows, when neither is set) and checks it once the editor exits. Per-repository settings are the entries under repos.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := config.GetConfigPath()
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			// Writes the default config to edit
+			if _, err := config.LoadConfig(); err != nil {
+				return fmt.Errorf("failed to create config: %w", err)
+			}
+		}
+		
+		editor := os.Getenv("VISUAL")
+		if editor == "" {
+			editor = os.Getenv("EDITOR")
+		}
+		if editor == "" {
+			editor = "vi"
+			if runtime.GOOS == "windows" {
+				editor = "notepad"
+			}
+		}
+		fields := strings.Fields(editor)
+		c := exec.Command(fields[0], append(fields[1:], path)...)
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("failed to run %s: %w", editor, err)
+		}
+		
+		if _, err := config.LoadConfig(); err != nil {
+			return fmt.Errorf("%w\nrun 'autogit config edit' again to correct it", err)
+		}
+		fmt.Println("✓ Config is valid")
+		return nil
+	},
+}
+
+// loadSettings loads the config and returns the settings 'autogit config'
+// works on: the global ones, or with --local the current repository's
+// entry, which is created when missing
+func loadSettings(cmd *cobra.Command) (*config.Config, map[string]interface{}, config.RepoConfig, error) {
+	var rc config.RepoConfig
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, rc, fmt.Errorf("failed to load config: %w", err)
+	}
+	
+	var settings map[string]interface{}
+	if local, _ := cmd.Flags().GetBool("local"); local {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return nil, nil, rc, fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		var ok bool
+		if rc, ok = cfg.FindRepo(rootPath); !ok {
+			rc.Path = rootPath
+		}
+		settings, err = config.Settings(rc)
+	} else {
+		settings, err = config.Settings(cfg)
+	}
+	if err != nil {
+		return nil, nil, rc, fmt.Errorf("failed to read settings: %w", err)
+	}
+	return cfg, settings, rc, nil
+}
+
+// restartStale starts the daemon again for the repository of a record whose
+// process no longer runs. The record is only removed on pause --stop, so the
+// daemon was meant to be running. A service manager restarts its own.
+func restartStale(stale *config.DaemonInfo) error {
+	fmt.Printf("Status: Process %d not found (may have crashed or the system rebooted)\n", stale.PID)
+	fmt.Printf("Repository: %s\n", stale.RepoPath)
+	if service.Installed(stale.RepoPath) {
+		fmt.Println("The service manager will start it again")
+		return nil
+	}
+	
+	if err := daemon.StartDaemonProcess(stale.RepoPath); err != nil {
+		return fmt.Errorf("failed to restart daemon: %w", err)
+	}
+	if info, _ := config.LoadDaemonInfo(config.StateName(stale.RepoPath)); info != nil {
+		fmt.Printf("✓ Daemon restarted (PID: %d)\n", info.PID)
+	}
+	return nil
+}
+
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Relink a moved or re-cloned repository to its settings and history",
+	Long:  "Looks up the repository registered with this repository's ID (autogit.id in its git config) and points it at the current path, moving its log, commit history, statistics and pending state along.\n\nA fresh clone has no ID; pass the ID shown by 'autogit status' at the old location with --id.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootPath, err := getRootPath()
+		if err != nil {
+			return fmt.Errorf("failed to detect Git root: %w", err)
+		}
+		repo := openGit(rootPath)
+		id, _ := cmd.Flags().GetString("id")
+		if id == "" {
+			id = repo.RepoID()
+		}
+		if id == "" {
+			return fmt.Errorf("this repository has no autogit ID\nPass the ID of its old location with --id, or run 'autogit init' to register it as a new repository")
+		}
+		
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		entry, ok := cfg.FindRepoByID(id)
+		if !ok {
+			return fmt.Errorf("no repository is registered with ID %s", id)
+		}
+		if pathutil.Same(entry.Path, rootPath) {
+			if err := repo.SetRepoID(id); err != nil {
+				return err
+			}
+			fmt.Printf("✓ %s is already linked to ID %s\n", rootPath, id)
+			return nil
+		}
+		
+		daemons, _, _ := config.ListLiveDaemonInfo()
+		if daemonInfo := config.DaemonFor(daemons, entry.Path); daemonInfo != nil {
+			if err := daemon.StopDaemonProcess(daemonInfo); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Stopped the daemon for %s\n", entry.Path)
+		}
+		
+		oldPath, moved, err := cfg.Relink(id, rootPath)
+		if err != nil {
+			return err
+		}
+		store, err := history.Open(cfg.Storage)
+		if err != nil {
+			return err
+		}
+		before, after := config.RepoConfig{ID: id, Path: oldPath}, cfg.ForRepo(rootPath)
+		for _, name := range append(before.LegacyStateNames(), before.StateName()) {
+			if err = store.Rename(name, after.StateName()); err != nil {
+				break
+			}
+		}
+		store.Close()
+		if err != nil {
+			return err
+		}
+		if err := config.SaveConfig(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		if err := repo.SetRepoID(id); err != nil {
+			return err
+		}
+		
+		fmt.Printf("✓ Relinked %s to %s\n", oldPath, rootPath)
+		for _, path := range moved {
+			fmt.Printf("✓ Moved %s\n", path)
+		}
+		if service.Installed(oldPath) {
+			fmt.Println("The service still starts the daemon at the old path; run 'autogit install-service' again here")
+		} else {
+			fmt.Println("Run 'autogit init' to start the daemon")
+		}
+		return nil
+	},
+}
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop all daemons and remove every service, optionally with all data",
+	Long:  "Stops every autogit daemon, including ones whose record was lost, and removes the services registered for any repository, so that the binary can be deleted without leaving processes behind.\n\nWith --purge, the config directory (config, logs, commit history, statistics and state), log files kept elsewhere, the API key in the OS keyring and the autogit.id of registered repositories are deleted as well, after confirmation. Repositories and their commits are not touched.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		purge, _ := cmd.Flags().GetBool("purge")
+		dir := config.GetConfigDir()
+		if purge {
+			if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+				fmt.Printf("This deletes %s with the config, logs, commit history, statistics and state of every repository.\n", dir)
+				fmt.Print("Continue? [y/N] ")
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+					fmt.Println("Nothing was removed")
+					return nil
+				}
+			}
+		}
+		
+		names, err := service.List()
+		if err != nil {
+			fmt.Printf("⚠ Could not list services: %v\n", err)
+		}
+		for _, name := range names {
+			if err := service.Remove(name); err != nil {
+				return fmt.Errorf("failed to remove service %s: %w", name, err)
+			}
+			fmt.Printf("✓ Removed service %s\n", name)
+		}
+		
+		// Supervisors go first, as they would restart a daemon stopped
+		// under them
+		supervisors, err := proc.Find("supervise")
+		if err != nil {
+			fmt.Printf("⚠ Could not look for supervisor processes: %v\n", err)
+		}
+		stopProcesses("supervisor", supervisors)
+		
+		pids, err := proc.Find("start-daemon")
+		if err != nil {
+			fmt.Printf("⚠ Could not look for daemon processes: %v\n", err)
+		}
+		infos, _ := config.ListDaemonInfo()
+		for _, info := range infos {
+			if info.Alive() && !containsPID(pids, info.PID) {
+				pids = append(pids, info.PID)
+			}
+		}
+		stopProcesses("daemon", pids)
+		for _, info := range infos {
+			config.DeleteDaemonInfo(info.Name)
+			config.DeleteHealth(info.Name)
+		}
+		
+		if !purge {
+			fmt.Printf("autogit is stopped. Its config and history are kept in %s; 'autogit uninstall --purge' deletes them\n", dir)
+			return nil
+		}
+		
+		if cfg, err := config.LoadConfig(); err == nil {
+			for _, r := range cfg.Repos {
+				rc := cfg.ForRepo(r.Path)
+				if logPath := rc.LogPath(); rc.GetLogDestination() == config.LogToFile && !pathutil.Within(logPath, dir) {
+					if err := os.Remove(logPath); err == nil {
+						fmt.Printf("✓ Removed %s\n", logPath)
+					}
+				}
+				if r.ID != "" {
+					if err := git.OpenLocation(git.Location{GitDir: r.GitDir, WorkTree: r.Path}).UnsetRepoID(); err != nil {
+						fmt.Printf("⚠ %v\n", err)
+					}
+				}
+			}
+			if cfg.APIKeyRef != "" {
+				if err := config.DeleteKeyringKey(); err != nil {
+					fmt.Printf("⚠ %v\n", err)
+				} else {
+					fmt.Println("✓ Removed the API key from the keyring")
+				}
+			}
+		} else {
+			fmt.Printf("⚠ Could not read the config, so log files outside %s, autogit.id in repositories and the API key in the keyring are kept: %v\n", dir, err)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", dir, err)
+		}
+		fmt.Printf("✓ Removed %s\n", dir)
+		fmt.Println("autogit has been removed; delete the autogit binary to finish")
+		return nil
+	},
+}
+
+// stopProcesses terminates the processes of pids that still run and waits
+// for them to exit. Daemons flush their state on the way out, and Windows
+// keeps files in use until they have exited.
+func stopProcesses(kind string, pids []int) {
+	var stopped []int
+	for _, pid := range pids {
+		if !proc.Running(pid) {
+			continue
+		}
+		if err := proc.Terminate(pid); err != nil {
+			fmt.Printf("⚠ Failed to stop %s PID %d: %v\n", kind, pid, err)
+			continue
+		}
+		fmt.Printf("✓ Stopped %s PID %d\n", kind, pid)
+		stopped = append(stopped, pid)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for _, pid := range stopped {
+		for proc.Running(pid) && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if proc.Running(pid) {
+			fmt.Printf("⚠ The %s with PID %d has not exited yet; end it with your system's task manager\n", kind, pid)
+		}
+	}
+}
+
+func containsPID(pids []int, pid int) bool {
+	for _, p := range pids {
+		if p == pid {
+			return true
+		}
+	}
+	return false
+}
+
+// identify makes sure repo has an ID, recorded in both the config and its
+// git config (or .hg/hgrc), so that 'autogit repair' can find its settings and history
+// after it moves
+func identify(cfg *config.Config, repo vcs.Repo) error {
+	rootPath := repo.Root()
+	rc, registered := cfg.FindRepo(rootPath)
+	id := repo.RepoID()
+	if other, ok := cfg.FindRepoByID(id); ok && !registered {
+		if _, err := os.Stat(other.Path); err != nil {
+			return fmt.Errorf("this repository was registered at %s, which no longer exists\nRun 'autogit repair' to move its settings and history here", other.Path)
+		}
+		// Both exist, so this one is a copy and needs its own ID
+		fmt.Printf("This repository is a copy of %s, giving it a new ID\n", other.Path)
+		id = ""
+	}
+	if rc.ID != "" {
+		id = rc.ID
+	} else if id == "" {
+		id = config.NewRepoID()
+	}
+	
+	if rc.ID != id {
+		rc.Path = rootPath
+		rc.ID = id
+		cfg.SetRepo(rc)
+	}
+	if repo.RepoID() != id {
+		return repo.SetRepoID(id)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(setupCmd)
+	rootCmd.AddCommand(menuCmd)
+	rootCmd.AddCommand(startDaemonCmd)
+	rootCmd.AddCommand(superviseCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(triggerCmd)
+	hookCmd.AddCommand(hookInstallCmd, hookUninstallCmd)
+	rootCmd.AddCommand(hookCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(installServiceCmd)
+	rootCmd.AddCommand(uninstallServiceCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(whyCmd)
+	rootCmd.AddCommand(approveCmd)
+	backupsCmd.AddCommand(backupsListCmd, backupsRestoreCmd)
+	rootCmd.AddCommand(backupsCmd)
+	storageCmd.AddCommand(storageMigrateCmd)
+	rootCmd.AddCommand(storageCmd)
+	fleetApplyCmd.Flags().Bool("dry-run", false, "Report what would change without changing anything")
+	fleetApplyCmd.Flags().Bool("no-services", false, "Register repositories without installing services for their daemons")
+	fleetCmd.AddCommand(fleetApplyCmd)
+	rootCmd.AddCommand(fleetCmd)
+	rootCmd.AddCommand(muteCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(uninitCmd)
+	rootCmd.AddCommand(reauthCmd)
+	groupCmd.AddCommand(groupAddCmd, groupRemoveCmd, groupListCmd)
+	rootCmd.AddCommand(groupCmd)
+	rootCmd.AddCommand(repairCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configListCmd, configEditCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(uninstallCmd)
+	
+	statsCmd.Flags().Int("days", 0, "Also show a row for each of the last N days")
+	approveCmd.Flags().BoolP("yes", "y", false, "Approve without asking")
+	backupsRestoreCmd.Flags().Bool("reset", false, "Also move the current branch back to the backed up commits")
+	muteCmd.Flags().Bool("off", false, "Unmute notifications now")
+	serveCmd.Flags().String("addr", server.DefaultAddr, "Address to listen on")
+	uninitCmd.Flags().Bool("purge", false, "Also remove the commit history, statistics, backups and per-repository settings")
+	reauthCmd.Flags().String("key", "", "New API key; prompted for when omitted")
+	pauseCmd.Flags().String("group", "", "Pause the daemon of any repository in this group")
+	pauseCmd.Flags().Bool("stop", false, "Stop the daemon process instead of pausing it")
+	resumeCmd.Flags().String("group", "", "Resume the daemon of any repository in this group")
+	statusCmd.Flags().String("group", "", "List the repositories in this group and their daemons")
+	statusCmd.Flags().Bool("serve-ssh", false, "Serve the read-only dashboard over SSH")
+	statusCmd.Flags().String("ssh-addr", tui.DefaultSSHAddr, "Address the SSH server listens on")
+	statusCmd.Flags().String("authorized-keys", "", "Keys allowed to connect (default ~/.ssh/authorized_keys)")
+	serveCmd.Flags().String("token", "", "Token required by every request (default $AUTOGIT_SERVE_TOKEN, or random)")
+	uninstallCmd.Flags().Bool("purge", false, "Also delete the config, logs, commit history, statistics and stored API key")
+	uninstallCmd.Flags().BoolP("yes", "y", false, "Delete without asking")
+	repairCmd.Flags().String("id", "", "ID of the repository's old location, for a clone without autogit.id")
+	configCmd.PersistentFlags().Bool("local", false, "Use the current repository's settings instead of the global ones")
+	menuCmd.Flags().Bool("demo", false, "Explore the dashboard with synthetic data instead of your repositories")
+	menuCmd.Flags().Bool("basic", false, "Use a linear, screen-reader friendly interface instead of the full-screen TUI")
+	
+	initCmd.Flags().String("git-dir", "", "Path to the git directory (for bare repositories such as dotfiles)")
+	initCmd.Flags().String("work-tree", "", "Path to the work tree used with --git-dir")
+	initCmd.Flags().StringArray("set", nil, "Override a setting of this repository for the daemon, as key=value (repeatable)")
+	
+	// Enable version flag
+	rootCmd.SetVersionTemplate("autogit version {{.Version}}\n")
+	
+	// Alias --menu for menu command
+	rootCmd.PersistentFlags().BoolP("menu", "m", false, "Open interactive TUI dashboard")
+	rootCmd.PersistentFlags().Bool("no-keyring", false, "Store the API key in the config file instead of the OS keyring")
+	rootCmd.PersistentFlags().String("repo", "", "Run for this repository, by path or by its name in the config, instead of the current one")
+	rootCmd.RegisterFlagCompletionFunc("repo", completeRepos)
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if noKeyring, _ := cmd.Flags().GetBool("no-keyring"); noKeyring {
+			config.DisableKeyring()
+		}
+		
+		// Commands find their repository from the working directory
+		// unless --repo names one
+		if repo, _ := cmd.Flags().GetString("repo"); repo != "" {
+			rc, err := resolveRepo(repo)
+			if err != nil {
+				return err
+			}
+			repoFlag = rc
+		}
+		
+		if menu, _ := cmd.Flags().GetBool("menu"); menu {
+			// Execute menu command
+			menuCmd.RunE(cmd, args)
+			os.Exit(0)
+		}
+		return nil
 	}
 }
 
@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -11,9 +12,9 @@ type AnthropicProvider struct {
 	apiKey string
 }
 
-func NewAnthropicProvider(apiKey string) *AnthropicProvider {
+func NewAnthropicProvider(apiKey string, opts Options) *AnthropicProvider {
 	return &AnthropicProvider{
-		BaseProvider: NewBaseProvider(),
+		BaseProvider: NewBaseProvider(opts),
 		apiKey:       apiKey,
 	}
 }
@@ -22,32 +23,61 @@ type AnthropicRequest struct {
 	Model     string   `json:"model"`
 	MaxTokens int      `json:"max_tokens"`
 	Messages  []Message `json:"messages"`
+	Stream    bool      `json:"stream,omitempty"`
 }
 
 type AnthropicResponse struct {
 	Content []AnthropicContent `json:"content"`
+	Usage   AnthropicUsage     `json:"usage"`
+}
+
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
 
 type AnthropicContent struct {
 	Text string `json:"text"`
 }
 
-func (a *AnthropicProvider) GenerateCommitMsg(diff string) (string, error) {
+// AnthropicStreamEvent is one server-sent event of a streamed message.
+// Input tokens arrive with message_start, text with content_block_delta
+// and output tokens with message_delta.
+type AnthropicStreamEvent struct {
+	Type    string                 `json:"type"`
+	Message AnthropicStreamMessage `json:"message"`
+	Delta   AnthropicContent       `json:"delta"`
+	Usage   AnthropicUsage         `json:"usage"`
+	Error   AnthropicError         `json:"error"`
+}
+
+type AnthropicStreamMessage struct {
+	Usage AnthropicUsage `json:"usage"`
+}
+
+type AnthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func (a *AnthropicProvider) Model() string {
+	return a.modelOr(DefaultModel("anthropic", ""))
+}
+
+func (a *AnthropicProvider) GenerateCommitMsg(ctx context.Context, diff string) (string, error) {
 	if a.apiKey == "" {
 		return "", fmt.Errorf("Anthropic API key is not set")
 	}
 	
-	// Truncate diff if too long
-	if len(diff) > 100000 {
-		diff = diff[:100000] + "\n... (truncated)"
-	}
+	// Fit the diff into the token budget
+	diff = a.prepareDiff(diff)
 	
-	prompt := fmt.Sprintf("%s\n\nCode diff:\n%s", SystemPrompt, diff)
+	prompt := fmt.Sprintf("%s\n\nCode diff:\n%s", a.systemPrompt(), diff)
 	
 	url := "https://api.anthropic.com/v1/messages"
 	
 	reqBody := AnthropicRequest{
-		Model:     "claude-3-haiku-20240307",
+		Model:     a.Model(),
 		MaxTokens: 1024,
 		Messages: []Message{
 			{
@@ -55,6 +85,7 @@ func (a *AnthropicProvider) GenerateCommitMsg(diff string) (string, error) {
 				Content: prompt,
 			},
 		},
+		Stream: a.stream,
 	}
 	
 	jsonData, err := json.Marshal(reqBody)
@@ -68,7 +99,11 @@ func (a *AnthropicProvider) GenerateCommitMsg(diff string) (string, error) {
 		"anthropic-version": "2023-06-01",
 	}
 	
-	respBody, err := a.doRequest(url, headers, strings.NewReader(string(jsonData)))
+	if a.stream {
+		return a.streamMessage(ctx, url, headers, jsonData)
+	}
+	
+	respBody, err := a.doRequest(ctx, url, headers, strings.NewReader(string(jsonData)))
 	if err != nil {
 		return "", err
 	}
@@ -77,15 +112,43 @@ func (a *AnthropicProvider) GenerateCommitMsg(diff string) (string, error) {
 	if err := json.Unmarshal(respBody, &resp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	a.addTokens(resp.Usage.InputTokens + resp.Usage.OutputTokens)
 	
 	if len(resp.Content) == 0 {
 		return "", fmt.Errorf("no response from Anthropic API")
 	}
 	
-	message := strings.TrimSpace(resp.Content[0].Text)
-	// Remove quotes if present
-	message = strings.Trim(message, "\"'`")
-	
-	return message, nil
+	return a.cleanMessage(resp.Content[0].Text), nil
 }
 
+
+// streamMessage reads the message from a streamed response
+func (a *AnthropicProvider) streamMessage(ctx context.Context, url string, headers map[string]string, jsonData []byte) (string, error) {
+	var message strings.Builder
+	err := a.doStream(ctx, url, headers, strings.NewReader(string(jsonData)), func(data []byte) error {
+		var event AnthropicStreamEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal stream event: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			a.addTokens(event.Message.Usage.InputTokens)
+		case "content_block_delta":
+			message.WriteString(event.Delta.Text)
+		case "message_delta":
+			a.addTokens(event.Usage.OutputTokens)
+		case "error":
+			return fmt.Errorf("API error (%s): %s", event.Error.Type, event.Error.Message)
+		}
+		return nil
+	}, message.Len)
+	if err != nil {
+		return "", err
+	}
+	
+	if message.Len() == 0 {
+		return "", fmt.Errorf("no response from Anthropic API")
+	}
+	
+	return a.cleanMessage(message.String()), nil
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxSummaryMessage bounds the provider message kept in an error summary
+const maxSummaryMessage = 100
+
+// APIError is a response of the provider with a status other than 200
+type APIError struct {
+	Status int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	if e.Status == http.StatusUnauthorized {
+		return fmt.Sprintf("%v (status %d): %s", ErrUnauthorized, e.Status, e.Body)
+	}
+	return fmt.Sprintf("API error (status %d): %s", e.Status, e.Body)
+}
+
+// Unwrap makes a 401 match ErrUnauthorized and an unknown or retired
+// model match ErrModelNotFound
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.Status == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.modelMissing():
+		return ErrModelNotFound
+	}
+	return nil
+}
+
+// modelMissing reports whether the provider rejected the request because
+// it does not know the model, or no longer serves it
+func (e *APIError) modelMissing() bool {
+	if e.Status != http.StatusNotFound && e.Status != http.StatusBadRequest && e.Status != http.StatusGone {
+		return false
+	}
+	body := e.parse()
+	if strings.Contains(strings.ToLower(string(body.Error.Code)), "model_not_found") {
+		return true
+	}
+	message := strings.ToLower(body.Error.Message)
+	if !strings.Contains(message, "model") {
+		return false
+	}
+	if e.Status == http.StatusNotFound {
+		return true // Anthropic only says "model: <name>"
+	}
+	for _, hint := range []string{"not found", "does not exist", "not exist", "deprecated", "retired", "decommissioned", "no longer", "not supported", "invalid model", "unknown model"} {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// parse reads the error body, leaving it empty when it is not JSON
+func (e *APIError) parse() errorBody {
+	var body errorBody
+	json.Unmarshal([]byte(e.Body), &body)
+	return body
+}
+
+// errorBody covers the error bodies of Gemini, OpenAI-compatible APIs and
+// Anthropic, which all nest the details under "error"
+type errorBody struct {
+	Error struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`   // OpenAI and Anthropic
+		Status  string          `json:"status"` // Gemini, e.g. RESOURCE_EXHAUSTED
+		Code    json.RawMessage `json:"code"`   // A string for OpenAI, a number for Gemini
+	} `json:"error"`
+}
+
+// ErrorSummary condenses a failed request into a short line such as
+// "rate limited: Too many requests", parsing the provider's error body
+// where there is one
+func ErrorSummary(err error) string {
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out"
+	case !errors.As(err, &apiErr):
+		return truncate(err.Error(), maxSummaryMessage)
+	}
+	
+	body := apiErr.parse()
+	message := strings.Join(strings.Fields(body.Error.Message), " ")
+	code := strings.Trim(string(body.Error.Code), `"`)
+	kind := strings.ToLower(strings.Join([]string{body.Error.Type, body.Error.Status, code}, " "))
+	
+	var label string
+	switch {
+	case strings.Contains(kind, "quota") || strings.Contains(kind, "billing") ||
+		(apiErr.Status == http.StatusTooManyRequests && strings.Contains(strings.ToLower(message), "quota")):
+		label = "quota exceeded"
+	case apiErr.Status == http.StatusTooManyRequests || strings.Contains(kind, "rate_limit") || strings.Contains(kind, "resource_exhausted"):
+		label = "rate limited"
+	case apiErr.modelMissing():
+		label = "invalid model"
+	case apiErr.Status == http.StatusUnauthorized || apiErr.Status == http.StatusForbidden:
+		label = "API key rejected"
+	case strings.Contains(kind, "overloaded") || apiErr.Status == http.StatusServiceUnavailable:
+		label = "provider overloaded"
+	case apiErr.Status >= 500:
+		label = "provider error"
+	default:
+		label = "request rejected"
+	}
+	label += fmt.Sprintf(" (status %d)", apiErr.Status)
+	
+	if message == "" {
+		return label
+	}
+	return label + ": " + truncate(message, maxSummaryMessage)
+}
+
+// truncate shortens s to at most n characters, marking the cut
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
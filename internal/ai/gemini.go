@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -11,9 +12,9 @@ type GeminiProvider struct {
 	apiKey string
 }
 
-func NewGeminiProvider(apiKey string) *GeminiProvider {
+func NewGeminiProvider(apiKey string, opts Options) *GeminiProvider {
 	return &GeminiProvider{
-		BaseProvider: NewBaseProvider(),
+		BaseProvider: NewBaseProvider(opts),
 		apiKey:       apiKey,
 	}
 }
@@ -31,27 +32,33 @@ type GeminiPart struct {
 }
 
 type GeminiResponse struct {
-	Candidates []GeminiCandidate `json:"candidates"`
+	Candidates    []GeminiCandidate `json:"candidates"`
+	UsageMetadata GeminiUsage       `json:"usageMetadata"`
+}
+
+type GeminiUsage struct {
+	TotalTokenCount int `json:"totalTokenCount"`
 }
 
 type GeminiCandidate struct {
 	Content GeminiContent `json:"content"`
 }
 
-func (g *GeminiProvider) GenerateCommitMsg(diff string) (string, error) {
+func (g *GeminiProvider) Model() string {
+	return g.modelOr(DefaultModel("gemini", ""))
+}
+
+func (g *GeminiProvider) GenerateCommitMsg(ctx context.Context, diff string) (string, error) {
 	if g.apiKey == "" {
 		return "", fmt.Errorf("Gemini API key is not set")
 	}
 	
-	// Truncate diff if too long (Gemini has token limits)
-	if len(diff) > 100000 {
-		diff = diff[:100000] + "\n... (truncated)"
-	}
+	// Fit the diff into the token budget
+	diff = g.prepareDiff(diff)
 	
-	prompt := fmt.Sprintf("%s\n\nCode diff:\n%s", SystemPrompt, diff)
+	prompt := fmt.Sprintf("%s\n\nCode diff:\n%s", g.systemPrompt(), diff)
 	
-	// Use gemini-1.5-flash as it's the current recommended model
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-3-flash-preview:generateContent?key=%s", g.apiKey)
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", g.Model(), g.apiKey)
 	
 	reqBody := GeminiRequest{
 		Contents: []GeminiContent{
@@ -72,7 +79,7 @@ func (g *GeminiProvider) GenerateCommitMsg(diff string) (string, error) {
 		"Content-Type": "application/json",
 	}
 	
-	respBody, err := g.doRequest(url, headers, strings.NewReader(string(jsonData)))
+	respBody, err := g.doRequest(ctx, url, headers, strings.NewReader(string(jsonData)))
 	if err != nil {
 		return "", err
 	}
@@ -81,15 +88,12 @@ func (g *GeminiProvider) GenerateCommitMsg(diff string) (string, error) {
 	if err := json.Unmarshal(respBody, &resp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	g.addTokens(resp.UsageMetadata.TotalTokenCount)
 	
 	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
 		return "", fmt.Errorf("no response from Gemini API")
 	}
 	
-	message := strings.TrimSpace(resp.Candidates[0].Content.Parts[0].Text)
-	// Remove quotes if present
-	message = strings.Trim(message, "\"'`")
-	
-	return message, nil
+	return g.cleanMessage(resp.Candidates[0].Content.Parts[0].Text), nil
 }
 
@@ -0,0 +1,124 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultModel returns the model provider requests when none is
+// configured. baseURL tells OpenRouter, which names models by vendor, from
+// other OpenAI-compatible APIs, as in NewProvider.
+func DefaultModel(provider, baseURL string) string {
+	switch strings.ToLower(provider) {
+	case "gemini":
+		return "gemini-3-flash-preview"
+	case "openai", "openrouter":
+		if baseURL == "" && provider != "openai" {
+			baseURL = "https://openrouter.ai/api/v1"
+		}
+		if strings.Contains(baseURL, "openrouter") {
+			return "openai/gpt-3.5-turbo" // OpenRouter format
+		}
+		return "gpt-3.5-turbo"
+	case "anthropic", "claude":
+		return "claude-3-haiku-20240307"
+	}
+	return ""
+}
+
+// modelsRequest builds the request that lists the models of provider, at
+// most limit of them where the API pages its answer. It returns nil for a
+// provider without an API.
+func modelsRequest(provider, apiKey, baseURL string, limit int) (*http.Request, error) {
+	var url string
+	headers := map[string]string{}
+	
+	switch strings.ToLower(provider) {
+	case "none", "template":
+		return nil, nil // Nothing to reach
+	case "gemini":
+		url = fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models?pageSize=%d", limit)
+		headers["x-goog-api-key"] = apiKey
+	case "openai", "openrouter":
+		if baseURL == "" && provider == "openai" {
+			baseURL = "https://api.openai.com/v1"
+		} else if baseURL == "" {
+			baseURL = "https://openrouter.ai/api/v1"
+		}
+		url = fmt.Sprintf("%s/models", strings.TrimSuffix(baseURL, "/"))
+		headers["Authorization"] = "Bearer " + apiKey
+	case "anthropic", "claude":
+		url = fmt.Sprintf("https://api.anthropic.com/v1/models?limit=%d", limit)
+		headers["x-api-key"] = apiKey
+		headers["anthropic-version"] = "2023-06-01"
+	default:
+		return nil, fmt.Errorf("unknown AI provider: %s", provider)
+	}
+	
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// ListModels returns the IDs of the models provider offers for generating
+// text, sorted, as they are set in ai_models, asking through transport
+func ListModels(provider, apiKey, baseURL string, transport http.RoundTripper) ([]string, error) {
+	req, err := modelsRequest(provider, apiKey, baseURL, 1000)
+	if err != nil || req == nil {
+		return nil, err
+	}
+	
+	client := newClient(transport, PingTimeout)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("%w (status %d)", ErrUnauthorized, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("API error (status %d)", resp.StatusCode)
+	}
+	
+	// Gemini lists "models", the others "data" in the OpenAI format
+	var list struct {
+		Models []struct {
+			Name    string   `json:"name"`
+			Methods []string `json:"supportedGenerationMethods"`
+		} `json:"models"`
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse the model list: %w", err)
+	}
+	var models []string
+	for _, m := range list.Models {
+		for _, method := range m.Methods {
+			if method == "generateContent" {
+				models = append(models, strings.TrimPrefix(m.Name, "models/"))
+				break
+			}
+		}
+	}
+	for _, m := range list.Data {
+		models = append(models, m.ID)
+	}
+	sort.Strings(models)
+	return models, nil
+}
@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// NewTransport returns the transport for requests to the providers, pings
+// and model lists through proxy, or the proxy of the environment when it
+// is empty. Certificates in the PEM file caBundle are trusted besides the
+// system's; insecureSkipVerify accepts any certificate, for proxies whose
+// authority cannot be exported.
+func NewTransport(proxy, caBundle string, insecureSkipVerify bool) (http.RoundTripper, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if caBundle != "" || insecureSkipVerify {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if caBundle != "" {
+			pem, err := os.ReadFile(caBundle)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", caBundle)
+			}
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, InsecureSkipVerify: insecureSkipVerify}
+	}
+	return t, nil
+}
+
+// TransportFor returns the transport the network settings n describe, see
+// NewTransport
+func TransportFor(n config.Network) (http.RoundTripper, error) {
+	return NewTransport(n.Proxy, n.CABundlePath(), n.InsecureSkipVerify)
+}
+
+// newClient returns a client for requests to the providers through
+// transport, bounded by timeout, or by their context when it is 0. A nil
+// transport is the default, which honors HTTPS_PROXY, HTTP_PROXY and
+// NO_PROXY.
+func newClient(transport http.RoundTripper, timeout time.Duration) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -12,17 +13,23 @@ type OpenAIProvider struct {
 	baseURL string
 }
 
-func NewOpenAIProvider(apiKey, baseURL string) *OpenAIProvider {
+func NewOpenAIProvider(apiKey, baseURL string, opts Options) *OpenAIProvider {
 	return &OpenAIProvider{
-		BaseProvider: NewBaseProvider(),
+		BaseProvider: NewBaseProvider(opts),
 		apiKey:       apiKey,
 		baseURL:      baseURL,
 	}
 }
 
 type OpenAIRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model         string               `json:"model"`
+	Messages      []Message            `json:"messages"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *OpenAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type Message struct {
@@ -31,35 +38,48 @@ type Message struct {
 }
 
 type OpenAIResponse struct {
-	Choices []Choice `json:"choices"`
+	Choices []Choice    `json:"choices"`
+	Usage   OpenAIUsage `json:"usage"`
+}
+
+type OpenAIUsage struct {
+	TotalTokens int `json:"total_tokens"`
 }
 
 type Choice struct {
 	Message Message `json:"message"`
 }
 
-func (o *OpenAIProvider) GenerateCommitMsg(diff string) (string, error) {
+// OpenAIStreamChunk is one server-sent event of a streamed completion. The
+// last chunk carries the usage and no choices.
+type OpenAIStreamChunk struct {
+	Choices []StreamChoice `json:"choices"`
+	Usage   *OpenAIUsage   `json:"usage"`
+}
+
+type StreamChoice struct {
+	Delta Message `json:"delta"`
+}
+
+// Model returns the model requested, which depends on the base URL
+func (o *OpenAIProvider) Model() string {
+	return o.modelOr(DefaultModel("openai", o.baseURL))
+}
+
+func (o *OpenAIProvider) GenerateCommitMsg(ctx context.Context, diff string) (string, error) {
 	if o.apiKey == "" {
 		return "", fmt.Errorf("OpenAI API key is not set")
 	}
 	
-	// Truncate diff if too long
-	if len(diff) > 100000 {
-		diff = diff[:100000] + "\n... (truncated)"
-	}
+	// Fit the diff into the token budget
+	diff = o.prepareDiff(diff)
 	
-	prompt := fmt.Sprintf("%s\n\nCode diff:\n%s", SystemPrompt, diff)
+	prompt := fmt.Sprintf("%s\n\nCode diff:\n%s", o.systemPrompt(), diff)
 	
 	url := fmt.Sprintf("%s/chat/completions", strings.TrimSuffix(o.baseURL, "/"))
 	
-	// Determine model based on base URL
-	model := "gpt-3.5-turbo"
-	if strings.Contains(o.baseURL, "openrouter") {
-		model = "openai/gpt-3.5-turbo" // OpenRouter format
-	}
-	
 	reqBody := OpenAIRequest{
-		Model: model,
+		Model: o.Model(),
 		Messages: []Message{
 			{
 				Role:    "user",
@@ -67,6 +87,10 @@ func (o *OpenAIProvider) GenerateCommitMsg(diff string) (string, error) {
 			},
 		},
 	}
+	if o.stream {
+		reqBody.Stream = true
+		reqBody.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+	}
 	
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -84,7 +108,11 @@ func (o *OpenAIProvider) GenerateCommitMsg(diff string) (string, error) {
 		headers["X-Title"] = "Autogit"
 	}
 	
-	respBody, err := o.doRequest(url, headers, strings.NewReader(string(jsonData)))
+	if o.stream {
+		return o.streamCompletion(ctx, url, headers, jsonData)
+	}
+	
+	respBody, err := o.doRequest(ctx, url, headers, strings.NewReader(string(jsonData)))
 	if err != nil {
 		return "", err
 	}
@@ -93,15 +121,39 @@ func (o *OpenAIProvider) GenerateCommitMsg(diff string) (string, error) {
 	if err := json.Unmarshal(respBody, &resp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	o.addTokens(resp.Usage.TotalTokens)
 	
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("no response from OpenAI API")
 	}
 	
-	message := strings.TrimSpace(resp.Choices[0].Message.Content)
-	// Remove quotes if present
-	message = strings.Trim(message, "\"'`")
-	
-	return message, nil
+	return o.cleanMessage(resp.Choices[0].Message.Content), nil
 }
 
+
+// streamCompletion reads the message from a streamed completion
+func (o *OpenAIProvider) streamCompletion(ctx context.Context, url string, headers map[string]string, jsonData []byte) (string, error) {
+	var message strings.Builder
+	err := o.doStream(ctx, url, headers, strings.NewReader(string(jsonData)), func(data []byte) error {
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			message.WriteString(choice.Delta.Content)
+		}
+		if chunk.Usage != nil {
+			o.addTokens(chunk.Usage.TotalTokens)
+		}
+		return nil
+	}, message.Len)
+	if err != nil {
+		return "", err
+	}
+	
+	if message.Len() == 0 {
+		return "", fmt.Errorf("no response from OpenAI API")
+	}
+	
+	return o.cleanMessage(message.String()), nil
+}
@@ -0,0 +1,39 @@
+package ai
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// PingTimeout bounds a connectivity check
+const PingTimeout = 10 * time.Second
+
+// Ping checks that the provider is reachable through transport and accepts
+// apiKey by listing its models, which costs no tokens. It returns the
+// round-trip latency.
+func Ping(provider, apiKey, baseURL string, transport http.RoundTripper) (time.Duration, error) {
+	req, err := modelsRequest(provider, apiKey, baseURL, 1)
+	if err != nil || req == nil {
+		return 0, err
+	}
+	
+	client := newClient(transport, PingTimeout)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	latency := time.Since(start)
+	
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return latency, fmt.Errorf("%w (status %d)", ErrUnauthorized, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return latency, fmt.Errorf("API error (status %d)", resp.StatusCode)
+	}
+	return latency, nil
+}
@@ -1,36 +1,82 @@
 package ai
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	SystemPrompt = "You are a git automation bot. Analyze the provided code diff. Respond ONLY with a concise, Conventional Commit message (e.g., 'fix(ui): adjust button padding'). Do not add quotes or markdown."
+	BodyPrompt   = "You are a git automation bot. Analyze the provided code diff. Respond ONLY with a Conventional Commit message: a concise subject line (e.g., 'fix(ui): adjust button padding'), a blank line, then one line per file with notable changes in the form '- path: what changed and why'. Skip files with trivial changes. Do not add quotes or markdown."
 )
 
-// AIProvider defines the interface for AI commit message generation
+// ErrUnauthorized is wrapped by the error of a request the provider
+// rejected with 401, typically because the API key expired or was revoked
+var ErrUnauthorized = errors.New("API key rejected")
+
+// ErrModelNotFound is wrapped by the error of a request the provider
+// rejected because it does not know the model, e.g. after retiring it
+var ErrModelNotFound = errors.New("model not found")
+
+// DefaultTimeout bounds a request when no timeout is configured
+const DefaultTimeout = 30 * time.Second
+
+// Options configures behaviour shared by all providers
+type Options struct {
+	TokenBudget int           // Maximum diff size sent to the AI, in estimated tokens
+	FileLines   int           // Maximum diff lines sent per file, 0 for no limit
+	Timeout     time.Duration // Limit for one request, including a streamed response
+	Stream      bool          // Stream responses where the provider supports it
+	Body        bool          // Ask for a body listing notable changes per file
+	Prompt      string        // Replaces SystemPrompt or BodyPrompt when set
+	Model       string        // Replaces the provider's default model when set
+	Transport   http.RoundTripper // Carries the requests, see NewTransport; nil for the default
+}
+
+func (o Options) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return o.Timeout
+}
+
+func (o Options) tokenBudget() int {
+	if o.TokenBudget <= 0 {
+		return DefaultTokenBudget
+	}
+	return o.TokenBudget
+}
+
+// AIProvider defines the interface for AI commit message generation.
+// Requests stop when ctx is cancelled or the provider timeout expires.
 type AIProvider interface {
-	GenerateCommitMsg(diff string) (string, error)
+	GenerateCommitMsg(ctx context.Context, diff string) (string, error)
 }
 
 // NewProvider creates an AI provider based on the provider name
-func NewProvider(provider, apiKey, baseURL string) (AIProvider, error) {
+func NewProvider(provider, apiKey, baseURL string, opts Options) (AIProvider, error) {
 	switch strings.ToLower(provider) {
 	case "gemini":
-		return NewGeminiProvider(apiKey), nil
+		return NewGeminiProvider(apiKey, opts), nil
 	case "openai", "openrouter":
 		if baseURL == "" && provider == "openai" {
 			baseURL = "https://api.openai.com/v1"
 		} else if baseURL == "" {
 			baseURL = "https://openrouter.ai/api/v1"
 		}
-		return NewOpenAIProvider(apiKey, baseURL), nil
+		return NewOpenAIProvider(apiKey, baseURL, opts), nil
 	case "anthropic", "claude":
-		return NewAnthropicProvider(apiKey), nil
+		return NewAnthropicProvider(apiKey, opts), nil
+	case "none", "template":
+		return NewTemplateProvider(), nil
 	default:
 		return nil, fmt.Errorf("unknown AI provider: %s", provider)
 	}
@@ -38,19 +84,194 @@ func NewProvider(provider, apiKey, baseURL string) (AIProvider, error) {
 
 // BaseProvider provides common HTTP client functionality
 type BaseProvider struct {
-	client *http.Client
+	client      *http.Client // Carries the requests through Options.Transport
+	tokenBudget int
+	fileLines   int    // Lines per file sent to the AI, 0 for no limit
+	timeout     time.Duration
+	stream      bool
+	body        bool   // Ask for a body summarizing the changes per file
+	prompt      string // Custom instructions, empty for the built-in ones
+	model       string // Model requested instead of the provider's default
+	tokensUsed  int64  // Total tokens reported by the API, updated atomically
 }
 
-func NewBaseProvider() *BaseProvider {
+func NewBaseProvider(opts Options) *BaseProvider {
 	return &BaseProvider{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		// Requests are bounded by their context instead of a client timeout
+		client:      newClient(opts.Transport, 0),
+		tokenBudget: opts.tokenBudget(),
+		fileLines:   opts.FileLines,
+		timeout:     opts.timeout(),
+		stream:      opts.Stream,
+		body:        opts.Body,
+		prompt:      opts.Prompt,
+		model:       opts.Model,
+	}
+}
+
+// modelOr returns the configured model, or fallback when none is set
+func (b *BaseProvider) modelOr(fallback string) string {
+	if b.model != "" {
+		return b.model
+	}
+	return fallback
+}
+
+// systemPrompt returns the instructions sent ahead of the diff
+func (b *BaseProvider) systemPrompt() string {
+	if b.prompt != "" {
+		return b.prompt
+	}
+	if b.body {
+		return BodyPrompt
 	}
+	return SystemPrompt
 }
 
-func (b *BaseProvider) doRequest(url string, headers map[string]string, body io.Reader) ([]byte, error) {
-	req, err := http.NewRequest("POST", url, body)
+// prepareDiff fits diff into the token budget and lists moved and copied
+// files ahead of it, so that the model describes them as moves. The list
+// counts against the budget; it is left out when it would take more than
+// half of it, as the diff headers name the moves as well.
+func (b *BaseProvider) prepareDiff(diff string) string {
+	notes := renameNotes(parseDiff(diff))
+	budget := b.tokenBudget - EstimateTokens(notes)
+	if budget < b.tokenBudget/2 {
+		notes, budget = "", b.tokenBudget
+	}
+	return notes + SummarizeDiff(diff, budget, b.fileLines)
+}
+
+// cleanMessage turns the text of a response into a commit message,
+// removing the quotes and code fences models add despite the prompt. With
+// a body, the subject is separated from it by exactly one blank line and
+// bullets use "-".
+func (b *BaseProvider) cleanMessage(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		// Drop the fence lines, including a language tag
+		text = strings.TrimSuffix(text, "```")
+		if _, rest, ok := strings.Cut(text, "\n"); ok {
+			text = rest
+		}
+		text = strings.TrimSpace(text)
+	}
+	// Remove quotes if present
+	text = strings.Trim(text, "\"'`")
+	if !b.body {
+		return text
+	}
+	
+	subject, rest, _ := strings.Cut(text, "\n")
+	var body []string
+	blank := false
+	for _, line := range strings.Split(rest, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(body) > 0
+			continue
+		}
+		item := strings.TrimLeft(line, " ")
+		for _, bullet := range []string{"* ", "• "} {
+			if strings.HasPrefix(item, bullet) {
+				line = line[:len(line)-len(item)] + "- " + strings.TrimPrefix(item, bullet)
+			}
+		}
+		if blank {
+			body = append(body, "")
+			blank = false
+		}
+		body = append(body, line)
+	}
+	
+	subject = strings.Trim(strings.TrimSpace(subject), "\"'`")
+	if len(body) == 0 {
+		return subject
+	}
+	return subject + "\n\n" + strings.Join(body, "\n")
+}
+
+// addTokens records the token usage reported in an API response
+func (b *BaseProvider) addTokens(n int) {
+	atomic.AddInt64(&b.tokensUsed, int64(n))
+}
+
+// TokensUsed returns the tokens used by all requests so far
+func (b *BaseProvider) TokensUsed() int64 {
+	return atomic.LoadInt64(&b.tokensUsed)
+}
+
+// Model returns the model provider requests, or "" when unknown
+func Model(provider AIProvider) string {
+	if m, ok := provider.(interface{ Model() string }); ok {
+		return m.Model()
+	}
+	return ""
+}
+
+// TokensUsed returns the tokens provider has used so far, or 0 when it
+// does not report usage
+func TokensUsed(provider AIProvider) int64 {
+	if counter, ok := provider.(interface{ TokensUsed() int64 }); ok {
+		return counter.TokensUsed()
+	}
+	return 0
+}
+
+func (b *BaseProvider) doRequest(ctx context.Context, url string, headers map[string]string, body io.Reader) ([]byte, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+	
+	resp, err := b.post(reqCtx, url, headers, body)
+	if err != nil {
+		return nil, b.requestError(ctx, reqCtx, err, 0)
+	}
+	defer resp.Body.Close()
+	
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, b.requestError(ctx, reqCtx, fmt.Errorf("failed to read response: %w", err), 0)
+	}
+	
+	return respBody, nil
+}
+
+// doStream sends a streaming request and calls onEvent with the data of
+// each server-sent event until the stream ends or onEvent returns an error.
+// received reports how much text has arrived, for timeout errors.
+func (b *BaseProvider) doStream(ctx context.Context, url string, headers map[string]string, body io.Reader, onEvent func(data []byte) error, received func() int) error {
+	reqCtx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+	
+	resp, err := b.post(reqCtx, url, headers, body)
+	if err != nil {
+		return b.requestError(ctx, reqCtx, err, 0)
+	}
+	defer resp.Body.Close()
+	
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue // Event names, comments and keep-alives
+		}
+		data := bytes.TrimSpace(line[len("data:"):])
+		if string(data) == "[DONE]" {
+			return nil
+		}
+		if err := onEvent(data); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return b.requestError(ctx, reqCtx, fmt.Errorf("stream interrupted: %w", err), received())
+	}
+	return nil
+}
+
+// post sends the request and fails on any status other than 200
+func (b *BaseProvider) post(ctx context.Context, url string, headers map[string]string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -63,17 +284,30 @@ func (b *BaseProvider) doRequest(url string, headers map[string]string, body io.
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	defer resp.Body.Close()
-	
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
 	
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{Status: resp.StatusCode, Body: string(respBody)}
 	}
 	
-	return respBody, nil
+	return resp, nil
+}
+
+// requestError explains why a request stopped: cancelled by the caller,
+// past the provider timeout, or failed. received is the number of
+// characters streamed before it stopped.
+func (b *BaseProvider) requestError(ctx, reqCtx context.Context, err error, received int) error {
+	var partial string
+	if received > 0 {
+		partial = fmt.Sprintf(" (%d characters received)", received)
+	}
+	switch {
+	case ctx.Err() != nil:
+		return fmt.Errorf("request cancelled%s: %w", partial, ctx.Err())
+	case reqCtx.Err() == context.DeadlineExceeded:
+		return fmt.Errorf("request timed out after %s%s, consider raising ai_timeouts: %w", b.timeout, partial, reqCtx.Err())
+	}
+	return err
 }
 
@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// moved returns a diff that moves name into dir/ unchanged
+func moved(dir, name string) string {
+	return fmt.Sprintf("diff --git a/%s b/%s/%s\nsimilarity index 100%%\nrename from %s\nrename to %s/%s\n", name, dir, name, name, dir, name)
+}
+
+// TestPrepareDiffBudget checks that the list of moved files counts against
+// the token budget, and is left out when it would crowd out the diff
+func TestPrepareDiffBudget(t *testing.T) {
+	var edit string
+	for i := 0; i < 10; i++ {
+		edit += fmt.Sprintf("diff --git a/pkg%02d.go b/pkg%02d.go\n--- a/pkg%02d.go\n+++ b/pkg%02d.go\n@@ -1 +1 @@\n-old\n+new\n", i, i, i, i)
+	}
+	tests := []struct {
+		name    string
+		moves   int
+		spare   int  // Tokens of the budget left after the whole diff
+		notes   bool // Whether the moves are listed
+		summary bool // Whether the diff is summarized
+	}{
+		{"no moves", 0, 5, false, false},
+		{"moves listed", 2, 200, true, false},
+		{"moves summarized", 2, 5, true, true},
+		{"too many moves", 20, -400, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := edit
+			for i := 0; i < tt.moves; i++ {
+				diff += moved("lib", fmt.Sprintf("file%02d.go", i))
+			}
+			got := NewBaseProvider(Options{TokenBudget: EstimateTokens(diff) + tt.spare}).prepareDiff(diff)
+			if notes := strings.HasPrefix(got, "Renamed, moved or copied files:"); notes != tt.notes {
+				t.Errorf("prepareDiff() lists moves %v, want %v", notes, tt.notes)
+			}
+			if summary := strings.Contains(got, "Diff summary"); summary != tt.summary {
+				t.Errorf("prepareDiff() summarizes %v, want %v:\n%s", summary, tt.summary, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package ai
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultTokenBudget is the diff budget used when none is configured
+	DefaultTokenBudget = 20000
+	
+	// charsPerToken is a conservative estimate shared by all providers
+	charsPerToken = 4
+	
+	// hunkSampleLines is how many lines of each hunk are kept when a file
+	// does not fit the remaining budget
+	hunkSampleLines = 12
+)
+
+// EstimateTokens approximates the token count of s
+func EstimateTokens(s string) int {
+	return (len(s) + charsPerToken - 1) / charsPerToken
+}
+
+// fileDiff is one "diff --git" section of a unified diff
+type fileDiff struct {
+	name     string
+	header   []string   // Lines before the first hunk
+	hunks    [][]string // Each hunk including its @@ line
+	added    int
+	removed  int
+	priority int
+	text     string
+	from     string // Original path of a renamed or copied file
+	copied   bool   // from was copied rather than moved
+	similar  int    // Similarity to from, in percent
+}
+
+// SummarizeDiff fits diff into budget estimated tokens. When fileLines is
+// positive, each file is first cut to that many lines, so that one large
+// file cannot crowd out the others. Diffs that then fit are returned
+// unchanged. Otherwise the result starts with per-file statistics,
+// followed by whole files in priority order (source before docs before
+// lockfiles and generated output), then sampled hunks of files that do not
+// fit in full. Files are never cut mid-line.
+func SummarizeDiff(diff string, budget, fileLines int) string {
+	if budget <= 0 {
+		budget = DefaultTokenBudget
+	}
+	
+	var files []*fileDiff
+	if fileLines > 0 {
+		files = parseDiff(diff)
+		limited := false
+		for _, f := range files {
+			if limitFile(f, fileLines) {
+				limited = true
+			}
+		}
+		if limited {
+			var b strings.Builder
+			for _, f := range files {
+				b.WriteString(f.text)
+			}
+			diff = strings.TrimRight(b.String(), "\n") + "\n"
+		}
+	}
+	if EstimateTokens(diff) <= budget {
+		return diff
+	}
+	
+	if files == nil {
+		files = parseDiff(diff)
+	}
+	
+	var b strings.Builder
+	b.WriteString("Diff summary (large diff, some content omitted):\n")
+	for _, f := range files {
+		fmt.Fprintf(&b, "  %s | +%d -%d\n", f.name, f.added, f.removed)
+	}
+	b.WriteString("\n")
+	
+	remaining := budget*charsPerToken - b.Len()
+	
+	ordered := make([]*fileDiff, len(files))
+	copy(ordered, files)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].priority < ordered[j].priority
+	})
+	
+	var omitted []string
+	for _, f := range ordered {
+		if remaining <= 0 {
+			omitted = append(omitted, f.name)
+			continue
+		}
+		if len(f.text) <= remaining {
+			b.WriteString(f.text)
+			remaining -= len(f.text)
+			continue
+		}
+		if f.priority == priorityLow {
+			omitted = append(omitted, f.name)
+			continue
+		}
+		sample := sampleFile(f, remaining)
+		if sample == "" {
+			omitted = append(omitted, f.name)
+			continue
+		}
+		b.WriteString(sample)
+		remaining -= len(sample)
+	}
+	
+	if len(omitted) > 0 {
+		fmt.Fprintf(&b, "\n(content omitted for: %s)\n", strings.Join(omitted, ", "))
+	}
+	
+	return b.String()
+}
+
+// limitFile cuts the hunks of f after limit lines and notes how many lines
+// were left out. It reports whether f was cut; its statistics still count
+// the whole file.
+func limitFile(f *fileDiff, limit int) bool {
+	total := 0
+	for _, hunk := range f.hunks {
+		total += len(hunk) - 1
+	}
+	if total <= limit {
+		return false
+	}
+	
+	var b strings.Builder
+	for _, line := range f.header {
+		b.WriteString(line + "\n")
+	}
+	var hunks [][]string
+	kept := 0
+	for _, hunk := range f.hunks {
+		if kept >= limit {
+			break
+		}
+		n := len(hunk) - 1
+		if kept+n > limit {
+			n = limit - kept
+		}
+		hunks = append(hunks, hunk[:n+1])
+		for _, line := range hunk[:n+1] {
+			b.WriteString(line + "\n")
+		}
+		kept += n
+	}
+	fmt.Fprintf(&b, "... and %d more lines\n", total-kept)
+	
+	f.hunks = hunks
+	f.text = b.String()
+	return true
+}
+
+// sampleFile keeps the header and the first lines of each hunk that fit
+func sampleFile(f *fileDiff, limit int) string {
+	var b strings.Builder
+	for _, line := range f.header {
+		b.WriteString(line + "\n")
+	}
+	if b.Len() > limit {
+		return ""
+	}
+	
+	for _, hunk := range f.hunks {
+		n := len(hunk)
+		if n > hunkSampleLines+1 {
+			n = hunkSampleLines + 1
+		}
+		var h strings.Builder
+		for _, line := range hunk[:n] {
+			h.WriteString(line + "\n")
+		}
+		if n < len(hunk) {
+			fmt.Fprintf(&h, "... (%d more lines in hunk)\n", len(hunk)-n)
+		}
+		if b.Len()+h.Len() > limit {
+			b.WriteString("... (remaining hunks omitted)\n")
+			break
+		}
+		b.WriteString(h.String())
+	}
+	
+	if b.Len() > limit {
+		return ""
+	}
+	return b.String()
+}
+
+func parseDiff(diff string) []*fileDiff {
+	var files []*fileDiff
+	var cur *fileDiff
+	var text strings.Builder
+	
+	flush := func() {
+		if cur != nil {
+			cur.text = text.String()
+			cur.priority = filePriority(cur.name)
+			files = append(files, cur)
+		}
+		text.Reset()
+	}
+	
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			cur = &fileDiff{name: diffFileName(line)}
+		}
+		if cur == nil {
+			continue
+		}
+		text.WriteString(line + "\n")
+		
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			cur.hunks = append(cur.hunks, []string{line})
+		case len(cur.hunks) == 0:
+			cur.header = append(cur.header, line)
+			switch {
+			case strings.HasPrefix(line, "rename from "):
+				cur.from = strings.TrimPrefix(line, "rename from ")
+			case strings.HasPrefix(line, "copy from "):
+				cur.from, cur.copied = strings.TrimPrefix(line, "copy from "), true
+			case strings.HasPrefix(line, "similarity index "):
+				cur.similar, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+			}
+		default:
+			last := len(cur.hunks) - 1
+			cur.hunks[last] = append(cur.hunks[last], line)
+			if strings.HasPrefix(line, "+") {
+				cur.added++
+			} else if strings.HasPrefix(line, "-") {
+				cur.removed++
+			}
+		}
+	}
+	flush()
+	
+	return files
+}
+
+// renameNotes lists the renamed and copied files, e.g. "a.go → b.go (with
+// minor edits)", or returns "" when there are none. A rename shows up in
+// the diff as a few header lines, which a model easily mistakes for an
+// unrelated edit.
+func renameNotes(files []*fileDiff) string {
+	var b strings.Builder
+	for _, f := range files {
+		if f.from == "" {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("Renamed, moved or copied files:\n")
+		}
+		verb := "moved"
+		if f.copied {
+			verb = "copied"
+		}
+		edits := "unchanged"
+		switch {
+		case f.similar >= 100:
+		case f.similar >= 80:
+			edits = "with minor edits"
+		default:
+			edits = fmt.Sprintf("with edits, %d%% similar", f.similar)
+		}
+		fmt.Fprintf(&b, "  %s %s → %s (%s)\n", verb, f.from, f.name, edits)
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// diffFileName extracts "path" from "diff --git a/path b/path"
+func diffFileName(line string) string {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.Index(rest, " b/"); i >= 0 {
+		return rest[i+3:]
+	}
+	return strings.TrimPrefix(rest, "a/")
+}
+
+const (
+	priorityHigh = iota // Source code
+	priorityMedium      // Docs and config
+	priorityLow         // Lockfiles, generated and vendored files
+)
+
+var lockFiles = map[string]bool{
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"go.sum":            true,
+	"Cargo.lock":        true,
+	"Gemfile.lock":      true,
+	"poetry.lock":       true,
+	"composer.lock":     true,
+}
+
+func filePriority(name string) int {
+	base := path.Base(name)
+	switch {
+	case lockFiles[base],
+		strings.HasSuffix(base, ".min.js"),
+		strings.HasSuffix(base, ".min.css"),
+		strings.HasSuffix(base, ".map"),
+		strings.HasPrefix(name, "vendor/"),
+		strings.HasPrefix(name, "node_modules/"),
+		strings.HasPrefix(name, "dist/"):
+		return priorityLow
+	}
+	
+	switch strings.ToLower(path.Ext(base)) {
+	case ".md", ".txt", ".rst", ".json", ".yaml", ".yml", ".toml", ".ini", ".xml", ".csv":
+		return priorityMedium
+	}
+	return priorityHigh
+}
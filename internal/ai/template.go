@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// maxStatLines caps the per-file lines in a template message body
+const maxStatLines = 10
+
+// TemplateProvider builds commit messages from diff statistics alone. It
+// needs no API key and makes no requests.
+type TemplateProvider struct{}
+
+func NewTemplateProvider() *TemplateProvider {
+	return &TemplateProvider{}
+}
+
+func (t *TemplateProvider) Model() string {
+	return "template"
+}
+
+// NeedsAPIKey reports whether provider calls an API
+func NeedsAPIKey(provider string) bool {
+	switch strings.ToLower(provider) {
+	case "none", "template":
+		return false
+	}
+	return true
+}
+
+// GenerateCommitMsg derives a Conventional Commit message from the files in
+// diff, their directories and line counts, like git diff --stat
+func (t *TemplateProvider) GenerateCommitMsg(ctx context.Context, diff string) (string, error) {
+	files := parseDiff(diff)
+	if len(files) == 0 {
+		return "chore: update files", nil
+	}
+	
+	var added, removed, created, deleted, moved int
+	for _, f := range files {
+		added += f.added
+		removed += f.removed
+		switch {
+		case hasHeader(f, "new file mode"):
+			created++
+		case hasHeader(f, "deleted file mode"):
+			deleted++
+		case f.from != "" && !f.copied:
+			moved++
+		}
+	}
+	
+	verb := "update"
+	switch {
+	case created == len(files):
+		verb = "add"
+	case deleted == len(files):
+		verb = "remove"
+	case moved == len(files):
+		verb = "move"
+	}
+	
+	kind := commitType(files, created, added, removed)
+	if moved == len(files) {
+		kind = "refactor"
+	}
+	header := kind
+	if scope := commonScope(files); scope != "" {
+		header += "(" + scope + ")"
+	}
+	if verb == "move" && len(files) == 1 {
+		header += ": " + describeMove(files[0])
+	} else {
+		header += ": " + verb + " " + describeFiles(files)
+	}
+	
+	var body strings.Builder
+	for i, f := range files {
+		if i == maxStatLines {
+			fmt.Fprintf(&body, "... and %d more\n", len(files)-maxStatLines)
+			break
+		}
+		name := f.name
+		if f.from != "" {
+			name = f.from + " → " + f.name
+		}
+		fmt.Fprintf(&body, "%s | +%d -%d\n", name, f.added, f.removed)
+	}
+	fmt.Fprintf(&body, "%d files changed, %d insertions(+), %d deletions(-)", len(files), added, removed)
+	
+	return header + "\n\n" + body.String(), nil
+}
+
+func hasHeader(f *fileDiff, prefix string) bool {
+	for _, line := range f.header {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// commitType guesses the Conventional Commit type: docs, test or chore when
+// every file is of that kind, feat for new source files, refactor when more
+// lines go than come, chore otherwise
+func commitType(files []*fileDiff, created, added, removed int) string {
+	docs, tests, chores := true, true, true
+	for _, f := range files {
+		docs = docs && isDoc(f.name)
+		tests = tests && isTest(f.name)
+		chores = chores && (f.priority == priorityLow || (f.priority == priorityMedium && !isDoc(f.name)))
+	}
+	switch {
+	case docs:
+		return "docs"
+	case tests:
+		return "test"
+	case chores:
+		return "chore"
+	case created > 0:
+		return "feat"
+	case removed > added:
+		return "refactor"
+	}
+	return "chore"
+}
+
+func isDoc(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".txt", ".rst", ".adoc":
+		return true
+	}
+	return strings.HasPrefix(name, "docs/")
+}
+
+func isTest(name string) bool {
+	base := path.Base(name)
+	return strings.HasSuffix(base, "_test.go") ||
+		strings.Contains(base, ".test.") ||
+		strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(base, "test_") ||
+		strings.HasPrefix(name, "test/") ||
+		strings.HasPrefix(name, "tests/")
+}
+
+// commonScope returns the last element of the directory shared by all
+// files, or "" when they only share the repository root
+func commonScope(files []*fileDiff) string {
+	dir := path.Dir(files[0].name)
+	for _, f := range files[1:] {
+		for dir != "." && dir != path.Dir(f.name) && !strings.HasPrefix(f.name, dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+	if dir == "." {
+		return ""
+	}
+	return path.Base(dir)
+}
+
+// describeMove names a single moved file and where it went, e.g. "move
+// parser.go into internal/parse" or "rename a.go to b.go"
+func describeMove(f *fileDiff) string {
+	if path.Base(f.from) == path.Base(f.name) {
+		if dir := path.Dir(f.name); dir != "." {
+			return fmt.Sprintf("move %s into %s", path.Base(f.name), dir)
+		}
+		return fmt.Sprintf("move %s to the top level", path.Base(f.name))
+	}
+	return fmt.Sprintf("rename %s to %s", path.Base(f.from), path.Base(f.name))
+}
+
+// describeFiles names a single file, or counts files and directories
+func describeFiles(files []*fileDiff) string {
+	if len(files) == 1 {
+		return path.Base(files[0].name)
+	}
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		dirs[path.Dir(f.name)] = true
+	}
+	if len(dirs) == 1 {
+		return fmt.Sprintf("%d files", len(files))
+	}
+	return fmt.Sprintf("%d files across %d directories", len(files), len(dirs))
+}
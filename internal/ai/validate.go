@@ -1,12 +1,18 @@
 package ai
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 )
 
 // ValidateAPIKey validates an API key by attempting to create a provider and make a test request
 func ValidateAPIKey(provider, apiKey, baseURL string) error {
+	if !NeedsAPIKey(provider) {
+		return nil
+	}
+	
 	if apiKey == "" {
 		return fmt.Errorf("API key is required")
 	}
@@ -35,7 +41,7 @@ func ValidateAPIKey(provider, apiKey, baseURL string) error {
 	}
 	
 	// Try to create provider to check for basic errors
-	_, err := NewProvider(provider, apiKey, baseURL)
+	_, err := NewProvider(provider, apiKey, baseURL, Options{})
 	if err != nil {
 		return fmt.Errorf("failed to create provider: %w", err)
 	}
@@ -43,3 +49,31 @@ func ValidateAPIKey(provider, apiKey, baseURL string) error {
 	return nil
 }
 
+
+// ErrModelsUnavailable is returned by ValidateModel when the provider's
+// model list could not be fetched, so the model could not be checked
+var ErrModelsUnavailable = errors.New("cannot list the provider's models")
+
+// ValidateModel checks that provider offers model, by looking it up in
+// the provider's model list, fetched through transport. An empty model
+// stands for the provider's default and is always valid.
+func ValidateModel(provider, apiKey, baseURL, model string, transport http.RoundTripper) error {
+	if model == "" || !NeedsAPIKey(provider) {
+		return nil
+	}
+	models, err := ListModels(provider, apiKey, baseURL, transport)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrModelsUnavailable, err)
+	}
+	return CheckModel(provider, model, models)
+}
+
+// CheckModel checks that model is among models, the list of provider
+func CheckModel(provider, model string, models []string) error {
+	for _, m := range models {
+		if m == model {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s does not offer a model named %q", provider, model)
+}
@@ -0,0 +1,113 @@
+package approval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// Split separates files matching any of patterns from the rest. A pattern
+// without a slash matches the base name anywhere in the tree, a pattern
+// ending in a slash matches everything below that directory, and any other
+// pattern is matched against the whole path with path.Match.
+func Split(files, patterns []string) (sensitive, normal []string) {
+	for _, f := range files {
+		if Matches(f, patterns) {
+			sensitive = append(sensitive, f)
+		} else {
+			normal = append(normal, f)
+		}
+	}
+	return sensitive, normal
+}
+
+// Matches reports whether file matches any of patterns, as in Split
+func Matches(file string, patterns []string) bool {
+	file = filepath.ToSlash(file)
+	for _, p := range patterns {
+		switch {
+		case strings.HasSuffix(p, "/"):
+			if strings.HasPrefix(file, p) {
+				return true
+			}
+		case !strings.Contains(p, "/"):
+			if ok, _ := path.Match(p, path.Base(file)); ok {
+				return true
+			}
+		default:
+			if ok, _ := path.Match(p, file); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Hash identifies the exact changes being approved, so that editing a file
+// after approval requires approving it again
+func Hash(diff string) string {
+	sum := sha256.Sum256([]byte(diff))
+	return hex.EncodeToString(sum[:])
+}
+
+// Approval records that the user approved the changes with Hash
+type Approval struct {
+	Hash       string    `json:"hash"`
+	Files      []string  `json:"files"`
+	ApprovedAt time.Time `json:"approved_at"`
+	By         string    `json:"by,omitempty"` // Who approved, e.g. "autogit approve" or "@alice on Slack"
+}
+
+// Approve records approval by by of the changes to files with the given
+// hash
+func Approve(repoName string, files []string, hash, by string) error {
+	data, err := json.MarshalIndent(Approval{Hash: hash, Files: files, ApprovedAt: time.Now(), By: by}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal approval: %w", err)
+	}
+	
+	path := config.GetApprovalPath(repoName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create approval directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write approval: %w", err)
+	}
+	return nil
+}
+
+// Approved reports whether the changes with hash were approved
+func Approved(repoName, hash string) bool {
+	return Load(repoName, hash) != nil
+}
+
+// Load returns the approval of the changes with hash, or nil when they
+// were not approved
+func Load(repoName, hash string) *Approval {
+	data, err := os.ReadFile(config.GetApprovalPath(repoName))
+	if err != nil {
+		return nil
+	}
+	var a Approval
+	if err := json.Unmarshal(data, &a); err != nil || a.Hash != hash {
+		return nil
+	}
+	return &a
+}
+
+// Clear forgets the approval once the approved changes are committed
+func Clear(repoName string) error {
+	err := os.Remove(config.GetApprovalPath(repoName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package approval
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// TestMatches checks base name, directory and whole path patterns
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{".gitignore", true},
+		{"web/.gitignore", true},
+		{".github/workflows/ci.yml", true},
+		{".github/dependabot.yml", false},
+		{"docs/CODEOWNERS", true},
+		{"Jenkinsfile.bak", false},
+		{"deploy/prod.tf", true},
+		{"deploy/modules/net.tf", false},
+		{"main.go", false},
+	}
+	patterns := append(append([]string(nil), config.DefaultSensitivePaths...), "deploy/*.tf")
+	for _, tt := range tests {
+		if got := Matches(tt.file, patterns); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+	
+	sensitive, normal := Split([]string{"main.go", ".gitignore", "README.md"}, patterns)
+	if !reflect.DeepEqual(sensitive, []string{".gitignore"}) || !reflect.DeepEqual(normal, []string{"main.go", "README.md"}) {
+		t.Errorf("Split() = %q, %q, want .gitignore held", sensitive, normal)
+	}
+}
+
+// TestApprove checks that an approval only covers the exact changes it was
+// given for, and is gone once cleared
+func TestApprove(t *testing.T) {
+	old := config.GetConfigDir()
+	if err := config.UseConfigDir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { config.UseConfigDir(old) })
+	
+	diff := "+node_modules/\n"
+	if Approved("app", Hash(diff)) {
+		t.Fatalf("changes are approved before Approve")
+	}
+	if err := Approve("app", []string{".gitignore"}, Hash(diff), "autogit approve"); err != nil {
+		t.Fatal(err)
+	}
+	a := Load("app", Hash(diff))
+	if a == nil || a.By != "autogit approve" || !reflect.DeepEqual(a.Files, []string{".gitignore"}) {
+		t.Errorf("Load() = %+v, want the approval by autogit approve", a)
+	}
+	if Approved("app", Hash(diff+"+dist/\n")) {
+		t.Errorf("changes edited after approval are approved")
+	}
+	if Approved("other", Hash(diff)) {
+		t.Errorf("the approval covers another repository")
+	}
+	
+	if err := Clear("app"); err != nil {
+		t.Fatal(err)
+	}
+	if Approved("app", Hash(diff)) {
+		t.Errorf("changes are approved after Clear")
+	}
+	if err := Clear("app"); err != nil {
+		t.Errorf("second Clear() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,179 @@
+// Package backup keeps a git bundle of the commits a history rewrite, such
+// as the daily squash, is about to replace, so that they can be restored
+// if the rewrite goes wrong.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/git"
+)
+
+// Keep is how many backups are kept per repository; older ones are removed
+// when a new one is made
+const Keep = 20
+
+// BranchPrefix names the branches that restored backups are put on
+const BranchPrefix = "autogit-backup/"
+
+// Backup describes a bundle of the commits after Base up to Tip, which
+// Reason was about to rewrite on Branch
+type Backup struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Reason  string    `json:"reason"`
+	Branch  string    `json:"branch"`
+	Base    string    `json:"base"`
+	Tip     string    `json:"tip"`
+	Commits int       `json:"commits"`
+	After   string    `json:"after,omitempty"` // HEAD once the rewrite was done
+}
+
+// Bundle returns the bundle file of the backup
+func (b *Backup) Bundle(repoName string) string {
+	return filepath.Join(config.GetBackupDir(repoName), b.ID+".bundle")
+}
+
+// Source is what Create reads of a repository
+type Source interface {
+	Log(limit int) ([]git.LogEntry, error)
+	CurrentBranch() string
+	CreateBundle(path, base string) error
+}
+
+// Create bundles the commits of repo after base up to HEAD before reason
+// rewrites them, and removes the oldest backups beyond Keep
+func Create(repo Source, repoName, reason, base string, commits int) (*Backup, error) {
+	entries, err := repo.Log(1)
+	if err != nil || len(entries) == 0 {
+		return nil, fmt.Errorf("failed to read HEAD: %v", err)
+	}
+	now := time.Now()
+	b := &Backup{
+		ID:      now.Format("20060102-150405"),
+		Created: now,
+		Reason:  reason,
+		Branch:  repo.CurrentBranch(),
+		Base:    base,
+		Tip:     entries[0].Hash,
+		Commits: commits,
+	}
+	
+	dir := config.GetBackupDir(repoName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := repo.CreateBundle(b.Bundle(repoName), base); err != nil {
+		return nil, err
+	}
+	if err := b.save(repoName); err != nil {
+		os.Remove(b.Bundle(repoName))
+		return nil, err
+	}
+	
+	prune(repoName)
+	return b, nil
+}
+
+// Done records where the rewrite left HEAD, which restoring with a reset
+// requires to be unchanged
+func (b *Backup) Done(repoName, head string) error {
+	b.After = head
+	return b.save(repoName)
+}
+
+func (b *Backup) save(repoName string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup: %w", err)
+	}
+	path := filepath.Join(config.GetBackupDir(repoName), b.ID+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// List returns the backups of repoName, newest first
+func List(repoName string) ([]Backup, error) {
+	paths, err := filepath.Glob(filepath.Join(config.GetBackupDir(repoName), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	
+	var backups []Backup
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var b Backup
+		if err := json.Unmarshal(data, &b); err != nil {
+			continue
+		}
+		backups = append(backups, b)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+	return backups, nil
+}
+
+// Find returns the backup of repoName with the given ID, or the newest
+// one for "latest"
+func Find(repoName, id string) (*Backup, error) {
+	backups, err := List(repoName)
+	if err != nil {
+		return nil, err
+	}
+	for i, b := range backups {
+		if b.ID == id || (id == "latest" && i == 0) {
+			return &b, nil
+		}
+	}
+	return nil, fmt.Errorf("no backup %q for %s; 'autogit backups list' shows them", id, repoName)
+}
+
+// Restore puts the backed up commits on a branch named after the backup
+// and returns its name. With reset, the current branch is moved back to
+// them too, which requires that nothing was committed since the rewrite.
+func Restore(repo *git.Repo, repoName string, b *Backup, reset bool) (string, error) {
+	branch := BranchPrefix + b.ID
+	if err := repo.Unbundle(b.Bundle(repoName), branch, b.Tip); err != nil {
+		return "", err
+	}
+	if !reset {
+		return branch, nil
+	}
+	
+	if current := repo.CurrentBranch(); current != b.Branch {
+		return branch, fmt.Errorf("the backup was taken on %s, but %s is checked out", b.Branch, current)
+	}
+	entries, err := repo.Log(1)
+	if err != nil || len(entries) == 0 {
+		return branch, fmt.Errorf("failed to read HEAD: %v", err)
+	}
+	if b.After == "" || entries[0].Hash != b.After {
+		return branch, fmt.Errorf("%s has moved on since the %s; reset it to %s yourself if you want to", b.Branch, b.Reason, branch)
+	}
+	return branch, repo.ResetKeep(b.Tip)
+}
+
+// prune removes the oldest backups beyond Keep
+func prune(repoName string) {
+	backups, err := List(repoName)
+	if err != nil || len(backups) <= Keep {
+		return
+	}
+	for _, b := range backups[Keep:] {
+		os.Remove(b.Bundle(repoName))
+		os.Remove(strings.TrimSuffix(b.Bundle(repoName), ".bundle") + ".json")
+	}
+}
@@ -0,0 +1,157 @@
+package breaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+const (
+	// maxCooldownFactor caps how far the cooldown doubles while a provider
+	// keeps failing
+	maxCooldownFactor = 32
+	
+	// maxSamples is how many recent error summaries are kept per provider
+	maxSamples = 5
+)
+
+// State is the circuit of one AI provider. The circuit opens after enough
+// consecutive failures; while open the provider is skipped. The first
+// request after the cooldown decides: a success closes the circuit, a
+// failure opens it again for twice as long.
+type State struct {
+	Failures  int       `json:"failures"`             // Consecutive failures
+	Opens     int       `json:"opens"`                // Consecutive times the circuit opened
+	OpenUntil time.Time `json:"open_until,omitempty"` // Zero while closed
+	LastError string    `json:"last_error,omitempty"`
+	Recent    []Sample  `json:"recent,omitempty"` // Summaries of the last failures, oldest first
+}
+
+// Sample summarizes one failed request, e.g. "rate limited (status 429)"
+type Sample struct {
+	At      time.Time `json:"at"`
+	Summary string    `json:"summary"`
+}
+
+// zero reports whether the provider has not failed since it last succeeded
+func (s State) zero() bool {
+	return s.Failures == 0 && s.Opens == 0 && s.OpenUntil.IsZero() && s.LastError == "" && len(s.Recent) == 0
+}
+
+// Open reports whether the circuit is open at now
+func (s State) Open(now time.Time) bool {
+	return now.Before(s.OpenUntil)
+}
+
+// Breaker tracks provider failures in a file shared by every daemon, so
+// that a failing API is skipped for all repositories at once
+type Breaker struct {
+	failures int
+	cooldown time.Duration
+}
+
+func New(cfg config.AIBreaker) *Breaker {
+	return &Breaker{failures: cfg.GetFailures(), cooldown: cfg.GetCooldown()}
+}
+
+// Load returns the state of every provider that has failed recently
+func Load() (map[string]State, error) {
+	data, err := os.ReadFile(config.GetBreakerPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]State{}, nil
+		}
+		return nil, fmt.Errorf("failed to read breaker state: %w", err)
+	}
+	
+	states := make(map[string]State)
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal breaker state: %w", err)
+	}
+	return states, nil
+}
+
+// Get returns the state of provider
+func Get(provider string) State {
+	states, err := Load()
+	if err != nil {
+		return State{}
+	}
+	return states[strings.ToLower(provider)]
+}
+
+// Failure records a failed request to provider and returns its state,
+// opening the circuit once failures reach the threshold
+func (b *Breaker) Failure(provider string, cause error, now time.Time) (State, error) {
+	var state State
+	err := update(provider, func(s *State) {
+		s.Failures++
+		s.LastError = cause.Error()
+		s.Recent = append(s.Recent, Sample{At: now, Summary: ai.ErrorSummary(cause)})
+		if len(s.Recent) > maxSamples {
+			s.Recent = s.Recent[len(s.Recent)-maxSamples:]
+		}
+		// After a cooldown, one more failure is enough to open again
+		if (s.Failures >= b.failures || s.Opens > 0) && !s.Open(now) {
+			factor := 1 << s.Opens
+			if factor > maxCooldownFactor {
+				factor = maxCooldownFactor
+			}
+			s.Opens++
+			s.OpenUntil = now.Add(b.cooldown * time.Duration(factor))
+			s.Failures = 0
+		}
+		state = *s
+	})
+	return state, err
+}
+
+// Success closes the circuit of provider
+func (b *Breaker) Success(provider string) error {
+	if Get(provider).zero() {
+		return nil // Nothing to reset, skip the write
+	}
+	return update(provider, func(s *State) {
+		*s = State{}
+	})
+}
+
+// update changes the state of provider in place. Daemons may race on the
+// file; the last write wins, which at worst loses one failure.
+func update(provider string, change func(s *State)) error {
+	states, err := Load()
+	if err != nil {
+		states = make(map[string]State)
+	}
+	
+	key := strings.ToLower(provider)
+	s := states[key]
+	change(&s)
+	if s.zero() {
+		delete(states, key)
+	} else {
+		states[key] = s
+	}
+	
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal breaker state: %w", err)
+	}
+	path := config.GetBreakerPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write breaker state: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write breaker state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package commitmsg
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// headerPattern matches "type(scope)!: subject"
+var headerPattern = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*(.*)$`)
+
+// trailerPattern matches a git trailer line such as "Refs: PROJ-123"
+var trailerPattern = regexp.MustCompile(`^[A-Za-z0-9-]+: \S`)
+
+// Message is a parsed Conventional Commit message
+type Message struct {
+	Type     string
+	Scope    string
+	Breaking bool
+	Subject  string
+	Body     string
+}
+
+// Parse splits a commit message into its Conventional Commit parts. ok is
+// false when the header does not follow the "type(scope): subject" form.
+func Parse(raw string) (msg Message, ok bool) {
+	raw = strings.TrimSpace(raw)
+	header, body, _ := strings.Cut(raw, "\n")
+	msg.Body = strings.TrimSpace(body)
+	
+	m := headerPattern.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		msg.Subject = strings.TrimSpace(header)
+		return msg, false
+	}
+	
+	msg.Type = strings.ToLower(m[1])
+	msg.Scope = strings.TrimSpace(m[2])
+	msg.Breaking = m[3] == "!"
+	msg.Subject = strings.TrimSpace(m[4])
+	return msg, true
+}
+
+// Header renders the first line of the message
+func (m Message) Header() string {
+	var b strings.Builder
+	b.WriteString(m.Type)
+	if m.Scope != "" {
+		b.WriteString("(" + m.Scope + ")")
+	}
+	if m.Breaking {
+		b.WriteString("!")
+	}
+	b.WriteString(": ")
+	b.WriteString(m.Subject)
+	return b.String()
+}
+
+// String renders the full message, including body
+func (m Message) String() string {
+	if m.Body == "" {
+		return m.Header()
+	}
+	return m.Header() + "\n\n" + m.Body
+}
+
+// Validate reports every way raw violates style
+func Validate(raw string, style config.CommitStyle) []string {
+	var problems []string
+	
+	msg, ok := Parse(raw)
+	if !ok {
+		return []string{"header is not in 'type(scope): subject' form"}
+	}
+	if !contains(style.GetTypes(), msg.Type) {
+		problems = append(problems, fmt.Sprintf("type %q is not allowed", msg.Type))
+	}
+	if msg.Subject == "" {
+		problems = append(problems, "subject is empty")
+	}
+	if max := style.GetMaxSubjectLength(); utf8.RuneCountInString(msg.Header()) > max {
+		problems = append(problems, fmt.Sprintf("header is longer than %d characters", max))
+	}
+	if style.RequireBody && msg.Body == "" {
+		problems = append(problems, "body is required")
+	}
+	
+	return problems
+}
+
+// Apply enforces style on an AI-generated message. changedFiles is used to
+// infer a scope from the configured path mapping. In fix mode the message
+// is rewritten to conform; in reject mode an error describes the problems.
+// Enforcement is opt-in, so without a mode the message is left as it is.
+func Apply(raw string, changedFiles []string, style config.CommitStyle) (string, error) {
+	switch style.Mode {
+	case config.EnforceFix:
+		return withFooter(Fix(raw, changedFiles, style), style), nil
+	case config.EnforceReject:
+		if problems := Validate(raw, style); len(problems) > 0 {
+			return "", fmt.Errorf("commit message rejected: %s", strings.Join(problems, "; "))
+		}
+		return withFooter(raw, style), nil
+	default:
+		return raw, nil
+	}
+}
+
+// Fix rewrites raw so that it satisfies style as closely as possible
+func Fix(raw string, changedFiles []string, style config.CommitStyle) string {
+	types := style.GetTypes()
+	
+	msg, ok := Parse(raw)
+	if !ok || !contains(types, msg.Type) {
+		msg.Type = fallbackType(types)
+	}
+	
+	if msg.Scope == "" {
+		msg.Scope = InferScope(changedFiles, style.Scopes)
+	}
+	
+	msg.Subject = strings.TrimRight(msg.Subject, ". ")
+	if msg.Subject == "" {
+		msg.Subject = "update files"
+	}
+	// Conventional Commit subjects start lowercase
+	r, size := utf8.DecodeRuneInString(msg.Subject)
+	msg.Subject = string(unicode.ToLower(r)) + msg.Subject[size:]
+	
+	// Trim the subject so the whole header fits
+	max := style.GetMaxSubjectLength()
+	if over := utf8.RuneCountInString(msg.Header()) - max; over > 0 {
+		msg.Subject = truncateWords(msg.Subject, utf8.RuneCountInString(msg.Subject)-over)
+	}
+	
+	if style.RequireBody && msg.Body == "" && len(changedFiles) > 0 {
+		msg.Body = fileSummary(changedFiles)
+	}
+	
+	return msg.String()
+}
+
+// InferScope returns the scope shared by all changed files, or "" if the
+// files map to different scopes or none at all
+func InferScope(changedFiles []string, rules []config.ScopeRule) string {
+	scope := ""
+	for _, file := range changedFiles {
+		s := scopeFor(file, rules)
+		if s == "" {
+			return ""
+		}
+		if scope != "" && s != scope {
+			return ""
+		}
+		scope = s
+	}
+	return scope
+}
+
+// scopeFor returns the scope of the longest matching path prefix
+func scopeFor(file string, rules []config.ScopeRule) string {
+	file = filepath.ToSlash(file)
+	best, bestLen := "", -1
+	for _, rule := range rules {
+		prefix := strings.TrimSuffix(filepath.ToSlash(rule.Path), "/")
+		if file != prefix && !strings.HasPrefix(file, prefix+"/") {
+			continue
+		}
+		if len(prefix) > bestLen {
+			best, bestLen = rule.Scope, len(prefix)
+		}
+	}
+	return best
+}
+
+func fallbackType(types []string) string {
+	if contains(types, "chore") {
+		return "chore"
+	}
+	return types[0]
+}
+
+// truncateWords shortens s to at most limit characters, at a word
+// boundary when one is near. It counts and cuts whole runes, so that
+// multi-byte characters are never split.
+func truncateWords(s string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	cut := runes[:limit]
+	for i := len(cut) - 1; i > limit/2; i-- {
+		if cut[i] == ' ' {
+			cut = cut[:i]
+			break
+		}
+	}
+	return strings.TrimRight(string(cut), " ,;:-")
+}
+
+func fileSummary(files []string) string {
+	var b strings.Builder
+	for i, f := range files {
+		if i == 10 {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(files)-10)
+			break
+		}
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func withFooter(msg string, style config.CommitStyle) string {
+	if style.Footer == "" || strings.Contains(msg, style.Footer) {
+		return msg
+	}
+	return strings.TrimSpace(msg) + "\n\n" + style.Footer
+}
+
+// TicketKey returns the ticket key t finds in branch, or "" when t is off
+// or the branch names no ticket
+func TicketKey(branch string, t config.Ticket) string {
+	if t.Pattern == "" || branch == "" {
+		return ""
+	}
+	re, err := regexp.Compile(t.Pattern)
+	if err != nil {
+		return ""
+	}
+	m := re.FindStringSubmatch(branch)
+	switch {
+	case m == nil:
+		return ""
+	case len(m) > 1 && m[1] != "":
+		return m[1]
+	default:
+		return m[0]
+	}
+}
+
+// WithTicket adds key to msg where t places it, unless msg mentions the
+// key already
+func WithTicket(msg, key string, t config.Ticket) string {
+	msg = strings.TrimSpace(msg)
+	if key == "" || strings.Contains(msg, key) {
+		return msg
+	}
+	ref := strings.ReplaceAll(t.GetFormat(), "{key}", key)
+	
+	if t.GetPosition() == config.TicketFooter {
+		return WithTrailers(msg, []string{ref})
+	}
+	
+	header, body, hasBody := strings.Cut(msg, "\n")
+	if t.GetPosition() == config.TicketSuffix {
+		header = header + " " + ref
+	} else {
+		header = ref + " " + header
+	}
+	if !hasBody {
+		return header
+	}
+	return header + "\n" + body
+}
+
+// WithTrailers adds the trailers not yet in msg at its end. Trailers
+// already at the end, such as the style footer, are joined so that git
+// reads them as one block.
+func WithTrailers(msg string, trailers []string) string {
+	msg = strings.TrimSpace(msg)
+	for _, trailer := range trailers {
+		trailer = strings.TrimSpace(trailer)
+		if trailer == "" || strings.Contains(msg, trailer) {
+			continue
+		}
+		paragraphs := strings.Split(msg, "\n\n")
+		if last := paragraphs[len(paragraphs)-1]; len(paragraphs) > 1 && isTrailers(last) {
+			msg += "\n" + trailer
+		} else {
+			msg += "\n\n" + trailer
+		}
+	}
+	return msg
+}
+
+func isTrailers(paragraph string) bool {
+	for _, line := range strings.Split(paragraph, "\n") {
+		if !trailerPattern.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Heuristic builds a message without AI, e.g. "notes: update 3 files"
+func Heuristic(prefix string, changedFiles []string) string {
+	if prefix == "" {
+		prefix = "chore"
+	}
+	switch len(changedFiles) {
+	case 0:
+		return prefix + ": update files"
+	case 1:
+		return fmt.Sprintf("%s: update %s", prefix, filepath.Base(changedFiles[0]))
+	default:
+		return fmt.Sprintf("%s: update %d files", prefix, len(changedFiles))
+	}
+}
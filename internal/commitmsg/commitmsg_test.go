@@ -0,0 +1,121 @@
+package commitmsg
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+func TestFixTruncatesByRune(t *testing.T) {
+	style := config.CommitStyle{Mode: config.EnforceFix, MaxSubjectLength: 20}
+	for _, subject := range []string{
+		"ändere die Übersicht über alle Einträge",
+		"更新所有文件的配置和文档以及测试用例",
+		"add 🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀",
+	} {
+		msg := Fix("feat: "+subject, nil, style)
+		if !utf8.ValidString(msg) {
+			t.Errorf("Fix(%q) = %q, not valid UTF-8", subject, msg)
+		}
+		if n := utf8.RuneCountInString(msg); n > 20 {
+			t.Errorf("Fix(%q) = %q, %d characters, want at most 20", subject, msg, n)
+		}
+		if problems := Validate(msg, style); len(problems) > 0 {
+			t.Errorf("Validate(%q) = %v", msg, problems)
+		}
+	}
+}
+
+func TestValidateCountsRunes(t *testing.T) {
+	style := config.CommitStyle{Mode: config.EnforceReject, MaxSubjectLength: 20}
+	// 20 characters, but more than 20 bytes
+	msg := "feat: " + strings.Repeat("ü", 14)
+	if problems := Validate(msg, style); len(problems) > 0 {
+		t.Errorf("Validate(%q) = %v, want no problems", msg, problems)
+	}
+}
+
+func TestApplyWithoutModeKeepsMessage(t *testing.T) {
+	raw := "Updated the README."
+	for _, mode := range []string{"", config.EnforceOff} {
+		got, err := Apply(raw, []string{"README.md"}, config.CommitStyle{Mode: mode, Footer: "Automated-By: autogit"})
+		if err != nil || got != raw {
+			t.Errorf("Apply with mode %q = %q, %v, want %q unchanged", mode, got, err, raw)
+		}
+	}
+}
+
+// TestTicketKey checks that the key comes from the first group of the
+// pattern, else from the whole match
+func TestTicketKey(t *testing.T) {
+	tests := []struct {
+		branch, pattern, want string
+	}{
+		{"feature/PROJ-123-login", `[A-Z]+-\d+`, "PROJ-123"},
+		{"fix/gh-42", `gh-(\d+)`, "42"},
+		{"main", `[A-Z]+-\d+`, ""},
+		{"feature/PROJ-123", "", ""},
+		{"", `[A-Z]+-\d+`, ""},
+		{"feature/PROJ-123", `[`, ""},
+	}
+	for _, tt := range tests {
+		if got := TicketKey(tt.branch, config.Ticket{Pattern: tt.pattern}); got != tt.want {
+			t.Errorf("TicketKey(%q, %q) = %q, want %q", tt.branch, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// TestWithTicket checks each position and format, and that a message
+// mentioning the key is left as it is
+func TestWithTicket(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    string
+		ticket config.Ticket
+		want   string
+	}{
+		{"prefix", "feat: add login", config.Ticket{}, "PROJ-1 feat: add login"},
+		{"prefix keeps body", "feat: add login\n\nWith tests.", config.Ticket{}, "PROJ-1 feat: add login\n\nWith tests."},
+		{"suffix", "feat: add login", config.Ticket{Position: config.TicketSuffix, Format: "({key})"}, "feat: add login (PROJ-1)"},
+		{"footer", "feat: add login\n\nWith tests.", config.Ticket{Position: config.TicketFooter}, "feat: add login\n\nWith tests.\n\nRefs: PROJ-1"},
+		{"footer format", "feat: add login", config.Ticket{Position: config.TicketFooter, Format: "Closes: {key}"}, "feat: add login\n\nCloses: PROJ-1"},
+		{"already mentioned", "feat: add login for PROJ-1", config.Ticket{}, "feat: add login for PROJ-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithTicket(tt.msg, "PROJ-1", tt.ticket); got != tt.want {
+				t.Errorf("WithTicket(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+	if got := WithTicket("feat: add login", "", config.Ticket{}); got != "feat: add login" {
+		t.Errorf("WithTicket without a key = %q, want the message unchanged", got)
+	}
+}
+
+// TestWithTrailers checks that trailers join a trailer block at the end of
+// the message and are not repeated
+func TestWithTrailers(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		trailers []string
+		want     string
+	}{
+		{"header only", "feat: add login", []string{"Auto-Commit: autogit"}, "feat: add login\n\nAuto-Commit: autogit"},
+		{"after body", "feat: add login\n\nWith tests.", []string{"Auto-Commit: autogit"}, "feat: add login\n\nWith tests.\n\nAuto-Commit: autogit"},
+		{"joins footer", "feat: add login\n\nRefs: PROJ-1", []string{"Auto-Commit: autogit"}, "feat: add login\n\nRefs: PROJ-1\nAuto-Commit: autogit"},
+		{"several", "feat: add login", []string{"Auto-Commit: autogit", "Host: laptop"}, "feat: add login\n\nAuto-Commit: autogit\nHost: laptop"},
+		{"already present", "feat: add login\n\nAuto-Commit: autogit", []string{"Auto-Commit: autogit"}, "feat: add login\n\nAuto-Commit: autogit"},
+		{"blank trailers", "feat: add login\n", []string{"", "  "}, "feat: add login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithTrailers(tt.msg, tt.trailers); got != tt.want {
+				t.Errorf("WithTrailers(%q, %q) = %q, want %q", tt.msg, tt.trailers, got, tt.want)
+			}
+		})
+	}
+}
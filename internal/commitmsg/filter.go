@@ -0,0 +1,51 @@
+package commitmsg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// FilterTimeout bounds a message filter command
+const FilterTimeout = 10 * time.Second
+
+// Filter runs command through the shell with msg on stdin and returns what
+// it prints as the new message. The command runs in dir, the repository
+// root, so it can inspect the repository, e.g. to read a ticket number from
+// the branch name.
+func Filter(ctx context.Context, dir, command, msg string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, FilterTimeout)
+	defer cancel()
+	
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(msg)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("message filter timed out after %s", FilterTimeout)
+		}
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return "", fmt.Errorf("message filter failed: %w: %s", err, detail)
+		}
+		return "", fmt.Errorf("message filter failed: %w", err)
+	}
+	
+	filtered := strings.TrimSpace(stdout.String())
+	if filtered == "" {
+		return "", fmt.Errorf("message filter printed an empty message")
+	}
+	return filtered, nil
+}
@@ -5,29 +5,793 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
+	"github.com/aadityansha/autogit/internal/pathutil"
+	"github.com/aadityansha/autogit/internal/proc"
+	"github.com/aadityansha/autogit/internal/schedule"
+	"github.com/aadityansha/autogit/internal/timefmt"
 	"github.com/spf13/viper"
 )
 
+const (
+	MinCheckIntervalMinutes = 1
+	MaxCheckIntervalMinutes = 24 * 60
+	MinCheckInterval        = 10 * time.Second // Shortest check_interval duration
+)
+
 const (
 	DefaultCheckInterval = 10 * time.Minute
 	ConfigFileName       = "config.json"
 	DaemonFileName      = "daemon.json"
 )
 
+// Commit triggers
+const (
+	TriggerInterval  = "interval"  // Check on a fixed ticker (default)
+	TriggerImmediate = "immediate" // Commit shortly after files change on disk
+)
+
+const (
+	DefaultDebounce = 15 * time.Second
+	MinDebounce     = 10 * time.Second
+	MaxDebounce     = 30 * time.Second
+)
+
+// DefaultCycleBudget is the time allowed for one commit cycle
+const DefaultCycleBudget = 90 * time.Second
+
+// DefaultAITimeout is the request timeout for providers without one in
+// ai_timeouts
+const DefaultAITimeout = 30 * time.Second
+
+// MinCrossBoundaryInterval is the shortest polling interval used for
+// repositories on a file system shared across the WSL boundary
+const MinCrossBoundaryInterval = 15 * time.Minute
+
 type Config struct {
-	AIProvider   string `json:"ai_provider" mapstructure:"ai_provider"`     // "gemini", "openai", "anthropic", "openrouter"
-	APIKey       string `json:"api_key" mapstructure:"api_key"`
+	AIProvider   string `json:"ai_provider" mapstructure:"ai_provider"`     // "gemini", "openai", "anthropic", "openrouter", "none"
+	APIKey       string `json:"api_key,omitempty" mapstructure:"api_key"`
+	APIKeyRef    string `json:"api_key_ref,omitempty" mapstructure:"api_key_ref"` // Where the key is stored instead of api_key, e.g. the OS keyring
+	NoKeyring    bool   `json:"no_keyring,omitempty" mapstructure:"no_keyring"`   // Keep the API key in this file
+	APIKeyEnvOnly bool  `json:"api_key_env_only,omitempty" mapstructure:"api_key_env_only"` // Read the key only from the environment, never store it
+	APIKeyEnv    string `json:"-" mapstructure:"-"`                                 // Environment variable the key was read from, if any
 	BaseURL      string `json:"base_url" mapstructure:"base_url"`           // For OpenRouter or custom OpenAI-compatible
 	CheckIntervalMinutes int `json:"check_interval_minutes" mapstructure:"check_interval_minutes"`
+	CheckInterval string   `json:"check_interval,omitempty" mapstructure:"check_interval"` // Duration such as "45s" or cron expression; replaces check_interval_minutes
 	RootPath     string `json:"root_path" mapstructure:"root_path"`         // Git root path
+	Trigger      string `json:"trigger" mapstructure:"trigger"`             // "interval" or "immediate"
+	DebounceSeconds   int `json:"debounce_seconds" mapstructure:"debounce_seconds"`       // Quiet period before an immediate commit
+	MinSpacingSeconds int `json:"min_spacing_seconds" mapstructure:"min_spacing_seconds"` // Minimum time between two commits
+	Repos        []RepoConfig `json:"repos,omitempty" mapstructure:"repos"`  // Per-repository overrides
+	RepoGroups   map[string]RepoConfig `json:"repo_groups,omitempty" mapstructure:"repo_groups"` // Overrides for repositories tagged with the group name
+	LogFile      string `json:"log_file,omitempty" mapstructure:"log_file"`               // Daemon log file name, may use {name}, {id} and {home}
+	LogDestination string `json:"log_destination,omitempty" mapstructure:"log_destination"` // "file", "syslog" or "journald"
+	StatusAddr   string `json:"status_addr,omitempty" mapstructure:"status_addr"`         // Address of the daemon's /status, /health and /metrics endpoint, off when empty
+	RestartOnCrash bool `json:"restart_on_crash,omitempty" mapstructure:"restart_on_crash"` // Run the daemon under a watchdog that logs crashes and restarts it
+	CrashReports bool `json:"crash_reports,omitempty" mapstructure:"crash_reports"` // Write a local report when a commit cycle panics, never uploaded
+	CommitPrompt string `json:"commit_prompt,omitempty" mapstructure:"commit_prompt"` // Replaces the built-in instructions sent to the AI with the diff
+	CommitStyle  CommitStyle `json:"commit_style" mapstructure:"commit_style"` // Conventional Commit enforcement
+	Preset       string `json:"preset,omitempty" mapstructure:"preset"`             // Built-in preset, e.g. "notes"
+	MessageSource string `json:"message_source" mapstructure:"message_source"`      // "ai" or "heuristic"
+	Exclude      []string `json:"exclude,omitempty" mapstructure:"exclude"`         // Paths never staged or watched
+	StagingMode  string   `json:"staging_mode,omitempty" mapstructure:"staging_mode"` // "all", "tracked", "patterns" or "staged"
+	StagedOnly   bool     `json:"staged_only,omitempty" mapstructure:"staged_only"`   // Commit only what the user staged, as staging_mode "staged"
+	Cadences     []Cadence `json:"cadences,omitempty" mapstructure:"cadences"`       // How often changes to some paths may be committed
+	StagingPatterns []string `json:"staging_patterns,omitempty" mapstructure:"staging_patterns"` // Globs the "patterns" staging mode stages
+	Grouping     Grouping `json:"grouping" mapstructure:"grouping"`                 // Split changes into several commits
+	MaxDiffTokens int     `json:"max_diff_tokens" mapstructure:"max_diff_tokens"`   // Token budget for diffs sent to AI
+	MaxDiffLinesPerFile int `json:"max_diff_lines_per_file,omitempty" mapstructure:"max_diff_lines_per_file"` // Diff lines sent to AI per file, 0 for no limit
+	TimeFormat    string  `json:"time_format" mapstructure:"time_format"`           // "24h" or "12h"
+	SecretScan    SecretScan `json:"secret_scan" mapstructure:"secret_scan"`      // Block commits that contain credentials
+	CycleBudgetSeconds int   `json:"cycle_budget_seconds" mapstructure:"cycle_budget_seconds"` // Time allowed for one commit cycle; AI may use half
+	Schedule      Schedule   `json:"schedule" mapstructure:"schedule"`            // When the daemon may commit
+	AIStream      bool       `json:"ai_stream" mapstructure:"ai_stream"`          // Stream responses from OpenAI-compatible and Anthropic APIs
+	AITimeouts    map[string]int `json:"ai_timeouts,omitempty" mapstructure:"ai_timeouts"` // Request timeout in seconds by provider name
+	AIModels      map[string]string `json:"ai_models,omitempty" mapstructure:"ai_models"` // Model requested by provider name, replacing the built-in default
+	Approval      Approval   `json:"approval" mapstructure:"approval"`            // Hold changes to sensitive paths until approved
+	Markers       bool       `json:"markers" mapstructure:"markers"`              // Act on "autogit:" markers in added lines
+	MessageFilterCmd string  `json:"message_filter_cmd,omitempty" mapstructure:"message_filter_cmd"` // Shell command that rewrites every message, stdin to stdout
+	Email         Email      `json:"email" mapstructure:"email"`                  // Error notifications by email, for machines without a desktop
+	Notifications Notifications `json:"notifications" mapstructure:"notifications"` // Which desktop notifications are shown
+	AIBreaker     AIBreaker  `json:"ai_breaker" mapstructure:"ai_breaker"`        // Skip a failing AI provider for a while
+	AIBudget      AIBudget   `json:"ai_budget" mapstructure:"ai_budget"`          // Caps on AI requests, tokens and estimated cost
+	Network       Network    `json:"network" mapstructure:"network"`              // Proxy and certificates for AI requests
+	Signing       Signing    `json:"signing" mapstructure:"signing"`              // Signatures on auto-commits
+	AuthorName    string     `json:"author_name,omitempty" mapstructure:"author_name"`   // Author of auto-commits, e.g. "autogit[bot]"; git's user.name by default
+	AuthorEmail   string     `json:"author_email,omitempty" mapstructure:"author_email"` // Author email of auto-commits; git's user.email by default
+	AheadAlert    AheadAlert `json:"ahead_alert" mapstructure:"ahead_alert"`      // Notify when the branch drifts far from its pull request base
+	SharedBranch  SharedBranch `json:"shared_branch" mapstructure:"shared_branch"` // What to do when teammates push to the same branch
+	CommitTrailers []string  `json:"commit_trailers,omitempty" mapstructure:"commit_trailers"` // Trailers such as "Autogit-Version: 1.0.0" added to every auto-commit
+	Storage       Storage    `json:"storage" mapstructure:"storage"`              // Where the commit history is kept
+	MetricsExport MetricsExport `json:"metrics_export" mapstructure:"metrics_export"` // Metrics written to files, where status_addr cannot open a port
+}
+
+// DefaultMetricsInterval is how often metrics are written to files when
+// metrics_export.every is not set
+const DefaultMetricsInterval = time.Minute
+
+// MetricsExport has each daemon write its metrics to a Prometheus text
+// file, which the node exporter's textfile collector picks up, and the
+// same as JSON next to it
+type MetricsExport struct {
+	Enabled bool   `json:"enabled" mapstructure:"enabled"`
+	Dir     string `json:"dir,omitempty" mapstructure:"dir"`     // metrics in the config directory by default
+	Every   string `json:"every,omitempty" mapstructure:"every"` // Duration between two snapshots, 1m by default
+}
+
+// GetDir returns the directory the metrics files are written to
+func (m MetricsExport) GetDir() string {
+	if m.Dir == "" {
+		return filepath.Join(configDir, "metrics")
+	}
+	return m.Dir
+}
+
+// Interval returns the parsed Every, the default when it is not set, or 0
+// when it is invalid
+func (m MetricsExport) Interval() time.Duration {
+	if m.Every == "" {
+		return DefaultMetricsInterval
+	}
+	d, err := time.ParseDuration(m.Every)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// MetricsPath returns the metrics file of a repository with extension
+// ext, ".prom" or ".json"
+func (m MetricsExport) MetricsPath(repoName, ext string) string {
+	return filepath.Join(m.GetDir(), "autogit_"+pathutil.SafeFileName(repoName)+ext)
+}
+
+// Storage backends
+const (
+	StorageFile   = "file"   // One JSON Lines file per repository (default)
+	StorageSQLite = "sqlite" // One SQLite database for every repository
+)
+
+// Storage selects the backend that keeps the commit history, which
+// 'autogit why' and 'autogit serve' read. Other state always stays in
+// files.
+type Storage struct {
+	Backend string `json:"backend,omitempty" mapstructure:"backend"` // "file" or "sqlite"
+	Path    string `json:"path,omitempty" mapstructure:"path"`       // SQLite database file; autogit.db in the config directory by default
+}
+
+// GetBackend returns the storage backend, defaulting to files
+func (s Storage) GetBackend() string {
+	if s.Backend == "" {
+		return StorageFile
+	}
+	return s.Backend
+}
+
+// GetPath returns the SQLite database file
+func (s Storage) GetPath() string {
+	if s.Path == "" {
+		return filepath.Join(configDir, "autogit.db")
+	}
+	return s.Path
+}
+
+// AheadAlert configures a reminder to push or open a pull request once the
+// branch is more than Commits commits or Lines changed lines ahead of Base.
+// It is off while both limits are 0.
+type AheadAlert struct {
+	Commits int    `json:"commits,omitempty" mapstructure:"commits"` // Commits ahead of the base
+	Lines   int    `json:"lines,omitempty" mapstructure:"lines"`     // Lines added and deleted since the merge base
+	Base    string `json:"base,omitempty" mapstructure:"base"`       // Ref the pull request targets; the push remote's default branch, e.g. origin/main, by default
+}
+
+func (a AheadAlert) Enabled() bool {
+	return a.Commits > 0 || a.Lines > 0
+}
+
+// Shared branch modes
+const (
+	SharedBranchWarn    = "warn"    // Recommend a WIP branch of one's own (default)
+	SharedBranchSwitch  = "switch"  // Move the commits to a WIP branch of one's own
+	SharedBranchEnforce = "enforce" // Only ever commit on a WIP branch of one's own
+)
+
+// Shared branch defaults
+const (
+	DefaultSharedRejections = 3
+	DefaultSharedWindow     = time.Hour
+	DefaultWIPBranch        = "wip/{user}/{branch}"
+)
+
+// SharedBranch guards against teammates running autogit on the same
+// branch, where pushes keep being rejected. A branch counts as shared once
+// rejections pushes within the window were rejected because of commits by
+// someone else.
+type SharedBranch struct {
+	Mode          string `json:"mode,omitempty" mapstructure:"mode"`                     // "warn", "switch" or "enforce"
+	Rejections    int    `json:"rejections,omitempty" mapstructure:"rejections"`         // 3 by default
+	WindowMinutes int    `json:"window_minutes,omitempty" mapstructure:"window_minutes"` // 60 by default
+	Branch        string `json:"branch,omitempty" mapstructure:"branch"`                 // WIP branch name, with {user} and {branch}; wip/{user}/{branch} by default
+}
+
+func (s SharedBranch) GetMode() string {
+	if s.Mode == "" {
+		return SharedBranchWarn
+	}
+	return s.Mode
+}
+
+func (s SharedBranch) GetRejections() int {
+	if s.Rejections <= 0 {
+		return DefaultSharedRejections
+	}
+	return s.Rejections
+}
+
+func (s SharedBranch) GetWindow() time.Duration {
+	if s.WindowMinutes <= 0 {
+		return DefaultSharedWindow
+	}
+	return time.Duration(s.WindowMinutes) * time.Minute
+}
+
+func (s SharedBranch) GetBranch() string {
+	if s.Branch == "" {
+		return DefaultWIPBranch
+	}
+	return s.Branch
+}
+
+// Circuit breaker defaults
+const (
+	DefaultBreakerFailures = 3
+	DefaultBreakerCooldown = time.Minute
+)
+
+// AIBreaker configures when a failing AI provider is skipped. After
+// failures consecutive failures it is skipped for the cooldown, which
+// doubles each time the circuit opens again, and local messages are used.
+type AIBreaker struct {
+	Failures        int `json:"failures,omitempty" mapstructure:"failures"`
+	CooldownSeconds int `json:"cooldown_seconds,omitempty" mapstructure:"cooldown_seconds"`
+}
+
+func (b AIBreaker) GetFailures() int {
+	if b.Failures <= 0 {
+		return DefaultBreakerFailures
+	}
+	return b.Failures
+}
+
+func (b AIBreaker) GetCooldown() time.Duration {
+	if b.CooldownSeconds <= 0 {
+		return DefaultBreakerCooldown
+	}
+	return time.Duration(b.CooldownSeconds) * time.Second
+}
+
+// DefaultAIPrices are rough estimates in USD per million tokens, input and
+// output together, of each provider's default model
+var DefaultAIPrices = map[string]float64{
+	"gemini":     0.50,
+	"openai":     1.00,
+	"openrouter": 1.00,
+	"anthropic":  0.50,
+	"claude":     0.50,
+}
+
+// AIBudget caps the AI calls of all daemons together. Once a cap is
+// reached, messages come from the template provider until the hour, day or
+// month is over. Zero leaves a cap off.
+type AIBudget struct {
+	RequestsPerHour int                `json:"requests_per_hour,omitempty" mapstructure:"requests_per_hour"`
+	DailyTokens     int64              `json:"daily_tokens,omitempty" mapstructure:"daily_tokens"`
+	MonthlyTokens   int64              `json:"monthly_tokens,omitempty" mapstructure:"monthly_tokens"`
+	DailyCost       float64            `json:"daily_cost,omitempty" mapstructure:"daily_cost"`     // Estimated USD
+	MonthlyCost     float64            `json:"monthly_cost,omitempty" mapstructure:"monthly_cost"` // Estimated USD
+	Prices          map[string]float64 `json:"prices,omitempty" mapstructure:"prices"`             // USD per million tokens by provider, replacing DefaultAIPrices
+}
+
+// Network is how AI requests reach the providers, e.g. through a
+// corporate proxy that inspects TLS with its own certificate authority.
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY apply when Proxy is empty.
+type Network struct {
+	Proxy              string `json:"proxy,omitempty" mapstructure:"proxy"`                               // e.g. "http://proxy.corp:3128"; credentials may go in the URL
+	CABundle           string `json:"ca_bundle,omitempty" mapstructure:"ca_bundle"`                       // PEM file of certificates trusted besides the system's
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" mapstructure:"insecure_skip_verify"` // Accept any certificate; prefer ca_bundle
+}
+
+// CABundlePath returns CABundle with a leading ~ expanded
+func (n Network) CABundlePath() string {
+	if strings.HasPrefix(n.CABundle, "~/") || strings.HasPrefix(n.CABundle, `~\`) {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, n.CABundle[2:])
+	}
+	return n.CABundle
+}
+
+// Enabled reports whether any cap is set
+func (b AIBudget) Enabled() bool {
+	return b.RequestsPerHour > 0 || b.DailyTokens > 0 || b.MonthlyTokens > 0 || b.DailyCost > 0 || b.MonthlyCost > 0
+}
+
+// Price returns the estimated USD per million tokens of provider
+func (b AIBudget) Price(provider string) float64 {
+	provider = strings.ToLower(provider)
+	if price, ok := b.Prices[provider]; ok {
+		return price
+	}
+	if price, ok := DefaultAIPrices[provider]; ok {
+		return price
+	}
+	return 1.00
+}
+
+// DefaultMuteDuration is how long 'autogit mute' silences notifications
+// when no duration is given or configured
+const DefaultMuteDuration = time.Hour
+
+// Notifications selects the desktop notifications that are shown
+type Notifications struct {
+	OnSuccess    bool   `json:"on_success" mapstructure:"on_success"`               // After every pushed commit
+	OnError      bool   `json:"on_error" mapstructure:"on_error"`                   // Push failures, blocked secrets and pending approvals
+	MaxPerHour   int    `json:"max_per_hour,omitempty" mapstructure:"max_per_hour"` // 0 for no limit
+	MuteDuration string `json:"mute_duration,omitempty" mapstructure:"mute_duration"` // Default for 'autogit mute', e.g. "2h"
+	Private      bool   `json:"private,omitempty" mapstructure:"private"`           // Show counts instead of commit messages and file names
+}
+
+// GetMuteDuration returns the configured default mute duration
+func (n Notifications) GetMuteDuration() time.Duration {
+	if d, err := time.ParseDuration(n.MuteDuration); err == nil && d > 0 {
+		return d
+	}
+	return DefaultMuteDuration
+}
+
+// Email defaults
+const (
+	DefaultSMTPPort        = 587
+	DefaultEmailThrottle   = 60 * time.Minute
+	DefaultEmailAIFailures = 3
+)
+
+// Email configures error notifications sent over SMTP. It is off while
+// host or recipients are unset.
+type Email struct {
+	Host            string   `json:"host,omitempty" mapstructure:"host"`
+	Port            int      `json:"port,omitempty" mapstructure:"port"`                         // 587 (STARTTLS) by default, 465 for implicit TLS
+	Username        string   `json:"username,omitempty" mapstructure:"username"`
+	Password        string   `json:"password,omitempty" mapstructure:"password"`                 // AUTOGIT_SMTP_PASSWORD takes precedence
+	From            string   `json:"from,omitempty" mapstructure:"from"`                         // Defaults to username
+	To              []string `json:"to,omitempty" mapstructure:"to"`
+	ThrottleMinutes int      `json:"throttle_minutes,omitempty" mapstructure:"throttle_minutes"` // Minimum time between two emails about the same problem
+	AIFailures      int      `json:"ai_failures,omitempty" mapstructure:"ai_failures"`           // Consecutive AI failures before an email
+}
+
+// Enabled reports whether email notifications are configured
+func (e Email) Enabled() bool {
+	return e.Host != "" && len(e.To) > 0
+}
+
+func (e Email) GetPort() int {
+	if e.Port <= 0 {
+		return DefaultSMTPPort
+	}
+	return e.Port
+}
+
+// GetPassword returns the SMTP password, preferring the environment
+func (e Email) GetPassword() string {
+	if p := os.Getenv("AUTOGIT_SMTP_PASSWORD"); p != "" {
+		return p
+	}
+	return e.Password
+}
+
+func (e Email) GetFrom() string {
+	if e.From == "" {
+		return e.Username
+	}
+	return e.From
+}
+
+func (e Email) GetThrottle() time.Duration {
+	if e.ThrottleMinutes <= 0 {
+		return DefaultEmailThrottle
+	}
+	return time.Duration(e.ThrottleMinutes) * time.Minute
+}
+
+func (e Email) GetAIFailures() int {
+	if e.AIFailures <= 0 {
+		return DefaultEmailAIFailures
+	}
+	return e.AIFailures
+}
+
+// Staging modes
+const (
+	StagingAll      = "all"      // Every change, new files included (default)
+	StagingTracked  = "tracked"  // Changes to files git already tracks, like git add -u
+	StagingPatterns = "patterns" // Changes to paths matching staging_patterns only
+	StagingStaged   = "staged"   // Nothing is staged; only changes the user staged are committed
+)
+
+// Version control systems
+const (
+	VCSGit   = "git"   // Commit with git (default)
+	VCSGoGit = "gogit" // Commit with go-git, built into autogit, without running git (experimental)
+	VCSJJ    = "jj"    // Commit with Jujutsu in a repository colocated with git (experimental)
+	VCSHg    = "hg"    // Commit with Mercurial (experimental)
+)
+
+// Approval modes
+const (
+	ApprovalSensitive = "sensitive" // Hold changes to sensitive paths until 'autogit approve' (default)
+	ApprovalOff       = "off"       // Auto-commit every path
+)
+
+// DefaultSensitivePaths are meta files whose changes affect the whole
+// project and should not be committed blindly
+var DefaultSensitivePaths = []string{".gitignore", ".gitattributes", "CODEOWNERS", ".github/workflows/", ".gitlab-ci.yml", ".circleci/", "Jenkinsfile"}
+
+// Approval configures which changes need the user's approval before the
+// daemon commits them
+type Approval struct {
+	Mode  string   `json:"mode" mapstructure:"mode"`             // "sensitive" or "off"
+	Paths []string `json:"paths,omitempty" mapstructure:"paths"` // Added to DefaultSensitivePaths
+	Slack Slack    `json:"slack" mapstructure:"slack"`           // Approve held changes from Slack
+}
+
+// Slack posts held changes to a Slack channel with Approve and Reject
+// buttons, whose clicks reach 'autogit serve' at /slack/actions. It is off
+// while webhook_url is unset.
+type Slack struct {
+	WebhookURL    string `json:"webhook_url,omitempty" mapstructure:"webhook_url"`       // Incoming webhook of the Slack app
+	SigningSecret string `json:"signing_secret,omitempty" mapstructure:"signing_secret"` // AUTOGIT_SLACK_SIGNING_SECRET takes precedence
+}
+
+// Enabled reports whether held changes are posted to Slack
+func (s Slack) Enabled() bool {
+	return s.WebhookURL != ""
+}
+
+// GetSigningSecret returns the secret that verifies button clicks,
+// preferring the environment
+func (s Slack) GetSigningSecret() string {
+	if secret := os.Getenv("AUTOGIT_SLACK_SIGNING_SECRET"); secret != "" {
+		return secret
+	}
+	return s.SigningSecret
+}
+
+// SensitivePaths returns the patterns whose changes need approval, or nil
+// when approval is off
+func (a Approval) SensitivePaths() []string {
+	if a.Mode == ApprovalOff {
+		return nil
+	}
+	return append(append([]string(nil), DefaultSensitivePaths...), a.Paths...)
+}
+
+// Signing modes
+const (
+	SigningGit    = "git"    // Sign when git's commit.gpgsign is on (default)
+	SigningAlways = "always" // Sign every auto-commit
+	SigningNever  = "never"  // Never sign auto-commits
+)
+
+// Signing configures signatures on auto-commits, e.g. to satisfy branch
+// protection that requires verified commits. Format and Key fall back to
+// git's gpg.format and user.signingkey.
+type Signing struct {
+	Mode   string `json:"mode,omitempty" mapstructure:"mode"`     // "git", "always" or "never"
+	Format string `json:"format,omitempty" mapstructure:"format"` // "openpgp", "ssh" or "x509"
+	Key    string `json:"key,omitempty" mapstructure:"key"`       // GPG key ID, or SSH public key file or literal
+}
+
+func (s Signing) GetMode() string {
+	if s.Mode == "" {
+		return SigningGit
+	}
+	return s.Mode
+}
+
+// Schedule limits auto-commits to working hours. Each list restricts
+// independently; an empty schedule allows commits at any time.
+type Schedule struct {
+	ActiveHours []string `json:"active_hours,omitempty" mapstructure:"active_hours"` // Local time windows, e.g. "09:00-18:00"
+	ActiveDays  []string `json:"active_days,omitempty" mapstructure:"active_days"`   // e.g. "mon-fri", "weekdays"
+	Cron        []string `json:"cron,omitempty" mapstructure:"cron"`                 // Five-field cron expressions, any of which must match
+	Timezone    string   `json:"timezone,omitempty" mapstructure:"timezone"`         // IANA zone such as "Europe/Berlin"; empty follows the system zone
+}
+
+// IsZero reports whether the schedule places no restriction
+func (s Schedule) IsZero() bool {
+	return len(s.ActiveHours) == 0 && len(s.ActiveDays) == 0 && len(s.Cron) == 0
+}
+
+// Location returns the time zone of the schedule, or nil when it follows
+// the system zone
+func (s Schedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q, expected a name such as Europe/Berlin", s.Timezone)
+	}
+	return loc, nil
+}
+
+// Parse returns the schedule in its time zone, or nil when it places no
+// restriction
+func (s Schedule) Parse() (*schedule.Schedule, error) {
+	loc, err := s.Location()
+	if err != nil {
+		return nil, err
+	}
+	sched, err := schedule.New(s.ActiveHours, s.ActiveDays, s.Cron)
+	if err != nil {
+		return nil, err
+	}
+	return sched.In(loc), nil
+}
+
+// Grouping modes
+const (
+	GroupingNone      = "none"      // One commit for all changes (default)
+	GroupingDirectory = "directory" // One commit per top-level directory or path group
+)
+
+// Grouping configures how changed files are split into separate commits
+type Grouping struct {
+	Mode   string      `json:"mode" mapstructure:"mode"`
+	Groups []PathGroup `json:"groups,omitempty" mapstructure:"groups"` // Custom groups; other files fall back to top-level directory
+}
+
+// PathGroup names a set of path prefixes that are committed together
+type PathGroup struct {
+	Name  string   `json:"name" mapstructure:"name"`
+	Paths []string `json:"paths" mapstructure:"paths"`
+}
+
+// Cadence limits how often changes to some paths are committed, e.g.
+// docs/** every 5m. Other changes are committed as usual.
+type Cadence struct {
+	Paths []string `json:"paths" mapstructure:"paths"` // Globs relative to the repository root; ** crosses directories
+	Every string   `json:"every" mapstructure:"every"` // At most one commit of these paths per duration, e.g. "60m"
+}
+
+// Interval returns the parsed Every, or 0 when it is invalid
+func (c Cadence) Interval() time.Duration {
+	d, err := time.ParseDuration(c.Every)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// DefaultMirrorInterval is how often a mirror receives pushes when its
+// every is not set
+const DefaultMirrorInterval = time.Hour
+
+// Mirror is a secondary push target, such as a second host, that receives
+// every branch and tag on a slower cadence than the main remote, as an
+// offsite backup
+type Mirror struct {
+	Remote string `json:"remote" mapstructure:"remote"`          // Name or URL of a git remote, or a file ending in .bundle
+	Every  string `json:"every,omitempty" mapstructure:"every"` // At most one mirror push per duration, e.g. "6h"; 1h by default
+}
+
+// Interval returns the parsed Every, the default when it is not set, or 0
+// when it is invalid
+func (m Mirror) Interval() time.Duration {
+	if m.Every == "" {
+		return DefaultMirrorInterval
+	}
+	d, err := time.ParseDuration(m.Every)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// BundlePath returns the bundle file the mirror writes, with a leading ~
+// expanded, or "" when it pushes to a remote
+func (m Mirror) BundlePath() string {
+	if !strings.HasSuffix(m.Remote, ".bundle") || strings.Contains(m.Remote, "://") {
+		return ""
+	}
+	if strings.HasPrefix(m.Remote, "~/") || strings.HasPrefix(m.Remote, `~\`) {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, m.Remote[2:])
+	}
+	return m.Remote
+}
+
+// Commit style enforcement modes
+const (
+	EnforceOff    = "off"    // Use AI output as-is (default)
+	EnforceFix    = "fix"    // Rewrite AI output to conform
+	EnforceReject = "reject" // Skip the commit when AI output does not conform
+)
+
+// CommitStyle configures how generated messages are validated against
+// Conventional Commits before committing.
+type CommitStyle struct {
+	Mode             string      `json:"mode" mapstructure:"mode"`                             // "off", "fix" or "reject"
+	Types            []string    `json:"types,omitempty" mapstructure:"types"`                 // Allowed commit types
+	Scopes           []ScopeRule `json:"scopes,omitempty" mapstructure:"scopes"`               // Scope mapping by path prefix
+	MaxSubjectLength int         `json:"max_subject_length" mapstructure:"max_subject_length"` // Header length limit
+	RequireBody      bool        `json:"require_body" mapstructure:"require_body"`             // Reject/fix messages without a body
+	Body             bool        `json:"body,omitempty" mapstructure:"body"`                   // Ask the AI for a body listing notable changes per file
+	Footer           string      `json:"footer,omitempty" mapstructure:"footer"`               // Appended to every message
+	Ticket           Ticket      `json:"ticket" mapstructure:"ticket"`                         // Ticket key taken from the branch name
+}
+
+// Ticket placements
+const (
+	TicketPrefix = "prefix" // Before the header, e.g. "PROJ-123 feat: ..." (default)
+	TicketSuffix = "suffix" // After the header, e.g. "feat: ... PROJ-123"
+	TicketFooter = "footer" // In a trailer below the body, e.g. "Refs: PROJ-123"
+)
+
+// Ticket configures how a ticket key such as PROJ-123 is taken from the
+// branch name and added to every message. It is off while Pattern is unset.
+type Ticket struct {
+	Pattern  string `json:"pattern,omitempty" mapstructure:"pattern"`   // Regexp matched against the branch; its first group, else the whole match, is the key
+	Position string `json:"position,omitempty" mapstructure:"position"` // "prefix", "suffix" or "footer"
+	Format   string `json:"format,omitempty" mapstructure:"format"`     // How the key is written, e.g. "[{key}]"; "{key}", or "Refs: {key}" in a footer, by default
+}
+
+func (t Ticket) GetPosition() string {
+	if t.Position == "" {
+		return TicketPrefix
+	}
+	return t.Position
+}
+
+func (t Ticket) GetFormat() string {
+	switch {
+	case t.Format != "":
+		return t.Format
+	case t.GetPosition() == TicketFooter:
+		return "Refs: {key}"
+	default:
+		return "{key}"
+	}
+}
+
+// ScopeRule maps files under Path to a Conventional Commit scope
+type ScopeRule struct {
+	Path  string `json:"path" mapstructure:"path"`
+	Scope string `json:"scope" mapstructure:"scope"`
+}
+
+var DefaultCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+const DefaultMaxSubjectLength = 72
+
+// Secret scan modes
+const (
+	SecretScanBlock = "block" // Skip the commit and notify (default)
+	SecretScanOff   = "off"
+)
+
+const (
+	DefaultEntropyThreshold = 4.5
+	DefaultMinEntropyLength = 24
+)
+
+// SecretScan configures the credential check run on the diff before staging
+type SecretScan struct {
+	Mode             string       `json:"mode" mapstructure:"mode"`                             // "block" or "off"
+	Rules            []SecretRule `json:"rules,omitempty" mapstructure:"rules"`                 // Added to the built-in rules
+	Allow            []string     `json:"allow,omitempty" mapstructure:"allow"`                 // Regexps for lines that are never reported
+	EntropyThreshold float64      `json:"entropy_threshold" mapstructure:"entropy_threshold"`   // Bits per character; 0 disables the entropy check
+	MinEntropyLength int          `json:"min_entropy_length" mapstructure:"min_entropy_length"` // Shortest token checked for entropy
+}
+
+// SecretRule names a regular expression that matches a credential
+type SecretRule struct {
+	Name    string `json:"name" mapstructure:"name"`
+	Pattern string `json:"pattern" mapstructure:"pattern"`
+}
+
+func (s SecretScan) GetMinEntropyLength() int {
+	if s.MinEntropyLength <= 0 {
+		return DefaultMinEntropyLength
+	}
+	return s.MinEntropyLength
+}
+
+// RepoConfig holds settings that can be overridden for a single repository.
+// Zero values fall back to the global settings.
+type RepoConfig struct {
+	Path              string `json:"path" mapstructure:"path"`
+	ID                string `json:"id,omitempty" mapstructure:"id"` // Stable identity, also stored in the repository's git config as autogit.id
+	Trigger           string `json:"trigger,omitempty" mapstructure:"trigger"`
+	CheckIntervalMinutes int `json:"check_interval_minutes,omitempty" mapstructure:"check_interval_minutes"`
+	CheckInterval     string `json:"check_interval,omitempty" mapstructure:"check_interval"` // Replaces the global check_interval and check_interval_minutes
+	DebounceSeconds   int    `json:"debounce_seconds,omitempty" mapstructure:"debounce_seconds"`
+	MinSpacingSeconds int    `json:"min_spacing_seconds,omitempty" mapstructure:"min_spacing_seconds"`
+	Preset            string   `json:"preset,omitempty" mapstructure:"preset"`
+	MessageSource     string   `json:"message_source,omitempty" mapstructure:"message_source"`
+	MessagePrefix     string   `json:"message_prefix,omitempty" mapstructure:"message_prefix"` // Prefix for heuristic messages
+	AIEnabled         *bool    `json:"ai_enabled,omitempty" mapstructure:"ai_enabled"`         // False never sends this repository's changes to the AI provider
+	Push              *bool    `json:"push,omitempty" mapstructure:"push"`                     // False only commits, e.g. where branch rules forbid pushing
+	VerifyPush        *bool    `json:"verify_push,omitempty" mapstructure:"verify_push"`       // False trusts git push without asking the remote where the branch points
+	Mirror            *Mirror  `json:"mirror,omitempty" mapstructure:"mirror"`                 // Secondary remote that receives every branch as a backup
+	Exclude           []string `json:"exclude,omitempty" mapstructure:"exclude"`               // Added to the global exclude list
+	SquashDaily       bool     `json:"squash_daily,omitempty" mapstructure:"squash_daily"`     // Squash each day's bot commits into one
+	VCS               string   `json:"vcs,omitempty" mapstructure:"vcs"`                       // Version control system to commit through: "git" (default), "gogit", "jj" or "hg"
+	GitDir            string   `json:"git_dir,omitempty" mapstructure:"git_dir"`               // Explicit GIT_DIR, e.g. a bare dotfiles repo
+	WorkTree          string   `json:"work_tree,omitempty" mapstructure:"work_tree"`           // Explicit GIT_WORK_TREE
+	TrackedOnly       bool     `json:"tracked_only,omitempty" mapstructure:"tracked_only"`     // Only stage files git already tracks, as staging_mode "tracked"
+	StagedOnly        bool     `json:"staged_only,omitempty" mapstructure:"staged_only"`       // Replaces the global staged_only when true
+	Cadences          []Cadence `json:"cadences,omitempty" mapstructure:"cadences"`            // Replace the global cadences
+	StagingMode       string   `json:"staging_mode,omitempty" mapstructure:"staging_mode"`     // Replaces the global staging_mode
+	StagingPatterns   []string `json:"staging_patterns,omitempty" mapstructure:"staging_patterns"` // Replace the global staging_patterns
+	Schedule          *Schedule `json:"schedule,omitempty" mapstructure:"schedule"`            // Replaces the global schedule
+	Groups            []string `json:"groups,omitempty" mapstructure:"groups"`                 // Repository groups, e.g. "work", for bulk commands and repo_groups settings
+	LogFile           string   `json:"log_file,omitempty" mapstructure:"log_file"`             // Replaces the global log_file
+	StatusAddr        string   `json:"status_addr,omitempty" mapstructure:"status_addr"`       // Replaces the global status_addr
+	LogDestination    string   `json:"log_destination,omitempty" mapstructure:"log_destination"` // Replaces the global log_destination
+	CommitPrompt      string   `json:"commit_prompt,omitempty" mapstructure:"commit_prompt"`     // Replaces the global commit_prompt; PromptFile takes precedence
+	Signing           *Signing `json:"signing,omitempty" mapstructure:"signing"`                 // Replaces the global signing
+	AuthorName        string   `json:"author_name,omitempty" mapstructure:"author_name"`         // Replaces the global author_name
+	AuthorEmail       string   `json:"author_email,omitempty" mapstructure:"author_email"`       // Replaces the global author_email
+	AutoApproveAfter  string   `json:"auto_approve_after,omitempty" mapstructure:"auto_approve_after"` // Approve held sensitive changes nobody acted on within this time, e.g. "4h"
 }
 
 type DaemonInfo struct {
-	PID      int    `json:"pid"`
-	RepoPath string `json:"repo_path"`
-	Status   string `json:"status"` // "running", "error", "paused"
+	Name       string    `json:"name,omitempty"` // StateName of the repository, which names the daemon's files
+	PID        int       `json:"pid"`
+	RepoPath   string    `json:"repo_path"`
+	Status     string    `json:"status"` // "running", "error", "paused"
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	ProcStart  string    `json:"proc_start,omitempty"` // Start time of the process, to detect a reused PID
+	Executable string    `json:"executable,omitempty"` // Program the process runs, likewise
+}
+
+// NewDaemonInfo describes the running daemon process pid for the repository
+// named name, recording how to recognize it once its PID has been reused
+func NewDaemonInfo(pid int, name, repoPath, status string) *DaemonInfo {
+	info := &DaemonInfo{
+		Name:      name,
+		PID:       pid,
+		RepoPath:  repoPath,
+		Status:    status,
+		StartedAt: time.Now(),
+	}
+	info.ProcStart, _ = proc.StartTime(pid)
+	info.Executable, _ = proc.Executable(pid)
+	return info
+}
+
+// Alive reports whether the recorded daemon process still runs. A process
+// with the same PID is not enough, as PIDs are reused after a reboot: its
+// start time and executable must match too, where they were recorded and
+// can be read on this platform.
+func (i *DaemonInfo) Alive() bool {
+	if !proc.Running(i.PID) {
+		return false
+	}
+	if i.ProcStart != "" {
+		if start, err := proc.StartTime(i.PID); err == nil && start != i.ProcStart {
+			return false
+		}
+	}
+	if i.Executable != "" {
+		if exe, err := proc.Executable(i.PID); err == nil && !pathutil.Same(exe, i.Executable) {
+			return false
+		}
+	}
+	return true
 }
 
 var configDir string
@@ -47,6 +811,16 @@ func init() {
 	}
 }
 
+// UseConfigDir keeps the config and all state in dir instead of the user's
+// config directory, e.g. for a demo that must not touch them
+func UseConfigDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	configDir = dir
+	return nil
+}
+
 func GetConfigDir() string {
 	return configDir
 }
@@ -55,69 +829,231 @@ func GetConfigPath() string {
 	return filepath.Join(configDir, ConfigFileName)
 }
 
-func GetDaemonPath() string {
-	return filepath.Join(configDir, DaemonFileName)
+func GetDaemonDir() string {
+	return filepath.Join(configDir, "daemons")
 }
 
-func LoadConfig() (*Config, error) {
-	// Initialize viper
-	viper.SetConfigName("config")
-	viper.SetConfigType("json")
-	viper.AddConfigPath(configDir)
+// GetDaemonPath returns the daemon info file of the daemon for the
+// repository named name, see RepoConfig.StateName. The empty name stands
+// for the single daemon.json of older versions.
+func GetDaemonPath(name string) string {
+	if name == "" {
+		return filepath.Join(configDir, DaemonFileName)
+	}
+	return filepath.Join(GetDaemonDir(), pathutil.SafeFileName(name)+".json")
+}
+
+// GetHookPath returns the on-save script installed by 'autogit hook
+// install' for editors to run
+func GetHookPath() string {
+	name := "on-save"
+	if runtime.GOOS == "windows" {
+		name += ".cmd"
+	}
+	return filepath.Join(configDir, "hooks", name)
+}
+
+func GetLogDir() string {
+	return filepath.Join(configDir, "logs")
+}
+
+// GetBreakerPath returns the circuit breaker state shared by all daemons
+func GetBreakerPath() string {
+	return filepath.Join(configDir, "breakers.json")
+}
+
+// GetAIUsagePath returns the AI usage shared by all daemons, kept for
+// ai_budget
+func GetAIUsagePath() string {
+	return filepath.Join(configDir, "ai_usage.json")
+}
+
+func GetApprovalDir() string {
+	return filepath.Join(configDir, "approvals")
+}
+
+// GetApprovalPath returns the pending approval file for a repository
+func GetApprovalPath(repoName string) string {
+	return filepath.Join(GetApprovalDir(), pathutil.SafeFileName(repoName)+".json")
+}
+
+func GetHistoryDir() string {
+	return filepath.Join(configDir, "history")
+}
+
+// GetHistoryPath returns the commit history file for a repository
+func GetHistoryPath(repoName string) string {
+	return filepath.Join(GetHistoryDir(), pathutil.SafeFileName(repoName)+".jsonl")
+}
+
+// GetBackupDir returns where bundles of history rewritten in a repository
+// are kept
+func GetBackupDir(repoName string) string {
+	return filepath.Join(configDir, "backups", pathutil.SafeFileName(repoName))
+}
+
+// GetIgnorePath returns the .gitignore suggestions for a repository
+func GetIgnorePath(repoName string) string {
+	return filepath.Join(configDir, "ignore", pathutil.SafeFileName(repoName)+".json")
+}
+
+func GetStatsDir() string {
+	return filepath.Join(configDir, "stats")
+}
+
+// GetStatsPath returns the activity statistics file for a repository
+func GetStatsPath(repoName string) string {
+	return filepath.Join(GetStatsDir(), pathutil.SafeFileName(repoName)+".json")
+}
+
+// newViper returns a viper instance for the config file in dir with all
+// defaults set. Every load uses its own instance, so concurrent loads do
+// not share state.
+func newViper(dir string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigName("config")
+	v.SetConfigType("json")
+	v.AddConfigPath(dir)
 	
 	// Set defaults
-	viper.SetDefault("ai_provider", "gemini")
-	viper.SetDefault("check_interval_minutes", 10)
-	viper.SetDefault("base_url", "")
+	v.SetDefault("ai_provider", "gemini")
+	v.SetDefault("check_interval_minutes", 10)
+	v.SetDefault("base_url", "")
+	v.SetDefault("trigger", TriggerInterval)
+	v.SetDefault("debounce_seconds", int(DefaultDebounce/time.Second))
+	v.SetDefault("min_spacing_seconds", 0)
+	v.SetDefault("message_source", MessageAI)
+	v.SetDefault("grouping.mode", GroupingNone)
+	v.SetDefault("max_diff_tokens", 20000)
+	v.SetDefault("time_format", timefmt.Clock24h)
+	v.SetDefault("cycle_budget_seconds", int(DefaultCycleBudget/time.Second))
+	v.SetDefault("ai_stream", true)
+	v.SetDefault("markers", true)
+	v.SetDefault("notifications.on_success", true)
+	v.SetDefault("notifications.on_error", true)
+	v.SetDefault("approval.mode", ApprovalSensitive)
+	v.SetDefault("secret_scan.mode", SecretScanBlock)
+	v.SetDefault("secret_scan.entropy_threshold", DefaultEntropyThreshold)
+	v.SetDefault("secret_scan.min_entropy_length", DefaultMinEntropyLength)
+	v.SetDefault("commit_style.mode", EnforceOff)
+	v.SetDefault("commit_style.max_subject_length", DefaultMaxSubjectLength)
+	
+	// Also read from environment variables
+	v.SetEnvPrefix("AUTOGIT")
+	v.AutomaticEnv()
+	
+	return v
+}
+
+func LoadConfig() (*Config, error) {
+	v := newViper(configDir)
 	
 	// Read from file if exists
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found; create default
 			cfg := &Config{
 				AIProvider:          "gemini",
 				CheckIntervalMinutes: 10,
+				Trigger:             TriggerInterval,
+				MessageSource:       MessageAI,
+				DebounceSeconds:     int(DefaultDebounce / time.Second),
+				Grouping:            Grouping{Mode: GroupingNone},
+				MaxDiffTokens:       20000,
+				TimeFormat:          timefmt.Clock24h,
+				CycleBudgetSeconds:  int(DefaultCycleBudget / time.Second),
+				AIStream:            true,
+				Markers:             true,
+				Notifications:       Notifications{OnSuccess: true, OnError: true},
+				Approval:            Approval{Mode: ApprovalSensitive},
+				SecretScan: SecretScan{
+					Mode:             SecretScanBlock,
+					EntropyThreshold: DefaultEntropyThreshold,
+					MinEntropyLength: DefaultMinEntropyLength,
+				},
+				CommitStyle: CommitStyle{
+					Mode:             EnforceOff,
+					MaxSubjectLength: DefaultMaxSubjectLength,
+				},
 			}
 			if err := SaveConfig(cfg); err != nil {
 				return nil, fmt.Errorf("failed to create default config: %w", err)
 			}
+			cfg.APIKey, cfg.APIKeyEnv = EnvAPIKey(cfg.AIProvider)
 			return cfg, nil
 		}
+		if _, ok := err.(viper.ConfigParseError); ok {
+			if data, readErr := os.ReadFile(v.ConfigFileUsed()); readErr == nil {
+				if _, parseErr := parseFile(v.ConfigFileUsed(), data); parseErr != nil {
+					return nil, parseErr
+				}
+			}
+		}
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 	
-	// Also read from environment variables
-	viper.SetEnvPrefix("AUTOGIT")
-	viper.AutomaticEnv()
-	
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 	
+	// Report unknown keys, wrong types and out-of-range values up front
+	// rather than falling back to defaults or failing in the daemon
+	data, err := os.ReadFile(v.ConfigFileUsed())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := validateFile(v.ConfigFileUsed(), data, &cfg); err != nil {
+		return nil, err
+	}
+	
+	if err := resolveAPIKey(&cfg, v.InConfig("api_key")); err != nil {
+		return nil, err
+	}
+	
 	return &cfg, nil
 }
 
 func SaveConfig(cfg *Config) error {
 	configPath := GetConfigPath()
 	
-	// Convert to JSON
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	// Convert to JSON, keeping the API key out of the file when possible
+	data, err := json.MarshalIndent(storeAPIKey(cfg), "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 	
-	// Write to file
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	// Write to a temporary file of its own and rename it over the config,
+	// so that a concurrent load never reads a partial write and concurrent
+	// saves never mix
+	tmp, err := os.CreateTemp(filepath.Dir(configPath), ConfigFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// CreateTemp makes the file readable by the user only, as the
+		// config may hold the API key
+		err = os.Rename(tmp.Name(), configPath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 	
 	return nil
 }
 
-func LoadDaemonInfo() (*DaemonInfo, error) {
-	daemonPath := GetDaemonPath()
-	
+// LoadDaemonInfo returns the daemon info of the repository named name, or
+// nil when no daemon runs for it
+func LoadDaemonInfo(name string) (*DaemonInfo, error) {
+	return loadDaemonInfo(GetDaemonPath(name))
+}
+
+func loadDaemonInfo(daemonPath string) (*DaemonInfo, error) {
 	data, err := os.ReadFile(daemonPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -134,14 +1070,79 @@ func LoadDaemonInfo() (*DaemonInfo, error) {
 	return &info, nil
 }
 
+// LoadLiveDaemonInfo loads the daemon info like LoadDaemonInfo but removes
+// a stale record, whose process no longer runs (e.g. after a crash or a
+// reboot), together with its health file. stale is the removed record.
+func LoadLiveDaemonInfo(name string) (info, stale *DaemonInfo, err error) {
+	info, err = LoadDaemonInfo(name)
+	if err != nil || info == nil || info.Alive() {
+		return info, nil, err
+	}
+	DeleteDaemonInfo(name)
+	DeleteHealth(name)
+	return nil, info, nil
+}
+
+// ListDaemonInfo returns the daemon info of every repository, including the
+// daemon.json of a daemon started by an older version
+func ListDaemonInfo() ([]*DaemonInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(GetDaemonDir(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, GetDaemonPath(""))
+	var infos []*DaemonInfo
+	for _, path := range paths {
+		info, err := loadDaemonInfo(path)
+		if err != nil {
+			return infos, err
+		}
+		if info != nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// ListLiveDaemonInfo returns the daemon info of every running daemon, and
+// removes the stale records like LoadLiveDaemonInfo
+func ListLiveDaemonInfo() (live, stale []*DaemonInfo, err error) {
+	infos, err := ListDaemonInfo()
+	for _, info := range infos {
+		if info.Alive() {
+			live = append(live, info)
+			continue
+		}
+		DeleteDaemonInfo(info.Name)
+		DeleteHealth(info.Name)
+		stale = append(stale, info)
+	}
+	return live, stale, err
+}
+
+// DaemonFor returns the daemon among infos that runs for the repository at
+// rootPath, or nil when none does
+func DaemonFor(infos []*DaemonInfo, rootPath string) *DaemonInfo {
+	for _, info := range infos {
+		if pathutil.Same(info.RepoPath, rootPath) {
+			return info
+		}
+	}
+	return nil
+}
+
+// SaveDaemonInfo writes info to the daemon info file of info.Name
 func SaveDaemonInfo(info *DaemonInfo) error {
-	daemonPath := GetDaemonPath()
+	daemonPath := GetDaemonPath(info.Name)
 	
 	data, err := json.MarshalIndent(info, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal daemon info: %w", err)
 	}
 	
+	if err := os.MkdirAll(filepath.Dir(daemonPath), 0755); err != nil {
+		return fmt.Errorf("failed to create daemons directory: %w", err)
+	}
 	if err := os.WriteFile(daemonPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write daemon info: %w", err)
 	}
@@ -149,9 +1150,8 @@ func SaveDaemonInfo(info *DaemonInfo) error {
 	return nil
 }
 
-func DeleteDaemonInfo() error {
-	daemonPath := GetDaemonPath()
-	return os.Remove(daemonPath)
+func DeleteDaemonInfo(name string) error {
+	return os.Remove(GetDaemonPath(name))
 }
 
 func (c *Config) GetCheckInterval() time.Duration {
@@ -161,3 +1161,411 @@ func (c *Config) GetCheckInterval() time.Duration {
 	return time.Duration(c.CheckIntervalMinutes) * time.Minute
 }
 
+func (c *Config) GetCycleBudget() time.Duration {
+	if c.CycleBudgetSeconds <= 0 {
+		return DefaultCycleBudget
+	}
+	return time.Duration(c.CycleBudgetSeconds) * time.Second
+}
+
+// GetAITimeout returns the request timeout for the configured provider
+func (c *Config) GetAITimeout() time.Duration {
+	seconds := c.AITimeouts[strings.ToLower(c.AIProvider)]
+	if seconds <= 0 {
+		return DefaultAITimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetAIModel returns the model set in ai_models for the configured
+// provider, or "" for the provider's default
+func (c *Config) GetAIModel() string {
+	return c.AIModels[strings.ToLower(c.AIProvider)]
+}
+
+// Clock returns the configured wall-clock format
+func (c *Config) Clock() timefmt.Clock {
+	if c.TimeFormat == timefmt.Clock12h {
+		return timefmt.Clock12h
+	}
+	return timefmt.Clock24h
+}
+
+
+// ForRepo returns the effective settings for the repository at rootPath,
+// merging the settings of its groups and then any matching entry in Repos
+// over the global settings.
+func (c *Config) ForRepo(rootPath string) RepoConfig {
+	rc := RepoConfig{
+		Path:                 rootPath,
+		Trigger:              c.Trigger,
+		CheckIntervalMinutes: c.CheckIntervalMinutes,
+		CheckInterval:        c.CheckInterval,
+		DebounceSeconds:      c.DebounceSeconds,
+		MinSpacingSeconds:    c.MinSpacingSeconds,
+		Preset:               c.Preset,
+		MessageSource:        c.MessageSource,
+		Exclude:              append([]string(nil), c.Exclude...),
+		StagingMode:          c.StagingMode,
+		StagingPatterns:      c.StagingPatterns,
+		StagedOnly:           c.StagedOnly,
+		Cadences:             c.Cadences,
+		Schedule:             &c.Schedule,
+		LogFile:              c.LogFile,
+		StatusAddr:           c.StatusAddr,
+		LogDestination:       c.LogDestination,
+		CommitPrompt:         c.CommitPrompt,
+		Signing:              &c.Signing,
+		AuthorName:           c.AuthorName,
+		AuthorEmail:          c.AuthorEmail,
+	}
+	
+	var override *RepoConfig
+	for i := range c.Repos {
+		if pathutil.Same(c.Repos[i].Path, rootPath) {
+			override = &c.Repos[i]
+			break
+		}
+	}
+	
+	// Group settings sit between the global settings and per-repo
+	// overrides, in the order the groups are listed
+	var layers []RepoConfig
+	if override != nil {
+		for _, name := range override.Groups {
+			if g, ok := c.RepoGroups[name]; ok {
+				layers = append(layers, g)
+			}
+		}
+		layers = append(layers, *override)
+		rc.ID = override.ID
+		rc.Groups = override.Groups
+	}
+	
+	// Presets sit below both
+	for _, r := range layers {
+		if r.Preset != "" {
+			rc.Preset = r.Preset
+		}
+	}
+	if apply, ok := presets[rc.Preset]; ok {
+		apply(&rc)
+	}
+	
+	for _, r := range layers {
+		rc.merge(r)
+	}
+	
+	// Variables such as AUTOGIT_REPO_<id>__CHECK_INTERVAL_MINUTES come last
+	overrides, _ := RepoEnvOverrides(rc)
+	for _, o := range overrides {
+		rc.merge(o.layer)
+	}
+	
+	if rc.Trigger == "" {
+		rc.Trigger = TriggerInterval
+	}
+	if rc.MessageSource == "" {
+		rc.MessageSource = MessageAI
+	}
+	
+	return rc
+}
+
+// merge applies the settings r sets over rc
+func (rc *RepoConfig) merge(r RepoConfig) {
+	if r.Trigger != "" {
+		rc.Trigger = r.Trigger
+	}
+	if r.CheckIntervalMinutes > 0 {
+		rc.CheckIntervalMinutes = r.CheckIntervalMinutes
+		rc.CheckInterval = ""
+	}
+	if r.CheckInterval != "" {
+		rc.CheckInterval = r.CheckInterval
+	}
+	if r.DebounceSeconds > 0 {
+		rc.DebounceSeconds = r.DebounceSeconds
+	}
+	if r.MinSpacingSeconds > 0 {
+		rc.MinSpacingSeconds = r.MinSpacingSeconds
+	}
+	if r.MessageSource != "" {
+		rc.MessageSource = r.MessageSource
+	}
+	if r.MessagePrefix != "" {
+		rc.MessagePrefix = r.MessagePrefix
+	}
+	if r.AIEnabled != nil {
+		rc.AIEnabled = r.AIEnabled
+	}
+	if r.Push != nil {
+		rc.Push = r.Push
+	}
+	if r.VerifyPush != nil {
+		rc.VerifyPush = r.VerifyPush
+	}
+	if r.Mirror != nil {
+		rc.Mirror = r.Mirror
+	}
+	rc.Exclude = append(rc.Exclude, r.Exclude...)
+	if r.SquashDaily {
+		rc.SquashDaily = true
+	}
+	if r.VCS != "" {
+		rc.VCS = r.VCS
+	}
+	if r.GitDir != "" {
+		rc.GitDir = r.GitDir
+		rc.WorkTree = r.WorkTree
+	}
+	if r.TrackedOnly {
+		rc.TrackedOnly = true
+	}
+	if r.StagedOnly {
+		rc.StagedOnly = true
+	}
+	if r.StagingMode != "" {
+		rc.StagingMode = r.StagingMode
+		rc.StagedOnly = r.StagedOnly // A mode of its own replaces a global staged_only
+	}
+	if len(r.StagingPatterns) > 0 {
+		rc.StagingPatterns = r.StagingPatterns
+	}
+	if len(r.Cadences) > 0 {
+		rc.Cadences = r.Cadences
+	}
+	if r.Schedule != nil {
+		rc.Schedule = r.Schedule
+	}
+	if r.LogFile != "" {
+		rc.LogFile = r.LogFile
+	}
+	if r.StatusAddr != "" {
+		rc.StatusAddr = r.StatusAddr
+	}
+	if r.LogDestination != "" {
+		rc.LogDestination = r.LogDestination
+	}
+	if r.CommitPrompt != "" {
+		rc.CommitPrompt = r.CommitPrompt
+	}
+	if r.Signing != nil {
+		rc.Signing = r.Signing
+	}
+	if r.AuthorName != "" {
+		rc.AuthorName = r.AuthorName
+	}
+	if r.AuthorEmail != "" {
+		rc.AuthorEmail = r.AuthorEmail
+	}
+	if r.AutoApproveAfter != "" {
+		rc.AutoApproveAfter = r.AutoApproveAfter
+	}
+}
+
+// GetAutoApproveAfter returns how long held sensitive changes wait before
+// they are approved automatically, or 0 when they wait for a person
+func (r RepoConfig) GetAutoApproveAfter() time.Duration {
+	d, err := time.ParseDuration(r.AutoApproveAfter)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// PromptFile, relative to the repository root, holds instructions for the
+// AI that the repository's team shares through version control
+const PromptFile = ".autogit/prompt.txt"
+
+// Prompt returns the instructions sent to the AI ahead of the diff: the
+// contents of PromptFile if the repository has one, otherwise
+// commit_prompt. An empty prompt selects the built-in instructions.
+func (r RepoConfig) Prompt() (string, error) {
+	data, err := os.ReadFile(filepath.Join(r.Path, filepath.FromSlash(PromptFile)))
+	if os.IsNotExist(err) {
+		return strings.TrimSpace(r.CommitPrompt), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// InGroup reports whether the repository is tagged with group
+func (r RepoConfig) InGroup(group string) bool {
+	for _, g := range r.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// AIDisabled reports whether the repository's changes must never be sent
+// to the AI provider. Its messages are then written locally.
+func (r RepoConfig) AIDisabled() bool {
+	return r.AIEnabled != nil && !*r.AIEnabled
+}
+
+// PushDisabled reports whether the daemon only commits, leaving pushing to
+// the user
+func (r RepoConfig) PushDisabled() bool {
+	return r.Push != nil && !*r.Push
+}
+
+// VerifiesPush reports whether the daemon fetches after each push to check
+// that it landed on the remote, the default
+func (r RepoConfig) VerifiesPush() bool {
+	return r.VerifyPush == nil || *r.VerifyPush
+}
+
+// GetStagingMode returns what the daemon may stage. staged_only means
+// "staged", and tracked_only, set for dotfiles repositories, means
+// "tracked" unless a mode is set.
+func (r RepoConfig) GetStagingMode() string {
+	switch {
+	case r.StagedOnly:
+		return StagingStaged
+	case r.StagingMode != "":
+		return r.StagingMode
+	case r.TrackedOnly:
+		return StagingTracked
+	}
+	return StagingAll
+}
+
+// GetVCS returns the version control system to commit through
+func (r RepoConfig) GetVCS() string {
+	if r.VCS == "" {
+		return VCSGit
+	}
+	return r.VCS
+}
+
+// VCSProblems lists the settings of r its version control system cannot
+// honour. go-git cannot sign or write bundles. Jujutsu and Mercurial have
+// no index to stage from, sign with their own settings and rewrite history
+// with their own commands.
+func (r RepoConfig) VCSProblems() []string {
+	vcs := r.GetVCS()
+	if vcs == VCSGit {
+		return nil
+	}
+	var problems []string
+	if vcs == VCSGoGit {
+		if r.Signing != nil && r.Signing.GetMode() == SigningAlways {
+			problems = append(problems, fmt.Sprintf("signing is not supported with vcs %q; commits that must be signed need git", vcs))
+		}
+		if r.SquashDaily {
+			problems = append(problems, fmt.Sprintf("squash_daily is not supported with vcs %q, which cannot back up commits before squashing", vcs))
+		}
+		if r.Mirror != nil {
+			problems = append(problems, fmt.Sprintf("mirror is not supported with vcs %q", vcs))
+		}
+		return problems
+	}
+	if r.GitDir != "" {
+		problems = append(problems, fmt.Sprintf("git_dir is not supported with vcs %q", vcs))
+	}
+	if mode := r.GetStagingMode(); mode != StagingAll {
+		problems = append(problems, fmt.Sprintf("staging_mode %q is not supported with vcs %q, which commits every change", mode, vcs))
+	}
+	if r.Signing != nil && r.Signing.GetMode() == SigningAlways {
+		problems = append(problems, fmt.Sprintf("signing is not supported with vcs %q; configure signing in %s instead", vcs, vcs))
+	}
+	if r.SquashDaily {
+		problems = append(problems, fmt.Sprintf("squash_daily is not supported with vcs %q", vcs))
+	}
+	if vcs == VCSHg && r.Mirror != nil {
+		problems = append(problems, fmt.Sprintf("mirror is not supported with vcs %q", vcs))
+	}
+	return problems
+}
+
+// GetCheckTiming returns when to check for changes: check_interval when it
+// is set and valid, read in the schedule's time zone, otherwise every
+// check_interval_minutes
+func (r RepoConfig) GetCheckTiming() *schedule.Timing {
+	if r.CheckInterval != "" {
+		if t, err := schedule.ParseTiming(r.CheckInterval); err == nil {
+			if r.Schedule != nil {
+				loc, _ := r.Schedule.Location()
+				t = t.In(loc)
+			}
+			return t
+		}
+	}
+	if r.CheckIntervalMinutes <= 0 {
+		return schedule.Interval(DefaultCheckInterval)
+	}
+	return schedule.Interval(time.Duration(r.CheckIntervalMinutes) * time.Minute)
+}
+
+// GetDebounce returns the quiet period for immediate mode, clamped to 10-30s
+func (r RepoConfig) GetDebounce() time.Duration {
+	if r.DebounceSeconds <= 0 {
+		return DefaultDebounce
+	}
+	d := time.Duration(r.DebounceSeconds) * time.Second
+	if d < MinDebounce {
+		return MinDebounce
+	}
+	if d > MaxDebounce {
+		return MaxDebounce
+	}
+	return d
+}
+
+func (r RepoConfig) GetMinSpacing() time.Duration {
+	if r.MinSpacingSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(r.MinSpacingSeconds) * time.Second
+}
+
+func (s CommitStyle) GetTypes() []string {
+	if len(s.Types) == 0 {
+		return DefaultCommitTypes
+	}
+	return s.Types
+}
+
+func (s CommitStyle) GetMaxSubjectLength() int {
+	if s.MaxSubjectLength <= 0 {
+		return DefaultMaxSubjectLength
+	}
+	return s.MaxSubjectLength
+}
+
+// SetRepo adds or replaces the per-repo entry for rc.Path
+func (c *Config) SetRepo(rc RepoConfig) {
+	for i := range c.Repos {
+		if pathutil.Same(c.Repos[i].Path, rc.Path) {
+			c.Repos[i] = rc
+			return
+		}
+	}
+	c.Repos = append(c.Repos, rc)
+}
+
+// GroupRepos returns the paths of the repositories tagged with group
+func (c *Config) GroupRepos(group string) []string {
+	var paths []string
+	for _, r := range c.Repos {
+		if r.InGroup(group) {
+			paths = append(paths, r.Path)
+		}
+	}
+	return paths
+}
+
+// FindRepo returns the per-repo entry for rootPath, if any
+func (c *Config) FindRepo(rootPath string) (RepoConfig, bool) {
+	for _, r := range c.Repos {
+		if pathutil.Same(r.Path, rootPath) {
+			return r, true
+		}
+	}
+	return RepoConfig{}, false
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useTempConfigDir keeps the config of a test in a temporary directory,
+// without the keyring
+func useTempConfigDir(t *testing.T) {
+	t.Helper()
+	old, oldNoKeyring := configDir, noKeyring
+	if err := UseConfigDir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	noKeyring = true
+	t.Cleanup(func() { configDir, noKeyring = old, oldNoKeyring })
+}
+
+// TestLoadSaveConfigConcurrently loads and saves the config from many
+// goroutines at once, as the daemons, the TUI and commands do. Run with
+// -race, it checks that every load uses a viper instance of its own.
+func TestLoadSaveConfigConcurrently(t *testing.T) {
+	useTempConfigDir(t)
+	if _, err := LoadConfig(); err != nil { // Creates the default config
+		t.Fatal(err)
+	}
+	
+	const workers, rounds = 8, 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers*rounds)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				cfg, err := LoadConfig()
+				if err != nil {
+					errs <- fmt.Errorf("load before save: %w", err)
+					continue
+				}
+				cfg.CheckIntervalMinutes = 20 + i
+				cfg.AIModels = map[string]string{"gemini": fmt.Sprintf("model-%d", i)}
+				if err := SaveConfig(cfg); err != nil {
+					errs <- fmt.Errorf("save: %w", err)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				cfg, err := LoadConfig()
+				if err != nil {
+					errs <- fmt.Errorf("load: %w", err)
+					continue
+				}
+				// Every load sees one complete save, never a mix
+				if n := cfg.CheckIntervalMinutes; n != 10 {
+					if want := fmt.Sprintf("model-%d", n-20); cfg.AIModels["gemini"] != want {
+						errs <- fmt.Errorf("loaded check interval %d with model %q, want %q", n, cfg.AIModels["gemini"], want)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestDaemonInfoPerRepository checks that the daemons of two repositories
+// keep their info and pause apart
+func TestDaemonInfoPerRepository(t *testing.T) {
+	useTempConfigDir(t)
+	a := NewDaemonInfo(os.Getpid(), "app-aaaaaaaa", "/work/app", "running")
+	b := NewDaemonInfo(os.Getpid(), "app-bbbbbbbb", "/home/app", "running")
+	for _, info := range []*DaemonInfo{a, b} {
+		if err := SaveDaemonInfo(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	live, stale, err := ListLiveDaemonInfo()
+	if err != nil || len(live) != 2 || len(stale) != 0 {
+		t.Fatalf("ListLiveDaemonInfo() = %d live, %d stale, %v, want 2 live", len(live), len(stale), err)
+	}
+	if info := DaemonFor(live, "/home/app"); info == nil || info.Name != b.Name {
+		t.Errorf("DaemonFor(/home/app) = %+v, want %s", info, b.Name)
+	}
+	
+	if err := SavePause(a.Name, &Pause{Since: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := LoadPause(b.Name); p != nil {
+		t.Errorf("pausing %s paused %s too", a.Name, b.Name)
+	}
+}
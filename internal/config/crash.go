@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+// CrashReport describes a commit cycle that panicked. With crash_reports
+// on it is written to the crash directory for a bug report; it is never
+// sent anywhere.
+type CrashReport struct {
+	Time      time.Time `json:"time"`
+	Repo      string    `json:"repo"`
+	Trigger   string    `json:"trigger"`       // What started the cycle
+	Panic     string    `json:"panic"`
+	Stack     []string  `json:"stack"`
+	Build     string    `json:"build"`         // Module version and VCS revision of the binary
+	Platform  string    `json:"platform"`      // GOOS/GOARCH and Go version
+	Git       string    `json:"git,omitempty"`
+	Log       []string  `json:"log,omitempty"` // Last lines of the repository's log
+}
+
+func GetCrashDir() string {
+	return filepath.Join(configDir, "crashes")
+}
+
+// SaveCrashReport writes the report, readable only by the user as the log
+// lines may name private files, and returns its path
+func SaveCrashReport(report *CrashReport) (string, error) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal crash report: %w", err)
+	}
+	
+	if err := os.MkdirAll(GetCrashDir(), 0700); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+	name := pathutil.SafeFileName(report.Repo) + "-" + report.Time.Format("20060102-150405") + ".json"
+	path := filepath.Join(GetCrashDir(), name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	return path, nil
+}
+
+// ListCrashReports returns the paths of the crash reports written so far,
+// oldest first
+func ListCrashReports() ([]string, error) {
+	entries, err := os.ReadDir(GetCrashDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read crash directory: %w", err)
+	}
+	
+	type report struct {
+		path string
+		mod  time.Time
+	}
+	var reports []report
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		reports = append(reports, report{filepath.Join(GetCrashDir(), e.Name()), info.ModTime()})
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].mod.Before(reports[j].mod) })
+	paths := make([]string, len(reports))
+	for i, r := range reports {
+		paths[i] = r.path
+	}
+	return paths, nil
+}
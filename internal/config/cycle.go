@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+// CycleToken records a commit cycle in progress so that a daemon restarted
+// in the middle of it can resume the cycle instead of repeating it
+type CycleToken struct {
+	Head      string            `json:"head"`       // HEAD when the cycle started
+	StartedAt time.Time         `json:"started_at"`
+	Messages  map[string]string `json:"messages"`   // Generated commit message by diff hash
+}
+
+func GetCycleDir() string {
+	return filepath.Join(configDir, "cycles")
+}
+
+// GetCyclePath returns the cycle token file for a repository
+func GetCyclePath(repoName string) string {
+	return filepath.Join(GetCycleDir(), pathutil.SafeFileName(repoName)+".json")
+}
+
+func LoadCycleToken(repoName string) (*CycleToken, error) {
+	data, err := os.ReadFile(GetCyclePath(repoName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // No cycle was interrupted
+		}
+		return nil, fmt.Errorf("failed to read cycle token: %w", err)
+	}
+	
+	var token CycleToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cycle token: %w", err)
+	}
+	
+	return &token, nil
+}
+
+// SaveCycleToken writes the token atomically, so that a crash never
+// leaves a partial token behind
+func SaveCycleToken(repoName string, token *CycleToken) error {
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cycle token: %w", err)
+	}
+	
+	path := GetCyclePath(repoName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cycle directory: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write cycle token: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write cycle token: %w", err)
+	}
+	
+	return nil
+}
+
+func DeleteCycleToken(repoName string) error {
+	err := os.Remove(GetCyclePath(repoName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
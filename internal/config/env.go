@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// providerKeyVars lists the provider-native environment variables checked
+// after AUTOGIT_API_KEY
+var providerKeyVars = map[string][]string{
+	"gemini":     {"GEMINI_API_KEY", "GOOGLE_API_KEY"},
+	"openai":     {"OPENAI_API_KEY"},
+	"openrouter": {"OPENROUTER_API_KEY"},
+	"anthropic":  {"ANTHROPIC_API_KEY"},
+	"claude":     {"ANTHROPIC_API_KEY"},
+}
+
+// APIKeyVars returns the environment variables an API key for provider is
+// read from, in order of precedence
+func APIKeyVars(provider string) []string {
+	return append([]string{"AUTOGIT_API_KEY"}, providerKeyVars[strings.ToLower(provider)]...)
+}
+
+// EnvAPIKey returns the API key for provider from the environment and the
+// variable it was found in
+func EnvAPIKey(provider string) (string, string) {
+	for _, name := range APIKeyVars(provider) {
+		if key := strings.TrimSpace(os.Getenv(name)); key != "" {
+			return key, name
+		}
+	}
+	return "", ""
+}
+
+// RepoEnvPrefix starts the environment variables that override a setting
+// of one repository, named by its short ID or its name in upper case, e.g.
+// AUTOGIT_REPO_3F2A9C1B__CHECK_INTERVAL_MINUTES=5. Nested settings are
+// separated by "__" too, as in AUTOGIT_REPO_NOTES__SCHEDULE__ACTIVE_HOURS.
+const RepoEnvPrefix = "AUTOGIT_REPO_"
+
+// RepoEnvName returns the variable that sets key, a dotted setting name,
+// for the repository rc describes
+func RepoEnvName(rc RepoConfig, key string) string {
+	return RepoEnvPrefix + strings.ToUpper(rc.ShortID()) + "__" + strings.ToUpper(strings.ReplaceAll(key, ".", "__"))
+}
+
+// envName turns a repository name into the form it takes in a variable
+// name: upper case, with anything but letters and digits as "_"
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// EnvOverride is a setting of one repository taken from the environment
+type EnvOverride struct {
+	Name  string // Variable name
+	Key   string // Dotted setting name
+	Value string
+	layer RepoConfig
+}
+
+// RepoEnvOverrides returns the overrides the environment holds for the
+// repository rc describes, sorted by variable name, and a problem for each
+// variable naming it that cannot be used
+func RepoEnvOverrides(rc RepoConfig) ([]EnvOverride, []string) {
+	ids := []string{strings.ToUpper(rc.ShortID()), envName(filepath.Base(rc.Path))}
+	var overrides []EnvOverride
+	var problems []string
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		target, setting, ok := strings.Cut(strings.TrimPrefix(name, RepoEnvPrefix), "__")
+		if !strings.HasPrefix(name, RepoEnvPrefix) || !ok || target == "" || (target != ids[0] && target != ids[1]) {
+			continue
+		}
+		
+		o := EnvOverride{Name: name, Key: strings.ToLower(strings.ReplaceAll(setting, "__", ".")), Value: value}
+		switch strings.SplitN(o.Key, ".", 2)[0] {
+		case "path", "id", "groups", "git_dir", "work_tree", "preset":
+			problems = append(problems, fmt.Sprintf("%s: path, id, groups, git_dir, work_tree and preset cannot be set from the environment", name))
+			continue
+		}
+		settings, err := ParseSetting(o.Key, value, true)
+		if err == nil {
+			err = ApplySettings(&o.layer, settings)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		
+		prefix := name + ": "
+		if p := o.layer.validate(prefix); len(p) > 0 {
+			problems = append(problems, p...)
+			continue
+		}
+		if n := o.layer.CheckIntervalMinutes; o.Key == "check_interval_minutes" && (n < MinCheckIntervalMinutes || n > MaxCheckIntervalMinutes) {
+			problems = append(problems, fmt.Sprintf("%scheck_interval_minutes must be ≥ %d and ≤ %d, got %d", prefix, MinCheckIntervalMinutes, MaxCheckIntervalMinutes, n))
+			continue
+		}
+		if s := o.layer.Schedule; s != nil {
+			if _, err := s.Parse(); err != nil {
+				problems = append(problems, fmt.Sprintf("%sschedule: %v", prefix, err))
+				continue
+			}
+		}
+		overrides = append(overrides, o)
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Name < overrides[j].Name })
+	return overrides, problems
+}
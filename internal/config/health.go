@@ -0,0 +1,110 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+const HealthFileName = "health.json"
+
+// HeartbeatInterval is how often a running daemon rewrites its health file
+const HeartbeatInterval = 30 * time.Second
+
+// Health is the daemon's view of itself, rewritten on every heartbeat and
+// after every cycle so that status and the TUI show live state
+type Health struct {
+	PID           int       `json:"pid"`
+	RepoPath      string    `json:"repo_path"`
+	Status        string    `json:"status"` // "running", "error", "paused"
+	Heartbeat     time.Time `json:"heartbeat"`
+	LastCheck     time.Time `json:"last_check,omitempty"`
+	LastCommit    time.Time `json:"last_commit,omitempty"`
+	LastCommitMsg string    `json:"last_commit_msg,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorAt   time.Time `json:"last_error_at,omitempty"`
+	NextRun       time.Time `json:"next_run,omitempty"` // Zero when no check is scheduled
+	PendingApproval []string `json:"pending_approval,omitempty"` // Sensitive changes held until 'autogit approve'
+	HeldLFS       []string  `json:"held_lfs,omitempty"`         // Git LFS files held because LFS is unusable on this machine
+	ModelFallback string    `json:"model_fallback,omitempty"`   // Why the provider's default model is used instead of the configured one
+}
+
+func GetHealthDir() string {
+	return filepath.Join(configDir, "health")
+}
+
+// GetHealthPath returns the health file of the daemon for the repository
+// named name. The empty name stands for the single health.json of older
+// versions.
+func GetHealthPath(name string) string {
+	if name == "" {
+		return filepath.Join(configDir, HealthFileName)
+	}
+	return filepath.Join(GetHealthDir(), pathutil.SafeFileName(name)+".json")
+}
+
+// Stale reports whether the daemon has missed several heartbeats, which
+// means it is hung or was killed without cleaning up
+func (h *Health) Stale(now time.Time) bool {
+	return now.Sub(h.Heartbeat) > 3*HeartbeatInterval
+}
+
+func LoadHealth(name string) (*Health, error) {
+	data, err := os.ReadFile(GetHealthPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // No daemon has reported yet
+		}
+		return nil, fmt.Errorf("failed to read health file: %w", err)
+	}
+	
+	var health Health
+	if err := json.Unmarshal(data, &health); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal health file: %w", err)
+	}
+	
+	return &health, nil
+}
+
+// SaveHealth writes the health file atomically so readers never see a
+// partial write
+func SaveHealth(name string, health *Health) error {
+	data, err := json.MarshalIndent(health, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal health: %w", err)
+	}
+	
+	path := GetHealthPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create health directory: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write health file: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write health file: %w", err)
+	}
+	
+	return nil
+}
+
+func DeleteHealth(name string) error {
+	return os.Remove(GetHealthPath(name))
+}
+
+// HealthFor returns the health reported by the daemon described by info,
+// or nil when that daemon has not written one
+func HealthFor(info *DaemonInfo) *Health {
+	if info == nil {
+		return nil
+	}
+	health, err := LoadHealth(info.Name)
+	if err != nil || health == nil || health.PID != info.PID {
+		return nil
+	}
+	return health
+}
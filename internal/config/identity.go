@@ -0,0 +1,78 @@
+package config
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+// NewRepoID returns a random UUID that identifies a repository wherever
+// it is moved
+func NewRepoID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// FindRepoByID returns the per-repo entry registered with id, if any
+func (c *Config) FindRepoByID(id string) (RepoConfig, bool) {
+	for _, r := range c.Repos {
+		if id != "" && r.ID == id {
+			return r, true
+		}
+	}
+	return RepoConfig{}, false
+}
+
+// Relink points the entry registered with id at newPath, for a repository
+// that was moved or cloned there, and moves its log, history, statistics
+// and pending state along. It returns the previous path and the files
+// moved; the config itself is not saved.
+func (c *Config) Relink(id, newPath string) (oldPath string, moved []string, err error) {
+	index := -1
+	for i := range c.Repos {
+		if id != "" && c.Repos[i].ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return "", nil, fmt.Errorf("no repository is registered with ID %s", id)
+	}
+	if other, ok := c.FindRepo(newPath); ok && other.ID != id {
+		return "", nil, fmt.Errorf("%s is already registered with ID %s", newPath, other.ID)
+	}
+	
+	oldPath = c.Repos[index].Path
+	before := c.ForRepo(oldPath)
+	c.Repos[index].Path = newPath
+	if c.Repos[index].WorkTree != "" && pathutil.Same(c.Repos[index].WorkTree, oldPath) {
+		c.Repos[index].WorkTree = newPath
+	}
+	if pathutil.Same(c.RootPath, oldPath) {
+		c.RootPath = newPath
+	}
+	after := c.ForRepo(newPath)
+	
+	// State still kept under a legacy name moves along as well
+	if _, err := before.MigrateState(); err != nil {
+		return oldPath, nil, err
+	}
+	moved, err = MoveState(before.StateName(), after.StateName())
+	if err != nil {
+		return oldPath, moved, err
+	}
+	if before.GetLogDestination() == LogToFile && after.GetLogDestination() == LogToFile {
+		ok, err := moveFile(before.LogPath(), after.LogPath())
+		if err != nil {
+			return oldPath, moved, err
+		}
+		if ok {
+			moved = append(moved, after.LogPath())
+		}
+	}
+	return oldPath, moved, nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// API keys are stored in the OS credential store (Keychain, Windows
+// Credential Manager, or the freedesktop Secret Service) under this
+// service and user. The config file only records KeyringRef.
+const (
+	keyringService = "autogit"
+	keyringUser    = "api_key"
+	KeyringRef     = "keyring:" + keyringService + "/" + keyringUser
+)
+
+var noKeyring bool
+
+// DisableKeyring forces the API key to be kept in the config file, for
+// systems without a usable credential store
+func DisableKeyring() {
+	noKeyring = true
+}
+
+// resolveAPIKey fills cfg.APIKey from the environment or the keyring, or
+// moves a plaintext key into the keyring. Keyring failures leave the
+// config usable: the key is kept in plaintext so that headless systems
+// keep working. fileHasKey reports whether the config file holds api_key.
+func resolveAPIKey(cfg *Config, fileHasKey bool) error {
+	if noKeyring {
+		cfg.NoKeyring = true
+	}
+	
+	if cfg.APIKeyEnvOnly {
+		persisted := fileHasKey || cfg.APIKeyRef != ""
+		cfg.APIKey, cfg.APIKeyEnv = EnvAPIKey(cfg.AIProvider)
+		if persisted {
+			// Scrub a key stored before env-only mode was turned on
+			return SaveConfig(cfg)
+		}
+		return nil
+	}
+	
+	if key := os.Getenv("AUTOGIT_API_KEY"); key != "" {
+		// Viper only applies the variable when the file has api_key
+		cfg.APIKey, cfg.APIKeyEnv = key, "AUTOGIT_API_KEY"
+		return nil
+	}
+	
+	if cfg.APIKeyRef != "" && cfg.APIKey == "" {
+		key, err := keyring.Get(keyringService, keyringUser)
+		if err != nil {
+			if errors.Is(err, keyring.ErrNotFound) {
+				return fmt.Errorf("API key not found in keyring, please set it again using 'autogit --menu'")
+			}
+			return fmt.Errorf("failed to read API key from keyring: %w", err)
+		}
+		cfg.APIKey = key
+		
+		if cfg.NoKeyring {
+			// Move the key back into the config file
+			return SaveConfig(cfg)
+		}
+		return nil
+	}
+	
+	// Migrate a plaintext key
+	if cfg.APIKey != "" && cfg.APIKeyRef == "" && !cfg.NoKeyring {
+		return SaveConfig(cfg)
+	}
+	
+	// Fall back to provider-native variables such as OPENAI_API_KEY
+	if cfg.APIKey == "" && cfg.APIKeyRef == "" {
+		cfg.APIKey, cfg.APIKeyEnv = EnvAPIKey(cfg.AIProvider)
+	}
+	return nil
+}
+
+// storeAPIKey returns the copy of cfg that is written to disk, with the API
+// key replaced by a keyring reference when the keyring can be used
+func storeAPIKey(cfg *Config) *Config {
+	stored := *cfg
+	
+	if cfg.APIKeyEnvOnly {
+		// Never persist the key, not even to the keyring
+		if cfg.APIKeyRef != "" {
+			keyring.Delete(keyringService, keyringUser)
+			cfg.APIKeyRef = ""
+		}
+		stored.APIKey = ""
+		stored.APIKeyRef = ""
+		return &stored
+	}
+	if cfg.APIKeyEnv != "" {
+		// A key from the environment stays there
+		stored.APIKey = ""
+		return &stored
+	}
+	
+	if cfg.NoKeyring || noKeyring {
+		if cfg.APIKeyRef != "" && cfg.APIKey != "" {
+			keyring.Delete(keyringService, keyringUser)
+			cfg.APIKeyRef = ""
+			stored.APIKeyRef = ""
+		}
+		return &stored
+	}
+	
+	if cfg.APIKey == "" {
+		// Nothing to store, or a referenced key that could not be loaded
+		return &stored
+	}
+	
+	if err := keyring.Set(keyringService, keyringUser, cfg.APIKey); err != nil {
+		// No credential store available; fall back to the config file
+		stored.APIKeyRef = ""
+		return &stored
+	}
+	stored.APIKey = ""
+	stored.APIKeyRef = KeyringRef
+	cfg.APIKeyRef = KeyringRef
+	return &stored
+}
+
+// DeleteKeyringKey removes the API key from the OS credential store, if
+// one was stored there
+func DeleteKeyringKey() error {
+	if err := keyring.Delete(keyringService, keyringUser); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to remove API key from keyring: %w", err)
+	}
+	return nil
+}
+
+// KeyStorage describes where the API key is kept, for diagnostics
+func (c *Config) KeyStorage() string {
+	switch {
+	case c.APIKeyEnvOnly && c.APIKeyEnv == "":
+		return "environment only, not set (" + strings.Join(APIKeyVars(c.AIProvider), ", ") + ")"
+	case c.APIKeyEnvOnly:
+		return "environment only ($" + c.APIKeyEnv + ")"
+	case c.APIKeyEnv != "":
+		return "environment ($" + c.APIKeyEnv + ")"
+	case c.APIKeyRef != "":
+		return "OS keyring"
+	case c.APIKey == "":
+		return "not set"
+	default:
+		return "config file (plaintext)"
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+// TestAPIKeyFromEnvironment checks that AUTOGIT_API_KEY is used when the
+// config file has no api_key at all
+func TestAPIKeyFromEnvironment(t *testing.T) {
+	useTempConfigDir(t)
+	if err := os.WriteFile(GetConfigPath(), []byte(`{"ai_provider":"openai","no_keyring":true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AUTOGIT_API_KEY", "sk-from-env")
+	t.Setenv("OPENAI_API_KEY", "")
+	
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "sk-from-env" || cfg.APIKeyEnv != "AUTOGIT_API_KEY" {
+		t.Errorf("APIKey, APIKeyEnv = %q, %q, want the key from AUTOGIT_API_KEY", cfg.APIKey, cfg.APIKeyEnv)
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+// Log destinations
+const (
+	LogToFile     = "file"     // A file per repository, see RepoConfig.LogPath
+	LogToSyslog   = "syslog"   // The local syslog daemon (Unix)
+	LogToJournald = "journald" // The systemd journal (Linux)
+)
+
+// DefaultLogFile is the log file name within the logs directory
+const DefaultLogFile = "{name}-{id}.log"
+
+// logVariable matches a {variable} in log_file
+var logVariable = regexp.MustCompile(`\{[a-z]+\}`)
+
+// PathID returns a short hash of rootPath that tells apart repositories
+// with the same directory name
+func PathID(rootPath string) string {
+	sum := sha256.Sum256([]byte(pathutil.Key(rootPath)))
+	return hex.EncodeToString(sum[:4])
+}
+
+// ShortID returns the first characters of the repository's ID, or its
+// PathID while it has none
+func (r RepoConfig) ShortID() string {
+	if id := strings.ReplaceAll(r.ID, "-", ""); len(id) >= 8 {
+		return id[:8]
+	}
+	return PathID(r.Path)
+}
+
+// GetLogDestination returns where the daemon logs, LogToFile by default
+func (r RepoConfig) GetLogDestination() string {
+	if r.LogDestination == "" {
+		return LogToFile
+	}
+	return r.LogDestination
+}
+
+// LogPath returns the daemon log file of the repository, expanding the
+// variables in log_file: {name} is the repository's directory name, {id}
+// its ShortID and {home} the user's home directory. A relative log_file is
+// taken from the logs directory in the config directory.
+func (r RepoConfig) LogPath() string {
+	file := r.LogFile
+	if file == "" {
+		file = DefaultLogFile
+	}
+	home, _ := os.UserHomeDir()
+	values := map[string]string{
+		"{name}": pathutil.SafeFileName(filepath.Base(r.Path)),
+		"{id}":   r.ShortID(),
+		"{home}": home,
+	}
+	file = logVariable.ReplaceAllStringFunc(file, func(v string) string {
+		if value, ok := values[v]; ok {
+			return value
+		}
+		return v
+	})
+	if strings.HasPrefix(file, "~/") || strings.HasPrefix(file, `~\`) {
+		file = filepath.Join(home, file[2:])
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(GetLogDir(), file)
+	}
+	return filepath.Clean(file)
+}
+
+// MigrateLog moves the log written under the repository's name alone, or
+// under its PathID before it had an ID, to its current path. Logs with a
+// custom log_file are left alone.
+func (r RepoConfig) MigrateLog() {
+	if r.LogFile != "" {
+		return
+	}
+	current := r.LogPath()
+	if _, err := os.Stat(current); err == nil {
+		return
+	}
+	name := pathutil.SafeFileName(filepath.Base(r.Path))
+	for _, legacy := range []string{name + "-" + PathID(r.Path) + ".log", name + ".log"} {
+		if os.Rename(filepath.Join(GetLogDir(), legacy), current) == nil {
+			return
+		}
+	}
+}
+
+// checkLogFile reports the unknown variables in a log_file template
+func checkLogFile(file string) []string {
+	var unknown []string
+	for _, v := range logVariable.FindAllString(file, -1) {
+		if v != "{name}" && v != "{id}" && v != "{home}" {
+			unknown = append(unknown, v)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const MuteFileName = "mute.json"
+
+// Mute silences desktop notifications from every daemon until a time
+type Mute struct {
+	Until time.Time `json:"until"`
+}
+
+func GetMutePath() string {
+	return filepath.Join(configDir, MuteFileName)
+}
+
+// LoadMute returns the current mute, or nil when notifications are not
+// muted
+func LoadMute() (*Mute, error) {
+	data, err := os.ReadFile(GetMutePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read mute: %w", err)
+	}
+	
+	var mute Mute
+	if err := json.Unmarshal(data, &mute); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mute: %w", err)
+	}
+	if !time.Now().Before(mute.Until) {
+		return nil, nil // Expired
+	}
+	
+	return &mute, nil
+}
+
+func SaveMute(mute *Mute) error {
+	data, err := json.MarshalIndent(mute, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal mute: %w", err)
+	}
+	
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	path := GetMutePath()
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write mute: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write mute: %w", err)
+	}
+	
+	return nil
+}
+
+func DeleteMute() error {
+	err := os.Remove(GetMutePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+const PauseFileName = "pause.json"
+
+// Pause holds the daemon's checks while the process keeps running, until
+// 'autogit resume'. It survives a restart of the daemon.
+type Pause struct {
+	Since time.Time `json:"since"`
+}
+
+// GetPausePath returns the pause file of the daemon for the repository
+// named name. The empty name stands for the single pause.json of older
+// versions.
+func GetPausePath(name string) string {
+	if name == "" {
+		return filepath.Join(configDir, PauseFileName)
+	}
+	return filepath.Join(configDir, "pauses", pathutil.SafeFileName(name)+".json")
+}
+
+// LoadPause returns the current pause, or nil when the daemon is not paused
+func LoadPause(name string) (*Pause, error) {
+	data, err := os.ReadFile(GetPausePath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read pause: %w", err)
+	}
+	
+	var pause Pause
+	if err := json.Unmarshal(data, &pause); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pause: %w", err)
+	}
+	
+	return &pause, nil
+}
+
+func SavePause(name string, pause *Pause) error {
+	data, err := json.MarshalIndent(pause, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pause: %w", err)
+	}
+	
+	path := GetPausePath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create pauses directory: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write pause: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write pause: %w", err)
+	}
+	
+	return nil
+}
+
+func DeletePause(name string) error {
+	err := os.Remove(GetPausePath(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+package config
+
+// Built-in presets
+const (
+	PresetNotes = "notes" // Markdown / Obsidian vaults
+)
+
+// Message sources
+const (
+	MessageAI        = "ai"        // Generate messages with the configured AI provider
+	MessageHeuristic = "heuristic" // Generate "prefix: update N files" locally
+)
+
+// presets maps a preset name to the settings it applies. Presets are
+// applied on top of the global settings and below per-repo overrides.
+var presets = map[string]func(*RepoConfig){
+	PresetNotes: func(rc *RepoConfig) {
+		rc.Trigger = TriggerImmediate
+		rc.DebounceSeconds = 15
+		rc.MinSpacingSeconds = 60
+		rc.MessageSource = MessageHeuristic
+		rc.MessagePrefix = "notes"
+		rc.Exclude = append(rc.Exclude, ".obsidian", ".trash", ".cache", ".DS_Store")
+		rc.SquashDaily = true
+	},
+}
+
+// IsPreset reports whether name is a known preset
+func IsPreset(name string) bool {
+	_, ok := presets[name]
+	return ok
+}
@@ -0,0 +1,604 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/schedule"
+	"github.com/aadityansha/autogit/internal/timefmt"
+)
+
+// trailerPattern matches a git trailer line such as "Autogit-Version: 1.0.0"
+var trailerPattern = regexp.MustCompile(`^[A-Za-z0-9-]+: \S[^\n]*$`)
+
+// ValidationError lists every problem found in a config file, each naming
+// the key it concerns
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config %s:\n  %s", e.Path, strings.Join(e.Problems, "\n  "))
+}
+
+// parseFile decodes the config file at path, locating syntax errors by
+// line and column
+func parseFile(path string, data []byte) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		if syntax, ok := err.(*json.SyntaxError); ok {
+			line, col := position(data, syntax.Offset)
+			return nil, fmt.Errorf("invalid config %s: line %d, column %d: %v", path, line, col, err)
+		}
+		return nil, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	return raw, nil
+}
+
+// validateFile checks the config file at path against the Config schema,
+// then checks the values decoded into cfg
+func validateFile(path string, data []byte, cfg *Config) error {
+	raw, err := parseFile(path, data)
+	if err != nil {
+		return err
+	}
+	
+	problems := checkKeys(raw, reflect.TypeOf(Config{}), "")
+	if len(problems) == 0 {
+		// Values are only meaningful once every key has the right type
+		problems = cfg.Validate()
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Path: path, Problems: problems}
+	}
+	return nil
+}
+
+// CheckSettings checks raw, decoded from JSON, against the settings of the
+// global config, or of an entry under repos with repo, as the config file
+// is checked. Keys are reported with prefix.
+func CheckSettings(raw map[string]interface{}, repo bool, prefix string) []string {
+	if repo {
+		return checkKeys(raw, reflect.TypeOf(RepoConfig{}), prefix)
+	}
+	return checkKeys(raw, reflect.TypeOf(Config{}), prefix)
+}
+
+// position converts a byte offset into a 1-based line and column
+func position(data []byte, offset int64) (int, int) {
+	line, col := 1, 1
+	for i := int64(0); i < offset && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// checkKeys reports keys in raw that t has no field for and values whose
+// JSON type does not match the field
+func checkKeys(raw map[string]interface{}, t reflect.Type, prefix string) []string {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+	
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	
+	var problems []string
+	for _, key := range keys {
+		ft, ok := fields[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is not a known setting", prefix+key))
+			continue
+		}
+		problems = append(problems, checkValue(raw[key], ft, prefix+key)...)
+	}
+	return problems
+}
+
+func checkValue(value interface{}, t reflect.Type, key string) []string {
+	if value == nil {
+		return nil // null leaves the default
+	}
+	
+	mismatch := func(want string) []string {
+		return []string{fmt.Sprintf("%s must be %s, got %s", key, want, describe(value))}
+	}
+	
+	switch t.Kind() {
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			return mismatch("a string")
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return mismatch("true or false")
+		}
+	case reflect.Int, reflect.Int64:
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return mismatch("a whole number")
+		}
+	case reflect.Float64:
+		if _, ok := value.(float64); !ok {
+			return mismatch("a number")
+		}
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return mismatch("a list")
+		}
+		var problems []string
+		for i, item := range items {
+			problems = append(problems, checkValue(item, t.Elem(), fmt.Sprintf("%s[%d]", key, i))...)
+		}
+		return problems
+	case reflect.Ptr:
+		return checkValue(value, t.Elem(), key)
+	case reflect.Map:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return mismatch("an object")
+		}
+		var problems []string
+		for k, v := range obj {
+			problems = append(problems, checkValue(v, t.Elem(), key+"."+k)...)
+		}
+		sort.Strings(problems)
+		return problems
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return mismatch("an object")
+		}
+		return checkKeys(obj, t, key+".")
+	}
+	return nil
+}
+
+// describe names the JSON type of value for error messages
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("%v", v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// Validate checks that every setting is in range and every name is known.
+// Zero values that fall back to defaults are accepted.
+func (c *Config) Validate() []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	
+	switch strings.ToLower(c.AIProvider) {
+	case "gemini", "openai", "openrouter", "anthropic", "claude", "none", "template":
+	default:
+		add("ai_provider must be one of gemini, openai, openrouter, anthropic, none, got %q", c.AIProvider)
+	}
+	if c.BaseURL != "" {
+		if u, err := url.Parse(c.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("base_url must be an http:// or https:// URL, got %q", c.BaseURL)
+		}
+	}
+	if c.CheckIntervalMinutes < MinCheckIntervalMinutes || c.CheckIntervalMinutes > MaxCheckIntervalMinutes {
+		add("check_interval_minutes must be ≥ %d and ≤ %d, got %d", MinCheckIntervalMinutes, MaxCheckIntervalMinutes, c.CheckIntervalMinutes)
+	}
+	for provider, seconds := range c.AITimeouts {
+		switch strings.ToLower(provider) {
+		case "gemini", "openai", "openrouter", "anthropic", "claude":
+		default:
+			add("ai_timeouts.%s is not a known provider", provider)
+		}
+		if seconds < 0 {
+			add("ai_timeouts.%s must be ≥ 0, got %d", provider, seconds)
+		}
+	}
+	for provider, model := range c.AIModels {
+		switch strings.ToLower(provider) {
+		case "gemini", "openai", "openrouter", "anthropic", "claude":
+		default:
+			add("ai_models.%s is not a known provider", provider)
+		}
+		if strings.TrimSpace(model) == "" || strings.ContainsAny(model, " \t\n") {
+			add("ai_models.%s must be a model ID such as \"gpt-4o-mini\", got %q", provider, model)
+		}
+	}
+	if c.CycleBudgetSeconds < 0 {
+		add("cycle_budget_seconds must be ≥ 0, got %d", c.CycleBudgetSeconds)
+	}
+	if c.MaxDiffTokens < 0 {
+		add("max_diff_tokens must be ≥ 0, got %d", c.MaxDiffTokens)
+	}
+	if c.AheadAlert.Commits < 0 || c.AheadAlert.Lines < 0 {
+		add("ahead_alert.commits and ahead_alert.lines must be ≥ 0, got %d and %d", c.AheadAlert.Commits, c.AheadAlert.Lines)
+	}
+	switch c.SharedBranch.Mode {
+	case "", SharedBranchWarn, SharedBranchSwitch, SharedBranchEnforce:
+	default:
+		add("shared_branch.mode must be %q, %q or %q, got %q", SharedBranchWarn, SharedBranchSwitch, SharedBranchEnforce, c.SharedBranch.Mode)
+	}
+	if c.SharedBranch.Rejections < 0 || c.SharedBranch.WindowMinutes < 0 {
+		add("shared_branch.rejections and shared_branch.window_minutes must be ≥ 0, got %d and %d", c.SharedBranch.Rejections, c.SharedBranch.WindowMinutes)
+	}
+	if b := c.SharedBranch.Branch; b != "" && (!strings.Contains(b, "{user}") || !strings.Contains(b, "{branch}")) {
+		add("shared_branch.branch must contain {user} and {branch}, got %q", b)
+	}
+	for i, trailer := range c.CommitTrailers {
+		if !trailerPattern.MatchString(trailer) {
+			add("commit_trailers[%d] must look like \"Key: value\", got %q", i, trailer)
+		}
+	}
+	if c.MaxDiffLinesPerFile < 0 {
+		add("max_diff_lines_per_file must be ≥ 0, got %d", c.MaxDiffLinesPerFile)
+	}
+	switch c.TimeFormat {
+	case "", timefmt.Clock24h, timefmt.Clock12h:
+	default:
+		add("time_format must be %q or %q, got %q", timefmt.Clock24h, timefmt.Clock12h, c.TimeFormat)
+	}
+	
+	// Settings shared with per-repo entries
+	global := RepoConfig{
+		Trigger:           c.Trigger,
+		CheckInterval:     c.CheckInterval,
+		DebounceSeconds:   c.DebounceSeconds,
+		MinSpacingSeconds: c.MinSpacingSeconds,
+		Preset:            c.Preset,
+		MessageSource:     c.MessageSource,
+		StagingMode:       c.StagingMode,
+		StagingPatterns:   c.StagingPatterns,
+		StagedOnly:        c.StagedOnly,
+		Cadences:          c.Cadences,
+		LogFile:           c.LogFile,
+		LogDestination:    c.LogDestination,
+		StatusAddr:        c.StatusAddr,
+		Signing:           &c.Signing,
+		AuthorName:        c.AuthorName,
+		AuthorEmail:       c.AuthorEmail,
+	}
+	problems = append(problems, global.validate("")...)
+	if _, err := c.Schedule.Parse(); err != nil {
+		add("schedule: %v", err)
+	}
+	ids := make(map[string]int)
+	for i, r := range c.Repos {
+		prefix := fmt.Sprintf("repos[%d].", i)
+		if r.Path == "" {
+			add("%spath must be set", prefix)
+		}
+		if r.ID != "" {
+			if j, ok := ids[r.ID]; ok {
+				add("%sid %s is already used by repos[%d]", prefix, r.ID, j)
+			} else {
+				ids[r.ID] = i
+			}
+		}
+		if r.CheckIntervalMinutes != 0 && (r.CheckIntervalMinutes < MinCheckIntervalMinutes || r.CheckIntervalMinutes > MaxCheckIntervalMinutes) {
+			add("%scheck_interval_minutes must be ≥ %d and ≤ %d, got %d", prefix, MinCheckIntervalMinutes, MaxCheckIntervalMinutes, r.CheckIntervalMinutes)
+		}
+		if r.WorkTree != "" && r.GitDir == "" {
+			add("%swork_tree requires git_dir", prefix)
+		}
+		problems = append(problems, r.validate(prefix)...)
+		if s := r.Schedule; s != nil {
+			if _, err := s.Parse(); err != nil {
+				add("%sschedule: %v", prefix, err)
+			}
+		}
+	}
+	
+	names := make([]string, 0, len(c.RepoGroups))
+	for name := range c.RepoGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g := c.RepoGroups[name]
+		prefix := fmt.Sprintf("repo_groups.%s.", name)
+		if g.Path != "" || g.ID != "" || len(g.Groups) > 0 || g.GitDir != "" || g.WorkTree != "" {
+			add("%spath, id, groups, git_dir and work_tree can only be set on repos entries", prefix)
+		}
+		if g.CheckIntervalMinutes != 0 && (g.CheckIntervalMinutes < MinCheckIntervalMinutes || g.CheckIntervalMinutes > MaxCheckIntervalMinutes) {
+			add("%scheck_interval_minutes must be ≥ %d and ≤ %d, got %d", prefix, MinCheckIntervalMinutes, MaxCheckIntervalMinutes, g.CheckIntervalMinutes)
+		}
+		problems = append(problems, g.validate(prefix)...)
+		if s := g.Schedule; s != nil {
+			if _, err := s.Parse(); err != nil {
+				add("%sschedule: %v", prefix, err)
+			}
+		}
+	}
+	
+	switch c.Grouping.Mode {
+	case "", GroupingNone, GroupingDirectory:
+	default:
+		add("grouping.mode must be %q or %q, got %q", GroupingNone, GroupingDirectory, c.Grouping.Mode)
+	}
+	for i, g := range c.Grouping.Groups {
+		if g.Name == "" || len(g.Paths) == 0 {
+			add("grouping.groups[%d] must have a name and at least one path", i)
+		}
+	}
+	
+	switch c.CommitStyle.Mode {
+	case "", EnforceOff, EnforceFix, EnforceReject:
+	default:
+		add("commit_style.mode must be %q, %q or %q, got %q", EnforceOff, EnforceFix, EnforceReject, c.CommitStyle.Mode)
+	}
+	if c.CommitStyle.MaxSubjectLength < 0 {
+		add("commit_style.max_subject_length must be ≥ 0, got %d", c.CommitStyle.MaxSubjectLength)
+	}
+	if t := c.CommitStyle.Ticket; t.Pattern != "" {
+		if _, err := regexp.Compile(t.Pattern); err != nil {
+			add("commit_style.ticket.pattern %q: %v", t.Pattern, err)
+		}
+	}
+	switch c.CommitStyle.Ticket.Position {
+	case "", TicketPrefix, TicketSuffix, TicketFooter:
+	default:
+		add("commit_style.ticket.position must be %q, %q or %q, got %q", TicketPrefix, TicketSuffix, TicketFooter, c.CommitStyle.Ticket.Position)
+	}
+	if f := c.CommitStyle.Ticket.Format; f != "" && !strings.Contains(f, "{key}") {
+		add("commit_style.ticket.format must contain {key}, got %q", f)
+	}
+	
+	if e := c.Email; e.Host != "" || len(e.To) > 0 {
+		if e.Host == "" {
+			add("email.host must be set when email.to is")
+		}
+		if len(e.To) == 0 {
+			add("email.to must list at least one recipient when email.host is set")
+		}
+		if e.GetFrom() == "" {
+			add("email.from or email.username must be set")
+		}
+	}
+	if c.Email.Port < 0 || c.Email.Port > 65535 {
+		add("email.port must be between 1 and 65535, got %d", c.Email.Port)
+	}
+	if c.Email.ThrottleMinutes < 0 {
+		add("email.throttle_minutes must be ≥ 0, got %d", c.Email.ThrottleMinutes)
+	}
+	if c.Email.AIFailures < 0 {
+		add("email.ai_failures must be ≥ 0, got %d", c.Email.AIFailures)
+	}
+	
+	if c.AIBreaker.Failures < 0 {
+		add("ai_breaker.failures must be ≥ 0, got %d", c.AIBreaker.Failures)
+	}
+	if c.AIBreaker.CooldownSeconds < 0 {
+		add("ai_breaker.cooldown_seconds must be ≥ 0, got %d", c.AIBreaker.CooldownSeconds)
+	}
+	if b := c.AIBudget; b.RequestsPerHour < 0 || b.DailyTokens < 0 || b.MonthlyTokens < 0 || b.DailyCost < 0 || b.MonthlyCost < 0 {
+		add("ai_budget caps must be ≥ 0")
+	}
+	providers := make([]string, 0, len(c.AIBudget.Prices))
+	for provider := range c.AIBudget.Prices {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	for _, provider := range providers {
+		if price := c.AIBudget.Prices[provider]; price < 0 {
+			add("ai_budget.prices.%s must be ≥ 0, got %g", provider, price)
+		}
+	}
+	if p := c.Network.Proxy; p != "" {
+		if u, err := url.Parse(p); err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+			add("network.proxy must be an http, https or socks5 URL such as \"http://proxy:3128\", got %q", p)
+		}
+	}
+	if c.Network.CABundle != "" && !filepath.IsAbs(c.Network.CABundlePath()) {
+		add("network.ca_bundle must be an absolute path, got %q", c.Network.CABundle)
+	}
+	if c.Notifications.MaxPerHour < 0 {
+		add("notifications.max_per_hour must be ≥ 0, got %d", c.Notifications.MaxPerHour)
+	}
+	if m := c.Notifications.MuteDuration; m != "" {
+		if d, err := time.ParseDuration(m); err != nil || d <= 0 {
+			add("notifications.mute_duration must be a duration such as \"30m\" or \"2h\", got %q", m)
+		}
+	}
+	
+	switch c.Approval.Mode {
+	case "", ApprovalSensitive, ApprovalOff:
+	default:
+		add("approval.mode must be %q or %q, got %q", ApprovalSensitive, ApprovalOff, c.Approval.Mode)
+	}
+	if hook := c.Approval.Slack.WebhookURL; hook != "" {
+		if u, err := url.Parse(hook); err != nil || u.Scheme != "https" || u.Host == "" {
+			add("approval.slack.webhook_url must be an https URL, got %q", hook)
+		}
+	}
+	
+	switch c.Storage.Backend {
+	case "", StorageFile, StorageSQLite:
+	default:
+		add("storage.backend must be %q or %q, got %q", StorageFile, StorageSQLite, c.Storage.Backend)
+	}
+	if c.Storage.Path != "" && !filepath.IsAbs(c.Storage.Path) {
+		add("storage.path must be an absolute path, got %q", c.Storage.Path)
+	}
+	if m := c.MetricsExport; m.Dir != "" && !filepath.IsAbs(m.Dir) {
+		add("metrics_export.dir must be an absolute path, got %q", m.Dir)
+	}
+	if c.MetricsExport.Interval() < time.Second {
+		add("metrics_export.every must be a duration of at least 1s, got %q", c.MetricsExport.Every)
+	}
+	
+	switch c.SecretScan.Mode {
+	case "", SecretScanBlock, SecretScanOff:
+	default:
+		add("secret_scan.mode must be %q or %q, got %q", SecretScanBlock, SecretScanOff, c.SecretScan.Mode)
+	}
+	if c.SecretScan.EntropyThreshold < 0 || c.SecretScan.EntropyThreshold > 8 {
+		add("secret_scan.entropy_threshold must be between 0 and 8 bits per character, got %v", c.SecretScan.EntropyThreshold)
+	}
+	for i, r := range c.SecretScan.Rules {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			add("secret_scan.rules[%d].pattern is not a valid regular expression: %v", i, err)
+		}
+	}
+	for i, a := range c.SecretScan.Allow {
+		if _, err := regexp.Compile(a); err != nil {
+			add("secret_scan.allow[%d] is not a valid regular expression: %v", i, err)
+		}
+	}
+	
+	return problems
+}
+
+// validate checks the settings a per-repo entry shares with the global
+// config. prefix is prepended to key names.
+func (r RepoConfig) validate(prefix string) []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	
+	switch r.Trigger {
+	case "", TriggerInterval, TriggerImmediate:
+	default:
+		add("%strigger must be %q or %q, got %q", prefix, TriggerInterval, TriggerImmediate, r.Trigger)
+	}
+	if r.CheckInterval != "" {
+		if t, err := schedule.ParseTiming(r.CheckInterval); err != nil {
+			add("%scheck_interval: %v", prefix, err)
+		} else if t.Every != 0 && (t.Every < MinCheckInterval || t.Every > MaxCheckIntervalMinutes*time.Minute) {
+			add("%scheck_interval must be ≥ %s and ≤ %dh, got %q", prefix, MinCheckInterval, MaxCheckIntervalMinutes/60, r.CheckInterval)
+		}
+	}
+	if r.DebounceSeconds != 0 {
+		min, max := int(MinDebounce.Seconds()), int(MaxDebounce.Seconds())
+		if r.DebounceSeconds < min || r.DebounceSeconds > max {
+			add("%sdebounce_seconds must be ≥ %d and ≤ %d, got %d", prefix, min, max, r.DebounceSeconds)
+		}
+	}
+	if r.MinSpacingSeconds < 0 {
+		add("%smin_spacing_seconds must be ≥ 0, got %d", prefix, r.MinSpacingSeconds)
+	}
+	if r.Preset != "" && !IsPreset(r.Preset) {
+		add("%spreset %q is not a known preset", prefix, r.Preset)
+	}
+	switch r.MessageSource {
+	case "", MessageAI, MessageHeuristic:
+	default:
+		add("%smessage_source must be %q or %q, got %q", prefix, MessageAI, MessageHeuristic, r.MessageSource)
+	}
+	switch r.VCS {
+	case "", VCSGit, VCSGoGit, VCSJJ, VCSHg:
+	default:
+		add("%svcs must be %q, %q, %q or %q, got %q", prefix, VCSGit, VCSGoGit, VCSJJ, VCSHg, r.VCS)
+	}
+	for _, problem := range r.VCSProblems() {
+		add("%s%s", prefix, problem)
+	}
+	switch r.StagingMode {
+	case "", StagingAll, StagingTracked, StagingStaged:
+	case StagingPatterns:
+		if len(r.StagingPatterns) == 0 {
+			add("%sstaging_patterns must list at least one glob when staging_mode is %q", prefix, StagingPatterns)
+		}
+	default:
+		add("%sstaging_mode must be %q, %q, %q or %q, got %q", prefix, StagingAll, StagingTracked, StagingPatterns, StagingStaged, r.StagingMode)
+	}
+	if r.StagedOnly && r.StagingMode != "" && r.StagingMode != StagingStaged {
+		add("%sstaged_only cannot be combined with staging_mode %q", prefix, r.StagingMode)
+	}
+	for i, p := range r.StagingPatterns {
+		if strings.TrimSpace(p) == "" || strings.HasPrefix(p, "/") || strings.HasPrefix(p, ":") {
+			add("%sstaging_patterns[%d] must be a glob relative to the repository root, got %q", prefix, i, p)
+		}
+	}
+	for i, c := range r.Cadences {
+		if len(c.Paths) == 0 {
+			add("%scadences[%d].paths must list at least one glob", prefix, i)
+		}
+		for j, p := range c.Paths {
+			if strings.TrimSpace(p) == "" || strings.HasPrefix(p, "/") || strings.HasPrefix(p, ":") {
+				add("%scadences[%d].paths[%d] must be a glob relative to the repository root, got %q", prefix, i, j, p)
+			}
+		}
+		if c.Interval() == 0 {
+			add("%scadences[%d].every must be a duration such as \"5m\", got %q", prefix, i, c.Every)
+		}
+	}
+	switch r.LogDestination {
+	case "", LogToFile, LogToSyslog, LogToJournald:
+	default:
+		add("%slog_destination must be %q, %q or %q, got %q", prefix, LogToFile, LogToSyslog, LogToJournald, r.LogDestination)
+	}
+	if unknown := checkLogFile(r.LogFile); len(unknown) > 0 {
+		add("%slog_file uses unknown variables %s, expected {name}, {id} or {home}", prefix, strings.Join(unknown, ", "))
+	}
+	if r.StatusAddr != "" {
+		if _, port, err := net.SplitHostPort(r.StatusAddr); err != nil || port == "" {
+			add("%sstatus_addr must be a host and port such as \"127.0.0.1:7421\", got %q", prefix, r.StatusAddr)
+		}
+	}
+	// git drops or rejects these characters in an identity
+	if strings.ContainsAny(r.AuthorName, "<>\n") {
+		add("%sauthor_name must not contain <, > or line breaks, got %q", prefix, r.AuthorName)
+	}
+	if a := r.AutoApproveAfter; a != "" {
+		if d, err := time.ParseDuration(a); err != nil || d <= 0 {
+			add("%sauto_approve_after must be a duration such as \"4h\", got %q", prefix, a)
+		}
+	}
+	if m := r.Mirror; m != nil {
+		if strings.TrimSpace(m.Remote) == "" {
+			add("%smirror.remote must name a git remote, a URL or a .bundle file", prefix)
+		}
+		if m.Interval() == 0 {
+			add("%smirror.every must be a duration such as \"6h\", got %q", prefix, m.Every)
+		}
+	}
+	if strings.ContainsAny(r.AuthorEmail, "<>\n") {
+		add("%sauthor_email must not contain <, > or line breaks, got %q", prefix, r.AuthorEmail)
+	}
+	if s := r.Signing; s != nil {
+		switch s.Mode {
+		case "", SigningGit, SigningAlways, SigningNever:
+		default:
+			add("%ssigning.mode must be %q, %q or %q, got %q", prefix, SigningGit, SigningAlways, SigningNever, s.Mode)
+		}
+		switch s.Format {
+		case "", "openpgp", "ssh", "x509":
+		default:
+			add("%ssigning.format must be \"openpgp\", \"ssh\" or \"x509\", got %q", prefix, s.Format)
+		}
+	}
+	
+	return problems
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateVCS checks that each version control system accepts the
+// settings it can honour and rejects the others
+func TestValidateVCS(t *testing.T) {
+	tests := []struct {
+		name string
+		repo RepoConfig
+		want string // Part of the only problem expected, or "" for none
+	}{
+		{"git", RepoConfig{SquashDaily: true, Mirror: &Mirror{}}, ""},
+		{"gogit", RepoConfig{VCS: VCSGoGit, GitDir: "/srv/dotfiles", StagingMode: StagingTracked}, ""},
+		{"gogit squash", RepoConfig{VCS: VCSGoGit, SquashDaily: true}, "squash_daily is not supported"},
+		{"gogit mirror", RepoConfig{VCS: VCSGoGit, Mirror: &Mirror{}}, "mirror is not supported"},
+		{"gogit signing", RepoConfig{VCS: VCSGoGit, Signing: &Signing{Mode: SigningAlways}}, "signing is not supported"},
+		{"jj git dir", RepoConfig{VCS: VCSJJ, GitDir: "/srv/dotfiles"}, "git_dir is not supported"},
+		{"unknown", RepoConfig{VCS: "svn"}, "vcs must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var problems []string
+			for _, p := range tt.repo.validate("") {
+				if strings.Contains(p, "vcs") {
+					problems = append(problems, p)
+				}
+			}
+			switch {
+			case tt.want == "" && len(problems) != 0:
+				t.Errorf("problems = %q, want none", problems)
+			case tt.want != "" && (len(problems) != 1 || !strings.Contains(problems[0], tt.want)):
+				t.Errorf("problems = %q, want one containing %q", problems, tt.want)
+			}
+		})
+	}
+}
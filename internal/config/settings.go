@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// nest turns a dotted key such as "notifications.on_success" and its value
+// into the settings object the config file would hold
+func nest(key string, value interface{}) map[string]interface{} {
+	parts := strings.Split(key, ".")
+	settings := map[string]interface{}{parts[len(parts)-1]: value}
+	for i := len(parts) - 2; i >= 0; i-- {
+		settings = map[string]interface{}{parts[i]: settings}
+	}
+	return settings
+}
+
+// CheckKey reports whether key, a dotted path such as
+// "notifications.on_success", names a setting of the global config, or of
+// an entry under repos with repo
+func CheckKey(key string, repo bool) error {
+	if key == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+		return fmt.Errorf("invalid setting name %q", key)
+	}
+	if problems := CheckSettings(nest(key, nil), repo, ""); len(problems) > 0 {
+		return fmt.Errorf("%s", problems[0])
+	}
+	return nil
+}
+
+// ParseSetting returns the settings object that sets key to value, as
+// typed on a command line. value is read as JSON when it is a number,
+// true, false, a list or an object the setting accepts, and as a string
+// otherwise.
+func ParseSetting(key, value string, repo bool) (map[string]interface{}, error) {
+	if err := CheckKey(key, repo); err != nil {
+		return nil, err
+	}
+	var problems []string
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(value), &parsed); err == nil && parsed != nil {
+		settings := nest(key, parsed)
+		if problems = CheckSettings(settings, repo, ""); len(problems) == 0 {
+			return settings, nil
+		}
+	}
+	settings := nest(key, value)
+	if p := CheckSettings(settings, repo, ""); len(p) > 0 {
+		if problems == nil {
+			problems = p
+		}
+		return nil, fmt.Errorf("%s", problems[0])
+	}
+	return settings, nil
+}
+
+// ApplySettings sets settings on target, a *Config or *RepoConfig. Objects
+// only set the keys they list, also in maps such as repo_groups.
+func ApplySettings(target interface{}, settings map[string]interface{}) error {
+	current, err := Settings(target)
+	if err != nil {
+		return err
+	}
+	merge(current, settings)
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// merge sets the values of src on dst, descending into objects both have
+func merge(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if s, ok := value.(map[string]interface{}); ok {
+			if d, ok := dst[key].(map[string]interface{}); ok {
+				merge(d, s)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// Settings returns target, a Config or RepoConfig, as the object the
+// config file holds
+func Settings(target interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(target)
+	if err != nil {
+		return nil, err
+	}
+	var settings map[string]interface{}
+	err = json.Unmarshal(data, &settings)
+	return settings, err
+}
+
+// Lookup returns the value at key, a dotted path, in settings and whether
+// it is set. An empty string counts as unset, as the config file leaves
+// out most settings that are.
+func Lookup(settings map[string]interface{}, key string) (interface{}, bool) {
+	var value interface{} = settings
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, value != nil && value != ""
+}
+
+// Flatten lists every value that is set in settings by dotted key,
+// sorted, descending into objects but not lists
+func Flatten(settings map[string]interface{}) [][2]string {
+	var lines [][2]string
+	var walk func(prefix string, obj map[string]interface{})
+	walk = func(prefix string, obj map[string]interface{}) {
+		for key, value := range obj {
+			if nested, ok := value.(map[string]interface{}); ok {
+				walk(prefix+key+".", nested)
+				continue
+			}
+			if value != nil && value != "" {
+				lines = append(lines, [2]string{prefix + key, FormatValue(value)})
+			}
+		}
+	}
+	walk("", settings)
+	sort.Slice(lines, func(i, j int) bool { return lines[i][0] < lines[j][0] })
+	return lines
+}
+
+// FormatValue prints a setting the way ParseSetting reads it back: strings
+// as they are, anything else as JSON
+func FormatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
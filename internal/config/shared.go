@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+// SharedBranchState records the pushes to a branch that were rejected
+// because of someone else's commits, so that they add up across daemon
+// restarts until the branch counts as shared
+type SharedBranchState struct {
+	Branch     string      `json:"branch"`
+	Rejections []time.Time `json:"rejections"`
+	Authors    []string    `json:"authors"`          // Authors of the commits behind the last rejection
+	Warned     bool        `json:"warned,omitempty"` // The user was told the branch is shared
+}
+
+// Reject records a rejection at now on branch, forgets those older than
+// window or on another branch, and returns how many remain
+func (s *SharedBranchState) Reject(branch string, now time.Time, window time.Duration, authors []string) int {
+	if s.Branch != branch {
+		*s = SharedBranchState{Branch: branch}
+	}
+	recent := []time.Time{now}
+	for _, t := range s.Rejections {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	s.Rejections, s.Authors = recent, authors
+	return len(recent)
+}
+
+// GetSharedBranchPath returns the shared branch state of a repository
+func GetSharedBranchPath(repoName string) string {
+	return filepath.Join(configDir, "shared", pathutil.SafeFileName(repoName)+".json")
+}
+
+func LoadSharedBranchState(repoName string) (*SharedBranchState, error) {
+	data, err := os.ReadFile(GetSharedBranchPath(repoName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &SharedBranchState{}, nil
+		}
+		return nil, fmt.Errorf("failed to read shared branch state: %w", err)
+	}
+	
+	var state SharedBranchState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal shared branch state: %w", err)
+	}
+	return &state, nil
+}
+
+func SaveSharedBranchState(repoName string, state *SharedBranchState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal shared branch state: %w", err)
+	}
+	
+	path := GetSharedBranchPath(repoName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create shared branch directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write shared branch state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+// StateName returns the name the repository's history, statistics, backups
+// and pending state are kept under: its directory name and ShortID, like
+// its log, so that repositories with the same directory name keep theirs
+// apart
+func (r RepoConfig) StateName() string {
+	return pathutil.SafeFileName(filepath.Base(r.Path)) + "-" + r.ShortID()
+}
+
+// StateName returns the StateName of the repository at rootPath, with the
+// ID it is registered with in the saved config
+func StateName(rootPath string) string {
+	if cfg, err := LoadConfig(); err == nil {
+		return cfg.ForRepo(rootPath).StateName()
+	}
+	return RepoConfig{Path: rootPath}.StateName()
+}
+
+// LegacyStateNames returns the names the repository's state may still be
+// kept under: its directory name alone, as before StateName, and its
+// directory name and PathID, from before it had an ID
+func (r RepoConfig) LegacyStateNames() []string {
+	name := pathutil.SafeFileName(filepath.Base(r.Path))
+	legacy := []string{name}
+	if byPath := name + "-" + PathID(r.Path); byPath != r.StateName() {
+		legacy = append(legacy, byPath)
+	}
+	return legacy
+}
+
+// stateFiles returns the files and directories that keep the state of the
+// repository named name, the history file included
+func stateFiles(name string) []string {
+	return []string{
+		GetHistoryPath(name),
+		GetStatsPath(name),
+		GetCyclePath(name),
+		GetApprovalPath(name),
+		GetIgnorePath(name),
+		GetSharedBranchPath(name),
+		GetBackupDir(name),
+	}
+}
+
+// MoveState moves the state of the repository named oldName to newName and
+// returns the files moved. State that newName already has is never
+// overwritten. History kept in a database is moved by its Store.
+func MoveState(oldName, newName string) ([]string, error) {
+	if oldName == newName {
+		return nil, nil
+	}
+	var moved []string
+	from, to := stateFiles(oldName), stateFiles(newName)
+	for i := range from {
+		ok, err := moveFile(from[i], to[i])
+		if err != nil {
+			return moved, err
+		}
+		if ok {
+			moved = append(moved, to[i])
+		}
+	}
+	return moved, nil
+}
+
+// MigrateState moves the state kept under the repository's legacy names to
+// its StateName. With two repositories of the same directory name, the
+// first to migrate takes the state they shared.
+func (r RepoConfig) MigrateState() ([]string, error) {
+	var moved []string
+	for _, legacy := range r.LegacyStateNames() {
+		files, err := MoveState(legacy, r.StateName())
+		moved = append(moved, files...)
+		if err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// moveFile renames from to to and reports whether it did. Nothing is moved
+// when from is missing or to exists already.
+func moveFile(from, to string) (bool, error) {
+	if _, err := os.Stat(to); err == nil {
+		return false, nil
+	}
+	if _, err := os.Stat(from); os.IsNotExist(err) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return false, err
+	}
+	if err := os.Rename(from, to); err != nil {
+		return false, err
+	}
+	return true, nil
+}
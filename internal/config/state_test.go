@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestStateNameSeparatesSameDirectoryName checks that repositories with
+// the same directory name get state of their own, and that state from
+// before StateName moves to the first that starts
+func TestStateNameSeparatesSameDirectoryName(t *testing.T) {
+	useTempConfigDir(t)
+	a := RepoConfig{Path: filepath.Join(t.TempDir(), "work", "app")}
+	b := RepoConfig{Path: filepath.Join(t.TempDir(), "home", "app")}
+	if a.StateName() == b.StateName() {
+		t.Fatalf("both repositories are named %s", a.StateName())
+	}
+	
+	legacy := GetStatsPath("app")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := a.MigrateState()
+	if err != nil || len(moved) != 1 || moved[0] != GetStatsPath(a.StateName()) {
+		t.Fatalf("MigrateState() = %v, %v, want the statistics moved", moved, err)
+	}
+	if moved, err := b.MigrateState(); err != nil || len(moved) != 0 {
+		t.Errorf("second MigrateState() = %v, %v, want nothing moved", moved, err)
+	}
+}
+
+// TestRelinkMovesState checks that the state of a relinked repository is
+// found under its new path
+func TestRelinkMovesState(t *testing.T) {
+	useTempConfigDir(t)
+	oldPath, newPath := filepath.Join(t.TempDir(), "app"), filepath.Join(t.TempDir(), "app-clone")
+	c := &Config{Repos: []RepoConfig{{Path: oldPath, ID: NewRepoID()}}}
+	before := c.ForRepo(oldPath)
+	if err := os.MkdirAll(GetHistoryDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(GetHistoryPath(before.StateName()), []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	if _, _, err := c.Relink(before.ID, newPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(GetHistoryPath(c.ForRepo(newPath).StateName())); err != nil {
+		t.Errorf("history was not moved: %v", err)
+	}
+}
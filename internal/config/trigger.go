@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+// Trigger asks the daemon to check for changes right away, e.g. after an
+// editor saved Path
+type Trigger struct {
+	RequestedAt time.Time `json:"requested_at"`
+	Path        string    `json:"path,omitempty"`
+}
+
+// GetTriggerPath returns the trigger file of the daemon for the repository
+// named name
+func GetTriggerPath(name string) string {
+	return filepath.Join(configDir, "triggers", pathutil.SafeFileName(name)+".json")
+}
+
+func SaveTrigger(name string, trigger *Trigger) error {
+	data, err := json.MarshalIndent(trigger, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal trigger: %w", err)
+	}
+	
+	path := GetTriggerPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create triggers directory: %w", err)
+	}
+	
+	// Write to a temporary file of its own and rename it over the trigger,
+	// so that concurrent saves, e.g. from an on-save hook, never share one
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write trigger: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write trigger: %w", err)
+	}
+	
+	return nil
+}
+
+// TakeTrigger returns the pending trigger and removes it, or returns nil
+// when no check was requested. Requests made while the previous one was
+// pending count as one.
+func TakeTrigger(name string) (*Trigger, error) {
+	path := GetTriggerPath(name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trigger: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove trigger: %w", err)
+	}
+	
+	var trigger Trigger
+	if err := json.Unmarshal(data, &trigger); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trigger: %w", err)
+	}
+	
+	return &trigger, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSaveTriggerConcurrently saves triggers from many goroutines at once,
+// as on-save hooks of several editors may, and checks that one complete
+// trigger is taken
+func TestSaveTriggerConcurrently(t *testing.T) {
+	useTempConfigDir(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := SaveTrigger("app", &Trigger{RequestedAt: time.Now(), Path: "main.go"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	
+	trigger, err := TakeTrigger("app")
+	if err != nil || trigger == nil || trigger.Path != "main.go" {
+		t.Fatalf("TakeTrigger() = %+v, %v, want the trigger for main.go", trigger, err)
+	}
+	if trigger, err := TakeTrigger("app"); trigger != nil || err != nil {
+		t.Errorf("second TakeTrigger() = %+v, %v, want nothing pending", trigger, err)
+	}
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"github.com/aadityansha/autogit/internal/notify"
+)
+
+// checkAhead reminds the user to push or open a pull request once the
+// branch is further ahead of its base than ahead_alert allows. The reminder
+// is shown once, and again only after the branch got back within the
+// limits, e.g. because it was merged.
+func (d *Daemon) checkAhead() {
+	alert := d.config.AheadAlert
+	if !alert.Enabled() {
+		return
+	}
+	
+	base := alert.Base
+	if base == "" {
+		var err error
+		if base, err = d.repo.DefaultBranch(); err != nil {
+			d.logger.Printf("Cannot check how far the branch is ahead: %v", err)
+			return
+		}
+	}
+	ahead, _, err := d.repo.AheadBehind(base)
+	if err != nil {
+		d.logger.Printf("Cannot check how far the branch is ahead: %v", err)
+		return
+	}
+	mergeBase, err := d.repo.MergeBase(base)
+	if err != nil {
+		d.logger.Printf("Cannot check how far the branch is ahead: %v", err)
+		return
+	}
+	added, deleted, err := d.repo.LinesSince(mergeBase)
+	if err != nil {
+		d.logger.Printf("Cannot check how far the branch is ahead: %v", err)
+		return
+	}
+	
+	lines := added + deleted
+	if (alert.Commits == 0 || ahead <= alert.Commits) && (alert.Lines == 0 || lines <= alert.Lines) {
+		d.aheadAlerted = false
+		return
+	}
+	if d.aheadAlerted {
+		return
+	}
+	d.aheadAlerted = true
+	d.logger.Printf("Branch is %d commit(s) and %d line(s) ahead of %s, time to push or open a pull request", ahead, lines, base)
+	if d.notifications.Allow(notify.KindError) {
+		notify.NotifyAhead(d.repoName, base, ahead, lines)
+	}
+}
@@ -0,0 +1,113 @@
+package daemon
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/approval"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/notify"
+	"github.com/aadityansha/autogit/internal/timefmt"
+)
+
+// holdForApproval splits files into changes to sensitive paths the user has
+// not approved yet and the rest. approved reports whether files include
+// approved sensitive changes, whose approval is cleared once committed.
+func (d *Daemon) holdForApproval(files []string) (held, rest []string, approved bool) {
+	sensitive, rest := approval.Split(files, d.config.Approval.SensitivePaths())
+	if len(sensitive) == 0 {
+		d.approved = nil
+		d.setPendingApproval(nil)
+		return nil, files, false
+	}
+	
+	done := d.cycle.Stage("diff")
+	diff, err := d.repo.FullDiffPaths(sensitive)
+	done()
+	if err != nil {
+		d.logError("Failed to get diff of sensitive paths: %v", err)
+		return sensitive, rest, false
+	}
+	hash := approval.Hash(diff)
+	if d.approved = approval.Load(d.stateName, hash); d.approved == nil {
+		d.approved = d.autoApprove(sensitive, hash)
+	}
+	if d.approved != nil {
+		d.setPendingApproval(nil)
+		return nil, files, true
+	}
+	
+	d.logger.Printf("Holding %d changes for approval: %s", len(sensitive), strings.Join(sensitive, ", "))
+	if alert := strings.Join(sensitive, "\n"); alert != d.lastApprovalAlert {
+		d.lastApprovalAlert = alert
+		if d.notifications.Allow(notify.KindError) {
+			notify.NotifyApproval(d.repoName, sensitive, d.notifications.Private())
+		}
+	}
+	d.postApproval(sensitive, hash)
+	d.setPendingApproval(sensitive)
+	return sensitive, rest, false
+}
+
+// postApproval posts held changes to Slack, when configured, once for each
+// version of them, so that a click approves exactly what was posted
+func (d *Daemon) postApproval(files []string, hash string) {
+	hook := d.config.Approval.Slack.WebhookURL
+	if hook == "" || hash == d.slackApproval {
+		return
+	}
+	d.slackApproval = hash
+	
+	held := notify.SlackApproval{Repo: d.repoName, State: d.stateName, Hash: hash, Files: files}
+	private := d.notifications.Private()
+	go func() {
+		if err := notify.PostApproval(d.ctx, hook, held, private); err != nil {
+			d.logger.Printf("Failed to post approval to Slack: %v", err)
+			return
+		}
+		d.logger.Printf("Posted approval request to Slack")
+	}()
+}
+
+// autoApprove approves held changes that nobody acted on within the
+// repository's auto_approve_after, counted from when this daemon first held
+// them. It returns the approval, or nil while the changes keep waiting.
+func (d *Daemon) autoApprove(files []string, hash string) *approval.Approval {
+	after := d.repoConfig.GetAutoApproveAfter()
+	if after == 0 {
+		return nil
+	}
+	if hash != d.heldHash {
+		d.heldHash = hash
+		d.heldSince = time.Now()
+		return nil
+	}
+	if time.Since(d.heldSince) < after {
+		return nil
+	}
+	
+	by := "auto_approve_after (" + timefmt.Duration(after) + " without review)"
+	if err := approval.Approve(d.stateName, files, hash, by); err != nil {
+		d.logError("Failed to auto-approve held changes: %v", err)
+		return nil
+	}
+	d.logger.Printf("Auto-approved %d changes held for %s: %s", len(files), timefmt.Duration(after), strings.Join(files, ", "))
+	return approval.Load(d.stateName, hash)
+}
+
+// approvalCommitted forgets an approval whose changes were committed
+func (d *Daemon) approvalCommitted() {
+	if err := approval.Clear(d.stateName); err != nil {
+		d.logger.Printf("Failed to clear approval: %v", err)
+	}
+	d.lastApprovalAlert = ""
+	d.slackApproval = ""
+	d.heldHash = ""
+	d.approved = nil
+}
+
+func (d *Daemon) setPendingApproval(files []string) {
+	d.updateHealth(func(h *config.Health) {
+		h.PendingApproval = files
+	})
+}
@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/notify"
+)
+
+// keyRejected enters the auth-expired state after the provider rejected
+// the API key. AI calls pause until the key is replaced, which is
+// reported once rather than every cycle.
+func (d *Daemon) keyRejected(err error) {
+	if d.authExpired {
+		return
+	}
+	d.authExpired = true
+	d.logError("API key for %s was rejected, run 'autogit reauth' to replace it: %v", d.config.AIProvider, err)
+	d.setStatus(StatusAuthExpired)
+	
+	if d.notifications.Allow(notify.KindError) {
+		notify.NotifyAuthExpired(d.repoName, d.config.AIProvider)
+	}
+	provider := d.config.AIProvider
+	d.sendEmail(func() (bool, error) {
+		return d.mailer.EmailAuthExpired(d.repoName, provider)
+	})
+}
+
+// reloadKey checks whether the API key was replaced since it was rejected
+// and, if so, resumes AI calls with the new key. It reports whether the
+// daemon resumed.
+func (d *Daemon) reloadKey() bool {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		d.logger.Printf("Failed to reload config: %v", err)
+		return false
+	}
+	if cfg.APIKey == "" || cfg.APIKey == d.config.APIKey {
+		return false
+	}
+	
+	updated := *d.config
+	updated.APIKey = cfg.APIKey
+	provider, err := importAIProvider(&updated, d.prompt)
+	if err != nil {
+		d.logger.Printf("Failed to use the new API key: %v", err)
+		return false
+	}
+	
+	d.countTokens()
+	d.config.APIKey = cfg.APIKey
+	d.aiProvider = provider
+	d.tokensCounted = 0
+	d.authExpired = false
+	d.setStatus(d.runningStatus())
+	d.logger.Printf("API key for %s updated, resuming AI messages", d.config.AIProvider)
+	return true
+}
+
+// runningStatus is the status of a daemon whose last cycle succeeded
+func (d *Daemon) runningStatus() string {
+	if d.authExpired {
+		return StatusAuthExpired
+	}
+	return StatusRunning
+}
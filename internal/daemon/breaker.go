@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/aadityansha/autogit/internal/commitmsg"
+	"github.com/aadityansha/autogit/internal/history"
+)
+
+// aiFailed counts a failure to generate a message against the provider's
+// circuit and emails once failures have repeated. It reports whether the
+// circuit is open, in which case the cycle should fall back to a local
+// message.
+func (d *Daemon) aiFailed(err error) bool {
+	if d.ctx.Err() != nil {
+		return false // Stopping, not the provider's fault
+	}
+	
+	d.aiFailures++
+	if d.aiFailures >= d.config.Email.GetAIFailures() {
+		count, msg := d.aiFailures, err.Error()
+		d.sendEmail(func() (bool, error) {
+			return d.mailer.EmailAIFailing(d.repoName, count, msg)
+		})
+	}
+	
+	state, werr := d.breaker.Failure(d.config.AIProvider, err, time.Now())
+	if werr != nil {
+		d.logger.Printf("Failed to record provider failure: %v", werr)
+	}
+	if !state.Open(time.Now()) {
+		return false
+	}
+	d.logger.Printf("Circuit for AI provider %s open until %s", d.config.AIProvider, state.OpenUntil.Format(time.RFC3339))
+	return true
+}
+
+// aiSucceeded closes the provider's circuit
+func (d *Daemon) aiSucceeded() {
+	d.aiFailures = 0
+	if err := d.breaker.Success(d.config.AIProvider); err != nil {
+		d.logger.Printf("Failed to reset provider circuit: %v", err)
+	}
+}
+
+// fallbackMessage generates a local message when the AI provider cannot
+// be used, logging why
+func (d *Daemon) fallbackMessage(files []string, reason string) string {
+	d.msgSource = history.SourceFallback
+	msg := commitmsg.Heuristic(d.repoConfig.MessagePrefix, files)
+	d.logger.Printf("%s, using message: %s", reason, msg)
+	return msg
+}
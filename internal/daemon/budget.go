@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// budget tracks how a commit cycle spends its overall time allowance.
+// Git stages cannot be interrupted and only count against the budget;
+// the AI stage is cut short when it would exceed its slice.
+type budget struct {
+	start  time.Time
+	total  time.Duration
+	stages []stageTime
+}
+
+type stageTime struct {
+	name    string
+	elapsed time.Duration
+	note    string // e.g. "timeout"
+}
+
+func newBudget(total time.Duration) *budget {
+	return &budget{start: time.Now(), total: total}
+}
+
+// Stage starts timing name. The returned function ends the stage. Time
+// spent in stages of the same name, e.g. one per commit group, adds up.
+func (b *budget) Stage(name string) func() {
+	start := time.Now()
+	return func() {
+		b.stage(name).elapsed += time.Since(start)
+	}
+}
+
+// Note attaches a note to the stage called name
+func (b *budget) Note(name, note string) {
+	b.stage(name).note = note
+}
+
+func (b *budget) stage(name string) *stageTime {
+	for i := range b.stages {
+		if b.stages[i].name == name {
+			return &b.stages[i]
+		}
+	}
+	b.stages = append(b.stages, stageTime{name: name})
+	return &b.stages[len(b.stages)-1]
+}
+
+// Remaining returns the time left in the budget, never negative
+func (b *budget) Remaining() time.Duration {
+	left := b.total - time.Since(b.start)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// AISlice is how long the AI stage may run: half of the budget, capped by
+// what is left so that staging, committing and pushing still fit
+func (b *budget) AISlice() time.Duration {
+	slice := b.total / 2
+	if left := b.Remaining() / 2; left < slice {
+		slice = left
+	}
+	return slice
+}
+
+// Summary reports total time against the budget and every stage, slowest
+// first, so the budget can be tuned from the log
+func (b *budget) Summary() string {
+	stages := append([]stageTime(nil), b.stages...)
+	sort.SliceStable(stages, func(i, j int) bool {
+		return stages[i].elapsed > stages[j].elapsed
+	})
+	
+	var parts []string
+	for _, s := range stages {
+		part := fmt.Sprintf("%s %s", s.name, s.elapsed.Round(time.Millisecond))
+		if s.note != "" {
+			part += " (" + s.note + ")"
+		}
+		parts = append(parts, part)
+	}
+	
+	elapsed := time.Since(b.start).Round(time.Millisecond)
+	summary := fmt.Sprintf("Cycle took %s of %s budget", elapsed, b.total)
+	if elapsed > b.total && len(stages) > 0 {
+		summary += fmt.Sprintf(", over budget mostly in %s", stages[0].name)
+	}
+	if len(parts) > 0 {
+		summary += ": " + strings.Join(parts, ", ")
+	}
+	return summary
+}
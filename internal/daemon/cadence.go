@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+// cadenceKey identifies a cadence across config reloads by its paths
+func cadenceKey(c config.Cadence) string {
+	return strings.Join(c.Paths, "\x00")
+}
+
+// cadenceFor returns the first cadence whose paths match file
+func (d *Daemon) cadenceFor(file string) (config.Cadence, bool) {
+	for _, c := range d.repoConfig.Cadences {
+		for _, p := range c.Paths {
+			if pathutil.MatchGlob(p, file) {
+				return c, true
+			}
+		}
+	}
+	return config.Cadence{}, false
+}
+
+// holdForCadence splits files into changes to paths whose cadence allows
+// no commit yet and the rest. Each cadence counts from its last commit by
+// this daemon, so the first check after starting commits them.
+func (d *Daemon) holdForCadence(files []string, now time.Time) (held, rest []string) {
+	waiting := make(map[string]int)
+	due := make(map[string]time.Time)
+	for _, file := range files {
+		c, ok := d.cadenceFor(file)
+		if !ok {
+			rest = append(rest, file)
+			continue
+		}
+		next := d.cadenceCommits[cadenceKey(c)].Add(c.Interval())
+		if !now.Before(next) {
+			rest = append(rest, file)
+			continue
+		}
+		held = append(held, file)
+		name := c.Every + " for " + strings.Join(c.Paths, ", ")
+		waiting[name]++
+		due[name] = next
+	}
+	for name, count := range waiting {
+		d.logger.Printf("Holding %d changes until %s (cadence %s)", count, due[name].Format("15:04"), name)
+	}
+	return held, rest
+}
+
+// cadenceCommitted starts the next period of every cadence with a file in
+// the committed files
+func (d *Daemon) cadenceCommitted(files []string, now time.Time) {
+	for _, file := range files {
+		if c, ok := d.cadenceFor(file); ok {
+			if d.cadenceCommits == nil {
+				d.cadenceCommits = make(map[string]time.Time)
+			}
+			d.cadenceCommits[cadenceKey(c)] = now
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"os"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/history"
+)
+
+// pausePoll is how often the pause file is read besides being woken, which
+// is the only way to notice it on Windows
+const pausePoll = 5 * time.Second
+
+// applyPause pauses or resumes checks to match the pause file written by
+// 'autogit pause' and 'autogit resume'. A running cycle finishes first.
+// Resuming checks right away and restarts a ticker stopped by an error.
+func (d *Daemon) applyPause() {
+	pause, err := config.LoadPause(d.stateName)
+	if err != nil {
+		d.logger.Printf("Failed to read pause state: %v", err)
+		return
+	}
+	
+	d.mu.Lock()
+	if (pause != nil) == d.paused {
+		d.mu.Unlock()
+		return
+	}
+	d.paused = pause != nil
+	if d.paused {
+		d.ticker.Stop()
+		d.logger.Printf("Paused, not checking for changes until resumed")
+		d.setStatus(StatusPaused)
+		d.saveDaemonStatus(StatusPaused)
+		d.mu.Unlock()
+		return
+	}
+	d.ticker.Reset()
+	d.logger.Printf("Resumed")
+	d.setStatus(d.runningStatus())
+	d.saveDaemonStatus(StatusRunning)
+	d.mu.Unlock()
+	
+	d.checkAndCommit(history.TriggerResume)
+}
+
+// takeTrigger runs a check when 'autogit trigger' asked for one
+func (d *Daemon) takeTrigger() {
+	trigger, err := config.TakeTrigger(d.stateName)
+	if err != nil {
+		d.logger.Printf("Failed to read trigger: %v", err)
+		return
+	}
+	if trigger == nil {
+		return
+	}
+	
+	if trigger.Path != "" {
+		d.logger.Printf("Check requested after saving %s", trigger.Path)
+	} else {
+		d.logger.Printf("Check requested")
+	}
+	d.checkAndCommit(history.TriggerRequest)
+}
+
+// saveDaemonStatus records status in the daemon info, where status and the
+// TUI look when the health file is missing
+func (d *Daemon) saveDaemonStatus(status string) {
+	info, err := config.LoadDaemonInfo(d.stateName)
+	if err != nil || info == nil || info.PID != os.Getpid() {
+		return
+	}
+	info.Status = status
+	if err := config.SaveDaemonInfo(info); err != nil {
+		d.logger.Printf("Failed to save daemon info: %v", err)
+	}
+}
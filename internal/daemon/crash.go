@@ -0,0 +1,113 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/git"
+)
+
+const (
+	// crashLogLines is how many lines of the log a crash report keeps
+	crashLogLines = 50
+	// crashLogTail bounds how much of the log is read for them
+	crashLogTail = 64 << 10
+)
+
+// recoverCycle, deferred by checkAndCommit, turns a panic in a commit
+// cycle into an error, so that one bad cycle does not take the daemon and
+// every later check with it. With crash_reports on, it also writes a crash
+// report to attach to a bug report.
+func (d *Daemon) recoverCycle() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := strings.Split(strings.TrimRight(string(debug.Stack()), "\n"), "\n")
+	var logTail []string
+	if d.config.CrashReports {
+		logTail = d.logTail()
+	}
+	
+	// The next cycle starts over from the working tree
+	d.markers = nil
+	d.approved = nil
+	d.logError("Commit cycle panicked: %v", r)
+	d.logger.Printf("Stack trace:\n    %s", strings.Join(stack, "\n    "))
+	if !d.config.CrashReports {
+		return
+	}
+	
+	report := &config.CrashReport{
+		Time:     time.Now(),
+		Repo:     d.repoName,
+		Trigger:  d.trigger,
+		Panic:    fmt.Sprint(r),
+		Build:    buildVersion(),
+		Platform: fmt.Sprintf("%s/%s, %s", runtime.GOOS, runtime.GOARCH, runtime.Version()),
+		Log:      logTail,
+	}
+	for _, line := range stack {
+		report.Stack = append(report.Stack, strings.ReplaceAll(line, "\t", "    "))
+	}
+	if version, err := git.Version(); err == nil {
+		report.Git = version
+	}
+	path, err := config.SaveCrashReport(report)
+	if err != nil {
+		d.logger.Printf("Failed to write crash report: %v", err)
+		return
+	}
+	d.logger.Printf("Crash report written to %s; it stays on this machine, check it for private paths before attaching it to a bug report", path)
+}
+
+// logTail returns the last lines of the repository's log, when it is a file
+func (d *Daemon) logTail() []string {
+	if d.repoConfig.GetLogDestination() != config.LogToFile {
+		return nil
+	}
+	f, err := os.Open(d.repoConfig.LogPath())
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	
+	if info, err := f.Stat(); err == nil && info.Size() > crashLogTail {
+		f.Seek(-crashLogTail, io.SeekEnd)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > crashLogLines {
+		lines = lines[len(lines)-crashLogLines:]
+	}
+	return lines
+}
+
+// buildVersion returns the module version and VCS revision of the binary
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			version += " " + s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				version += " (modified)"
+			}
+		}
+	}
+	return version
+}
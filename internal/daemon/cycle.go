@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/approval"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/history"
+	"github.com/aadityansha/autogit/internal/marker"
+)
+
+// resumeCycle picks up the token of a cycle interrupted by a restart. If
+// the cycle already committed, the first check pushes without committing
+// again; otherwise its messages are reused while the diff is unchanged.
+func (d *Daemon) resumeCycle() {
+	token, err := config.LoadCycleToken(d.stateName)
+	if err != nil {
+		d.logger.Printf("Discarding unreadable cycle token: %v", err)
+		config.DeleteCycleToken(d.stateName)
+		return
+	}
+	if token == nil {
+		return
+	}
+	
+	entries, err := d.repo.Log(1)
+	if err != nil || len(entries) == 0 || entries[0].Hash == token.Head {
+		d.logger.Printf("Resuming cycle interrupted at %s", token.StartedAt.Format(time.RFC3339))
+		d.token = token
+		return
+	}
+	
+	for _, msg := range token.Messages {
+		if firstLine(msg) == entries[0].Subject {
+			d.logger.Printf("Cycle interrupted at %s already committed %s, pushing it", token.StartedAt.Format(time.RFC3339), entries[0].Hash[:7])
+			d.token = token
+			d.pendingPush = true
+			return
+		}
+	}
+	
+	// HEAD moved for another reason, e.g. a manual commit
+	d.logger.Printf("Discarding cycle interrupted at %s, HEAD has changed", token.StartedAt.Format(time.RFC3339))
+	config.DeleteCycleToken(d.stateName)
+}
+
+// messageFor returns the message for diff, reusing the one generated by an
+// interrupted cycle for the same diff. New AI messages are recorded in the
+// cycle token before anything is committed.
+func (d *Daemon) messageFor(diff string, files []string) (string, error) {
+	if text := marker.MessageFor(d.markers, files); text != "" {
+		d.logger.Printf("Using message from marker: %s", text)
+		d.msgSource = history.SourceMarker
+		return d.filterMessage(text)
+	}
+	if diff == "" || d.repoConfig.MessageSource == config.MessageHeuristic {
+		return d.generateMessage(diff, files)
+	}
+	
+	sum := sha256.Sum256([]byte(diff))
+	hash := hex.EncodeToString(sum[:])
+	if d.token != nil {
+		if msg, ok := d.token.Messages[hash]; ok {
+			d.logger.Printf("Reusing message from interrupted cycle: %s", msg)
+			d.msgSource = history.SourceResumed
+			return msg, nil
+		}
+	}
+	
+	msg, err := d.generateMessage(diff, files)
+	if err != nil {
+		return "", err
+	}
+	
+	if d.token == nil {
+		d.token = &config.CycleToken{StartedAt: time.Now(), Messages: make(map[string]string)}
+		if entries, err := d.repo.Log(1); err == nil && len(entries) > 0 {
+			d.token.Head = entries[0].Hash
+		}
+	}
+	d.token.Messages[hash] = msg
+	if err := config.SaveCycleToken(d.stateName, d.token); err != nil {
+		d.logger.Printf("Failed to save cycle token: %v", err)
+	}
+	return msg, nil
+}
+
+// finishCycle forgets the cycle token once its commits are pushed
+func (d *Daemon) finishCycle() {
+	d.pendingPush = false
+	if d.token == nil {
+		return
+	}
+	d.token = nil
+	if err := config.DeleteCycleToken(d.stateName); err != nil {
+		d.logger.Printf("Failed to delete cycle token: %v", err)
+	}
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+}
+
+// recordHistory adds the commit at HEAD to the repository's history, for
+// 'autogit why'
+func (d *Daemon) recordHistory(msg string, files []string) {
+	entry := history.Entry{
+		Time:    time.Now(),
+		Message: msg,
+		Files:   files,
+		Trigger: d.trigger,
+		Source:  d.msgSource,
+	}
+	if entries, err := d.repo.Log(1); err == nil && len(entries) > 0 {
+		entry.Commit = entries[0].Hash
+	}
+	if sensitive, _ := approval.Split(files, d.config.Approval.SensitivePaths()); d.approved != nil && len(sensitive) > 0 {
+		entry.Approval = d.approved.By
+	}
+	if d.msgSource != history.SourceHeuristic && d.msgSource != history.SourceMarker {
+		entry.Provider = d.config.AIProvider
+		entry.Model = ai.Model(d.aiProvider)
+	}
+	
+	if err := d.history.Append(d.stateName, entry); err != nil {
+		d.logger.Printf("Failed to write history: %v", err)
+	}
+}
@@ -1,103 +1,484 @@
 package daemon
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
-	"runtime"
-	"syscall"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/approval"
+	"github.com/aadityansha/autogit/internal/breaker"
+	"github.com/aadityansha/autogit/internal/commitmsg"
 	"github.com/aadityansha/autogit/internal/config"
 	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/gogit"
+	"github.com/aadityansha/autogit/internal/group"
+	"github.com/aadityansha/autogit/internal/hg"
+	"github.com/aadityansha/autogit/internal/history"
+	"github.com/aadityansha/autogit/internal/jj"
+	"github.com/aadityansha/autogit/internal/logdest"
+	"github.com/aadityansha/autogit/internal/marker"
 	"github.com/aadityansha/autogit/internal/notify"
+	"github.com/aadityansha/autogit/internal/proc"
+	"github.com/aadityansha/autogit/internal/schedule"
+	"github.com/aadityansha/autogit/internal/secrets"
+	"github.com/aadityansha/autogit/internal/stats"
+	"github.com/aadityansha/autogit/internal/vcs"
+	"github.com/aadityansha/autogit/internal/wsl"
 )
 
 const (
-	StatusRunning = "running"
-	StatusError   = "error"
-	StatusPaused  = "paused"
+	StatusRunning     = "running"
+	StatusError       = "error"
+	StatusPaused      = "paused"
+	StatusAuthExpired = "auth-expired" // The provider rejected the API key
+	StatusRestarting  = "restarting"   // Crashed, waiting to be started again
 )
 
+// restartTimeout bounds the wait for a stopped daemon to exit, which
+// finishes the cycle it is running first
+const restartTimeout = 30 * time.Second
+
 type Daemon struct {
 	config     *config.Config
+	repoConfig config.RepoConfig
 	aiProvider ai.AIProvider
-	ticker     *time.Ticker
+	prompt     string // Instructions for the AI from the repository or config, empty for the built-in ones
+	ticker     *checkTimer
 	stopChan   chan bool
 	status     string
 	rootPath   string
 	repoName   string
-	logFile    *os.File
+	stateName  string // Names the history, statistics and pending state of the repository, see config.RepoConfig.StateName
+	repo       vcs.Repo // The repository at rootPath, which every git or jj call goes through
+	logFile    io.WriteCloser // Log file, or connection to syslog or the journal
 	logger     *log.Logger
+	scanner    *secrets.Scanner
+	schedule   *schedule.Schedule // Nil when commits are allowed at any time
+	outsideSchedule bool          // Whether the last check fell outside the schedule
+	unsettled       string        // Why the last check found the repository mid-merge or conflicted, if it did
+	
+	mu         sync.Mutex // Serializes commit cycles from the ticker and the watcher
+	lastCommit time.Time
+	lastSquash time.Time // Day of the last daily squash check
+	watcher    *watcher
+	lastSecretAlert string // Findings last notified, to avoid repeating the alert every cycle
+	lastApprovalAlert string // Held files last notified, likewise
+	lastLFSAlert      string // Git LFS files last notified as held, likewise
+	cadenceCommits    map[string]time.Time // Last commit of each path cadence, by cadenceKey
+	slackApproval     string // Hash of the held changes last posted to Slack
+	heldHash          string    // Hash of the held changes, for auto_approve_after
+	heldSince         time.Time // When the changes with heldHash were first held
+	approved          *approval.Approval // Approval of the sensitive changes being committed
+	cycle      *budget // Time budget of the running commit cycle
+	ctx        context.Context    // Cancelled by Stop to abort in-flight AI requests
+	cancel     context.CancelFunc
+	token      *config.CycleToken // Persisted state of the cycle, nil when none is in progress
+	pendingPush bool              // An interrupted cycle committed but may not have pushed
+	pendingForcePush bool         // A daily squash rewrote history that was not force-pushed yet
+	trigger     string            // What started the running cycle, for the history
+	msgSource   string            // How the last commit message was produced, for the history
+	markers     []marker.Marker   // Markers in the changes of the running cycle
+	timing     *schedule.Timing // Effective check interval or cron expression
+	paused     bool          // Checks are held by 'autogit pause', guarded by mu
+	
+	healthMu      sync.Mutex // Guards health, written from the cycle, the watcher and the heartbeat
+	health        config.Health
+	heartbeatDone chan struct{}
+	
+	stats         *stats.Recorder // Activity statistics for 'autogit stats'
+	history       history.Store   // Commit history for 'autogit why'
+	tokensCounted int64           // AI tokens already added to stats
+	metrics       metrics         // Counters for the status endpoint
+	httpServer    *http.Server    // Status endpoint at status_addr, nil when off
+	
+	notifications *notify.Gate     // Desktop notification preferences
+	mailer        *notify.Mailer   // Nil when email is not configured
+	aiFailures    int              // Consecutive failures to generate a message
+	breaker       *breaker.Breaker // Circuit per AI provider, shared with other daemons
+	authExpired   bool             // The provider rejected the API key, AI calls are paused
+	signingAlerted bool            // A signing failure was notified since the last commit
+	aheadAlerted   bool            // The branch was reported to be too far ahead of its base
+	pushAlerted    bool            // A push that did not land was reported since the last one that did
+	mirrorAt       time.Time       // Last successful push to the backup mirror
+	mirroredRefs   string          // Branches and tags as of that push
+	mirrorAlerted  bool            // A mirror failure was reported since the last success
 }
 
 func NewDaemon(cfg *config.Config, rootPath string) (*Daemon, error) {
+	if cfg.ForRepo(rootPath).AIDisabled() {
+		// Messages come from the diff locally, as with provider "none"
+		local := *cfg
+		local.AIProvider = "none"
+		cfg = &local
+	}
+	
+	repoConfig := cfg.ForRepo(rootPath)
+	if _, problems := config.RepoEnvOverrides(repoConfig); len(problems) > 0 {
+		return nil, fmt.Errorf("invalid settings in the environment:\n  %s", strings.Join(problems, "\n  "))
+	}
+	prompt, err := repoConfig.Prompt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", config.PromptFile, err)
+	}
+	
 	// Import AI provider
-	ai, err := importAIProvider(cfg)
+	ai, err := importAIProvider(cfg, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AI provider: %w", err)
 	}
 	
+	scanner, err := secrets.New(cfg.SecretScan)
+	if err != nil {
+		return nil, err
+	}
+	
+	sched, err := repoConfig.Schedule.Parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule: %w", err)
+	}
+	
 	repoName := git.GetRepoName(rootPath)
+	stateName := repoConfig.StateName()
+	repo, err := openVCS(repoConfig)
+	if err != nil {
+		return nil, err
+	}
 	
 	// Setup logging
-	logDir := filepath.Join(config.GetConfigDir(), "logs")
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	if repoConfig.GetLogDestination() == config.LogToFile {
+		repoConfig.MigrateLog()
 	}
-	
-	logPath := filepath.Join(logDir, fmt.Sprintf("%s.log", repoName))
-	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	logFile, flags, err := logdest.Open(repoConfig.GetLogDestination(), repoConfig.LogPath(), repoName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+		return nil, err
+	}
+	store, err := history.Open(cfg.Storage)
+	if err != nil {
+		logFile.Close()
+		return nil, err
 	}
 	
-	logger := log.New(logFile, "", log.LstdFlags)
+	logger := log.New(logFile, "", flags)
+	if _, err := repoConfig.MigrateState(); err != nil {
+		logger.Printf("Failed to move state to %s: %v", stateName, err)
+	}
+	for _, legacy := range repoConfig.LegacyStateNames() {
+		if err := store.Rename(legacy, stateName); err != nil {
+			logger.Printf("Failed to move history to %s: %v", stateName, err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
 	
 	return &Daemon{
 		config:     cfg,
+		repoConfig: repoConfig,
 		aiProvider: ai,
+		prompt:     prompt,
 		status:     StatusRunning,
 		rootPath:   rootPath,
 		repoName:   repoName,
+		stateName:  stateName,
+		repo:       repo,
 		logFile:    logFile,
 		logger:     logger,
+		scanner:    scanner,
+		schedule:   sched,
+		ctx:        ctx,
+		cancel:     cancel,
 		stopChan:   make(chan bool),
+		heartbeatDone: make(chan struct{}),
+		stats:         stats.NewRecorder(stateName, rootPath),
+		history:       store,
+		notifications: notify.NewGate(cfg.Notifications),
+		mailer:        notify.NewMailer(cfg.Email),
+		breaker:       breaker.New(cfg.AIBreaker),
 	}, nil
 }
 
+// OpenRepo opens the repository rc describes, with its git dir, staging, commit
+// signing and author settings applied
+func OpenRepo(rc config.RepoConfig) *git.Repo {
+	repo := git.Open(rc.Path)
+	if rc.GitDir != "" {
+		repo = git.OpenLocation(git.Location{GitDir: rc.GitDir, WorkTree: rc.Path})
+	}
+	switch rc.GetStagingMode() {
+	case config.StagingTracked:
+		repo.UseStaging(git.Staging{TrackedOnly: true})
+	case config.StagingPatterns:
+		repo.UseStaging(git.Staging{Patterns: rc.StagingPatterns})
+	case config.StagingStaged:
+		repo.UseStaging(git.Staging{StagedOnly: true})
+	}
+	if s := rc.Signing; s != nil {
+		repo.UseSigning(git.Signing{
+			Always: s.GetMode() == config.SigningAlways,
+			Never:  s.GetMode() == config.SigningNever,
+			Format: s.Format,
+			Key:    s.Key,
+		})
+	}
+	repo.UseAuthor(rc.AuthorName, rc.AuthorEmail)
+	return repo
+}
+
+// openVCS opens the repository rc describes through its version control
+// system, checking that the system can commit there
+func openVCS(rc config.RepoConfig) (vcs.Repo, error) {
+	if problems := rc.VCSProblems(); len(problems) > 0 {
+		return nil, fmt.Errorf("invalid settings for vcs %q:\n  %s", rc.GetVCS(), strings.Join(problems, "\n  "))
+	}
+	switch rc.GetVCS() {
+	case config.VCSHg:
+		if !hg.Detect(rc.Path) {
+			return nil, fmt.Errorf("%s is not the root of a Mercurial repository", rc.Path)
+		}
+		if _, err := hg.Version(); err != nil {
+			return nil, fmt.Errorf("vcs is %q but hg cannot be run: %w", config.VCSHg, err)
+		}
+		repo := hg.Open(rc.Path)
+		repo.UseAuthor(rc.AuthorName, rc.AuthorEmail)
+		return repo, nil
+	case config.VCSGoGit:
+		return openGoGit(rc)
+	case config.VCSJJ:
+	default:
+		if _, err := exec.LookPath("git"); err != nil {
+			return openGoGit(rc)
+		}
+		return OpenRepo(rc), nil
+	}
+	
+	if !jj.Colocated(rc.Path) {
+		return nil, fmt.Errorf("%s is not a Jujutsu repository colocated with git; run 'jj git init --colocate' there first", rc.Path)
+	}
+	if _, err := jj.Version(); err != nil {
+		return nil, fmt.Errorf("vcs is %q but jj cannot be run: %w", config.VCSJJ, err)
+	}
+	j := jj.Open(OpenRepo(rc))
+	j.UseAuthor(rc.AuthorName, rc.AuthorEmail)
+	return j, nil
+}
+
+// openGoGit opens the repository rc describes through go-git, for vcs
+// gogit or when the git binary is not installed, with its git dir, staging
+// and author settings applied. Signing needs git, so commits that must be signed are
+// refused rather than made unsigned.
+func openGoGit(rc config.RepoConfig) (*gogit.Repo, error) {
+	if s := rc.Signing; s != nil && s.GetMode() == config.SigningAlways {
+		return nil, fmt.Errorf("%w: signing commits needs it", git.ErrNotInstalled)
+	}
+	repo, err := gogit.Open(rc.Path)
+	if rc.GitDir != "" {
+		repo, err = gogit.OpenLocation(git.Location{GitDir: rc.GitDir, WorkTree: rc.Path})
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch rc.GetStagingMode() {
+	case config.StagingTracked:
+		repo.UseStaging(git.Staging{TrackedOnly: true})
+	case config.StagingPatterns:
+		repo.UseStaging(git.Staging{Patterns: rc.StagingPatterns})
+	case config.StagingStaged:
+		repo.UseStaging(git.Staging{StagedOnly: true})
+	}
+	repo.UseAuthor(rc.AuthorName, rc.AuthorEmail)
+	return repo, nil
+}
+
 // Import AI provider
-func importAIProvider(cfg *config.Config) (ai.AIProvider, error) {
-	return ai.NewProvider(cfg.AIProvider, cfg.APIKey, cfg.BaseURL)
+func importAIProvider(cfg *config.Config, prompt string) (ai.AIProvider, error) {
+	transport, err := ai.TransportFor(cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+	return ai.NewProvider(cfg.AIProvider, cfg.APIKey, cfg.BaseURL, ai.Options{
+		TokenBudget: cfg.MaxDiffTokens,
+		FileLines:   cfg.MaxDiffLinesPerFile,
+		Timeout:     cfg.GetAITimeout(),
+		Stream:      cfg.AIStream,
+		Body:        cfg.CommitStyle.Body,
+		Prompt:      prompt,
+		Model:       cfg.GetAIModel(),
+		Transport:   transport,
+	})
 }
 
 func (d *Daemon) Start() {
 	d.logger.Printf("Daemon started for repository: %s", d.rootPath)
+	if _, ok := d.repo.(*gogit.Repo); ok && d.repoConfig.GetVCS() == config.VCSGoGit {
+		d.logger.Printf("Committing through go-git (experimental), without backups, mirrors, LFS or signing")
+	} else if ok {
+		d.logger.Printf("git is not installed; committing through go-git, without backups, mirrors, LFS or signing")
+	} else {
+		if version, err := git.Version(); err == nil {
+			d.logger.Printf("Using git %s", version)
+		}
+		for _, f := range git.Missing() {
+			d.logger.Printf("WARNING: %s needs git %s: %s", f.Name, f.Since, f.Fallback)
+		}
+	}
+	overrides, _ := config.RepoEnvOverrides(d.repoConfig)
+	for _, o := range overrides {
+		d.logger.Printf("Using %s=%s from %s", o.Key, o.Value, o.Name)
+	}
+	if proxy, err := url.Parse(d.config.Network.Proxy); err == nil && proxy.Host != "" {
+		d.logger.Printf("Sending AI requests through proxy %s", proxy.Redacted())
+	}
+	if d.config.Network.InsecureSkipVerify {
+		d.logger.Printf("WARNING: network.insecure_skip_verify is set; certificates of AI providers are not verified")
+	}
+	d.initHealth()
+	go d.heartbeatLoop()
+	go d.exportMetrics()
+	d.serveHTTP()
 	
-	// Change to root directory
-	if err := git.ChangeToRoot(d.rootPath); err != nil {
-		d.logger.Printf("ERROR: Failed to change to root directory: %v", err)
-		d.status = StatusError
-		return
+	if d.repoConfig.GitDir != "" {
+		d.logger.Printf("Using git dir %s with work tree %s", d.repoConfig.GitDir, d.rootPath)
+	}
+	if d.repoConfig.SquashDaily && d.repoConfig.PushDisabled() {
+		d.logger.Printf("Not squashing daily, push is off for this repository")
+	}
+	switch d.repoConfig.GetVCS() {
+	case config.VCSJJ:
+		version, _ := jj.Version()
+		d.logger.Printf("Committing through jj %s (experimental)", version)
+	case config.VCSHg:
+		version, _ := hg.Version()
+		d.logger.Printf("Committing through Mercurial %s (experimental)", version)
+	default:
+		if jj.Detect(d.rootPath) {
+			d.logger.Printf("Found a Jujutsu repository; committing with git unless vcs is set to %q", config.VCSJJ)
+		}
+	}
+	
+	mode, err := d.repo.DetectMode()
+	if err != nil {
+		d.logError("Failed to detect repository mode: %v", err)
+	}
+	d.repo.UseMode(mode)
+	if mode.SparseCheckout {
+		d.logger.Printf("Sparse checkout detected, limiting operations to: %s", strings.Join(mode.SparsePaths, ", "))
+	}
+	if mode.PartialClone {
+		d.logger.Printf("Partial clone detected, lazy object fetching disabled")
+	}
+	
+	d.resumeCycle()
+	
+	schedule.RefreshLocal()
+	if tz := d.repoConfig.Schedule.Timezone; tz != "" {
+		d.logger.Printf("Following the clock of %s for the schedule", tz)
+	}
+	timing := d.repoConfig.GetCheckTiming()
+	immediate := d.repoConfig.Trigger == config.TriggerImmediate
+	watchMarkers := d.config.Markers
+	
+	// File events do not cross the WSL boundary reliably and every git call
+	// over it is slow, so poll less often and do not watch
+	if boundary := wsl.Detect(d.rootPath); boundary.CrossBoundary {
+		d.logger.Printf("WARNING: %s", boundary.Describe())
+		if immediate {
+			d.logger.Printf("Immediate mode is unreliable across the WSL boundary, polling instead")
+			immediate = false
+		}
+		watchMarkers = false
+		if timing.Every != 0 {
+			if timing.Every < config.MinCrossBoundaryInterval {
+				timing = schedule.Interval(config.MinCrossBoundaryInterval)
+			}
+			d.logger.Printf("Polling every %s", timing)
+		}
+	}
+	if timing.Every == 0 {
+		d.logger.Printf("Checking for changes at the times of %q", timing.String())
 	}
 	
-	interval := d.config.GetCheckInterval()
-	d.ticker = time.NewTicker(interval)
+	d.timing = timing
+	d.ticker = newCheckTimer(timing)
+	
+	if immediate {
+		w, err := newWatcher(d.rootPath, d.repoConfig.Exclude, d.repoConfig.GetDebounce(), d.onFilesChanged)
+		if err != nil {
+			// Fall back to the interval ticker alone
+			d.logError("Failed to start file watcher: %v", err)
+		} else {
+			d.watcher = w
+			d.logger.Printf("Immediate mode enabled (debounce %s, min spacing %s)",
+				d.repoConfig.GetDebounce(), d.repoConfig.GetMinSpacing())
+		}
+	} else if watchMarkers {
+		// Checkpoint markers commit without waiting for the interval
+		w, err := newWatcher(d.rootPath, d.repoConfig.Exclude, d.repoConfig.GetDebounce(), d.onMarkerChange)
+		if err != nil {
+			d.logger.Printf("Failed to start file watcher for checkpoint markers: %v", err)
+		} else {
+			d.watcher = w
+		}
+	}
 	
 	go d.runLoop()
 }
 
+// onFilesChanged is called by the watcher once the working tree has been
+// quiet for the debounce period.
+func (d *Daemon) onFilesChanged() {
+	if wait := d.spacingRemaining(); wait > 0 {
+		d.logger.Printf("Changes detected, waiting %s for minimum commit spacing", wait.Round(time.Second))
+		d.watcher.Defer(wait)
+		return
+	}
+	d.checkAndCommit(history.TriggerWatch)
+}
+
+// spacingRemaining returns how long to wait before the next commit is allowed
+func (d *Daemon) spacingRemaining() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	
+	spacing := d.repoConfig.GetMinSpacing()
+	if spacing == 0 || d.lastCommit.IsZero() {
+		return 0
+	}
+	return spacing - time.Since(d.lastCommit)
+}
+
 func (d *Daemon) runLoop() {
-	// Run initial check
-	d.checkAndCommit()
+	wake := make(chan os.Signal, 1)
+	proc.NotifyWake(wake)
+	defer signal.Stop(wake)
+	poll := time.NewTicker(pausePoll)
+	defer poll.Stop()
+	
+	// Run initial check, unless the daemon starts paused
+	d.applyPause()
+	d.checkAndCommit(history.TriggerInterval)
 	
 	for {
 		select {
-		case <-d.ticker.C:
-			d.checkAndCommit()
+		case <-d.ticker.C():
+			d.ticker.Fired()
+			d.checkAndCommit(history.TriggerInterval)
+		case <-wake:
+			d.applyPause()
+			d.takeTrigger()
+		case <-poll.C:
+			d.applyPause()
+			d.takeTrigger()
+			d.checkZone()
 		case <-d.stopChan:
 			d.ticker.Stop()
 			d.logger.Printf("Daemon stopped")
@@ -106,81 +487,334 @@ func (d *Daemon) runLoop() {
 	}
 }
 
-func (d *Daemon) checkAndCommit() {
+// checkZone moves the check times to the clock of a new system time zone,
+// e.g. after travelling with a laptop, unless the schedule has a zone of
+// its own
+func (d *Daemon) checkZone() {
+	changed, from, to := schedule.RefreshLocal()
+	if !changed {
+		return
+	}
+	if tz := d.repoConfig.Schedule.Timezone; tz != "" {
+		d.logger.Printf("System time zone changed from %s to %s, keeping the schedule in %s", from, to, tz)
+		return
+	}
+	d.logger.Printf("System time zone changed from %s to %s, following the new clock", from, to)
+	d.ticker.Retime()
+	d.updateHealth(func(h *config.Health) {
+		if !h.NextRun.IsZero() {
+			h.NextRun = d.nextRun()
+		}
+	})
+}
+
+// checkAndCommit runs one commit cycle. trigger records what started it.
+func (d *Daemon) checkAndCommit(trigger string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer d.recoverCycle()
+	if d.paused {
+		return
+	}
+	d.trigger = trigger
+	
+	// Leave changes alone outside working hours; the first check inside
+	// the schedule picks them up
+	if now := time.Now(); !d.schedule.Active(now) {
+		if !d.outsideSchedule {
+			d.logger.Printf("Outside the active schedule, not committing until %s", d.schedule.Next(now).Format("Mon 15:04 MST"))
+			d.outsideSchedule = true
+		}
+		d.recordCheck()
+		return
+	}
+	if d.outsideSchedule {
+		d.logger.Printf("Inside the active schedule again")
+		d.outsideSchedule = false
+	}
+	
+	// Leave a merge or rebase the user is working through alone
+	if err := d.repo.CheckState(); err != nil {
+		if err.Error() != d.unsettled {
+			d.logger.Printf("Not committing until the repository is settled: %v", err)
+			d.unsettled = err.Error()
+		}
+		d.recordCheck()
+		return
+	}
+	if d.unsettled != "" {
+		d.logger.Printf("Repository settled, committing again")
+		d.unsettled = ""
+	}
+	
 	d.logger.Printf("Checking for changes...")
+	d.cycle = newBudget(d.config.GetCycleBudget())
+	d.recordCheck()
+	commitsBefore := d.metrics.commits.Load()
+	
+	// Back up to the mirror after the cycle, also when it commits nothing
+	defer d.mirror()
+	
+	exclude := d.repoConfig.Exclude
 	
-	hasChanges, err := git.HasChanges()
+	// Squashing rewrites commits that were pushed already, so it is only
+	// done where the daemon pushes the rewrite as well
+	if d.repoConfig.SquashDaily && !d.repoConfig.PushDisabled() {
+		now := time.Now()
+		if d.lastSquash.IsZero() || d.lastSquash.YearDay() != now.YearDay() || d.lastSquash.Year() != now.Year() {
+			d.lastSquash = now
+			done := d.cycle.Stage("squash")
+			squashed, err := d.squashPreviousDay(now)
+			done()
+			if err != nil {
+				d.logError("Daily squash failed: %v", err)
+			}
+			if squashed {
+				// Kept until a force push succeeds, also across cycles
+				// that end before pushing
+				d.pendingForcePush = true
+			}
+		}
+	}
+	forcePush := d.pendingForcePush
+	
+	done := d.cycle.Stage("status")
+	hasChanges, err := d.repo.HasChanges(exclude...)
+	done()
 	if err != nil {
-		d.logger.Printf("ERROR: Failed to check changes: %v", err)
+		d.logError("Failed to check changes: %v", err)
 		return
 	}
 	
-	if !hasChanges {
+	if !hasChanges && !forcePush && !d.pendingPush {
 		d.logger.Printf("No changes detected")
 		return
 	}
 	
-	d.logger.Printf("Changes detected, generating commit message...")
+	// Record where the time went for every cycle that did work
+	defer func() {
+		d.logger.Printf("%s", d.cycle.Summary())
+		d.countTokens()
+	}()
 	
-	// Get diff
-	diff, err := git.GetDiff()
-	if err != nil {
-		d.logger.Printf("ERROR: Failed to get diff: %v", err)
+	var commitMsg string
+	if hasChanges && !d.enforceWIP() {
 		return
 	}
+	if hasChanges {
+		msg, ok := d.commitChanges(exclude)
+		if !ok {
+			return
+		}
+		commitMsg = msg
+	}
+	
+	// Push, unless the user pushes themselves
+	landed := true
+	if d.repoConfig.PushDisabled() {
+		d.logger.Printf("Not pushing, push is off for this repository")
+	} else {
+		push := d.repo.Push
+		if forcePush {
+			push = d.repo.PushForce
+		}
+		done = d.cycle.Stage("push")
+		err = push()
+		if err == nil && forcePush {
+			d.pendingForcePush = false
+		}
+		if errors.Is(err, git.ErrPushRejected) && d.sharedRejection() {
+			err = nil
+		}
+		done()
+		if err != nil {
+			d.logError("Failed to push: %v", err)
+			d.setStatus(StatusError)
+			
+			// Notify user
+			if d.notifications.Allow(notify.KindError) {
+				notify.NotifyError(d.repoName, err.Error())
+			}
+			msg := err.Error()
+			d.sendEmail(func() (bool, error) {
+				return d.mailer.EmailPushFailed(d.repoName, msg)
+			})
+			
+			// Stop the ticker
+			if d.ticker != nil {
+				d.ticker.Stop()
+			}
+			
+			return
+		}
+		d.logger.Printf("Pushed successfully")
+		landed = d.verifyPush()
+	}
 	
-	// Generate commit message
-	commitMsg, err := d.aiProvider.GenerateCommitMsg(diff)
+	d.setStatus(d.runningStatus())
+	d.finishCycle()
+	if !landed {
+		// Push again on the next check, even without new changes
+		d.pendingPush = true
+	}
+	if commitMsg != "" {
+		d.checkAhead()
+	}
+	
+	// Notify success
+	if commitMsg != "" && d.notifications.Allow(notify.KindSuccess) {
+		notify.NotifySuccess(d.repoName, commitMsg, int(d.metrics.commits.Load()-commitsBefore), d.notifications.Private())
+	}
+}
+
+// commitChanges commits the working tree, first any files with checkpoint
+// markers and then the rest. It returns the commit messages joined and
+// whether at least one commit was created.
+func (d *Daemon) commitChanges(exclude []string) (string, bool) {
+	done := d.cycle.Stage("status")
+	changedFiles, err := d.repo.ChangedFiles(exclude...)
+	done()
 	if err != nil {
-		d.logger.Printf("ERROR: Failed to generate commit message: %v", err)
-		// Don't change status to error, just log and retry next cycle
-		return
+		d.logError("Failed to list changed files: %v", err)
+		return "", false
 	}
+	d.suggestIgnores(changedFiles)
 	
-	d.logger.Printf("Generated commit message: %s", commitMsg)
+	// Leave Git LFS files this machine cannot store, and unapproved changes
+	// to sensitive paths, in the working tree
+	lfsHeld, changedFiles := d.holdLFS(changedFiles)
+	held, changedFiles, approved := d.holdForApproval(changedFiles)
+	held = append(held, lfsHeld...)
+	if len(changedFiles) == 0 {
+		return "", false
+	}
+	if len(held) > 0 {
+		exclude = append(append([]string(nil), exclude...), held...)
+	}
+	if d.repo.Unborn() {
+		d.logger.Printf("Repository has no commits yet, creating the initial commit")
+	}
 	
-	// Stage changes
-	if err := git.AddAll(); err != nil {
-		d.logger.Printf("ERROR: Failed to stage changes: %v", err)
-		return
+	// Files carrying a checkpoint marker go first, each in its own commit
+	var messages []string
+	d.markers = nil
+	if d.config.Markers {
+		var committed []string
+		messages, committed = d.commitCheckpoints(changedFiles)
+		changedFiles = without(changedFiles, committed)
+		exclude = append(append([]string(nil), exclude...), committed...)
 	}
 	
-	// Commit
-	if err := git.Commit(commitMsg); err != nil {
-		d.logger.Printf("ERROR: Failed to commit: %v", err)
-		return
+	// Paths with a cadence wait for it; checkpoints above do not
+	if d.trigger != history.TriggerMarker && len(changedFiles) > 0 {
+		now := time.Now()
+		waiting, due := d.holdForCadence(changedFiles, now)
+		if len(waiting) > 0 {
+			exclude = append(append([]string(nil), exclude...), waiting...)
+		}
+		if len(due) > 0 {
+			if msg, ok := d.commitFiles(due, exclude, approved); ok {
+				messages = append(messages, msg)
+				d.cadenceCommitted(due, now)
+			}
+		}
 	}
 	
-	d.logger.Printf("Committed successfully")
+	if len(messages) == 0 {
+		return "", false
+	}
+	return strings.Join(messages, "\n"), true
+}
+
+// commitFiles generates a message, stages and commits changedFiles, or
+// commits them in groups. approved reports whether they include approved
+// changes to sensitive paths.
+func (d *Daemon) commitFiles(changedFiles, exclude []string, approved bool) (string, bool) {
+	var err error
+	if d.config.Grouping.Mode == config.GroupingDirectory {
+		msg, ok := d.commitGroups(changedFiles)
+		if ok && approved {
+			d.approvalCommitted()
+		}
+		return msg, ok
+	}
 	
-	// Push
-	if err := git.Push(); err != nil {
-		d.logger.Printf("ERROR: Failed to push: %v", err)
-		d.status = StatusError
-		
-		// Notify user
-		notify.NotifyError(d.repoName, err.Error())
-		
-		// Stop the ticker
-		if d.ticker != nil {
-			d.ticker.Stop()
+	var diff string
+	if d.repoConfig.MessageSource != config.MessageHeuristic || d.scanner != nil {
+		done := d.cycle.Stage("diff")
+		diff, err = d.repo.GetFullDiff(exclude...)
+		done()
+		if err != nil {
+			d.logError("Failed to get diff: %v", err)
+			return "", false
 		}
-		
-		return
 	}
 	
-	d.logger.Printf("Pushed successfully")
-	d.status = StatusRunning
+	if d.hasSecrets(diff) {
+		return "", false
+	}
 	
-	// Notify success
-	notify.NotifySuccess(d.repoName, commitMsg)
+	commitMsg, err := d.messageFor(diff, changedFiles)
+	if err != nil {
+		d.logError("%v", err)
+		// Don't change status to error, just log and retry next cycle
+		return "", false
+	}
+	
+	// Stage changes
+	done := d.cycle.Stage("stage")
+	err = d.repo.AddAll(exclude...)
+	done()
+	if err != nil {
+		d.logError("Failed to stage changes: %v", err)
+		return "", false
+	}
+	
+	// Commit
+	done = d.cycle.Stage("commit")
+	byName := len(exclude) > 0 || d.repoConfig.GetStagingMode() == config.StagingStaged
+	err = commitChanged(d.repo, commitMsg, changedFiles, byName)
+	done()
+	if err != nil {
+		d.logError("Failed to commit: %v", err)
+		d.signingFailed(err)
+		return "", false
+	}
+	
+	d.logger.Printf("Committed successfully")
+	d.lastCommit = time.Now()
+	d.recordCommit(commitMsg)
+	d.countCommit()
+	d.recordHistory(commitMsg, changedFiles)
+	if approved {
+		d.approvalCommitted()
+	}
+	
+	return commitMsg, true
+}
+
+// commitChanged commits files with message. Held and excluded paths may
+// already be staged by the user, so when any are left out the files are
+// committed by name instead of committing the whole index
+func commitChanged(repo vcs.Repo, message string, files []string, byName bool) error {
+	if byName {
+		return repo.CommitPaths(message, files)
+	}
+	return repo.Commit(message)
 }
 
 func (d *Daemon) Stop() {
 	if d.ticker != nil {
 		d.ticker.Stop()
 	}
+	if d.watcher != nil {
+		d.watcher.Close()
+	}
+	close(d.heartbeatDone)
+	d.stopHTTP()
+	d.cancel()
 	d.stopChan <- true
+	d.history.Close()
 	d.logFile.Close()
 }
 
@@ -202,16 +836,15 @@ func StartDaemonProcess(rootPath string) error {
 		return fmt.Errorf("failed to resolve executable path: %w", err)
 	}
 	
-	// Create command
-	cmd := exec.Command(absExecPath, "start-daemon", rootPath)
+	// With restart_on_crash a watchdog runs the daemon; see Supervise
+	command := "start-daemon"
+	if cfg, err := config.LoadConfig(); err == nil && cfg.RestartOnCrash {
+		command = "supervise"
+	}
+	cmd := exec.Command(absExecPath, command, rootPath)
 	
 	// Detach from terminal
-	if runtime.GOOS != "windows" {
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setsid: true,
-		}
-	}
-	// On Windows, the process will be detached by default when started
+	proc.Detach(cmd)
 	
 	// Redirect output to null
 	nullFile, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
@@ -225,12 +858,7 @@ func StartDaemonProcess(rootPath string) error {
 	}
 	
 	// Save daemon info
-	daemonInfo := &config.DaemonInfo{
-		PID:      cmd.Process.Pid,
-		RepoPath: rootPath,
-		Status:   StatusRunning,
-	}
-	
+	daemonInfo := config.NewDaemonInfo(cmd.Process.Pid, config.StateName(rootPath), rootPath, StatusRunning)
 	if err := config.SaveDaemonInfo(daemonInfo); err != nil {
 		return fmt.Errorf("failed to save daemon info: %w", err)
 	}
@@ -238,3 +866,287 @@ func StartDaemonProcess(rootPath string) error {
 	return nil
 }
 
+// StopDaemonProcess terminates the daemon process and removes its info,
+// health and pause files
+func StopDaemonProcess(info *config.DaemonInfo) error {
+	if err := proc.Terminate(info.PID); err != nil {
+		return fmt.Errorf("failed to stop daemon: %w", err)
+	}
+	
+	// Clean up daemon info
+	config.DeleteDaemonInfo(info.Name)
+	config.DeleteHealth(info.Name)
+	config.DeletePause(info.Name)
+	
+	return nil
+}
+
+// RestartDaemonProcess stops the daemon process and starts a new one for
+// the same repository, which reads the config again. A pause is kept. The
+// old process removes the daemon info when it exits, so the new one is only
+// started once it has.
+func RestartDaemonProcess(info *config.DaemonInfo) error {
+	if err := proc.Terminate(info.PID); err != nil {
+		return fmt.Errorf("failed to stop daemon: %w", err)
+	}
+	
+	deadline := time.Now().Add(restartTimeout)
+	for proc.Running(info.PID) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("daemon (PID %d) did not stop within %s", info.PID, restartTimeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	config.DeleteDaemonInfo(info.Name)
+	config.DeleteHealth(info.Name)
+	
+	return StartDaemonProcess(info.RepoPath)
+}
+
+
+// commitGroups creates one commit per file group, each with its own
+// message. It returns the messages joined for notification and whether at
+// least one commit was created.
+func (d *Daemon) commitGroups(changedFiles []string) (string, bool) {
+	var messages []string
+	for _, g := range group.Split(changedFiles, d.config.Grouping) {
+		var diff string
+		if d.repoConfig.MessageSource != config.MessageHeuristic || d.scanner != nil {
+			var err error
+			done := d.cycle.Stage("diff")
+			diff, err = d.repo.FullDiffPaths(g.Files)
+			done()
+			if err != nil {
+				d.logError("Failed to get diff for %s: %v", g.Name, err)
+				continue
+			}
+		}
+		
+		if d.hasSecrets(diff) {
+			continue
+		}
+		
+		commitMsg, err := d.messageFor(diff, g.Files)
+		if err != nil {
+			d.logError("%s: %v", g.Name, err)
+			continue
+		}
+		
+		done := d.cycle.Stage("stage")
+		err = d.repo.AddPaths(g.Files)
+		done()
+		if err != nil {
+			d.logError("Failed to stage %s: %v", g.Name, err)
+			continue
+		}
+		done = d.cycle.Stage("commit")
+		err = d.repo.CommitPaths(commitMsg, g.Files)
+		done()
+		if err != nil {
+			d.logError("Failed to commit %s: %v", g.Name, err)
+			d.signingFailed(err)
+			continue
+		}
+		
+		d.logger.Printf("Committed group %s (%d files)", g.Name, len(g.Files))
+		d.countCommit()
+		d.recordHistory(commitMsg, g.Files)
+		messages = append(messages, commitMsg)
+	}
+	
+	if len(messages) == 0 {
+		return "", false
+	}
+	
+	d.logger.Printf("Committed successfully")
+	d.lastCommit = time.Now()
+	d.recordCommit(strings.Join(messages, "\n"))
+	
+	return strings.Join(messages, "\n"), true
+}
+
+// generateMessage produces a commit message for diff, which covers files,
+// and passes it through the message filter
+func (d *Daemon) generateMessage(diff string, files []string) (string, error) {
+	msg, err := d.draftMessage(diff, files)
+	if err != nil {
+		return "", err
+	}
+	return d.filterMessage(d.addTicket(msg))
+}
+
+// signingFailed tells the user when err is a signing failure. Every cycle
+// fails the same way until the key is unlocked, so this happens once until
+// a commit succeeds again.
+func (d *Daemon) signingFailed(err error) {
+	if !errors.Is(err, git.ErrSigning) || d.signingAlerted {
+		return
+	}
+	d.signingAlerted = true
+	if d.notifications.Allow(notify.KindError) {
+		notify.NotifyError(d.repoName, err.Error())
+	}
+}
+
+// addTicket adds the ticket key named by the current branch to msg, when
+// commit_style.ticket is configured
+func (d *Daemon) addTicket(msg string) string {
+	ticket := d.config.CommitStyle.Ticket
+	branch := d.repo.CurrentBranch()
+	key := commitmsg.TicketKey(branch, ticket)
+	if key == "" {
+		return msg
+	}
+	
+	ticketed := commitmsg.WithTicket(msg, key, ticket)
+	if ticketed != strings.TrimSpace(msg) {
+		d.logger.Printf("Added ticket %s from branch %s", key, branch)
+	}
+	return ticketed
+}
+
+// filterMessage runs msg through message_filter_cmd, when one is set, and
+// adds commit_trailers afterwards so that a filter cannot drop them. A
+// failing filter fails the commit, so that messages never skip it.
+func (d *Daemon) filterMessage(msg string) (string, error) {
+	if d.config.MessageFilterCmd == "" {
+		return d.addTrailers(msg), nil
+	}
+	
+	done := d.cycle.Stage("filter")
+	filtered, err := commitmsg.Filter(d.ctx, d.rootPath, d.config.MessageFilterCmd, msg)
+	done()
+	if err != nil {
+		return "", err
+	}
+	if filtered != msg {
+		d.logger.Printf("Filtered commit message: %s", filtered)
+	}
+	return d.addTrailers(filtered), nil
+}
+
+// addTrailers adds commit_trailers to msg, which mark the commit as made by
+// autogit
+func (d *Daemon) addTrailers(msg string) string {
+	if len(d.config.CommitTrailers) == 0 {
+		return msg
+	}
+	return commitmsg.WithTrailers(msg, d.config.CommitTrailers)
+}
+
+// draftMessage produces the message for diff before filtering
+func (d *Daemon) draftMessage(diff string, files []string) (string, error) {
+	if d.repoConfig.MessageSource == config.MessageHeuristic {
+		d.msgSource = history.SourceHeuristic
+		commitMsg := commitmsg.Heuristic(d.repoConfig.MessagePrefix, files)
+		d.logger.Printf("Changes detected, using message: %s", commitMsg)
+		return commitMsg, nil
+	}
+	
+	if d.authExpired && !d.reloadKey() {
+		return d.fallbackMessage(files, fmt.Sprintf("API key for %s was rejected", d.config.AIProvider)), nil
+	}
+	
+	// Leave a provider that keeps failing alone for a while
+	if state := breaker.Get(d.config.AIProvider); state.Open(time.Now()) {
+		return d.fallbackMessage(files, fmt.Sprintf("AI provider %s is skipped until %s after repeated failures", d.config.AIProvider, state.OpenUntil.Format("15:04:05"))), nil
+	}
+	
+	// Stay within ai_budget, which all daemons share
+	if msg, ok := d.budgetMessage(diff); ok {
+		return msg, nil
+	}
+	
+	d.logger.Printf("Changes detected, generating commit message...")
+	
+	commitMsg, timedOut, err := d.generateWithBudget(diff)
+	if err != nil && d.useDefaultModel(err) {
+		commitMsg, timedOut, err = d.generateWithBudget(diff)
+	}
+	if timedOut {
+		d.aiFailed(fmt.Errorf("no answer within the AI time budget"))
+		// Commit with a local message rather than losing the cycle
+		return d.fallbackMessage(files, "AI stage exceeded its time budget"), nil
+	}
+	if errors.Is(err, ai.ErrUnauthorized) {
+		d.keyRejected(err)
+		return d.fallbackMessage(files, fmt.Sprintf("API key for %s was rejected", d.config.AIProvider)), nil
+	}
+	if err != nil {
+		if d.aiFailed(err) {
+			return d.fallbackMessage(files, fmt.Sprintf("AI provider %s failed repeatedly", d.config.AIProvider)), nil
+		}
+		return "", fmt.Errorf("failed to generate commit message: %w", err)
+	}
+	d.aiSucceeded()
+	
+	d.logger.Printf("Generated commit message: %s", commitMsg)
+	d.msgSource = history.SourceAI
+	
+	// Enforce commit style
+	styled, err := commitmsg.Apply(commitMsg, files, d.config.CommitStyle)
+	if err != nil {
+		return "", err
+	}
+	if styled != commitMsg {
+		d.logger.Printf("Adjusted commit message: %s", styled)
+	}
+	
+	return styled, nil
+}
+
+// generateWithBudget asks the AI provider for a message, cancelling the
+// request once the AI slice of the cycle budget is spent. timedOut reports
+// whether the provider did not answer in time.
+func (d *Daemon) generateWithBudget(diff string) (msg string, timedOut bool, err error) {
+	slice := d.cycle.AISlice()
+	if slice <= 0 {
+		d.cycle.Note("ai", "skipped, budget spent")
+		return "", true, nil
+	}
+	
+	ctx, cancel := context.WithTimeout(d.ctx, slice)
+	defer cancel()
+	
+	done := d.cycle.Stage("ai")
+	started := time.Now()
+	msg, err = d.aiProvider.GenerateCommitMsg(ctx, diff)
+	d.metrics.aiRequests.Add(1)
+	d.metrics.aiLatency.Store(int64(time.Since(started)))
+	done()
+	
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		d.cycle.Note("ai", fmt.Sprintf("timeout after %s", slice.Round(time.Second)))
+		return "", true, nil
+	}
+	return msg, false, err
+}
+
+// hasSecrets scans diff for credentials. Findings are logged and the user
+// is notified, unless the same findings were already reported.
+func (d *Daemon) hasSecrets(diff string) bool {
+	if d.scanner == nil {
+		return false
+	}
+	
+	done := d.cycle.Stage("scan")
+	findings := d.scanner.Scan(diff)
+	done()
+	if len(findings) == 0 {
+		return false
+	}
+	
+	var lines []string
+	for _, f := range findings {
+		lines = append(lines, f.String())
+	}
+	d.logError("Commit blocked, possible secrets found:\n  %s", strings.Join(lines, "\n  "))
+	
+	if alert := strings.Join(lines, "\n"); alert != d.lastSecretAlert {
+		d.lastSecretAlert = alert
+		if d.notifications.Allow(notify.KindError) {
+			notify.NotifySecrets(d.repoName, len(findings), findings[0].String(), d.notifications.Private())
+		}
+	}
+	return true
+}
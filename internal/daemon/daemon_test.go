@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/testutil"
+)
+
+// TestCommitChangedLeavesStagedHeldFiles stages a file held for approval
+// before the cycle runs, and checks that only the approved files are
+// committed while the held one stays staged
+func TestCommitChangedLeavesStagedHeldFiles(t *testing.T) {
+	for _, stagedOnly := range []bool{false, true} {
+		root := testutil.GitRepo(t)
+		testutil.WriteFile(t, root, "secret.env", "TOKEN=1\n")
+		testutil.Git(t, root, "add", "secret.env")
+		testutil.WriteFile(t, root, "main.go", "package main\n")
+		
+		repo := git.Open(root)
+		if stagedOnly {
+			repo.UseStaging(git.Staging{StagedOnly: true})
+			testutil.Git(t, root, "add", "main.go")
+		}
+		held := []string{"secret.env"}
+		if err := repo.AddAll(held...); err != nil {
+			t.Fatal(err)
+		}
+		if err := commitChanged(repo, "Add main", []string{"main.go"}, true); err != nil {
+			t.Fatalf("staged only %v: %v", stagedOnly, err)
+		}
+		
+		committed := testutil.Git(t, root, "show", "--name-only", "--format=", "HEAD")
+		if strings.Contains(committed, "secret.env") || !strings.Contains(committed, "main.go") {
+			t.Errorf("staged only %v: committed %q, want only main.go", stagedOnly, committed)
+		}
+		if staged := testutil.Git(t, root, "diff", "--cached", "--name-only"); strings.TrimSpace(staged) != "secret.env" {
+			t.Errorf("staged only %v: staged after commit %q, want secret.env", stagedOnly, staged)
+		}
+	}
+}
+
+// TestSquashWaitsForStagedChanges checks that the daily squash leaves
+// history and the index alone while the user has changes staged
+func TestSquashWaitsForStagedChanges(t *testing.T) {
+	root := testutil.GitRepo(t)
+	t.Setenv("GIT_COMMITTER_DATE", time.Now().AddDate(0, 0, -1).Format(time.RFC3339))
+	for _, name := range []string{"a.txt", "b.txt"} {
+		testutil.WriteFile(t, root, name, name+"\n")
+		testutil.Git(t, root, "add", name)
+		testutil.Git(t, root, "commit", "-q", "-m", "autogit: add "+name)
+	}
+	testutil.WriteFile(t, root, "draft.txt", "draft\n")
+	testutil.Git(t, root, "add", "draft.txt")
+	head := testutil.Git(t, root, "rev-parse", "HEAD")
+	
+	d := &Daemon{
+		repo:       git.Open(root),
+		repoConfig: config.RepoConfig{MessagePrefix: "autogit"},
+		logger:     log.New(io.Discard, "", 0),
+	}
+	squashed, err := d.squashPreviousDay(time.Now())
+	if err != nil || squashed {
+		t.Fatalf("squashPreviousDay() = %v, %v, want no squash", squashed, err)
+	}
+	if got := testutil.Git(t, root, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD moved from %s to %s", head, got)
+	}
+	if staged := testutil.Git(t, root, "diff", "--cached", "--name-only"); strings.TrimSpace(staged) != "draft.txt" {
+		t.Errorf("staged after squash %q, want draft.txt", staged)
+	}
+	if !d.lastSquash.IsZero() {
+		t.Errorf("the squash is not tried again next cycle")
+	}
+}
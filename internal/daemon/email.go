@@ -0,0 +1,18 @@
+package daemon
+
+// sendEmail runs send in the background so that a slow mail server does
+// not hold up the cycle
+func (d *Daemon) sendEmail(send func() (bool, error)) {
+	if d.mailer == nil {
+		return
+	}
+	go func() {
+		sent, err := send()
+		switch {
+		case err != nil:
+			d.logger.Printf("Failed to send email notification: %v", err)
+		case sent:
+			d.logger.Printf("Sent email notification")
+		}
+	}()
+}
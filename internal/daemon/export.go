@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// exportMetrics writes the metrics of /metrics and /status to files every
+// metrics_export.every until Stop, for hosts where the daemon may not
+// listen on a port
+func (d *Daemon) exportMetrics() {
+	export := d.config.MetricsExport
+	if !export.Enabled {
+		return
+	}
+	interval := export.Interval()
+	d.logger.Printf("Writing metrics to %s every %s", export.MetricsPath(d.repoName, ".prom"), interval)
+	
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	failed := false
+	for {
+		if err := d.writeMetricsFiles(); err != nil {
+			if !failed {
+				d.logger.Printf("Failed to write metrics: %v", err)
+			}
+			failed = true
+		} else {
+			failed = false
+		}
+		select {
+		case <-ticker.C:
+		case <-d.heartbeatDone:
+			return
+		}
+	}
+}
+
+// writeMetricsFiles replaces the metrics files of the repository. Each is
+// written beside its final path and renamed, so a collector never reads a
+// partial one.
+func (d *Daemon) writeMetricsFiles() error {
+	export := d.config.MetricsExport
+	if err := os.MkdirAll(export.GetDir(), 0755); err != nil {
+		return err
+	}
+	
+	var prom bytes.Buffer
+	d.writeMetrics(&prom)
+	status, err := json.MarshalIndent(d.endpointStatus(), "", "  ")
+	if err != nil {
+		return err
+	}
+	for ext, data := range map[string][]byte{".prom": prom.Bytes(), ".json": append(status, '\n')} {
+		path := export.MetricsPath(d.repoName, ext)
+		tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+		if err := os.WriteFile(tmp, data, 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// updateHealth applies change to the daemon's health and writes the
+// health file with a fresh heartbeat
+func (d *Daemon) updateHealth(change func(h *config.Health)) {
+	d.healthMu.Lock()
+	defer d.healthMu.Unlock()
+	
+	change(&d.health)
+	d.health.Heartbeat = time.Now()
+	if err := config.SaveHealth(d.stateName, &d.health); err != nil {
+		d.logger.Printf("Failed to write health file: %v", err)
+	}
+}
+
+// heartbeatLoop keeps the health file fresh, also while a long cycle runs
+func (d *Daemon) heartbeatLoop() {
+	ticker := time.NewTicker(config.HeartbeatInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			d.updateHealth(func(h *config.Health) {})
+		case <-d.heartbeatDone:
+			return
+		}
+	}
+}
+
+func (d *Daemon) setStatus(status string) {
+	d.updateHealth(func(h *config.Health) {
+		h.Status = status
+		d.status = status
+		if status == StatusError || status == StatusPaused {
+			// The ticker is stopped until the daemon is restarted or resumed
+			h.NextRun = time.Time{}
+		}
+	})
+}
+
+// logError logs an error, records it as the last error in the health file
+// and counts it as a failure in the statistics
+func (d *Daemon) logError(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	d.logger.Printf("ERROR: %s", msg)
+	d.metrics.failures.Add(1)
+	if err := d.stats.Failure(); err != nil {
+		d.logger.Printf("Failed to write stats: %v", err)
+	}
+	d.updateHealth(func(h *config.Health) {
+		h.LastError = msg
+		h.LastErrorAt = time.Now()
+	})
+}
+
+func (d *Daemon) recordCheck() {
+	d.metrics.checks.Add(1)
+	d.updateHealth(func(h *config.Health) {
+		now := time.Now()
+		h.LastCheck = now
+		if d.status != StatusError && d.status != StatusPaused {
+			h.NextRun = d.nextRun()
+		}
+	})
+}
+
+func (d *Daemon) recordCommit(msg string) {
+	d.signingAlerted = false
+	d.updateHealth(func(h *config.Health) {
+		h.LastCommit = time.Now()
+		h.LastCommitMsg = msg
+	})
+}
+
+func (d *Daemon) initHealth() {
+	d.updateHealth(func(h *config.Health) {
+		*h = config.Health{
+			PID:      os.Getpid(),
+			RepoPath: d.rootPath,
+			Status:   d.status,
+		}
+	})
+}
+
+// nextRun returns when the ticker will next fire inside the schedule
+func (d *Daemon) nextRun() time.Time {
+	return d.timing.Within(d.schedule, d.ticker.Next())
+}
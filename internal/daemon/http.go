@@ -0,0 +1,157 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/server"
+)
+
+// metrics counts what the daemon did since it started, for /metrics
+type metrics struct {
+	checks     atomic.Int64
+	commits    atomic.Int64
+	failures   atomic.Int64
+	aiRequests atomic.Int64
+	aiLatency  atomic.Int64 // Nanoseconds the last AI request took
+}
+
+// endpointStatus is what /status returns
+type endpointStatus struct {
+	Repo string `json:"repo"`
+	config.Health
+	Checks     int64   `json:"checks"`
+	Commits    int64   `json:"commits"`
+	Failures   int64   `json:"failures"`
+	AIRequests int64   `json:"ai_requests"`
+	AILatency  float64 `json:"ai_latency_seconds,omitempty"` // Of the last AI request
+}
+
+// serveHTTP serves /status, /health and /metrics at the repository's
+// status_addr until Stop. When AUTOGIT_STATUS_TOKEN is set, requests must
+// carry it like those to 'autogit serve'.
+func (d *Daemon) serveHTTP() {
+	addr := d.repoConfig.StatusAddr
+	if addr == "" {
+		return
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		d.logError("Failed to serve status on %s: %v", addr, err)
+		return
+	}
+	
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", d.handleHealth)
+	mux.HandleFunc("/status", d.handleStatus)
+	mux.HandleFunc("/metrics", d.handleMetrics)
+	d.httpServer = &http.Server{
+		Handler:           server.Authorize(os.Getenv("AUTOGIT_STATUS_TOKEN"), mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	d.logger.Printf("Serving status on http://%s", listener.Addr())
+	go func() {
+		if err := d.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			d.logger.Printf("Status endpoint stopped: %v", err)
+		}
+	}()
+}
+
+func (d *Daemon) stopHTTP() {
+	if d.httpServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	d.httpServer.Shutdown(ctx)
+}
+
+func (d *Daemon) snapshot() config.Health {
+	d.healthMu.Lock()
+	defer d.healthMu.Unlock()
+	return d.health
+}
+
+// handleHealth answers 200 while the daemon runs or is paused, and 503
+// once it stopped checking because of an error or hung
+func (d *Daemon) handleHealth(w http.ResponseWriter, r *http.Request) {
+	h := d.snapshot()
+	code := http.StatusOK
+	if h.Status == StatusError || h.Stale(time.Now()) {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": h.Status, "heartbeat": h.Heartbeat})
+}
+
+func (d *Daemon) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d.endpointStatus())
+}
+
+func (d *Daemon) endpointStatus() endpointStatus {
+	return endpointStatus{
+		Repo:       d.repoName,
+		Health:     d.snapshot(),
+		Checks:     d.metrics.checks.Load(),
+		Commits:    d.metrics.commits.Load(),
+		Failures:   d.metrics.failures.Load(),
+		AIRequests: d.metrics.aiRequests.Load(),
+		AILatency:  time.Duration(d.metrics.aiLatency.Load()).Seconds(),
+	}
+}
+
+func (d *Daemon) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	d.writeMetrics(w)
+}
+
+// writeMetrics writes the counters in the Prometheus text format
+func (d *Daemon) writeMetrics(w io.Writer) {
+	h := d.snapshot()
+	label := fmt.Sprintf("{repo=%q}", d.repoName)
+	gauge := func(on bool) int {
+		if on {
+			return 1
+		}
+		return 0
+	}
+	
+	for _, m := range []struct {
+		name, kind, help string
+		value            interface{}
+	}{
+		{"autogit_checks_total", "counter", "Checks for changes since the daemon started", d.metrics.checks.Load()},
+		{"autogit_commits_total", "counter", "Commits made since the daemon started", d.metrics.commits.Load()},
+		{"autogit_failures_total", "counter", "Errors since the daemon started", d.metrics.failures.Load()},
+		{"autogit_ai_requests_total", "counter", "Commit messages requested from the AI provider", d.metrics.aiRequests.Load()},
+		{"autogit_ai_tokens_total", "counter", "AI tokens used since the daemon started", ai.TokensUsed(d.aiProvider)},
+		{"autogit_ai_latency_seconds", "gauge", "Duration of the last AI request", time.Duration(d.metrics.aiLatency.Load()).Seconds()},
+		{"autogit_paused", "gauge", "Whether checks are paused", gauge(h.Status == StatusPaused)},
+		{"autogit_error", "gauge", "Whether the daemon stopped checking because of an error", gauge(h.Status == StatusError)},
+		{"autogit_pending_approval_files", "gauge", "Changes held for approval", len(h.PendingApproval)},
+		{"autogit_last_check_timestamp_seconds", "gauge", "When changes were last checked", unix(h.LastCheck)},
+		{"autogit_last_commit_timestamp_seconds", "gauge", "When the last commit was made", unix(h.LastCommit)},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s%s %v\n", m.name, m.help, m.name, m.kind, m.name, label, m.value)
+	}
+}
+
+// unix returns t in seconds since the epoch, or 0 for the zero time
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/ignore"
+	"github.com/aadityansha/autogit/internal/notify"
+)
+
+// suggestIgnores counts checks that find changes in what looks like build
+// output or caches, and suggests .gitignore entries for them once they
+// keep coming back. The changes are still committed until the user adds
+// the entries in 'autogit menu'.
+func (d *Daemon) suggestIgnores(files []string) {
+	entries := ignore.Entries(files)
+	if len(entries) == 0 {
+		return
+	}
+	state, err := ignore.Load(d.stateName)
+	if err != nil {
+		d.logger.Printf("Failed to load .gitignore suggestions: %v", err)
+		return
+	}
+	added := state.Observe(entries)
+	if err := state.Save(d.stateName); err != nil {
+		d.logger.Printf("Failed to save .gitignore suggestions: %v", err)
+		return
+	}
+	if len(added) == 0 {
+		return
+	}
+	
+	d.logger.Printf("Changes keep appearing in generated files, suggesting .gitignore entries: %s", strings.Join(added, ", "))
+	if d.notifications.Allow(notify.KindError) {
+		notify.NotifyIgnore(d.repoName, added, d.notifications.Private())
+	}
+}
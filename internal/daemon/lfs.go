@@ -0,0 +1,48 @@
+package daemon
+
+import (
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/notify"
+)
+
+// holdLFS splits files into changes to files tracked by Git LFS, when LFS
+// cannot store them on this machine, and the rest. Committing them without
+// LFS would put the whole files into the history, and pushing them could
+// exceed the remote's size limits.
+func (d *Daemon) holdLFS(files []string) (held, rest []string) {
+	lfs, err := d.repo.LFSFiles(files)
+	if err != nil {
+		d.logError("Failed to check for Git LFS files: %v", err)
+		return nil, files
+	}
+	if len(lfs) == 0 {
+		d.setHeldLFS(nil)
+		return nil, files
+	}
+	err = d.repo.LFSReady()
+	if err == nil {
+		d.setHeldLFS(nil)
+		return nil, files
+	}
+	
+	d.logger.Printf("Holding %d Git LFS changes, since %v: %s", len(lfs), err, strings.Join(lfs, ", "))
+	if alert := strings.Join(lfs, "\n"); alert != d.lastLFSAlert {
+		d.lastLFSAlert = alert
+		if d.notifications.Allow(notify.KindError) {
+			notify.NotifyLFS(d.repoName, lfs, err, d.notifications.Private())
+		}
+	}
+	d.setHeldLFS(lfs)
+	return lfs, without(files, lfs)
+}
+
+func (d *Daemon) setHeldLFS(files []string) {
+	if files == nil {
+		d.lastLFSAlert = ""
+	}
+	d.updateHealth(func(h *config.Health) {
+		h.HeldLFS = files
+	})
+}
@@ -0,0 +1,155 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/commitmsg"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/history"
+	"github.com/aadityansha/autogit/internal/secrets"
+	"github.com/aadityansha/autogit/internal/spend"
+)
+
+// ErrNoChanges is returned by SuggestMessage and CommitManual when there
+// is nothing to commit
+var ErrNoChanges = errors.New("no changes to commit")
+
+// SuggestMessage drafts the message the daemon would commit the pending
+// changes of rc with, for the user to edit before calling CommitManual.
+// Diffs with possible secrets are not sent to the AI provider.
+func SuggestMessage(ctx context.Context, cfg *config.Config, rc config.RepoConfig) (string, error) {
+	repo := OpenRepo(rc)
+	files, err := repo.ChangedFiles(rc.Exclude...)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", ErrNoChanges
+	}
+	
+	var msg string
+	if rc.MessageSource == config.MessageHeuristic || rc.AIDisabled() {
+		msg = commitmsg.Heuristic(rc.MessagePrefix, files)
+	} else {
+		diff, err := repo.GetFullDiff(rc.Exclude...)
+		if err != nil {
+			return "", err
+		}
+		scanner, err := secrets.New(cfg.SecretScan)
+		if err != nil {
+			return "", err
+		}
+		if scanner != nil {
+			if findings := scanner.Scan(diff); len(findings) > 0 {
+				return "", fmt.Errorf("possible secrets found, not sending the diff to the AI provider: %s", findings[0])
+			}
+		}
+		
+		prompt, err := rc.Prompt()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", config.PromptFile, err)
+		}
+		provider, err := importAIProvider(cfg, prompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to create AI provider: %w", err)
+		}
+		ledger, err := spend.Load()
+		if err != nil {
+			return "", err
+		}
+		if limit := ledger.Exceeded(cfg.AIBudget, time.Now()); limit != nil {
+			provider = ai.NewTemplateProvider()
+		} else if err := spend.Request(cfg.AIProvider, time.Now()); err != nil {
+			return "", err
+		}
+		ctx, cancel := context.WithTimeout(ctx, cfg.GetAITimeout())
+		defer cancel()
+		raw, err := provider.GenerateCommitMsg(ctx, diff)
+		if tokens := ai.TokensUsed(provider); tokens > 0 {
+			spend.Tokens(cfg.AIProvider, tokens, cfg.AIBudget.Price(cfg.AIProvider), time.Now())
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to generate commit message: %w", err)
+		}
+		if msg, err = commitmsg.Apply(raw, files, cfg.CommitStyle); err != nil {
+			return "", err
+		}
+	}
+	
+	if key := commitmsg.TicketKey(repo.CurrentBranch(), cfg.CommitStyle.Ticket); key != "" {
+		msg = commitmsg.WithTicket(msg, key, cfg.CommitStyle.Ticket)
+	}
+	if cfg.MessageFilterCmd != "" {
+		if msg, err = commitmsg.Filter(ctx, rc.Path, cfg.MessageFilterCmd, msg); err != nil {
+			return "", err
+		}
+	}
+	if len(cfg.CommitTrailers) > 0 {
+		msg = commitmsg.WithTrailers(msg, cfg.CommitTrailers)
+	}
+	return msg, nil
+}
+
+// CommitManual stages the pending changes of rc as the daemon would,
+// commits them with msg and records the commit in the history. With push
+// it also pushes, unless push is off for the repository, and reports
+// whether it did.
+func CommitManual(cfg *config.Config, rc config.RepoConfig, msg string, push bool) (bool, error) {
+	repo, err := openVCS(rc)
+	if err != nil {
+		return false, err
+	}
+	repo.UseQuiet()
+	files, err := repo.ChangedFiles(rc.Exclude...)
+	if err != nil {
+		return false, err
+	}
+	if len(files) == 0 {
+		return false, ErrNoChanges
+	}
+	
+	if err := repo.AddAll(rc.Exclude...); err != nil {
+		return false, fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if rc.GetStagingMode() == config.StagingStaged {
+		err = repo.CommitPaths(msg, files)
+	} else {
+		err = repo.Commit(msg)
+	}
+	if err != nil {
+		return false, err
+	}
+	
+	entry := history.Entry{
+		Time:    time.Now(),
+		Message: msg,
+		Files:   files,
+		Trigger: history.TriggerManual,
+		Source:  history.SourceManual,
+	}
+	if entries, err := repo.Log(1); err == nil && len(entries) > 0 {
+		entry.Commit = entries[0].Hash
+	}
+	if store, err := history.Open(cfg.Storage); err == nil {
+		store.Append(rc.StateName(), entry)
+		store.Close()
+	}
+	
+	if !push || rc.PushDisabled() {
+		return false, nil
+	}
+	if err := repo.Push(); err != nil {
+		return false, err
+	}
+	if rc.VerifiesPush() {
+		if err := repo.VerifyPush(); errors.Is(err, git.ErrPushNotLanded) {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/aadityansha/autogit/internal/history"
+	"github.com/aadityansha/autogit/internal/marker"
+)
+
+// onMarkerChange is called by the watcher in interval mode. It starts a
+// cycle only when a checkpoint marker was added, and that cycle commits
+// just the marked files.
+func (d *Daemon) onMarkerChange() {
+	diff, err := d.repo.GetFullDiff(d.repoConfig.Exclude...)
+	if err != nil {
+		return
+	}
+	if files, _ := marker.Checkpoints(marker.Find(diff)); len(files) == 0 {
+		return
+	}
+	d.checkAndCommit(history.TriggerMarker)
+}
+
+// commitCheckpoints finds the markers in the changes to files and commits
+// each file carrying a checkpoint marker on its own, with the marker's text
+// as the message when it has one. It returns the messages and the files
+// committed.
+func (d *Daemon) commitCheckpoints(files []string) (messages, committed []string) {
+	done := d.cycle.Stage("diff")
+	diff, err := d.repo.FullDiffPaths(files)
+	done()
+	if err != nil {
+		d.logError("Failed to get diff: %v", err)
+		return nil, nil
+	}
+	d.markers = marker.Find(diff)
+	
+	checkpoints, texts := marker.Checkpoints(d.markers)
+	for _, file := range checkpoints {
+		if !contains(files, file) {
+			continue
+		}
+		paths := []string{file}
+		
+		diff, err := d.repo.FullDiffPaths(paths)
+		if err != nil {
+			d.logError("Failed to get diff for %s: %v", file, err)
+			continue
+		}
+		if d.hasSecrets(diff) {
+			continue
+		}
+		
+		msg := texts[file]
+		if msg != "" {
+			d.msgSource = history.SourceMarker
+			msg, err = d.filterMessage(msg)
+		} else {
+			msg, err = d.messageFor(diff, paths)
+		}
+		if err != nil {
+			d.logError("%s: %v", file, err)
+			continue
+		}
+		
+		done := d.cycle.Stage("stage")
+		err = d.repo.AddPaths(paths)
+		done()
+		if err != nil {
+			d.logError("Failed to stage %s: %v", file, err)
+			continue
+		}
+		done = d.cycle.Stage("commit")
+		err = d.repo.CommitPaths(msg, paths)
+		done()
+		if err != nil {
+			d.logError("Failed to commit %s: %v", file, err)
+			d.signingFailed(err)
+			continue
+		}
+		
+		d.logger.Printf("Committed checkpoint %s", file)
+		d.lastCommit = time.Now()
+		d.recordCommit(msg)
+		d.countCommit()
+		d.recordHistory(msg, paths)
+		messages = append(messages, msg)
+		committed = append(committed, file)
+	}
+	return messages, committed
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// without returns list minus the entries in remove
+func without(list, remove []string) []string {
+	if len(remove) == 0 {
+		return list
+	}
+	var kept []string
+	for _, item := range list {
+		if !contains(remove, item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/aadityansha/autogit/internal/notify"
+)
+
+// mirror pushes every branch and tag to the backup mirror once its
+// interval has passed since the last push there and a branch or tag has
+// moved. A
+// failure is reported once and retried on the next check, until a push
+// succeeds again.
+func (d *Daemon) mirror() {
+	m := d.repoConfig.Mirror
+	if m == nil || m.Remote == "" {
+		return
+	}
+	if !d.mirrorAt.IsZero() && time.Since(d.mirrorAt) < m.Interval() {
+		return
+	}
+	refs, err := d.repo.Refs()
+	if err != nil || refs == d.mirroredRefs {
+		return
+	}
+	
+	target, bundle := m.Remote, false
+	if path := m.BundlePath(); path != "" {
+		target, bundle = path, true
+	}
+	done := d.cycle.Stage("mirror")
+	err = d.repo.PushMirror(target, bundle)
+	done()
+	if err != nil {
+		d.logError("Failed to mirror: %v", err)
+		if d.mirrorAlerted {
+			return
+		}
+		d.mirrorAlerted = true
+		if d.notifications.Allow(notify.KindError) {
+			notify.NotifyMirrorFailed(d.repoName, err.Error())
+		}
+		msg := err.Error()
+		d.sendEmail(func() (bool, error) {
+			return d.mailer.EmailMirrorFailed(d.repoName, target, msg)
+		})
+		return
+	}
+	
+	d.mirrorAt = time.Now()
+	d.mirroredRefs = refs
+	d.mirrorAlerted = false
+	d.logger.Printf("Mirrored to %s", target)
+}
@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/notify"
+)
+
+// useDefaultModel switches to the provider's default model after the
+// provider rejected the configured one, e.g. because it was retired. It
+// reports whether it switched, in which case the request is worth
+// repeating. The configured model is tried again once the daemon restarts.
+func (d *Daemon) useDefaultModel(err error) bool {
+	model := d.config.GetAIModel()
+	if !errors.Is(err, ai.ErrModelNotFound) || model == "" {
+		return false
+	}
+	fallback := ai.DefaultModel(d.config.AIProvider, d.config.BaseURL)
+	if fallback == "" || strings.EqualFold(fallback, model) {
+		return false // The default is gone too, nothing better to try
+	}
+	
+	updated := *d.config
+	updated.AIModels = make(map[string]string)
+	for name, m := range d.config.AIModels {
+		if name != strings.ToLower(d.config.AIProvider) {
+			updated.AIModels[name] = m
+		}
+	}
+	provider, perr := importAIProvider(&updated, d.prompt)
+	if perr != nil {
+		d.logger.Printf("Failed to switch to model %s: %v", fallback, perr)
+		return false
+	}
+	d.config.AIModels = updated.AIModels
+	d.aiProvider = provider
+	
+	warning := fmt.Sprintf("Model %s of %s is not available, using %s; pick another model in 'autogit menu'", model, d.config.AIProvider, fallback)
+	d.logger.Printf("WARNING: %s: %v", warning, err)
+	d.updateHealth(func(h *config.Health) {
+		h.ModelFallback = warning
+	})
+	if d.notifications.Allow(notify.KindError) {
+		notify.NotifyModelFallback(d.repoName, model, fallback)
+	}
+	return true
+}
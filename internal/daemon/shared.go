@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/notify"
+)
+
+// sharedRejection looks into a push rejected because the remote branch
+// moved. When the commits behind it are someone else's often enough for
+// shared_branch to count the branch as shared, it advises a WIP branch of
+// the user's own or, in the switch mode, moves the commits there and
+// pushes them. It reports whether the commits were pushed that way.
+func (d *Daemon) sharedRejection() bool {
+	branch := d.repo.CurrentBranch()
+	if err := d.repo.FetchUpstream(); err != nil {
+		d.logger.Printf("Cannot tell who else pushed to %s: %v", branch, err)
+		return false
+	}
+	authors, err := d.repo.ForeignAuthors()
+	if err != nil {
+		d.logger.Printf("Cannot tell who else pushed to %s: %v", branch, err)
+		return false
+	}
+	if len(authors) == 0 {
+		return false // Our own commits, e.g. pushed from another machine
+	}
+	
+	policy := d.config.SharedBranch
+	state, err := config.LoadSharedBranchState(d.stateName)
+	if err != nil {
+		d.logger.Printf("%v", err)
+		state = &config.SharedBranchState{}
+	}
+	count := state.Reject(branch, time.Now(), policy.GetWindow(), authors)
+	defer func() {
+		if err := config.SaveSharedBranchState(d.stateName, state); err != nil {
+			d.logger.Printf("%v", err)
+		}
+	}()
+	if count < policy.GetRejections() {
+		d.logger.Printf("Push rejected because of commits by %s (%d of %d rejections within %s before %s counts as shared)",
+			strings.Join(authors, ", "), count, policy.GetRejections(), policy.GetWindow(), branch)
+		return false
+	}
+	
+	wip, err := d.wipBranch(branch)
+	if err != nil {
+		d.logger.Printf("%s is shared with %s, but %v", branch, strings.Join(authors, ", "), err)
+		return false
+	}
+	if policy.GetMode() == config.SharedBranchWarn {
+		if !state.Warned {
+			state.Warned = true
+			advice := fmt.Sprintf("Commit on a branch of your own, e.g. 'git checkout -b %s', or set shared_branch.mode to switch.", wip)
+			d.logger.Printf("%s is shared with %s: %d pushes rejected within %s. %s", branch, strings.Join(authors, ", "), count, policy.GetWindow(), advice)
+			if d.notifications.Allow(notify.KindError) {
+				notify.NotifySharedBranch(d.repoName, branch, authors, advice)
+			}
+		}
+		return false
+	}
+	
+	d.logger.Printf("%s is shared with %s: %d pushes rejected within %s", branch, strings.Join(authors, ", "), count, policy.GetWindow())
+	if !d.moveToWIP(branch, wip, true) {
+		return false
+	}
+	*state = config.SharedBranchState{}
+	if d.notifications.Allow(notify.KindError) {
+		notify.NotifySharedBranch(d.repoName, branch, authors, fmt.Sprintf("Your commits moved to %s; open a pull request to bring them in.", wip))
+	}
+	return true
+}
+
+// enforceWIP moves to the user's WIP branch before committing, as the
+// enforce mode of shared_branch asks. It reports whether committing may
+// go ahead.
+func (d *Daemon) enforceWIP() bool {
+	if d.config.SharedBranch.GetMode() != config.SharedBranchEnforce {
+		return true
+	}
+	branch := d.repo.CurrentBranch()
+	if branch == "" || d.isWIPBranch(branch) {
+		return true // Detached HEAD or already on a WIP branch
+	}
+	wip, err := d.wipBranch(branch)
+	if err != nil {
+		d.logError("Not committing on %s, shared_branch.mode is enforce but %v", branch, err)
+		return false
+	}
+	return d.moveToWIP(branch, wip, false)
+}
+
+// moveToWIP checks out wip, a new branch at HEAD, keeping the changes in
+// the work tree. With push it is pushed and branch is moved back to its
+// upstream, so that the commits the remote rejected are only on wip.
+func (d *Daemon) moveToWIP(branch, wip string, push bool) bool {
+	if d.repo.BranchExists(wip) {
+		d.logError("Cannot move from %s to %s, which already exists; check it out or delete it", branch, wip)
+		return false
+	}
+	if err := d.repo.CheckoutNew(wip); err != nil {
+		d.logError("%v", err)
+		return false
+	}
+	d.logger.Printf("Committing on %s instead of %s from now on", wip, branch)
+	if !push {
+		return true
+	}
+	
+	if err := d.repo.Push(); err != nil {
+		d.logError("Failed to push %s: %v", wip, err)
+		return false
+	}
+	d.logger.Printf("Pushed %s", wip)
+	if err := d.repo.RewindToUpstream(branch); err != nil {
+		d.logger.Printf("%v", err)
+	} else {
+		d.logger.Printf("Moved %s back to its upstream; its commits are on %s", branch, wip)
+	}
+	return true
+}
+
+// wipBranch returns the user's WIP branch for branch, named after
+// shared_branch.branch
+func (d *Daemon) wipBranch(branch string) (string, error) {
+	user := d.wipUser()
+	if user == "" {
+		return "", fmt.Errorf("neither user.email nor user.name is set to name a WIP branch after")
+	}
+	return strings.NewReplacer("{user}", user, "{branch}", branch).Replace(d.config.SharedBranch.GetBranch()), nil
+}
+
+// isWIPBranch reports whether branch is one of the user's WIP branches
+func (d *Daemon) isWIPBranch(branch string) bool {
+	pattern := regexp.QuoteMeta(d.config.SharedBranch.GetBranch())
+	pattern = strings.NewReplacer(regexp.QuoteMeta("{user}"), regexp.QuoteMeta(d.wipUser()), regexp.QuoteMeta("{branch}"), ".+").Replace(pattern)
+	return regexp.MustCompile("^" + pattern + "$").MatchString(branch)
+}
+
+// wipUser returns the name of the user in WIP branches: the local part of
+// their email, or else their name, in lower case with anything but
+// letters, digits, dots and dashes as "-"
+func (d *Daemon) wipUser() string {
+	name, email := d.repo.Identity()
+	user := name
+	if local, _, ok := strings.Cut(email, "@"); ok && local != "" {
+		user = local
+	}
+	return strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, user), "-.")
+}
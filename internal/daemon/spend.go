@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/history"
+	"github.com/aadityansha/autogit/internal/notify"
+	"github.com/aadityansha/autogit/internal/spend"
+)
+
+// budgetMessage drafts a template message instead of calling the AI
+// provider once a cap of ai_budget is reached, notifying once per cap and
+// period. ok reports whether it did; otherwise the request about to be
+// made is counted against the budget.
+func (d *Daemon) budgetMessage(diff string) (msg string, ok bool) {
+	now := time.Now()
+	ledger, err := spend.Load()
+	if err != nil {
+		d.logger.Printf("Failed to read AI usage: %v", err)
+	} else if limit := ledger.Exceeded(d.config.AIBudget, now); limit != nil {
+		msg, err = ai.NewTemplateProvider().GenerateCommitMsg(d.ctx, diff)
+		if err != nil {
+			return "", false
+		}
+		d.msgSource = history.SourceFallback
+		until := limit.Until.Format("Jan 2 15:04")
+		d.logger.Printf("Reached the AI %s, using template message until %s: %s", limit.Cap, until, msg)
+		if spend.Notify(*limit) && d.notifications.Allow(notify.KindError) {
+			notify.NotifyBudget(d.repoName, limit.Cap, until)
+		}
+		return msg, true
+	}
+	
+	if err := spend.Request(d.config.AIProvider, now); err != nil {
+		d.logger.Printf("Failed to record AI usage: %v", err)
+	}
+	return "", false
+}
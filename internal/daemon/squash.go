@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/backup"
+	"github.com/aadityansha/autogit/internal/git"
+)
+
+// squashLimit bounds how far back a daily squash looks
+const squashLimit = 500
+
+// squashPreviousDay collapses the run of bot commits from before today that
+// sits at HEAD into a single snapshot commit. It only rewrites history when
+// every commit in that run carries the heuristic message prefix, and
+// reports whether a rewrite happened so the caller can force-push. It
+// waits while the index holds staged changes, which the snapshot would take.
+func (d *Daemon) squashPreviousDay(now time.Time) (bool, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	prefix := d.repoConfig.MessagePrefix + ":"
+	
+	entries, err := d.repo.Log(squashLimit)
+	if err != nil {
+		return false, err
+	}
+	
+	// Collect the consecutive bot commits from HEAD that share one day
+	var run []git.LogEntry
+	var day time.Time
+	for _, e := range entries {
+		if !e.Time.Before(today) || e.Parents != 1 || !strings.HasPrefix(e.Subject, prefix) {
+			break
+		}
+		eDay := time.Date(e.Time.Year(), e.Time.Month(), e.Time.Day(), 0, 0, 0, 0, now.Location())
+		if !day.IsZero() && !eDay.Equal(day) {
+			break
+		}
+		day = eDay
+		run = append(run, e)
+	}
+	
+	if len(run) < 2 {
+		return false, nil
+	}
+	
+	// The squash commits the whole index, which would take changes the
+	// user staged along into the snapshot. Try again next cycle instead.
+	staged, err := d.repo.HasStaged()
+	if err != nil {
+		return false, err
+	}
+	if staged {
+		d.logger.Printf("Daily squash postponed: the index has staged changes")
+		d.lastSquash = time.Time{}
+		return false, nil
+	}
+	
+	oldest := run[len(run)-1]
+	saved, err := backup.Create(d.repo, d.stateName, "daily squash", oldest.Hash+"^", len(run))
+	if err != nil {
+		// Never rewrite what could not be saved
+		return false, fmt.Errorf("failed to back up commits before squashing: %w", err)
+	}
+	d.logger.Printf("Backed up %d commits to %s", len(run), saved.Bundle(d.stateName))
+	
+	if err := d.repo.SoftReset(oldest.Hash + "^"); err != nil {
+		return false, err
+	}
+	
+	msg := fmt.Sprintf("%s daily snapshot %s (%d commits)", prefix, day.Format("2006-01-02"), len(run))
+	msg = d.addTrailers(msg)
+	if err := d.repo.Commit(msg); err != nil {
+		// Restore the original history so nothing is lost
+		d.repo.SoftReset(run[0].Hash)
+		return false, fmt.Errorf("failed to commit squash: %w", err)
+	}
+	
+	if entries, err := d.repo.Log(1); err == nil && len(entries) > 0 {
+		if err := saved.Done(d.stateName, entries[0].Hash); err != nil {
+			d.logger.Printf("Failed to update backup: %v", err)
+		}
+	}
+	
+	d.logger.Printf("Squashed %d commits from %s", len(run), day.Format("2006-01-02"))
+	return true, nil
+}
@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/spend"
+)
+
+// countCommit adds the commit at HEAD to the repository statistics and
+// the metrics
+func (d *Daemon) countCommit() {
+	d.metrics.commits.Add(1)
+	added, deleted, err := d.repo.LastCommitLines()
+	if err != nil {
+		d.logger.Printf("Failed to count changed lines: %v", err)
+	}
+	if err := d.stats.Commit(added, deleted); err != nil {
+		d.logger.Printf("Failed to write stats: %v", err)
+	}
+}
+
+// countTokens adds the AI tokens used since the last call to the
+// repository statistics. Requests that outlived their cycle are counted
+// by the next one.
+func (d *Daemon) countTokens() {
+	used := ai.TokensUsed(d.aiProvider)
+	if used <= d.tokensCounted {
+		return
+	}
+	if err := d.stats.Tokens(used - d.tokensCounted); err != nil {
+		d.logger.Printf("Failed to write stats: %v", err)
+	}
+	price := d.config.AIBudget.Price(d.config.AIProvider)
+	if err := spend.Tokens(d.config.AIProvider, used-d.tokensCounted, price, time.Now()); err != nil {
+		d.logger.Printf("Failed to record AI usage: %v", err)
+	}
+	d.tokensCounted = used
+}
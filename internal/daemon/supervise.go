@@ -0,0 +1,183 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/logdest"
+	"github.com/aadityansha/autogit/internal/notify"
+	"github.com/aadityansha/autogit/internal/proc"
+)
+
+const (
+	// restartBackoff is the wait before the first restart after a crash,
+	// doubled for every further crash up to maxRestartBackoff
+	restartBackoff    = 5 * time.Second
+	maxRestartBackoff = 5 * time.Minute
+	// stableRun is how long a daemon must run for a crash to count as the
+	// first one again
+	stableRun = 10 * time.Minute
+	// crashOutputLimit bounds the output of a crashed daemon kept for the log
+	crashOutputLimit = 64 << 10
+	// stopSettle is the time given to a stop to remove the daemon info once
+	// the daemon died, as on Windows it is killed first
+	stopSettle = time.Second
+)
+
+// Supervise runs the daemon for rootPath as a child process, as
+// restart_on_crash asks. When the daemon dies without removing its daemon
+// info, e.g. after a panic, what it wrote to stderr, the stack trace
+// included, goes to the repository's log and the daemon is started again
+// after a growing delay. Supervise returns once the daemon exits on its own
+// or is stopped, or when a signal arrives on signals, which it passes on.
+func Supervise(rootPath string, signals <-chan os.Signal) error {
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	
+	// Pause and trigger requests that reach us while the daemon is down
+	// must not kill us; the restarted daemon reads them
+	wake := make(chan os.Signal, 1)
+	proc.NotifyWake(wake)
+	
+	name := config.StateName(rootPath)
+	backoff := restartBackoff
+	for {
+		var output tailBuffer
+		cmd := exec.Command(execPath, "start-daemon", rootPath)
+		cmd.Stderr = &output
+		started := time.Now()
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("failed to start daemon: %w", err)
+		}
+		
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		var exitErr error
+	wait:
+		for {
+			select {
+			case <-signals:
+				proc.Terminate(cmd.Process.Pid)
+				<-done
+				return nil
+			case <-wake:
+				proc.Wake(cmd.Process.Pid)
+			case exitErr = <-done:
+				break wait
+			}
+		}
+		
+		// A daemon that exits on purpose removes its info first, and
+		// 'autogit stop' removes it too
+		reason := "exited"
+		if exitErr != nil {
+			reason = exitErr.Error()
+			time.Sleep(stopSettle)
+		}
+		info, _ := config.LoadDaemonInfo(name)
+		if info == nil || info.PID != cmd.Process.Pid {
+			if exitErr != nil {
+				logCrash(rootPath, fmt.Sprintf("ERROR: Daemon exited (%s)", reason), output.String())
+			}
+			return nil
+		}
+		
+		if time.Since(started) >= stableRun {
+			backoff = restartBackoff
+		}
+		logCrash(rootPath, fmt.Sprintf("ERROR: Daemon crashed (%s), restarting in %s", reason, backoff), output.String())
+		cfg, err := config.LoadConfig()
+		if err == nil && backoff == restartBackoff && notify.NewGate(cfg.Notifications).Allow(notify.KindError) {
+			notify.NotifyCrash(git.GetRepoName(rootPath), reason, backoff)
+		}
+		
+		// Until the restart, status and the dashboard show us instead
+		config.SaveDaemonInfo(config.NewDaemonInfo(os.Getpid(), name, rootPath, StatusRestarting))
+		health := &config.Health{
+			PID:         os.Getpid(),
+			RepoPath:    rootPath,
+			Status:      StatusRestarting,
+			Heartbeat:   time.Now(),
+			LastError:   fmt.Sprintf("Daemon crashed (%s), see the log for the stack trace", reason),
+			LastErrorAt: time.Now(),
+			NextRun:     time.Now().Add(backoff),
+		}
+		config.SaveHealth(name, health)
+		
+		restart := time.NewTimer(backoff)
+		heartbeat := time.NewTicker(config.HeartbeatInterval)
+	sleep:
+		for {
+			select {
+			case <-signals:
+				restart.Stop()
+				heartbeat.Stop()
+				config.DeleteDaemonInfo(name)
+				config.DeleteHealth(name)
+				return nil
+			case <-wake:
+			case <-heartbeat.C:
+				health.Heartbeat = time.Now()
+				config.SaveHealth(name, health)
+			case <-restart.C:
+				break sleep
+			}
+		}
+		heartbeat.Stop()
+		
+		backoff *= 2
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+	}
+}
+
+// logCrash writes line and the output of the daemon, indented, to the
+// repository's log
+func logCrash(rootPath, line, output string) {
+	rc := config.RepoConfig{Path: rootPath}
+	if cfg, err := config.LoadConfig(); err == nil {
+		rc = cfg.ForRepo(rootPath)
+	}
+	w, flags, err := logdest.Open(rc.GetLogDestination(), rc.LogPath(), git.GetRepoName(rootPath))
+	if err != nil {
+		return
+	}
+	defer w.Close()
+	
+	if output = strings.TrimRight(output, "\n"); output != "" {
+		line += ":\n    " + strings.ReplaceAll(output, "\n", "\n    ")
+	}
+	log.New(w, "", flags).Print(line)
+}
+
+// tailBuffer keeps the last crashOutputLimit bytes written to it
+type tailBuffer struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > crashOutputLimit {
+		b.data = b.data[len(b.data)-crashOutputLimit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
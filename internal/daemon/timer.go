@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/schedule"
+)
+
+// checkTimer fires at the check times of a timing: every interval from
+// when it was last reset, like a ticker, or at the minutes a cron
+// expression matches
+type checkTimer struct {
+	timing *schedule.Timing
+	timer  *time.Timer
+	
+	mu    sync.Mutex
+	start time.Time // Phase of an interval
+	next  time.Time // Zero while stopped
+}
+
+func newCheckTimer(timing *schedule.Timing) *checkTimer {
+	t := &checkTimer{timing: timing, timer: time.NewTimer(time.Hour)}
+	t.Reset()
+	return t
+}
+
+// C delivers a value at each check time. Call Fired after receiving.
+func (t *checkTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Reset starts over from now, as after a pause
+func (t *checkTimer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.start = now
+	t.arm(t.timing.Next(now, now))
+}
+
+// Fired schedules the check after the one that fired, skipping any missed
+// while a check ran long
+func (t *checkTimer) Fired() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.arm(t.timing.Next(t.start, time.Now()))
+}
+
+// Retime moves the next check to where the timing puts it now, as after
+// the system time zone changed. A stopped timer stays stopped.
+func (t *checkTimer) Retime() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.next.IsZero() {
+		t.arm(t.timing.Next(t.start, time.Now()))
+	}
+}
+
+func (t *checkTimer) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timer.Stop()
+	t.next = time.Time{}
+}
+
+// Next returns when the timer fires next, or the zero time while stopped
+func (t *checkTimer) Next() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.next
+}
+
+func (t *checkTimer) arm(next time.Time) {
+	if !t.timer.Stop() {
+		select {
+		case <-t.timer.C:
+		default:
+		}
+	}
+	t.next = next
+	if !next.IsZero() {
+		t.timer.Reset(time.Until(next))
+	}
+}
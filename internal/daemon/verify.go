@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"errors"
+
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/notify"
+)
+
+// verifyPush checks that the remote has the commit just pushed and alerts
+// the user once when it does not, until a push lands again. It reports
+// whether the push landed; a push that cannot be checked, e.g. while the
+// remote is unreachable, counts as landed.
+func (d *Daemon) verifyPush() bool {
+	if !d.repoConfig.VerifiesPush() {
+		return true
+	}
+	
+	done := d.cycle.Stage("verify")
+	err := d.repo.VerifyPush()
+	done()
+	if err == nil {
+		if d.pushAlerted {
+			d.logger.Printf("Push verified on the remote again")
+		}
+		d.pushAlerted = false
+		return true
+	}
+	if !errors.Is(err, git.ErrPushNotLanded) {
+		d.logger.Printf("Cannot verify the push: %v", err)
+		return true
+	}
+	
+	d.logError("%v", err)
+	if d.pushAlerted {
+		return false
+	}
+	d.pushAlerted = true
+	if d.notifications.Allow(notify.KindError) {
+		notify.NotifyPushNotLanded(d.repoName, err.Error())
+	}
+	detail := err.Error()
+	d.sendEmail(func() (bool, error) {
+		return d.mailer.EmailPushNotLanded(d.repoName, detail)
+	})
+	return false
+}
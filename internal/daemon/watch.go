@@ -0,0 +1,144 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/pathutil"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watcher recursively watches a working tree and invokes onChange once no
+// events have arrived for the debounce period.
+type watcher struct {
+	fs       *fsnotify.Watcher
+	root     string
+	exclude  []string
+	debounce time.Duration
+	onChange func()
+	
+	mu    sync.Mutex
+	timer *time.Timer
+	done  chan struct{}
+}
+
+func newWatcher(rootPath string, exclude []string, debounce time.Duration, onChange func()) (*watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	
+	w := &watcher{
+		fs:       fsw,
+		root:     rootPath,
+		exclude:  exclude,
+		debounce: debounce,
+		onChange: onChange,
+		done:     make(chan struct{}),
+	}
+	
+	if err := w.addTree(rootPath); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	
+	go w.loop()
+	
+	return w, nil
+}
+
+// addTree adds path and all of its subdirectories, skipping .git and
+// excluded directories
+func (w *watcher) addTree(path string) error {
+	return filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Directory vanished while walking
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if info.Name() == ".git" || w.isExcluded(p) {
+			return filepath.SkipDir
+		}
+		return w.fs.Add(pathutil.Long(p))
+	})
+}
+
+func (w *watcher) loop() {
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			event.Name = pathutil.TrimLong(event.Name)
+			if isGitPath(event.Name) || w.isExcluded(event.Name) {
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					w.addTree(event.Name)
+				}
+			}
+			w.Defer(w.debounce)
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Defer (re)schedules the change callback to fire after d
+func (w *watcher) Defer(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(d, w.onChange)
+}
+
+func (w *watcher) Close() {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+	
+	close(w.done)
+	w.fs.Close()
+}
+
+func isGitPath(path string) bool {
+	dir := filepath.Clean(path)
+	for dir != "." && dir != string(filepath.Separator) && dir != filepath.Dir(dir) {
+		if filepath.Base(dir) == ".git" {
+			return true
+		}
+		dir = filepath.Dir(dir)
+	}
+	return false
+}
+
+// isExcluded reports whether path lies under one of the excluded paths
+func (w *watcher) isExcluded(path string) bool {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, ex := range w.exclude {
+		ex = strings.TrimSuffix(filepath.ToSlash(ex), "/")
+		if rel == ex || strings.HasPrefix(rel, ex+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+// Package fleet reads fleet files, which describe the settings and
+// repositories a team wants on every machine, and compares them with the
+// local config so that 'autogit fleet apply' can bring a machine in line
+// and report what drifted.
+package fleet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Fleet is a fleet file, in YAML or JSON
+type Fleet struct {
+	CloneRoot string                 `yaml:"clone_root"` // Where missing repositories are cloned, the home directory by default
+	Settings  map[string]interface{} `yaml:"settings"`   // Global config settings, e.g. ai_provider or approval
+	Repos     []Repo                 `yaml:"repos"`
+}
+
+// Repo is a repository every machine of the fleet should have
+type Repo struct {
+	URL      string                 `yaml:"url"`
+	Path     string                 `yaml:"path"`     // Where it is cloned, clone_root/<name> by default; ~ is the home directory
+	Settings map[string]interface{} `yaml:"settings"` // Settings of its entry under repos, e.g. groups or trigger
+}
+
+// machineSettings differ from machine to machine and are never taken from
+// a fleet file
+var machineSettings = map[string]bool{
+	"api_key":   true, // Stays in the keyring or environment of each machine
+	"root_path": true,
+	"repos":     true, // Listed under the fleet's own repos
+	"id":        true,
+	"path":      true,
+	"git_dir":   true,
+	"work_tree": true,
+}
+
+// Load reads and checks the fleet file at path
+func Load(path string) (*Fleet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet file: %w", err)
+	}
+	
+	var f Fleet
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil {
+		return nil, fmt.Errorf("invalid fleet file %s: %v", path, err)
+	}
+	
+	var problems []string
+	if f.Settings, err = normalize(f.Settings); err != nil {
+		return nil, fmt.Errorf("invalid fleet file %s: %v", path, err)
+	}
+	problems = append(problems, check(f.Settings, false, "settings.")...)
+	for i := range f.Repos {
+		r := &f.Repos[i]
+		if r.URL == "" {
+			problems = append(problems, fmt.Sprintf("repos[%d].url is required", i))
+		}
+		if r.Settings, err = normalize(r.Settings); err != nil {
+			return nil, fmt.Errorf("invalid fleet file %s: %v", path, err)
+		}
+		problems = append(problems, check(r.Settings, true, fmt.Sprintf("repos[%d].settings.", i))...)
+	}
+	if len(problems) > 0 {
+		return nil, &config.ValidationError{Path: path, Problems: problems}
+	}
+	return &f, nil
+}
+
+// normalize converts settings decoded from YAML to the types JSON decodes
+// to, which the config schema is checked against
+func normalize(settings map[string]interface{}) (map[string]interface{}, error) {
+	if settings == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+func check(settings map[string]interface{}, repo bool, prefix string) []string {
+	var problems []string
+	for _, key := range sortedKeys(settings) {
+		if machineSettings[key] {
+			problems = append(problems, fmt.Sprintf("%s%s is set on each machine and cannot be part of a fleet", prefix, key))
+		}
+	}
+	return append(problems, config.CheckSettings(settings, repo, prefix)...)
+}
+
+// PathOf returns where r is cloned on this machine
+func (f *Fleet) PathOf(r Repo) (string, error) {
+	if r.Path != "" {
+		return expandHome(r.Path)
+	}
+	root := f.CloneRoot
+	if root == "" {
+		root = "~"
+	}
+	root, err := expandHome(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, NameOf(r.URL)), nil
+}
+
+// NameOf returns the directory git clone would create for url, e.g. "api"
+// for git@github.com:team/api.git
+func NameOf(url string) string {
+	name := strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:\\"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return filepath.Abs(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// SameURL reports whether two remote URLs name the same repository,
+// ignoring a trailing slash or .git
+func SameURL(a, b string) bool {
+	trim := func(url string) string {
+		return strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	}
+	return trim(a) == trim(b)
+}
+
+// Apply sets settings on target, a *config.Config or *config.RepoConfig,
+// and returns the keys whose values differed. Settings that are objects
+// only set the keys they list.
+func Apply(target interface{}, settings map[string]interface{}) ([]string, error) {
+	if len(settings) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(target)
+	if err != nil {
+		return nil, err
+	}
+	var current map[string]interface{}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, err
+	}
+	
+	var changed []string
+	for _, key := range sortedKeys(settings) {
+		if !matches(settings[key], current[key]) {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	
+	if data, err = json.Marshal(settings); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// matches reports whether have already has the value want. Objects match
+// when every key of want matches, and a missing value matches the zero
+// value, which the config file leaves out.
+func matches(want, have interface{}) bool {
+	if w, ok := want.(map[string]interface{}); ok {
+		h, _ := have.(map[string]interface{})
+		for key, value := range w {
+			if !matches(value, h[key]) {
+				return false
+			}
+		}
+		return true
+	}
+	if have == nil {
+		return want == nil || reflect.ValueOf(want).IsZero() || isEmpty(want)
+	}
+	return reflect.DeepEqual(want, have)
+}
+
+func isEmpty(value interface{}) bool {
+	if list, ok := value.([]interface{}); ok {
+		return len(list) == 0
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
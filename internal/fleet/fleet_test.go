@@ -0,0 +1,103 @@
+package fleet
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// TestApply checks which settings are reported as changed and that objects
+// only set the keys they list
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		repo     config.RepoConfig
+		settings map[string]interface{}
+		changed  []string
+		want     config.RepoConfig
+	}{
+		{"nothing", config.RepoConfig{Trigger: config.TriggerImmediate}, nil, nil, config.RepoConfig{Trigger: config.TriggerImmediate}},
+		{"same value", config.RepoConfig{Trigger: config.TriggerImmediate}, map[string]interface{}{"trigger": config.TriggerImmediate}, nil, config.RepoConfig{Trigger: config.TriggerImmediate}},
+		{"zero value left out", config.RepoConfig{}, map[string]interface{}{"squash_daily": false, "exclude": []interface{}{}}, nil, config.RepoConfig{}},
+		{"new value", config.RepoConfig{}, map[string]interface{}{"trigger": config.TriggerImmediate, "debounce_seconds": float64(20)}, []string{"debounce_seconds", "trigger"}, config.RepoConfig{Trigger: config.TriggerImmediate, DebounceSeconds: 20}},
+		{"list replaced", config.RepoConfig{Exclude: []string{"*.log"}}, map[string]interface{}{"exclude": []interface{}{"*.tmp"}}, []string{"exclude"}, config.RepoConfig{Exclude: []string{"*.tmp"}}},
+		{"object keeps other keys", config.RepoConfig{Mirror: &config.Mirror{Remote: "backup", Every: "1h"}}, map[string]interface{}{"mirror": map[string]interface{}{"every": "6h"}}, []string{"mirror"}, config.RepoConfig{Mirror: &config.Mirror{Remote: "backup", Every: "6h"}}},
+		{"object already matching", config.RepoConfig{Mirror: &config.Mirror{Remote: "backup", Every: "1h"}}, map[string]interface{}{"mirror": map[string]interface{}{"every": "1h"}}, nil, config.RepoConfig{Mirror: &config.Mirror{Remote: "backup", Every: "1h"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.repo
+			changed, err := Apply(&repo, tt.settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changed, tt.changed) {
+				t.Errorf("Apply() changed %q, want %q", changed, tt.changed)
+			}
+			if !reflect.DeepEqual(repo, tt.want) {
+				t.Errorf("Apply() left %+v, want %+v", repo, tt.want)
+			}
+		})
+	}
+}
+
+// TestNameOf checks the directory names of common remote URL forms
+func TestNameOf(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:team/api.git":      "api",
+		"https://github.com/team/web":      "web",
+		"https://github.com/team/web.git/": "web",
+		"ssh://git@host:2222/srv/notes":    "notes",
+		`C:\repos\dotfiles`:                "dotfiles",
+	}
+	for url, want := range tests {
+		if got := NameOf(url); got != want {
+			t.Errorf("NameOf(%q) = %q, want %q", url, got, want)
+		}
+	}
+	if !SameURL("git@github.com:team/api.git", "git@github.com:team/api/") {
+		t.Errorf("SameURL does not ignore .git and a trailing slash")
+	}
+}
+
+// TestLoad checks that fleet files with machine-specific or invalid
+// settings are rejected with every problem listed
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string // Parts of the expected problems, none for a valid file
+	}{
+		{"valid", "clone_root: ~/work\nsettings:\n  ai_provider: openai\nrepos:\n  - url: git@github.com:team/api.git\n    settings:\n      trigger: immediate\n", nil},
+		{"machine settings", "settings:\n  api_key: sk-1\nrepos:\n  - url: git@github.com:team/api.git\n    settings:\n      git_dir: /srv/api\n", []string{"settings.api_key is set on each machine", "repos[0].settings.git_dir is set on each machine"}},
+		{"missing url", "repos:\n  - path: ~/api\n", []string{"repos[0].url is required"}},
+		{"unknown field", "clone_rot: ~/work\n", []string{"clone_rot"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fleet.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := Load(path)
+			if len(tt.want) == 0 {
+				if err != nil || f == nil {
+					t.Fatalf("Load() = %v, %v, want the fleet", f, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Load() succeeded, want problems %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() error %q lacks %q", err, want)
+				}
+			}
+		})
+	}
+}
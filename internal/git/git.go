@@ -1,19 +1,217 @@
 package git
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// GetRootPath finds the Git root directory using git rev-parse --show-toplevel
+// Location points git at an explicit repository, for setups such as a bare
+// dotfiles repo where GIT_DIR and GIT_WORK_TREE differ
+type Location struct {
+	GitDir   string
+	WorkTree string
+}
+
+// Staging limits which changes a Repo sees and stages. The zero value
+// covers every change.
+type Staging struct {
+	TrackedOnly bool     // Never stage untracked files (e.g. a home directory work tree)
+	StagedOnly  bool     // Only see changes already in the index and never stage anything
+	Patterns    []string // Only paths matching these globs, relative to the root; ** crosses directories
+}
+
+// Mode describes repository features that change how autogit must operate
+type Mode struct {
+	SparseCheckout bool     // core.sparseCheckout is enabled
+	SparseCone     bool     // Sparse checkout uses cone mode
+	SparsePaths    []string // Directories in the sparse cone
+	PartialClone   bool     // Objects may be missing locally and fetched on demand
+}
+
+// Signing controls the signatures on commits made through a Repo. The zero
+// value leaves it to git's commit.gpgsign.
+type Signing struct {
+	Always bool   // Sign even when commit.gpgsign is off
+	Never  bool   // Do not sign even when commit.gpgsign is on
+	Format string // Replaces gpg.format: "openpgp", "ssh" or "x509"
+	Key    string // Replaces user.signingkey
+}
+
+// Repo runs git in one repository. Every command names the repository
+// explicitly with -C, so nothing depends on the process working directory.
+type Repo struct {
+	root        string
+	location    Location
+	mode        Mode
+	staging     Staging
+	signing     Signing
+	authorName  string // Replaces user.name as the author of commits, when set
+	authorEmail string // Replaces user.email likewise
+	quiet       bool   // Keep the output of commits off stdout and stderr
+}
+
+// Open returns the repository whose work tree root is rootPath
+func Open(rootPath string) *Repo {
+	return &Repo{root: rootPath}
+}
+
+// OpenLocation returns the repository described by loc, rooted at its
+// WorkTree. Without GitDir it is the same as Open(loc.WorkTree).
+func OpenLocation(loc Location) *Repo {
+	return &Repo{root: loc.WorkTree, location: loc}
+}
+
+// Root returns the work tree root of the repository
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// UseMode adapts all subsequent git calls on r to m
+func (r *Repo) UseMode(m Mode) {
+	r.mode = m
+}
+
+// UseStaging limits subsequent status, diffs and staging on r as s says
+func (r *Repo) UseStaging(s Staging) {
+	r.staging = s
+}
+
+// UseSigning signs subsequent commits on r as s says
+func (r *Repo) UseSigning(s Signing) {
+	r.signing = s
+}
+
+// UseAuthor attributes subsequent commits on r to name and email. Either
+// may be empty to keep git's user.name or user.email; the committer is
+// never changed.
+func (r *Repo) UseAuthor(name, email string) {
+	r.authorName, r.authorEmail = name, email
+}
+
+// UseQuiet keeps the output of subsequent commits on r off the terminal,
+// e.g. under a full-screen UI. Errors then carry what git printed.
+func (r *Repo) UseQuiet() {
+	r.quiet = true
+}
+
+// Signs reports whether commits on r are signed
+func (r *Repo) Signs() bool {
+	return r.signing.Always || !r.signing.Never && r.configBool("commit.gpgsign")
+}
+
+// SigningKey returns the format and key commits on r are signed with. An
+// empty key lets gpg choose one for the committer's email.
+func (r *Repo) SigningKey() (format, key string) {
+	format, key = r.signing.Format, r.signing.Key
+	if format == "" {
+		if format = r.configString("gpg.format"); format == "" {
+			format = "openpgp"
+		}
+	}
+	if key == "" {
+		key = r.configString("user.signingkey")
+	}
+	return format, key
+}
+
+// DetectMode inspects the repository for sparse-checkout and partial clone
+func (r *Repo) DetectMode() (Mode, error) {
+	var m Mode
+	
+	m.SparseCheckout = r.configBool("core.sparseCheckout")
+	if m.SparseCheckout {
+		// Without the sparse-checkout command the cone cannot be listed
+		m.SparseCone = r.configBool("core.sparseCheckoutCone") && Has(FeatureSparseCheckout)
+		if m.SparseCone {
+			output, err := r.command("sparse-checkout", "list").Output()
+			if err != nil {
+				return m, fmt.Errorf("failed to read sparse-checkout cone: %w", err)
+			}
+			for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					m.SparsePaths = append(m.SparsePaths, line)
+				}
+			}
+		}
+	}
+	
+	if output, err := r.command("config", "--get", "extensions.partialClone").Output(); err == nil && len(strings.TrimSpace(string(output))) > 0 {
+		m.PartialClone = true
+	}
+	if output, err := r.command("config", "--get-regexp", `^remote\..*\.promisor$`).Output(); err == nil && strings.Contains(string(output), "true") {
+		m.PartialClone = true
+	}
+	
+	return m, nil
+}
+
+func (r *Repo) configBool(key string) bool {
+	output, err := r.command("config", "--bool", "--get", key).Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+func (r *Repo) configString(key string) string {
+	output, err := r.command("config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// command builds a git command for the repository honouring its Location
+// and Mode. A Repo without root runs in the current directory.
+func (r *Repo) command(args ...string) *exec.Cmd {
+	var full []string
+	if r.root != "" {
+		full = append(full, "-C", r.root)
+	}
+	if r.location.GitDir != "" {
+		full = append(full, "--git-dir="+r.location.GitDir)
+		if r.location.WorkTree != "" {
+			full = append(full, "--work-tree="+r.location.WorkTree)
+		}
+	}
+	if runtime.GOOS == "windows" {
+		// Git for Windows refuses paths beyond MAX_PATH unless asked
+		full = append(full, "-c", "core.longpaths=true")
+	}
+	if r.mode.PartialClone {
+		// Rename detection reads blobs of deleted files, which in a partial
+		// clone may trigger a fetch of large objects
+		full = append(full, "-c", "diff.renames=false", "-c", "status.renames=false")
+	}
+	cmd := exec.Command("git", append(full, args...)...)
+	if r.mode.PartialClone {
+		// Never lazily fetch missing objects from the background daemon
+		cmd.Env = append(os.Environ(), "GIT_NO_LAZY_FETCH=1")
+	}
+	return cmd
+}
+
+// GetRootPath finds the Git root directory of the current directory using
+// git rev-parse --show-toplevel
 func GetRootPath() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	return FindRoot("")
+}
+
+// FindRoot finds the Git root directory of dir like GetRootPath
+func FindRoot(dir string) (string, error) {
+	cmd := (&Repo{root: dir}).command("rev-parse", "--show-toplevel")
 	output, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", ErrNotInstalled
+	}
 	if err != nil {
-		return "", fmt.Errorf("not a git repository or git not found: %w", err)
+		return "", fmt.Errorf("not a git repository: %w", err)
 	}
 	
 	rootPath := strings.TrimSpace(string(output))
@@ -27,9 +225,64 @@ func GetRootPath() (string, error) {
 	return absPath, nil
 }
 
-// HasChanges checks if there are uncommitted changes
-func HasChanges() (bool, error) {
-	cmd := exec.Command("git", "status", "--porcelain")
+// pathspec builds a pathspec covering the whole tree, or only the sparse
+// cone, minus the given paths
+func (r *Repo) pathspec(exclude []string) []string {
+	if len(exclude) == 0 && !r.mode.SparseCone && len(r.staging.Patterns) == 0 {
+		return nil
+	}
+	spec := []string{"--"}
+	if len(r.staging.Patterns) > 0 {
+		for _, p := range r.staging.Patterns {
+			spec = append(spec, ":(top,glob)"+p)
+		}
+	} else if r.mode.SparseCone && len(r.mode.SparsePaths) > 0 {
+		// Cone mode always includes files in the repository root
+		spec = append(spec, ":(top,glob)*")
+		for _, p := range r.mode.SparsePaths {
+			spec = append(spec, ":(top)"+p)
+		}
+	} else {
+		spec = append(spec, ".")
+	}
+	for _, p := range exclude {
+		spec = append(spec, ":(exclude)"+p)
+	}
+	return spec
+}
+
+func (r *Repo) untrackedFlag() string {
+	if r.staging.TrackedOnly {
+		return "--untracked-files=no"
+	}
+	return "--untracked-files=all"
+}
+
+// stagedFiles returns the paths with staged changes, both sides of a
+// rename included
+func (r *Repo) stagedFiles(exclude []string) ([]string, error) {
+	args := append([]string{"diff", "--cached", "--name-only", "--no-renames", "-z"}, r.pathspec(exclude)...)
+	output, err := r.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged changes: %w", err)
+	}
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// HasChanges checks if there are uncommitted changes outside the excluded paths
+func (r *Repo) HasChanges(exclude ...string) (bool, error) {
+	if r.staging.StagedOnly {
+		files, err := r.stagedFiles(exclude)
+		return len(files) > 0, err
+	}
+	args := append([]string{"status", "--porcelain", r.untrackedFlag()}, r.pathspec(exclude)...)
+	cmd := r.command(args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return false, fmt.Errorf("failed to check git status: %w", err)
@@ -38,9 +291,69 @@ func HasChanges() (bool, error) {
 	return len(strings.TrimSpace(string(output))) > 0, nil
 }
 
+// HasStaged reports whether the index differs from HEAD anywhere in the
+// repository, whatever the staging settings select
+func (r *Repo) HasStaged() (bool, error) {
+	err := r.command("diff", "--cached", "--quiet").Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check staged changes: %w", err)
+	}
+	return false, nil
+}
+
+// Status returns 'git status --porcelain' for the changes outside the
+// excluded paths, as the user would see them
+func (r *Repo) Status(exclude ...string) (string, error) {
+	args := append([]string{"-c", "core.quotePath=false", "status", "--porcelain", r.untrackedFlag()}, r.pathspec(exclude)...)
+	output, err := r.command(args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to check git status: %w", err)
+	}
+	return string(output), nil
+}
+
+// ChangedFiles returns the paths of all modified, added, deleted and
+// untracked files relative to the repository root
+func (r *Repo) ChangedFiles(exclude ...string) ([]string, error) {
+	if r.staging.StagedOnly {
+		return r.stagedFiles(exclude)
+	}
+	args := append([]string{"status", "--porcelain", "-z", r.untrackedFlag()}, r.pathspec(exclude)...)
+	cmd := r.command(args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check git status: %w", err)
+	}
+	
+	var files []string
+	entries := strings.Split(string(output), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		files = append(files, entry[3:])
+		// Renames and copies are followed by the original path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+	
+	return files, nil
+}
+
 // GetDiff returns the diff of uncommitted changes
-func GetDiff() (string, error) {
-	cmd := exec.Command("git", "diff")
+func (r *Repo) GetDiff(exclude ...string) (string, error) {
+	args := []string{"diff"}
+	if r.staging.StagedOnly {
+		args = append(args, "--cached")
+	}
+	args = append(args, r.pathspec(exclude)...)
+	cmd := r.command(args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get git diff: %w", err)
@@ -49,29 +362,1072 @@ func GetDiff() (string, error) {
 	return string(output), nil
 }
 
-// AddAll stages all changes
-func AddAll() error {
-	cmd := exec.Command("git", "add", ".")
+// AddAll stages all changes outside the excluded paths
+func (r *Repo) AddAll(exclude ...string) error {
+	var args []string
+	switch {
+	case r.staging.StagedOnly:
+		return nil
+	case r.staging.TrackedOnly:
+		args = append([]string{"add", "-u"}, r.pathspec(exclude)...)
+	case len(exclude) > 0 || r.mode.SparseCone || len(r.staging.Patterns) > 0:
+		args = append([]string{"add", "-A"}, r.pathspec(exclude)...)
+	default:
+		args = []string{"add", "."}
+	}
+	cmd := r.command(args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-// Commit creates a commit with the given message
-func Commit(message string) error {
-	// Escape the message properly for git commit
-	cmd := exec.Command("git", "commit", "-m", message)
+// maxUntrackedFileSize caps how much of a new file is included in a full diff
+const maxUntrackedFileSize = 64 * 1024
+
+// GetFullDiff returns a diff against HEAD covering staged, unstaged and
+// untracked changes outside the excluded paths, so that new files are
+// visible to the AI as well
+func (r *Repo) GetFullDiff(exclude ...string) (string, error) {
+	return r.fullDiff(r.pathspec(exclude))
+}
+
+// FullDiffPaths is GetFullDiff limited to paths
+func (r *Repo) FullDiffPaths(paths []string) (string, error) {
+	return r.fullDiff(append([]string{"--"}, paths...))
+}
+
+func (r *Repo) fullDiff(spec []string) (string, error) {
+	var untracked []string
+	if !r.staging.TrackedOnly && !r.staging.StagedOnly {
+		output, err := r.command(append([]string{"ls-files", "--others", "--exclude-standard", "--full-name", "-z"}, spec...)...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to list untracked files: %w", err)
+		}
+		for _, file := range strings.Split(string(output), "\x00") {
+			if file != "" {
+				untracked = append(untracked, file)
+			}
+		}
+	}
+	
+	// Tracked changes, staged and unstaged. A repository without commits
+	// has no HEAD, so fall back to the index.
+	args := []string{"-c", "core.quotePath=false", "diff", "HEAD"}
+	if r.staging.StagedOnly || r.Unborn() {
+		args[3] = "--cached"
+	}
+	
+	// Report moved and copied files as such rather than as a deletion and
+	// an addition, except in a partial clone where that may fetch blobs.
+	// A moved file is usually untracked at its new path, so it is added
+	// with intent to add to a copy of the index for git to pair it up.
+	var index string
+	if !r.mode.PartialClone {
+		args = append(args, "-M", "-C")
+		if args[3] == "HEAD" && len(untracked) > 0 {
+			if path, err := r.intentIndex(untracked); err == nil {
+				index = path
+				defer os.Remove(index)
+			}
+		}
+	}
+	
+	cmd := r.command(append(args, spec...)...)
+	if index != "" {
+		setEnv(cmd, "GIT_INDEX_FILE="+index)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git diff: %w", err)
+	}
+	diff := string(output)
+	moved := make(map[string]bool)
+	if index != "" {
+		diff, moved = dropIntentToAdd(diff, untracked)
+	}
+	
+	var b strings.Builder
+	b.WriteString(diff)
+	
+	// Untracked files never appear in git diff, so render them as new files
+	for _, file := range untracked {
+		if !moved[file] {
+			b.WriteString(NewFileDiff(file, filepath.Join(r.root, filepath.FromSlash(file))))
+		}
+	}
+	
+	return b.String(), nil
+}
+
+// intentIndex writes a copy of the index in which files are added with
+// intent to add, and returns its path. The caller removes it.
+func (r *Repo) intentIndex(files []string) (string, error) {
+	output, err := r.command("rev-parse", "--git-path", "index").Output()
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.root, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	
+	tmp, err := os.CreateTemp("", "autogit-index-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	
+	if err := r.addIntent(tmp.Name(), files); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// argBatch is how many paths are passed on one command line
+const argBatch = 200
+
+// addIntent adds files with intent to add to the index at index. Git
+// before 2.25 cannot read pathspecs from stdin, so files are then passed
+// as arguments, in batches that fit the command line.
+func (r *Repo) addIntent(index string, files []string) error {
+	if Has(FeaturePathspecFile) {
+		cmd := r.command("add", "--intent-to-add", "--pathspec-from-file=-", "--pathspec-file-nul")
+		cmd.Stdin = strings.NewReader(strings.Join(files, "\x00"))
+		return runIntent(cmd, index)
+	}
+	
+	for len(files) > 0 {
+		batch := files
+		if len(batch) > argBatch {
+			batch = batch[:argBatch]
+		}
+		files = files[len(batch):]
+		if err := runIntent(r.command(append([]string{"add", "--intent-to-add", "--"}, batch...)...), index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runIntent runs an add of addIntent on index with literal pathspecs
+func runIntent(cmd *exec.Cmd, index string) error {
+	setEnv(cmd, "GIT_INDEX_FILE="+index, "GIT_LITERAL_PATHSPECS=1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// dropIntentToAdd removes the sections of diff that show untracked files
+// as new, which NewFileDiff renders instead, and returns the untracked
+// files git found to be renamed or copied
+func dropIntentToAdd(diff string, untracked []string) (string, map[string]bool) {
+	isUntracked := make(map[string]bool, len(untracked))
+	for _, file := range untracked {
+		isUntracked[file] = true
+	}
+	moved := make(map[string]bool)
+	
+	var b strings.Builder
+	prefix := ""
+	for _, section := range strings.SplitAfter(diff, "\ndiff --git ") {
+		// Each section but the last ends with the start of the next one
+		next := ""
+		if strings.HasSuffix(section, "\ndiff --git ") {
+			section, next = strings.TrimSuffix(section, "diff --git "), "diff --git "
+		}
+		header, _, _ := strings.Cut(section, "\n@@")
+		lines := strings.Split(header, "\n")
+		
+		drop := false
+		for _, line := range lines[1:] {
+			switch {
+			case strings.HasPrefix(line, "rename to "):
+				moved[strings.TrimPrefix(line, "rename to ")] = true
+			case strings.HasPrefix(line, "copy to "):
+				moved[strings.TrimPrefix(line, "copy to ")] = true
+			case strings.HasPrefix(line, "new file mode"):
+				// "diff --git a/<name> b/<name>" for a new file
+				first := strings.TrimPrefix(lines[0], "diff --git ")
+				if len(first) > 5 && isUntracked[first[2:2+(len(first)-5)/2]] {
+					drop = true
+				}
+			}
+		}
+		if !drop {
+			b.WriteString(prefix + section)
+		}
+		prefix = next
+	}
+	return b.String(), moved
+}
+
+// setEnv adds vars to the environment of cmd
+func setEnv(cmd *exec.Cmd, vars ...string) {
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, vars...)
+}
+
+// NewFileDiff renders the new file at path, named name in the diff, in
+// unified diff form
+func NewFileDiff(name, path string) string {
+	header := fmt.Sprintf("diff --git a/%s b/%s\nnew file mode 100644\n", name, name)
+	
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return header
+	}
+	
+	probe := data
+	if len(probe) > 8000 {
+		probe = probe[:8000]
+	}
+	if strings.IndexByte(string(probe), 0) >= 0 {
+		return header + fmt.Sprintf("Binary files /dev/null and b/%s differ\n", name)
+	}
+	
+	truncated := false
+	if len(data) > maxUntrackedFileSize {
+		data = data[:maxUntrackedFileSize]
+		truncated = true
+	}
+	
+	content := strings.TrimSuffix(string(data), "\n")
+	lines := strings.Split(content, "\n")
+	if content == "" {
+		lines = nil
+	}
+	
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("--- /dev/null\n")
+	fmt.Fprintf(&b, "+++ b/%s\n", name)
+	fmt.Fprintf(&b, "@@ -0,0 +1,%d @@\n", len(lines))
+	for _, line := range lines {
+		b.WriteString("+" + line + "\n")
+	}
+	if truncated {
+		b.WriteString("+... (truncated)\n")
+	}
+	return b.String()
+}
+
+// AddPaths stages changes, including deletions, to the given paths
+func (r *Repo) AddPaths(paths []string) error {
+	if r.staging.StagedOnly {
+		return nil
+	}
+	args := append([]string{"add", "-A", "--"}, paths...)
+	cmd := r.command(args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-// Push pushes changes to remote
-func Push() error {
-	cmd := exec.Command("git", "push")
+// Untrack removes the files matching pathspecs from the index but keeps
+// them in the work tree, e.g. once they are ignored
+func (r *Repo) Untrack(pathspecs []string) error {
+	args := append([]string{"rm", "-r", "--cached", "--quiet", "--ignore-unmatch", "--"}, pathspecs...)
+	if output, err := r.command(args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to untrack files: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// CommitPaths commits only the given paths, leaving anything else in the
+// index untouched. With StagedOnly, it commits their staged content rather
+// than the working tree's.
+func (r *Repo) CommitPaths(message string, paths []string) error {
+	if r.staging.StagedOnly {
+		return r.commitStaged(message, paths)
+	}
+	return r.commit(message, "", append([]string{"--only", "--"}, paths...)...)
+}
+
+// Commit creates a commit with the given message
+func (r *Repo) Commit(message string) error {
+	return r.commit(message, "")
+}
+
+// commitStaged commits the staged changes to paths through a temporary
+// index of HEAD plus those changes, so that other staged files stay staged
+// and unstaged edits stay out
+func (r *Repo) commitStaged(message string, paths []string) error {
+	tmp, err := os.CreateTemp("", "autogit-index-*")
+	if err != nil {
+		return err
+	}
+	index := tmp.Name()
+	tmp.Close()
+	// git reads an empty file as a broken index, so let read-tree create it
+	os.Remove(index)
+	defer os.Remove(index)
+	
+	read := r.command("read-tree", "HEAD")
+	if r.Unborn() {
+		read = r.command("read-tree", "--empty")
+	}
+	setEnv(read, "GIT_INDEX_FILE="+index)
+	if output, err := read.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to prepare the commit: %s", strings.TrimSpace(string(output)))
+	}
+	
+	// Copy the staged entries of paths, and drop the paths they lack
+	output, err := r.command(append([]string{"ls-files", "--stage", "-z", "--"}, paths...)...).Output()
+	if err != nil {
+		return fmt.Errorf("failed to read the index: %w", err)
+	}
+	staged := make(map[string]bool)
+	for _, entry := range strings.Split(string(output), "\x00") {
+		if tab := strings.IndexByte(entry, '\t'); tab >= 0 {
+			staged[entry[tab+1:]] = true
+		}
+	}
+	var removed []string
+	for _, p := range paths {
+		if !staged[p] {
+			removed = append(removed, p)
+		}
+	}
+	update := r.command("update-index", "-z", "--index-info")
+	update.Stdin = bytes.NewReader(output)
+	setEnv(update, "GIT_INDEX_FILE="+index)
+	if output, err := update.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to prepare the commit: %s", strings.TrimSpace(string(output)))
+	}
+	if len(removed) > 0 {
+		remove := r.command(append([]string{"update-index", "--force-remove", "--"}, removed...)...)
+		setEnv(remove, "GIT_INDEX_FILE="+index)
+		if output, err := remove.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to prepare the commit: %s", strings.TrimSpace(string(output)))
+		}
+	}
+	
+	return r.commit(message, index)
+}
+
+// commit runs git commit with args, reading the message from stdin so that
+// bodies, quotes, leading dashes and non-ASCII text reach git unchanged and
+// long messages are not limited by the command line length. A non-empty
+// index replaces the repository's index.
+func (r *Repo) commit(message, index string, args ...string) error {
+	if !Has(FeatureSSHSigning) && r.Signs() {
+		if format, _ := r.SigningKey(); format == "ssh" {
+			return fmt.Errorf("%w: signing with an SSH key needs git %s or later", ErrGitTooOld, FeatureSSHSigning.Since)
+		}
+	}
+	
+	var full []string
+	if r.signing.Format != "" {
+		full = append(full, "-c", "gpg.format="+r.signing.Format)
+	}
+	if r.signing.Key != "" {
+		full = append(full, "-c", "user.signingkey="+r.signing.Key)
+	}
+	full = append(full, "commit", "--cleanup=whitespace", "-F", "-")
+	switch {
+	case r.signing.Never:
+		full = append(full, "--no-gpg-sign")
+	case r.signing.Always:
+		full = append(full, "--gpg-sign")
+	}
+	
+	// git refuses messages with NUL bytes, which a model may still return
+	message = strings.ReplaceAll(message, "\x00", "")
+	
+	var stderr bytes.Buffer
+	cmd := r.command(append(full, args...)...)
+	cmd.Stdin = strings.NewReader(message)
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if r.quiet {
+		cmd.Stdout, cmd.Stderr = nil, &stderr
+	}
+	if r.authorName != "" {
+		setEnv(cmd, "GIT_AUTHOR_NAME="+r.authorName)
+	}
+	if r.authorEmail != "" {
+		setEnv(cmd, "GIT_AUTHOR_EMAIL="+r.authorEmail)
+	}
+	if index != "" {
+		setEnv(cmd, "GIT_INDEX_FILE="+index)
+	}
+	if err := cmd.Run(); err != nil {
+		if r.Signs() {
+			if signErr := signingError(stderr.String()); signErr != nil {
+				return signErr
+			}
+		}
+		if r.quiet {
+			return fmt.Errorf("git commit failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	}
+	return nil
+}
+
+// ErrSigning is returned when git could not sign a commit
+var ErrSigning = errors.New("failed to sign the commit")
+
+// signingError explains a signed commit that failed in gpg or ssh-keygen,
+// or returns nil when the failure was something else. Without a terminal
+// neither can ask for a passphrase, which is the usual cause.
+func signingError(stderr string) error {
+	lower := strings.ToLower(stderr)
+	if !strings.Contains(lower, "failed to sign") && !strings.Contains(lower, "failed to write commit object") {
+		return nil
+	}
+	for _, hint := range []string{"passphrase", "pinentry", "inappropriate ioctl", "/dev/tty"} {
+		if strings.Contains(lower, hint) {
+			return fmt.Errorf("%w: the signing key needs a passphrase, which cannot be entered in the background; unlock the key in gpg-agent or ssh-agent, or use a key without one for auto-commits", ErrSigning)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrSigning, strings.TrimSpace(stderr))
+}
+
+// Unborn reports whether the current branch has no commits yet, as in a
+// freshly initialized repository
+func (r *Repo) Unborn() bool {
+	return r.command("rev-parse", "--verify", "-q", "HEAD").Run() != nil
+}
+
+// Push pushes changes to remote. A branch without upstream, such as the
+// first branch of a new repository, is pushed to its push remote and set
+// to track it.
+func (r *Repo) Push() error {
+	return r.push()
+}
+
+// PushForce pushes rewritten history, refusing to overwrite remote work
+// that has not been seen locally
+func (r *Repo) PushForce() error {
+	if !Has(FeatureForceWithLease) {
+		return fmt.Errorf("%w: pushing rewritten history safely needs git %s or later", ErrGitTooOld, FeatureForceWithLease.Since)
+	}
+	return r.push("--force-with-lease")
+}
+
+func (r *Repo) push(args ...string) error {
+	args = append([]string{"push"}, args...)
+	if err := r.command("rev-parse", "--verify", "-q", "@{upstream}").Run(); err != nil {
+		remote, _, err := r.PushRemote()
+		if err != nil {
+			return fmt.Errorf("cannot push: %w", err)
+		}
+		args = append(args, "--set-upstream", remote, "HEAD")
+	}
+	
+	output, err := r.command(args...).CombinedOutput()
+	if err != nil {
+		out := string(output)
+		if strings.Contains(out, "[rejected]") && (strings.Contains(out, "non-fast-forward") || strings.Contains(out, "fetch first")) {
+			return fmt.Errorf("git push failed: %w: %s", ErrPushRejected, strings.TrimSpace(out))
+		}
+		return fmt.Errorf("git push failed: %w: %s", err, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// ErrPushRejected is returned by Push when the remote branch has commits
+// the local branch lacks, e.g. because someone else pushed first
+var ErrPushRejected = errors.New("the remote branch has commits this branch lacks")
+
+// FetchUpstream updates the remote-tracking branches of the current
+// branch's upstream remote, without prompting for credentials
+func (r *Repo) FetchUpstream() error {
+	branch := r.CurrentBranch()
+	remote := r.configString("branch." + branch + ".remote")
+	if branch == "" || remote == "" {
+		return fmt.Errorf("no upstream branch to fetch")
+	}
+	
+	cmd := r.command("fetch", "-q", remote)
+	setEnv(cmd, "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w: %s", remote, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ForeignAuthors returns the authors, as "Name <email>", of the commits on
+// the upstream branch that HEAD lacks, leaving out the author r commits as
+func (r *Repo) ForeignAuthors() ([]string, error) {
+	output, err := r.command("log", "--format=%aN <%aE>", "HEAD..@{upstream}").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upstream commits: %w", err)
+	}
+	
+	_, own := r.Identity()
+	var authors []string
+	seen := make(map[string]bool)
+	for _, author := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		email := author[strings.LastIndex(author, "<")+1:]
+		email = strings.TrimSuffix(email, ">")
+		if author == "" || seen[author] || strings.EqualFold(email, own) {
+			continue
+		}
+		seen[author] = true
+		authors = append(authors, author)
+	}
+	return authors, nil
+}
+
+// Identity returns the name and email commits on r are authored with
+func (r *Repo) Identity() (name, email string) {
+	if name = r.authorName; name == "" {
+		name = r.configString("user.name")
+	}
+	if email = r.authorEmail; email == "" {
+		email = r.configString("user.email")
+	}
+	return name, email
+}
+
+// BranchExists reports whether the local branch name exists
+func (r *Repo) BranchExists(name string) bool {
+	return r.command("rev-parse", "--verify", "-q", "refs/heads/"+name).Run() == nil
+}
+
+// CheckoutNew creates the branch name at HEAD and checks it out, keeping
+// the changes in the work tree
+func (r *Repo) CheckoutNew(name string) error {
+	if output, err := r.command("checkout", "-q", "-b", name).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RewindToUpstream moves branch, which must not be checked out, back to
+// its upstream branch
+func (r *Repo) RewindToUpstream(branch string) error {
+	if output, err := r.command("branch", "-f", branch, branch+"@{upstream}").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to move %s to its upstream: %w: %s", branch, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ErrPushNotLanded is returned by VerifyPush when the remote branch does
+// not hold the commit git reported as pushed
+var ErrPushNotLanded = errors.New("push did not land on the remote")
+
+// VerifyPush fetches the upstream branch from the remote and checks that
+// HEAD is on it, as the tip or under commits pushed since. A proxy or server
+// hook may report an update as accepted and then drop it.
+func (r *Repo) VerifyPush() error {
+	branch := r.CurrentBranch()
+	remote, ref := r.configString("branch."+branch+".remote"), r.configString("branch."+branch+".merge")
+	if branch == "" || remote == "" || ref == "" {
+		return fmt.Errorf("no upstream branch to verify the push against")
+	}
+	
+	// FETCH_HEAD also brings the commits of anyone who pushed on top
+	cmd := r.command("fetch", "-q", "--no-tags", remote, ref)
+	setEnv(cmd, "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if strings.Contains(string(output), "couldn't find remote ref") {
+			return fmt.Errorf("%w: %s has no %s", ErrPushNotLanded, remote, ref)
+		}
+		return fmt.Errorf("failed to fetch %s from %s: %w: %s", ref, remote, err, strings.TrimSpace(string(output)))
+	}
+	if r.command("merge-base", "--is-ancestor", "HEAD", "FETCH_HEAD").Run() == nil {
+		return nil
+	}
+	
+	output, err := r.command("rev-parse", "HEAD", "FETCH_HEAD").Output()
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	heads := strings.Fields(string(output))
+	if len(heads) != 2 {
+		return fmt.Errorf("failed to read HEAD: unexpected output %q", output)
+	}
+	return fmt.Errorf("%w: %s %s is at %.7s, not %.7s", ErrPushNotLanded, remote, ref, heads[1], heads[0])
+}
+
+// PingRemote checks that the push remote of the repository is reachable
+// without prompting for credentials. It returns the round-trip latency.
+func (r *Repo) PingRemote(timeout time.Duration) (time.Duration, error) {
+	cmd := r.command("ls-remote", "-q", "--heads")
+	setEnv(cmd, "GIT_TERMINAL_PROMPT=0")
+	
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to run git: %w", err)
+	}
+	timer := time.AfterFunc(timeout, func() { cmd.Process.Kill() })
+	err := cmd.Wait()
+	latency := time.Since(start)
+	if !timer.Stop() {
+		return latency, fmt.Errorf("remote did not answer within %s", timeout)
+	}
+	if err != nil {
+		return latency, fmt.Errorf("remote unreachable: %w", err)
+	}
+	return latency, nil
+}
+
+// PushRemote returns the name and push URL of the remote the current
+// branch pushes to: its upstream remote, else origin, else the only remote
+func (r *Repo) PushRemote() (name, url string, err error) {
+	if branch, err := r.command("symbolic-ref", "--short", "HEAD").Output(); err == nil {
+		if remote, err := r.command("config", "--get", "branch."+strings.TrimSpace(string(branch))+".remote").Output(); err == nil {
+			name = strings.TrimSpace(string(remote))
+		}
+	}
+	if name == "" {
+		output, err := r.command("remote").Output()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to list remotes: %w", err)
+		}
+		remotes := strings.Fields(string(output))
+		switch {
+		case len(remotes) == 0:
+			return "", "", fmt.Errorf("no remote configured")
+		case contains(remotes, "origin"):
+			name = "origin"
+		case len(remotes) == 1:
+			name = remotes[0]
+		default:
+			return "", "", fmt.Errorf("no upstream and no origin among remotes %s", strings.Join(remotes, ", "))
+		}
+	}
+	
+	if !Has(FeatureRemoteGetURL) {
+		url := r.configString("remote." + name + ".pushurl")
+		if url == "" {
+			url = r.configString("remote." + name + ".url")
+		}
+		if url == "" {
+			return name, "", fmt.Errorf("failed to get URL of remote %s", name)
+		}
+		return name, url, nil
+	}
+	output, err := r.command("remote", "get-url", "--push", name).Output()
+	if err != nil {
+		return name, "", fmt.Errorf("failed to get URL of remote %s: %w", name, err)
+	}
+	return name, strings.TrimSpace(string(output)), nil
+}
+
+// RemoteURL returns the fetch URL of the remote name
+func (r *Repo) RemoteURL(name string) (string, error) {
+	if !Has(FeatureRemoteGetURL) {
+		if url := r.configString("remote." + name + ".url"); url != "" {
+			return url, nil
+		}
+		return "", fmt.Errorf("no remote %s", name)
+	}
+	output, err := r.command("remote", "get-url", name).Output()
+	if err != nil {
+		return "", fmt.Errorf("no remote %s", name)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Clone clones the repository at url into path
+func Clone(url, path string) error {
+	output, err := (&Repo{}).command("clone", "--", url, path).CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return ErrNotInstalled
+	}
+	if err != nil {
+		return fmt.Errorf("failed to clone %s: %s", url, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// DefaultBranch returns the remote-tracking branch of the push remote's
+// default branch, e.g. "origin/main"
+func (r *Repo) DefaultBranch() (string, error) {
+	remote, _, err := r.PushRemote()
+	if err != nil {
+		return "", err
+	}
+	if output, err := r.command("symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD").Output(); err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+	// Set by clone, but not when the remote was added later
+	for _, name := range []string{"main", "master"} {
+		if r.command("rev-parse", "--verify", "-q", "refs/remotes/"+remote+"/"+name).Run() == nil {
+			return remote + "/" + name, nil
+		}
+	}
+	return "", fmt.Errorf("cannot tell the default branch of %s; run 'git remote set-head %s --auto'", remote, remote)
+}
+
+// MergeBase returns the commit where HEAD and ref diverged
+func (r *Repo) MergeBase(ref string) (string, error) {
+	output, err := r.command("merge-base", "HEAD", ref).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base with %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// AheadBehind counts the commits on HEAD that ref lacks, and those on ref
+// that HEAD lacks
+func (r *Repo) AheadBehind(ref string) (ahead, behind int, err error) {
+	output, err := r.command("rev-list", "--left-right", "--count", "HEAD..."+ref).Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare with %s: %w", ref, err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", output)
+	}
+	ahead, _ = strconv.Atoi(fields[0])
+	behind, _ = strconv.Atoi(fields[1])
+	return ahead, behind, nil
+}
+
+// LinesSince returns the lines added and deleted between commit and HEAD.
+// Binary files are not counted.
+func (r *Repo) LinesSince(commit string) (int, int, error) {
+	output, err := r.command("diff", "--numstat", commit, "HEAD").Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read changes since %s: %w", commit, err)
+	}
+	added, deleted := numstat(output)
+	return added, deleted, nil
+}
+
+// CredentialHelper returns the credential helper git uses for HTTPS
+// remotes, or "" when none is configured
+func (r *Repo) CredentialHelper() string {
+	output, err := r.command("config", "--get-all", "credential.helper").Output()
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// operations are the files git keeps while an operation waits for the
+// user, and how to finish or cancel it
+var operations = []struct {
+	path, name, resolve string
+}{
+	{"MERGE_HEAD", "merge", "git merge --continue' or 'git merge --abort"},
+	{"rebase-merge", "rebase", "git rebase --continue' or 'git rebase --abort"},
+	{"rebase-apply", "rebase", "git rebase --continue' or 'git rebase --abort"},
+	{"CHERRY_PICK_HEAD", "cherry-pick", "git cherry-pick --continue' or 'git cherry-pick --abort"},
+	{"REVERT_HEAD", "revert", "git revert --continue' or 'git revert --abort"},
+	{"BISECT_LOG", "bisect", "git bisect reset"},
+}
+
+// CheckState returns an error explaining what to do when the repository
+// is in the middle of a merge, rebase, cherry-pick, revert or bisect, or
+// has files with unresolved conflicts. Committing in either state would
+// record half-finished work or conflict markers.
+func (r *Repo) CheckState() error {
+	args := []string{"rev-parse"}
+	for _, op := range operations {
+		args = append(args, "--git-path", op.path)
+	}
+	output, err := r.command(args...).Output()
+	if err != nil {
+		return fmt.Errorf("failed to inspect repository: %w", err)
+	}
+	for i, path := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		// Paths inside the .git directory are relative to the root
+		if path = strings.TrimSpace(path); !filepath.IsAbs(path) {
+			path = filepath.Join(r.root, path)
+		}
+		if _, err := os.Stat(path); err == nil && i < len(operations) {
+			op := operations[i]
+			return fmt.Errorf("a %s is in progress; finish it with '%s' first", op.name, op.resolve)
+		}
+	}
+	
+	conflicts, err := r.Conflicts()
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("these files have unresolved conflicts:\n  %s\nresolve them and commit first", strings.Join(conflicts, "\n  "))
+	}
+	return nil
+}
+
+// Conflicts returns the unmerged files. Files marked as resolved are left
+// to the user: conflict markers cannot be told apart from text that looks
+// like them, such as a Markdown setext heading
+func (r *Repo) Conflicts() ([]string, error) {
+	output, err := r.command("diff", "--name-only", "-z", "--diff-filter=U").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unmerged files: %w", err)
+	}
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// LFSFiles returns the files among files that .gitattributes routes
+// through Git LFS
+func (r *Repo) LFSFiles(files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	cmd := r.command("check-attr", "-z", "--stdin", "filter")
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00") + "\x00")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attributes: %w", err)
+	}
+	
+	// Each file is reported as path NUL attribute NUL value NUL
+	fields := strings.Split(string(output), "\x00")
+	var lfs []string
+	for i := 0; i+2 < len(fields); i += 3 {
+		if fields[i+2] == "lfs" {
+			lfs = append(lfs, fields[i])
+		}
+	}
+	return lfs, nil
+}
+
+// ErrLFSMissing is returned by LFSReady when git-lfs is not installed
+var ErrLFSMissing = errors.New("git-lfs is not installed")
+
+// LFSReady returns an error unless git-lfs is installed and its filter is
+// configured, without which files tracked by LFS are committed whole
+func (r *Repo) LFSReady() error {
+	if err := r.command("lfs", "version").Run(); err != nil {
+		return ErrLFSMissing
+	}
+	if r.configString("filter.lfs.process") == "" && r.configString("filter.lfs.clean") == "" {
+		return errors.New("the Git LFS filter is not configured; run 'git lfs install'")
+	}
+	return nil
+}
+
+// CurrentBranch returns the name of the checked-out branch, or "" when
+// HEAD is detached
+func (r *Repo) CurrentBranch() string {
+	output, err := r.command("symbolic-ref", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// RepoID returns the autogit ID stored in the repository's git config
+// under autogit.id, or "" when it has none
+func (r *Repo) RepoID() string {
+	output, err := r.command("config", "--local", "--get", "autogit.id").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// SetRepoID stores id in the repository's git config under autogit.id
+func (r *Repo) SetRepoID(id string) error {
+	if output, err := r.command("config", "--local", "autogit.id", id).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set autogit.id: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// UnsetRepoID removes autogit.id from the repository's git config
+func (r *Repo) UnsetRepoID() error {
+	cmd := r.command("config", "--local", "--unset", "autogit.id")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 5 {
+			return nil // Not set
+		}
+		return fmt.Errorf("failed to unset autogit.id in %s: %w\n%s", r.root, err, output)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// LogEntry is a single first-parent commit on HEAD
+type LogEntry struct {
+	Hash    string
+	Subject string
+	Time    time.Time
+	Parents int
+}
+
+// Log returns up to limit first-parent commits from HEAD, newest first.
+// A branch without commits has an empty log.
+func (r *Repo) Log(limit int) ([]LogEntry, error) {
+	if r.Unborn() {
+		return nil, nil
+	}
+	cmd := r.command("log", "--first-parent", fmt.Sprintf("-n%d", limit), "--format=%H%x00%s%x00%ct%x00%P")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git log: %w", err)
+	}
+	
+	var entries []LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		unix, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, LogEntry{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Time:    time.Unix(unix, 0),
+			Parents: len(strings.Fields(fields[3])),
+		})
+	}
+	
+	return entries, nil
+}
+
+// LastCommitLines returns the lines added and deleted by the commit at
+// HEAD. Binary files are not counted.
+func (r *Repo) LastCommitLines() (int, int, error) {
+	cmd := r.command("show", "--numstat", "--format=", "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read commit stats: %w", err)
+	}
+	
+	added, deleted := numstat(output)
+	return added, deleted, nil
+}
+
+// numstat sums the lines added and deleted in git --numstat output
+func numstat(output []byte) (int, int) {
+	added, deleted := 0, 0
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		a, errA := strconv.Atoi(fields[0])
+		d, errD := strconv.Atoi(fields[1])
+		if errA != nil || errD != nil {
+			continue // "-" for binary files
+		}
+		added += a
+		deleted += d
+	}
+	return added, deleted
+}
+
+// SoftReset moves HEAD to ref, keeping all changes staged
+func (r *Repo) SoftReset(ref string) error {
+	cmd := r.command("reset", "--soft", ref)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git reset failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ResetKeep moves the current branch to ref, keeping uncommitted changes.
+// It fails instead of overwriting changes to files that differ at ref.
+func (r *Repo) ResetKeep(ref string) error {
+	cmd := r.command("reset", "--keep", ref)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git reset failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// CreateBundle writes the commits reachable from HEAD but not from base to
+// a bundle file at path
+func (r *Repo) CreateBundle(path, base string) error {
+	cmd := r.command("bundle", "create", path, "HEAD", "^"+base)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git bundle create failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Unbundle checks the bundle file at path against the repository, adds its
+// commits to the object store and points branch at tip, one of them
+func (r *Repo) Unbundle(path, branch, tip string) error {
+	for _, args := range [][]string{
+		{"bundle", "verify", path},
+		{"bundle", "unbundle", path},
+		{"branch", "--force", branch, tip},
+	} {
+		output, err := r.command(args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git %s failed: %w: %s", strings.Join(args[:2], " "), err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// Refs lists the branches and tags of the repository with the commits
+// they point at, one per line
+func (r *Repo) Refs() (string, error) {
+	output, err := r.command("for-each-ref", "--format=%(objectname) %(refname)", "refs/heads", "refs/tags").Output()
+	if err != nil {
+		return "", fmt.Errorf("git for-each-ref failed: %w", err)
+	}
+	return string(output), nil
+}
+
+// PushMirror copies every branch and tag to target, a remote name or URL,
+// overwriting what it has there, or to a bundle file when bundle is set,
+// relative to the repository root. A bundle is written next to its path
+// first so a failure never leaves a partial one behind.
+func (r *Repo) PushMirror(target string, bundle bool) error {
+	if bundle {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(r.root, target)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create the bundle directory: %w", err)
+		}
+		tmp := target + ".tmp"
+		output, err := r.command("bundle", "create", tmp, "--branches", "--tags").CombinedOutput()
+		if err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("git bundle create failed: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return os.Rename(tmp, target)
+	}
+	
+	cmd := r.command("push", "--force", target, "refs/heads/*:refs/heads/*", "refs/tags/*:refs/tags/*")
+	setEnv(cmd, "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git push to %s failed: %w: %s", target, err, strings.TrimSpace(string(output)))
+	}
+	return nil
 }
 
 // GetRepoName extracts repository name from the root path
@@ -79,8 +1435,19 @@ func GetRepoName(rootPath string) string {
 	return filepath.Base(rootPath)
 }
 
-// ChangeToRoot changes the working directory to the Git root
-func ChangeToRoot(rootPath string) error {
-	return os.Chdir(rootPath)
-}
 
+// ErrNotInstalled is returned when the git binary, which every operation
+// of Repo runs, is not on PATH
+var ErrNotInstalled = errors.New("git is not installed or not on PATH")
+
+// Version returns the installed git version, e.g. "2.43.0"
+func Version() (string, error) {
+	output, err := exec.Command("git", "version").Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", ErrNotInstalled
+	}
+	if err != nil {
+		return "", fmt.Errorf("git not found: %w", err)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), "git version "), nil
+}
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aadityansha/autogit/internal/testutil"
+)
+
+// TestCommitMessages commits messages that would break if they were passed
+// as arguments or through a shell, and reads them back from the log
+func TestCommitMessages(t *testing.T) {
+	longBody := strings.Repeat("A line of a long commit body\n", 10000)
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"leading dash", "-m --amend", "-m --amend"},
+		{"leading double dash", "--help", "--help"},
+		{"comment lines", "Fix parser\n\n# Not a comment\n#42 stays too", "Fix parser\n\n# Not a comment\n#42 stays too"},
+		{"CRLF", "Fix parser\r\n\r\nHandle empty input\r\n", "Fix parser\n\nHandle empty input"},
+		{"NUL", "Fix\x00 parser\x00", "Fix parser"},
+		{"long body", "Update docs\n\n" + longBody, "Update docs\n\n" + strings.TrimSpace(longBody)},
+		{"shell metacharacters", "Fix $(rm -rf /) `id`; echo $HOME && exit | cat > out 'q' \"dq\" \\ *", "Fix $(rm -rf /) `id`; echo $HOME && exit | cat > out 'q' \"dq\" \\ *"},
+		{"non-ASCII", "Corrige l'été ✓ 日本語", "Corrige l'été ✓ 日本語"},
+	}
+	
+	root := testutil.GitRepo(t)
+	repo := Open(root)
+	repo.UseQuiet()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.WriteFile(t, root, "file.txt", fmt.Sprintf("change %d\n", i))
+			if err := repo.AddAll(); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Commit(tt.message); err != nil {
+				t.Fatalf("Commit: %v", err)
+			}
+			// %B ends the message with a blank line
+			if got := strings.TrimRight(testutil.Git(t, root, "log", "-1", "--format=%B"), "\n"); got != tt.want {
+				t.Errorf("message = %q, want %q", truncate(got), truncate(tt.want))
+			}
+		})
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > 200 {
+		return s[:200] + "..."
+	}
+	return s
+}
+
+// TestConflicts checks that only unmerged files count as conflicts, and
+// not a Markdown heading that looks like a conflict marker
+func TestConflicts(t *testing.T) {
+	root := testutil.GitRepo(t)
+	repo := Open(root)
+	testutil.WriteFile(t, root, "README.md", "Title\n=======\n\ntext\n")
+	if files, err := repo.Conflicts(); err != nil || len(files) != 0 {
+		t.Fatalf("Conflicts() = %v, %v, want none", files, err)
+	}
+	
+	branch := strings.TrimSpace(testutil.Git(t, root, "branch", "--show-current"))
+	testutil.Git(t, root, "checkout", "-q", "-b", "other")
+	testutil.WriteFile(t, root, "file.txt", "other\n")
+	testutil.Git(t, root, "add", "file.txt")
+	testutil.Git(t, root, "commit", "-q", "-m", "Other")
+	testutil.Git(t, root, "checkout", "-q", branch)
+	testutil.WriteFile(t, root, "file.txt", "main\n")
+	testutil.Git(t, root, "add", "file.txt")
+	testutil.Git(t, root, "commit", "-q", "-m", "Main")
+	exec.Command("git", "-C", root, "merge", "-q", "other").Run() // Fails with the conflict
+	
+	files, err := repo.Conflicts()
+	if err != nil || len(files) != 1 || files[0] != "file.txt" {
+		t.Errorf("Conflicts() = %v, %v, want [file.txt]", files, err)
+	}
+}
+
+// TestVerifyPush checks that a push counts as landed when someone pushed on
+// top of it before the check, and not when the remote dropped it
+func TestVerifyPush(t *testing.T) {
+	root := testutil.GitRepo(t)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	testutil.Git(t, root, "init", "-q", "--bare", remote)
+	branch := strings.TrimSpace(testutil.Git(t, root, "branch", "--show-current"))
+	testutil.Git(t, root, "remote", "add", "origin", remote)
+	testutil.Git(t, root, "push", "-q", "-u", "origin", branch)
+	repo := Open(root)
+	if err := repo.VerifyPush(); err != nil {
+		t.Fatalf("VerifyPush() after push = %v", err)
+	}
+	
+	other := filepath.Join(t.TempDir(), "other")
+	testutil.Git(t, root, "clone", "-q", remote, other)
+	testutil.Git(t, other, "config", "user.name", "Other")
+	testutil.Git(t, other, "config", "user.email", "other@example.com")
+	testutil.Git(t, other, "commit", "-q", "--allow-empty", "-m", "On top")
+	testutil.Git(t, other, "push", "-q", "origin", branch)
+	if err := repo.VerifyPush(); err != nil {
+		t.Errorf("VerifyPush() with a commit on top = %v, want nil", err)
+	}
+	
+	testutil.WriteFile(t, root, "file.txt", "dropped\n")
+	testutil.Git(t, root, "add", "file.txt")
+	testutil.Git(t, root, "commit", "-q", "-m", "Dropped")
+	if err := repo.VerifyPush(); !errors.Is(err, ErrPushNotLanded) {
+		t.Errorf("VerifyPush() of an unpushed commit = %v, want ErrPushNotLanded", err)
+	}
+}
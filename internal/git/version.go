@@ -0,0 +1,99 @@
+package git
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrGitTooOld is returned for an operation the installed git cannot do
+// safely, e.g. a force push without --force-with-lease
+var ErrGitTooOld = errors.New("the installed git is too old")
+
+// Feature is something autogit uses that older git versions lack. Without
+// it autogit falls back to what Fallback describes.
+type Feature struct {
+	Name     string
+	Since    string // First git version with the feature
+	Fallback string
+}
+
+var (
+	FeatureForceWithLease = Feature{"git push --force-with-lease", "1.8.5", "rewritten history, e.g. from daily squashing, is not pushed"}
+	FeatureRemoteGetURL   = Feature{"git remote get-url", "2.7", "remote URLs are read from the config, without url.<base>.insteadOf rewriting"}
+	FeaturePathspecFile   = Feature{"git add --pathspec-from-file", "2.25", "new files are passed on the command line in batches"}
+	FeatureSparseCheckout = Feature{"git sparse-checkout", "2.25", "the sparse-checkout cone is ignored and the whole tree is checked"}
+	FeatureSSHSigning     = Feature{"SSH commit signing", "2.34", "commits signed with an SSH key fail"}
+)
+
+// Features lists every feature whose availability depends on the version
+var Features = []Feature{
+	FeatureForceWithLease,
+	FeatureRemoteGetURL,
+	FeaturePathspecFile,
+	FeatureSparseCheckout,
+	FeatureSSHSigning,
+}
+
+// installed is the version of the git on PATH, read once
+var installed struct {
+	once    sync.Once
+	version [3]int
+	known   bool
+}
+
+func installedVersion() ([3]int, bool) {
+	installed.once.Do(func() {
+		if v, err := Version(); err == nil {
+			installed.version, installed.known = parseVersion(v)
+		}
+	})
+	return installed.version, installed.known
+}
+
+// parseVersion reads the numbers of a version such as "2.43.0",
+// "2.39.3 (Apple Git-145)" or "2.45.1.windows.1"
+func parseVersion(s string) ([3]int, bool) {
+	var v [3]int
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return v, false
+	}
+	parts := strings.Split(fields[0], ".")
+	for i := 0; i < len(v) && i < len(parts); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return v, i > 0
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// Has reports whether the installed git supports f. When its version
+// cannot be read, every feature is assumed to be there.
+func Has(f Feature) bool {
+	v, known := installedVersion()
+	if !known {
+		return true
+	}
+	since, _ := parseVersion(f.Since)
+	for i := range v {
+		if v[i] != since[i] {
+			return v[i] > since[i]
+		}
+	}
+	return true
+}
+
+// Missing returns the features the installed git lacks
+func Missing() []Feature {
+	var missing []Feature
+	for _, f := range Features {
+		if !Has(f) {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
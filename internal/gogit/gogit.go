@@ -0,0 +1,1132 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/pathutil"
+	"github.com/aadityansha/autogit/internal/vcs"
+)
+
+var _ vcs.Repo = (*Repo)(nil)
+
+// Repo commits to a git repository through go-git, a git implementation
+// in Go, for machines without the git binary. It covers what the commit
+// cycle needs: status, diffs, commits and pushes. Features that need git
+// itself, such as sparse checkouts, LFS, signing, bundles and mirrors,
+// report vcs.ErrUnsupported or find nothing to do.
+type Repo struct {
+	repo        *gogit.Repository
+	root        string
+	gitDir      string
+	staging     git.Staging
+	selected    []string // Files AddAll or AddPaths picked for the next Commit
+	authorName  string   // Replaces user.name as the author of commits, when set
+	authorEmail string   // Replaces user.email likewise
+}
+
+// Open opens the repository whose root is rootPath
+func Open(rootPath string) (*Repo, error) {
+	repo, err := gogit.PlainOpenWithOptions(rootPath, &gogit.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", rootPath, err)
+	}
+	r := &Repo{repo: repo, root: rootPath, gitDir: filepath.Join(rootPath, ".git")}
+	if s, ok := repo.Storer.(*filesystem.Storage); ok {
+		r.gitDir = s.Filesystem().Root()
+	}
+	return r, nil
+}
+
+// OpenLocation opens the repository at loc, for a git dir kept apart from
+// its work tree
+func OpenLocation(loc git.Location) (*Repo, error) {
+	storage := filesystem.NewStorage(osfs.New(loc.GitDir), cache.NewObjectLRUDefault())
+	repo, err := gogit.Open(storage, osfs.New(loc.WorkTree))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", loc.GitDir, err)
+	}
+	return &Repo{repo: repo, root: loc.WorkTree, gitDir: loc.GitDir}, nil
+}
+
+// FindRoot finds the root of the git repository of dir, or of the current
+// directory when dir is "", like git.FindRoot but without the git binary
+func FindRoot(dir string) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	return filepath.Abs(wt.Filesystem.Root())
+}
+
+// Root returns the root of the repository
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// UseStaging limits the changes r sees and stages as s describes
+func (r *Repo) UseStaging(s git.Staging) {
+	r.staging = s
+}
+
+// UseAuthor attributes subsequent commits on r to name and email. Either
+// may be empty to keep that part of git's user.name and user.email.
+func (r *Repo) UseAuthor(name, email string) {
+	r.authorName, r.authorEmail = name, email
+}
+
+// RepoID returns the autogit ID stored in the repository's git config
+// under autogit.id, or "" when it has none
+func (r *Repo) RepoID() string {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return ""
+	}
+	return cfg.Raw.Section("autogit").Option("id")
+}
+
+// SetRepoID stores id in the repository's git config under autogit.id
+func (r *Repo) SetRepoID(id string) error {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to set autogit.id: %w", err)
+	}
+	cfg.Raw.Section("autogit").SetOption("id", id)
+	if err := r.repo.SetConfig(cfg); err != nil {
+		return fmt.Errorf("failed to set autogit.id: %w", err)
+	}
+	return nil
+}
+
+// excluded reports whether exclude covers file, with the meaning git gives
+// exclude paths: a path without wildcards covers that file or directory,
+// and a wildcard pattern without a slash matches in any directory
+func excluded(file string, exclude []string) bool {
+	for _, p := range exclude {
+		p = strings.TrimSuffix(filepath.ToSlash(p), "/")
+		switch {
+		case !strings.ContainsAny(p, "*?["):
+			if file == p || strings.HasPrefix(file, p+"/") {
+				return true
+			}
+		case strings.Contains(p, "/"):
+			if pathutil.MatchGlob(p, file) {
+				return true
+			}
+		default:
+			if ok, _ := path.Match(p, path.Base(file)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sees reports whether r looks at the change s of file, as its staging
+// settings say
+func (r *Repo) sees(file string, s *gogit.FileStatus) bool {
+	switch {
+	case s.Staging == gogit.Unmodified && s.Worktree == gogit.Unmodified:
+		return false
+	case r.staging.TrackedOnly && s.Worktree == gogit.Untracked:
+		return false
+	case r.staging.StagedOnly && (s.Staging == gogit.Unmodified || s.Staging == gogit.Untracked):
+		return false
+	}
+	if len(r.staging.Patterns) == 0 {
+		return true
+	}
+	for _, p := range r.staging.Patterns {
+		if pathutil.MatchGlob(p, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// status returns the changes r sees outside exclude, by path
+func (r *Repo) status(exclude []string) (gogit.Status, error) {
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check git status: %w", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check git status: %w", err)
+	}
+	for file, s := range status {
+		if !r.sees(file, s) || excluded(file, exclude) {
+			delete(status, file)
+		}
+	}
+	return status, nil
+}
+
+// HasChanges checks if there are uncommitted changes outside the excluded
+// paths
+func (r *Repo) HasChanges(exclude ...string) (bool, error) {
+	files, err := r.ChangedFiles(exclude...)
+	return len(files) > 0, err
+}
+
+// HasStaged reports whether the index differs from HEAD anywhere in the
+// repository, whatever the staging settings select
+func (r *Repo) HasStaged() (bool, error) {
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("failed to check staged changes: %w", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return false, fmt.Errorf("failed to check staged changes: %w", err)
+	}
+	for _, s := range status {
+		if s.Staging != gogit.Unmodified && s.Staging != gogit.Untracked {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ChangedFiles returns the paths of all staged, modified, deleted and
+// untracked files relative to the repository root
+func (r *Repo) ChangedFiles(exclude ...string) ([]string, error) {
+	status, err := r.status(exclude)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for file := range status {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// GetFullDiff returns a diff covering tracked and untracked changes
+// outside the excluded paths, so that new files are visible to the AI as
+// well
+func (r *Repo) GetFullDiff(exclude ...string) (string, error) {
+	files, err := r.ChangedFiles(exclude...)
+	if err != nil {
+		return "", err
+	}
+	return r.diff(files)
+}
+
+// FullDiffPaths is GetFullDiff limited to paths
+func (r *Repo) FullDiffPaths(paths []string) (string, error) {
+	return r.diff(paths)
+}
+
+// diff renders the changes to files since HEAD in unified diff form, as
+// git diff HEAD would, or the staged changes only when r sees nothing else
+func (r *Repo) diff(files []string) (string, error) {
+	tree, err := r.headTree()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, name := range files {
+		name = filepath.ToSlash(name)
+		var from *object.File
+		if tree != nil {
+			if from, err = tree.File(name); err != nil {
+				from = nil
+			}
+		}
+		content, mode, exists, err := r.current(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to diff %s: %w", name, err)
+		}
+		switch {
+		case from == nil && !exists:
+			continue
+		case from == nil:
+			b.WriteString(git.NewFileDiff(name, filepath.Join(r.root, filepath.FromSlash(name))))
+			continue
+		}
+	
+		old, err := from.Contents()
+		if err != nil {
+			return "", fmt.Errorf("failed to diff %s: %w", name, err)
+		}
+		if exists && old == content && from.Mode == mode {
+			continue
+		}
+		fp := &filePatch{from: &file{from.Hash, from.Mode, name}}
+		if exists {
+			fp.to = &file{plumbing.ComputeHash(plumbing.BlobObject, []byte(content)), mode, name}
+		}
+		if binary(old) || binary(content) {
+			fp.binary = true
+		} else {
+			for _, d := range diff.Do(old, content) {
+				op := fdiff.Equal
+				switch d.Type {
+				case diffmatchpatch.DiffDelete:
+					op = fdiff.Delete
+				case diffmatchpatch.DiffInsert:
+					op = fdiff.Add
+				}
+				fp.chunks = append(fp.chunks, &chunk{d.Text, op})
+			}
+		}
+		if err := fdiff.NewUnifiedEncoder(&b, fdiff.DefaultContextLines).Encode(patch{fp}); err != nil {
+			return "", fmt.Errorf("failed to diff %s: %w", name, err)
+		}
+	}
+	return b.String(), nil
+}
+
+// current returns the content and mode of name in the work tree, or in
+// the index when only staged changes count, and whether it exists there
+func (r *Repo) current(name string) (string, filemode.FileMode, bool, error) {
+	if r.staging.StagedOnly {
+		idx, err := r.repo.Storer.Index()
+		if err != nil {
+			return "", 0, false, err
+		}
+		e, err := idx.Entry(name)
+		if err != nil {
+			return "", 0, false, nil
+		}
+		blob, err := r.repo.BlobObject(e.Hash)
+		if err != nil {
+			return "", 0, false, err
+		}
+		reader, err := blob.Reader()
+		if err != nil {
+			return "", 0, false, err
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		return string(data), e.Mode, true, err
+	}
+	
+	full := filepath.Join(r.root, filepath.FromSlash(name))
+	info, err := os.Lstat(full)
+	switch {
+	case os.IsNotExist(err):
+		return "", 0, false, nil
+	case err != nil:
+		return "", 0, false, err
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(full)
+		return target, filemode.Symlink, true, err
+	case info.IsDir():
+		return "", 0, false, nil // A submodule or nested repository
+	}
+	data, err := os.ReadFile(full)
+	mode := filemode.Regular
+	if info.Mode()&0111 != 0 {
+		mode = filemode.Executable
+	}
+	return string(data), mode, true, err
+}
+
+// binary reports whether content looks binary to git: a NUL byte within
+// its first 8000 bytes
+func binary(content string) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return strings.IndexByte(content, 0) >= 0
+}
+
+// patch, filePatch, file and chunk hold a diff in the form go-git's
+// unified encoder prints
+type patch []fdiff.FilePatch
+
+func (p patch) FilePatches() []fdiff.FilePatch { return p }
+func (p patch) Message() string                { return "" }
+
+type filePatch struct {
+	from, to fdiff.File // to is nil for a deleted file
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func (p *filePatch) IsBinary() bool                { return p.binary }
+func (p *filePatch) Files() (fdiff.File, fdiff.File) { return p.from, p.to }
+func (p *filePatch) Chunks() []fdiff.Chunk         { return p.chunks }
+
+type file struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	path string
+}
+
+func (f *file) Hash() plumbing.Hash     { return f.hash }
+func (f *file) Mode() filemode.FileMode { return f.mode }
+func (f *file) Path() string            { return f.path }
+
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c *chunk) Content() string       { return c.content }
+func (c *chunk) Type() fdiff.Operation { return c.op }
+
+// stage records the state of files in the work tree in the index, adding
+// the files that exist and removing those that are gone
+func (r *Repo) stage(files []string) error {
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	for _, name := range files {
+		name = filepath.ToSlash(name)
+		if _, err := os.Lstat(filepath.Join(r.root, filepath.FromSlash(name))); os.IsNotExist(err) {
+			if _, err := wt.Remove(name); err != nil && !errors.Is(err, index.ErrEntryNotFound) {
+				return fmt.Errorf("failed to stage %s: %w", name, err)
+			}
+			continue
+		}
+		if err := wt.AddWithOptions(&gogit.AddOptions{Path: name, SkipStatus: true}); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// AddAll stages every change outside the excluded paths, and picks them
+// as the changes the next Commit records. Only staged changes are picked
+// when r sees nothing else.
+func (r *Repo) AddAll(exclude ...string) error {
+	files, err := r.ChangedFiles(exclude...)
+	if err != nil {
+		return err
+	}
+	if !r.staging.StagedOnly {
+		if err := r.stage(files); err != nil {
+			return err
+		}
+	}
+	r.selected = files
+	return nil
+}
+
+// AddPaths stages paths as their state in the work tree says, and picks
+// them as the changes the next Commit records
+func (r *Repo) AddPaths(paths []string) error {
+	if !r.staging.StagedOnly {
+		if err := r.stage(paths); err != nil {
+			return err
+		}
+	}
+	r.selected = append([]string(nil), paths...)
+	return nil
+}
+
+// Commit records the changes AddAll or AddPaths picked, or the whole index
+// when neither was called
+func (r *Repo) Commit(message string) error {
+	paths := r.selected
+	r.selected = nil
+	return r.CommitPaths(message, paths)
+}
+
+// CommitPaths commits the staged changes to paths, or the whole index when
+// paths is empty. Other staged files stay staged.
+func (r *Repo) CommitPaths(message string, paths []string) error {
+	message = cleanup(message)
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("git commit failed: empty commit message")
+	}
+	name, email := r.Identity()
+	if email == "" {
+		return fmt.Errorf("git commit failed: no author; set user.name and user.email in ~/.gitconfig, or author_name and author_email for autogit")
+	}
+	committerName, committerEmail := r.configIdentity()
+	if committerEmail == "" {
+		committerName, committerEmail = name, email
+	}
+	
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("git commit failed: %w", err)
+	}
+	if len(paths) > 0 {
+		idx, err := r.repo.Storer.Index()
+		if err != nil {
+			return fmt.Errorf("failed to read the index: %w", err)
+		}
+		picked, err := r.headIndex(idx, paths)
+		if err != nil {
+			return fmt.Errorf("failed to prepare the commit: %w", err)
+		}
+		if err := r.repo.Storer.SetIndex(picked); err != nil {
+			return fmt.Errorf("failed to prepare the commit: %w", err)
+		}
+		// The picked paths now match the commit, and the rest stay staged
+		defer r.repo.Storer.SetIndex(idx)
+	}
+	
+	now := time.Now()
+	_, err = wt.Commit(message, &gogit.CommitOptions{
+		Author:    &object.Signature{Name: name, Email: email, When: now},
+		Committer: &object.Signature{Name: committerName, Email: committerEmail, When: now},
+	})
+	if errors.Is(err, gogit.ErrEmptyCommit) {
+		return fmt.Errorf("git commit failed: nothing to commit")
+	}
+	if err != nil {
+		return fmt.Errorf("git commit failed: %w", err)
+	}
+	return nil
+}
+
+// headIndex returns an index of HEAD plus the entries idx has for paths,
+// which commits only the staged changes to paths
+func (r *Repo) headIndex(idx *index.Index, paths []string) (*index.Index, error) {
+	picked := make(map[string]bool)
+	for _, p := range paths {
+		picked[filepath.ToSlash(p)] = true
+	}
+	out := &index.Index{Version: idx.Version}
+	for _, e := range idx.Entries {
+		if picked[e.Name] {
+			entry := *e
+			out.Entries = append(out.Entries, &entry)
+		}
+	}
+	
+	tree, err := r.headTree()
+	if err != nil || tree == nil {
+		return out, err
+	}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry.Mode != filemode.Dir && !picked[name] {
+			out.Entries = append(out.Entries, &index.Entry{Name: name, Hash: entry.Hash, Mode: entry.Mode})
+		}
+	}
+	sort.Slice(out.Entries, func(i, j int) bool { return out.Entries[i].Name < out.Entries[j].Name })
+	return out, nil
+}
+
+// cleanup tidies message like git commit --cleanup=whitespace: trailing
+// whitespace and leading and trailing blank lines go, and runs of blank
+// lines become one. NUL bytes, which a model may still return, are
+// dropped.
+func cleanup(message string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(strings.ReplaceAll(message, "\x00", ""), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// SoftReset moves HEAD to ref, keeping the index and work tree
+func (r *Repo) SoftReset(ref string) error {
+	commit, err := r.resolve(ref)
+	if err != nil {
+		return err
+	}
+	wt, err := r.repo.Worktree()
+	if err == nil {
+		err = wt.Reset(&gogit.ResetOptions{Commit: commit.Hash, Mode: gogit.SoftReset})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", ref, err)
+	}
+	return nil
+}
+
+// configIdentity returns user.name and user.email from the repository's
+// and the global git config
+func (r *Repo) configIdentity() (name, email string) {
+	cfg, err := r.repo.ConfigScoped(gitconfig.GlobalScope)
+	if err != nil {
+		return "", ""
+	}
+	return cfg.User.Name, cfg.User.Email
+}
+
+// Identity returns the name and email commits on r are authored with
+func (r *Repo) Identity() (name, email string) {
+	name, email = r.configIdentity()
+	if r.authorName != "" {
+		name = r.authorName
+	}
+	if r.authorEmail != "" {
+		email = r.authorEmail
+	}
+	return name, email
+}
+
+// operations are the files git keeps while an operation waits for the
+// user, and how to finish or cancel it
+var operations = []struct {
+	path, name, resolve string
+}{
+	{"MERGE_HEAD", "merge", "git merge --continue' or 'git merge --abort"},
+	{"rebase-merge", "rebase", "git rebase --continue' or 'git rebase --abort"},
+	{"rebase-apply", "rebase", "git rebase --continue' or 'git rebase --abort"},
+	{"CHERRY_PICK_HEAD", "cherry-pick", "git cherry-pick --continue' or 'git cherry-pick --abort"},
+	{"REVERT_HEAD", "revert", "git revert --continue' or 'git revert --abort"},
+	{"BISECT_LOG", "bisect", "git bisect reset"},
+}
+
+// CheckState returns an error explaining what to do when the repository
+// is in the middle of a merge, rebase, cherry-pick, revert or bisect, or
+// has unresolved conflicts, which a commit would record half-finished
+func (r *Repo) CheckState() error {
+	for _, op := range operations {
+		if _, err := os.Stat(filepath.Join(r.gitDir, op.path)); err == nil {
+			return fmt.Errorf("a %s is in progress; finish it with '%s' first", op.name, op.resolve)
+		}
+	}
+	
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to inspect repository: %w", err)
+	}
+	var conflicts []string
+	for _, e := range idx.Entries {
+		// Stage 0 is merged; go-git's index.Merged is wrongly 1
+		if e.Stage != 0 && !contains(conflicts, e.Name) {
+			conflicts = append(conflicts, e.Name)
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("these files have unresolved conflicts:\n  %s\nresolve them and commit first", strings.Join(conflicts, "\n  "))
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Unborn reports whether the current branch has no commits yet
+func (r *Repo) Unborn() bool {
+	_, err := r.repo.Head()
+	return err != nil
+}
+
+// head returns the commit HEAD is on
+func (r *Repo) head() (*object.Commit, error) {
+	ref, err := r.repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	commit, err := r.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	return commit, nil
+}
+
+// headTree returns the tree of HEAD, or nil when there are no commits yet
+func (r *Repo) headTree() (*object.Tree, error) {
+	if r.Unborn() {
+		return nil, nil
+	}
+	commit, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// resolve returns the commit ref names
+func (r *Repo) resolve(ref string) (*object.Commit, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	commit, err := r.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	return commit, nil
+}
+
+// Log returns up to limit first-parent commits from HEAD, newest first.
+// A branch without commits has an empty log.
+func (r *Repo) Log(limit int) ([]git.LogEntry, error) {
+	if r.Unborn() {
+		return nil, nil
+	}
+	commit, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	var entries []git.LogEntry
+	for len(entries) < limit {
+		subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+		entries = append(entries, git.LogEntry{
+			Hash:    commit.Hash.String(),
+			Subject: subject,
+			Time:    commit.Committer.When,
+			Parents: len(commit.ParentHashes),
+		})
+		if len(commit.ParentHashes) == 0 {
+			break
+		}
+		if commit, err = r.repo.CommitObject(commit.ParentHashes[0]); err != nil {
+			break // A shallow clone ends here
+		}
+	}
+	return entries, nil
+}
+
+// lines sums the lines added and deleted in stats
+func lines(stats object.FileStats) (added, deleted int) {
+	for _, s := range stats {
+		added += s.Addition
+		deleted += s.Deletion
+	}
+	return added, deleted
+}
+
+// LastCommitLines returns the lines added and deleted by the last commit
+func (r *Repo) LastCommitLines() (int, int, error) {
+	commit, err := r.head()
+	if err != nil {
+		return 0, 0, err
+	}
+	stats, err := commit.Stats()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read the last commit: %w", err)
+	}
+	added, deleted := lines(stats)
+	return added, deleted, nil
+}
+
+// LinesSince returns the lines added and deleted between commit and HEAD
+func (r *Repo) LinesSince(commit string) (int, int, error) {
+	from, err := r.resolve(commit)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := r.head()
+	if err != nil {
+		return 0, 0, err
+	}
+	p, err := from.Patch(to)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to diff %s: %w", commit, err)
+	}
+	added, deleted := lines(p.Stats())
+	return added, deleted, nil
+}
+
+// MergeBase returns the commit where HEAD and ref diverged
+func (r *Repo) MergeBase(ref string) (string, error) {
+	head, err := r.head()
+	if err != nil {
+		return "", err
+	}
+	other, err := r.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	bases, err := head.MergeBase(other)
+	if err != nil || len(bases) == 0 {
+		return "", fmt.Errorf("failed to find merge base with %s: %v", ref, err)
+	}
+	return bases[0].Hash.String(), nil
+}
+
+// only returns the commits reachable from include but not from exclude
+func only(include, exclude *object.Commit) ([]*object.Commit, error) {
+	seen := make(map[plumbing.Hash]bool)
+	err := object.NewCommitPreorderIter(exclude, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var commits []*object.Commit
+	err = object.NewCommitPreorderIter(include, seen, nil).ForEach(func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	})
+	return commits, err
+}
+
+// AheadBehind counts the commits on HEAD that ref lacks, and those on ref
+// that HEAD lacks
+func (r *Repo) AheadBehind(ref string) (ahead, behind int, err error) {
+	head, err := r.head()
+	if err != nil {
+		return 0, 0, err
+	}
+	other, err := r.resolve(ref)
+	if err != nil {
+		return 0, 0, err
+	}
+	mine, err := only(head, other)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare with %s: %w", ref, err)
+	}
+	theirs, err := only(other, head)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare with %s: %w", ref, err)
+	}
+	return len(mine), len(theirs), nil
+}
+
+// CurrentBranch returns the name of the checked-out branch, or "" when
+// HEAD is detached
+func (r *Repo) CurrentBranch() string {
+	ref, err := r.repo.Storer.Reference(plumbing.HEAD)
+	if err != nil || ref.Type() != plumbing.SymbolicReference || !ref.Target().IsBranch() {
+		return ""
+	}
+	return ref.Target().Short()
+}
+
+// pushRemote returns the remote the current branch pushes to: its
+// upstream remote, or else origin or the only remote
+func (r *Repo) pushRemote(cfg *gitconfig.Config) (string, error) {
+	if b := cfg.Branches[r.CurrentBranch()]; b != nil && b.Remote != "" {
+		return b.Remote, nil
+	}
+	var remotes []string
+	for name := range cfg.Remotes {
+		remotes = append(remotes, name)
+	}
+	sort.Strings(remotes)
+	switch {
+	case len(remotes) == 0:
+		return "", fmt.Errorf("no remote configured")
+	case cfg.Remotes["origin"] != nil:
+		return "origin", nil
+	case len(remotes) == 1:
+		return remotes[0], nil
+	}
+	return "", fmt.Errorf("no upstream and no origin among remotes %s", strings.Join(remotes, ", "))
+}
+
+// upstream returns the remote and remote branch the current branch tracks
+func (r *Repo) upstream() (remote string, merge plumbing.ReferenceName, err error) {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read git config: %w", err)
+	}
+	b := cfg.Branches[r.CurrentBranch()]
+	if b == nil || b.Remote == "" || b.Merge == "" {
+		return "", "", fmt.Errorf("no upstream branch")
+	}
+	return b.Remote, b.Merge, nil
+}
+
+// DefaultBranch returns the default branch of the push remote, e.g.
+// "origin/main"
+func (r *Repo) DefaultBranch() (string, error) {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return "", fmt.Errorf("failed to read git config: %w", err)
+	}
+	remote, err := r.pushRemote(cfg)
+	if err != nil {
+		return "", err
+	}
+	if ref, err := r.repo.Storer.Reference(plumbing.NewRemoteHEADReferenceName(remote)); err == nil && ref.Type() == plumbing.SymbolicReference {
+		return ref.Target().Short(), nil
+	}
+	// Set by clone, but not when the remote was added later
+	for _, name := range []string{"main", "master"} {
+		if _, err := r.repo.Reference(plumbing.NewRemoteReferenceName(remote, name), false); err == nil {
+			return remote + "/" + name, nil
+		}
+	}
+	return "", fmt.Errorf("cannot tell the default branch of %s; run 'git remote set-head %s --auto'", remote, remote)
+}
+
+// BranchExists reports whether the local branch name exists
+func (r *Repo) BranchExists(name string) bool {
+	_, err := r.repo.Reference(plumbing.NewBranchReferenceName(name), false)
+	return err == nil
+}
+
+// CheckoutNew creates the branch name at HEAD and checks it out, keeping
+// the changes in the work tree
+func (r *Repo) CheckoutNew(name string) error {
+	wt, err := r.repo.Worktree()
+	if err == nil {
+		err = wt.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name), Create: true, Keep: true})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// RewindToUpstream moves branch, which must not be checked out, back to
+// its upstream branch
+func (r *Repo) RewindToUpstream(branch string) error {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read git config: %w", err)
+	}
+	b := cfg.Branches[branch]
+	if b == nil || b.Remote == "" || b.Merge == "" {
+		return fmt.Errorf("failed to move %s to its upstream: no upstream branch", branch)
+	}
+	upstream, err := r.repo.Reference(plumbing.NewRemoteReferenceName(b.Remote, b.Merge.Short()), true)
+	if err != nil {
+		return fmt.Errorf("failed to move %s to its upstream: %w", branch, err)
+	}
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), upstream.Hash())); err != nil {
+		return fmt.Errorf("failed to move %s to its upstream: %w", branch, err)
+	}
+	return nil
+}
+
+// FetchUpstream fetches the remote of the current branch's upstream
+func (r *Repo) FetchUpstream() error {
+	remote, _, err := r.upstream()
+	if err != nil {
+		return fmt.Errorf("no upstream branch to fetch")
+	}
+	err = r.repo.Fetch(&gogit.FetchOptions{RemoteName: remote})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch %s: %w", remote, err)
+	}
+	return nil
+}
+
+// ForeignAuthors returns the authors, as "Name <email>", of the commits on
+// the upstream branch that HEAD lacks, leaving out the author r commits as
+func (r *Repo) ForeignAuthors() ([]string, error) {
+	remote, merge, err := r.upstream()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upstream commits: %w", err)
+	}
+	ref, err := r.repo.Reference(plumbing.NewRemoteReferenceName(remote, merge.Short()), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upstream commits: %w", err)
+	}
+	upstream, err := r.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upstream commits: %w", err)
+	}
+	head, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	commits, err := only(upstream, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upstream commits: %w", err)
+	}
+	
+	_, own := r.Identity()
+	var authors []string
+	seen := make(map[string]bool)
+	for _, c := range commits {
+		author := fmt.Sprintf("%s <%s>", c.Author.Name, c.Author.Email)
+		if seen[author] || strings.EqualFold(c.Author.Email, own) {
+			continue
+		}
+		seen[author] = true
+		authors = append(authors, author)
+	}
+	return authors, nil
+}
+
+// Push pushes the current branch to its upstream branch, setting one up
+// on the push remote when it has none
+func (r *Repo) Push() error {
+	return r.push(false)
+}
+
+// PushForce pushes like Push, replacing the remote branch as long as it is
+// still where it was last fetched
+func (r *Repo) PushForce() error {
+	return r.push(true)
+}
+
+func (r *Repo) push(force bool) error {
+	branch := r.CurrentBranch()
+	if branch == "" {
+		return fmt.Errorf("git push failed: not on a branch")
+	}
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read git config: %w", err)
+	}
+	remote, merge, err := r.upstream()
+	tracked := err == nil
+	if !tracked {
+		if remote, err = r.pushRemote(cfg); err != nil {
+			return err
+		}
+		merge = plumbing.NewBranchReferenceName(branch)
+	}
+	
+	opts := &gogit.PushOptions{
+		RemoteName: remote,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(plumbing.NewBranchReferenceName(branch).String() + ":" + merge.String())},
+	}
+	if force {
+		opts.ForceWithLease = &gogit.ForceWithLease{}
+	}
+	err = r.repo.Push(opts)
+	switch {
+	case errors.Is(err, gogit.NoErrAlreadyUpToDate):
+	case err != nil && strings.Contains(err.Error(), "non-fast-forward"):
+		return fmt.Errorf("git push failed: %w: %v", git.ErrPushRejected, err)
+	case err != nil:
+		return fmt.Errorf("git push failed: %w", err)
+	}
+	
+	if !tracked {
+		cfg.Branches[branch] = &gitconfig.Branch{Name: branch, Remote: remote, Merge: merge}
+		if err := r.repo.SetConfig(cfg); err != nil {
+			return fmt.Errorf("failed to set the upstream of %s: %w", branch, err)
+		}
+	}
+	return nil
+}
+
+// VerifyPush fetches the upstream branch from the remote and checks that
+// HEAD is on it, as the tip or under commits pushed since. A proxy or server
+// hook may report an update as accepted and then drop it.
+func (r *Repo) VerifyPush() error {
+	remote, merge, err := r.upstream()
+	if err != nil {
+		return fmt.Errorf("no upstream branch to verify the push against")
+	}
+	tracking := plumbing.NewRemoteReferenceName(remote, merge.Short())
+	err = r.repo.Fetch(&gogit.FetchOptions{
+		RemoteName: remote,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec("+" + merge.String() + ":" + tracking.String())},
+		Tags:       gogit.NoTags,
+	})
+	switch {
+	case errors.Is(err, gogit.NoMatchingRefSpecError{}):
+		return fmt.Errorf("%w: %s has no %s", git.ErrPushNotLanded, remote, merge)
+	case err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate):
+		return fmt.Errorf("failed to fetch %s from %s: %w", merge, remote, err)
+	}
+	
+	ref, err := r.repo.Reference(tracking, true)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", tracking.Short(), err)
+	}
+	fetched, err := r.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", tracking.Short(), err)
+	}
+	head, err := r.head()
+	if err != nil {
+		return err
+	}
+	if ok, err := head.IsAncestor(fetched); err == nil && ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %s is at %.7s, not %.7s", git.ErrPushNotLanded, remote, merge, fetched.Hash, head.Hash)
+}
+
+// DetectMode finds nothing: go-git does not support sparse checkouts or
+// partial clones
+func (r *Repo) DetectMode() (git.Mode, error) {
+	return git.Mode{}, nil
+}
+
+// UseMode does nothing, as DetectMode finds nothing
+func (r *Repo) UseMode(m git.Mode) {}
+
+// UseQuiet does nothing: go-git prints nothing
+func (r *Repo) UseQuiet() {}
+
+// LFSFiles returns the files among files that .gitattributes routes
+// through Git LFS
+func (r *Repo) LFSFiles(files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	patterns, err := gitattributes.ReadPatterns(osfs.New(r.root), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attributes: %w", err)
+	}
+	matcher := gitattributes.NewMatcher(patterns)
+	var lfs []string
+	for _, f := range files {
+		attrs, _ := matcher.Match(strings.Split(filepath.ToSlash(f), "/"), []string{"filter"})
+		if filter, ok := attrs["filter"]; ok && filter.Value() == "lfs" {
+			lfs = append(lfs, f)
+		}
+	}
+	return lfs, nil
+}
+
+// LFSReady always returns an error: go-git cannot run the LFS filter, and
+// would commit the whole files instead
+func (r *Repo) LFSReady() error {
+	return fmt.Errorf("%w: storing files in Git LFS without the git binary", vcs.ErrUnsupported)
+}
+
+// CreateBundle is not supported: go-git cannot write bundles
+func (r *Repo) CreateBundle(path, base string) error {
+	return fmt.Errorf("%w: backups without the git binary", vcs.ErrUnsupported)
+}
+
+// Refs is not supported: mirrors need the git binary
+func (r *Repo) Refs() (string, error) {
+	return "", fmt.Errorf("%w: mirrors without the git binary", vcs.ErrUnsupported)
+}
+
+// PushMirror is not supported, like Refs
+func (r *Repo) PushMirror(target string, bundle bool) error {
+	return fmt.Errorf("%w: mirrors without the git binary", vcs.ErrUnsupported)
+}
@@ -0,0 +1,105 @@
+package gogit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aadityansha/autogit/internal/testutil"
+	gogit "github.com/go-git/go-git/v5"
+)
+
+// newRepo opens a repository with one commit in a temporary directory,
+// without the git binary
+func newRepo(t *testing.T) *Repo {
+	t.Helper()
+	repo, err := Open(testutil.GoGitRepo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.UseAuthor("Test", "test@example.com")
+	return repo
+}
+
+// TestCommit checks that changes are seen, diffed and committed, and that
+// excluded paths stay out
+func TestCommit(t *testing.T) {
+	repo := newRepo(t)
+	if err := repo.CheckState(); err != nil {
+		t.Errorf("CheckState = %v on a clean repository", err)
+	}
+	testutil.WriteFile(t, repo.Root(), "README.md", "readme\nmore\n")
+	testutil.WriteFile(t, repo.Root(), "main.go", "package main\n")
+	testutil.WriteFile(t, repo.Root(), "local.env", "TOKEN=1\n")
+	
+	files, err := repo.ChangedFiles("*.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"README.md", "main.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles = %q, want %q", files, want)
+	}
+	diff, err := repo.GetFullDiff("*.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"+more", "b/main.go", "+package main"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff lacks %q:\n%s", want, diff)
+		}
+	}
+	
+	if err := repo.AddAll("*.env"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Commit("Add main\n\n\n"); err != nil {
+		t.Fatal(err)
+	}
+	files, _ = repo.ChangedFiles()
+	if want := []string{"local.env"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles after commit = %q, want %q", files, want)
+	}
+	log, err := repo.Log(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 2 || log[0].Subject != "Add main" || log[1].Parents != 0 {
+		t.Errorf("Log = %+v, want Add main on the initial commit", log)
+	}
+}
+
+// TestCommitPathsLeavesStagedFiles stages a file held back before
+// committing another, and checks that it stays staged and out of the
+// commit
+func TestCommitPathsLeavesStagedFiles(t *testing.T) {
+	repo := newRepo(t)
+	testutil.WriteFile(t, repo.Root(), "secret.env", "TOKEN=1\n")
+	if err := repo.AddPaths([]string{"secret.env"}); err != nil {
+		t.Fatal(err)
+	}
+	testutil.WriteFile(t, repo.Root(), "main.go", "package main\n")
+	if err := repo.AddPaths([]string{"main.go"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CommitPaths("Add main", []string{"main.go"}); err != nil {
+		t.Fatal(err)
+	}
+	
+	tree, err := repo.headTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.File("main.go"); err != nil {
+		t.Errorf("main.go was not committed: %v", err)
+	}
+	if _, err := tree.File("secret.env"); err == nil {
+		t.Errorf("secret.env was committed")
+	}
+	status, err := repo.status(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := status["secret.env"]; s == nil || s.Staging != gogit.Added {
+		t.Errorf("secret.env is not staged after the commit: %+v", s)
+	}
+}
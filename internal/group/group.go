@@ -0,0 +1,69 @@
+package group
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// RootGroup holds files that sit directly in the repository root. No
+// top-level directory can be named like it, as it contains a slash.
+const RootGroup = "/"
+
+// Group is a set of changed files committed together
+type Group struct {
+	Name  string
+	Files []string
+}
+
+// Split partitions changed files according to the grouping settings.
+// Files matching a configured path group are placed in that group; all
+// other files are grouped by their top-level directory. Groups are
+// returned sorted by name so commit order is deterministic.
+func Split(files []string, grouping config.Grouping) []Group {
+	byName := make(map[string][]string)
+	for _, file := range files {
+		name := groupFor(file, grouping.Groups)
+		byName[name] = append(byName[name], file)
+	}
+	
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	
+	groups := make([]Group, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, Group{Name: name, Files: byName[name]})
+	}
+	return groups
+}
+
+func groupFor(file string, groups []config.PathGroup) string {
+	file = filepath.ToSlash(file)
+	
+	// Longest matching configured prefix wins
+	best, bestLen := "", -1
+	for _, g := range groups {
+		for _, p := range g.Paths {
+			prefix := strings.TrimSuffix(filepath.ToSlash(p), "/")
+			if file != prefix && !strings.HasPrefix(file, prefix+"/") {
+				continue
+			}
+			if len(prefix) > bestLen {
+				best, bestLen = g.Name, len(prefix)
+			}
+		}
+	}
+	if best != "" {
+		return best
+	}
+	
+	if i := strings.Index(file, "/"); i >= 0 {
+		return file[:i]
+	}
+	return RootGroup
+}
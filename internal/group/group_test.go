@@ -0,0 +1,32 @@
+package group
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// TestSplit checks that configured groups win over top-level directories,
+// the longest prefix first, and that a directory named root stays apart
+// from the files in the repository root
+func TestSplit(t *testing.T) {
+	grouping := config.Grouping{
+		Mode: config.GroupingDirectory,
+		Groups: []config.PathGroup{
+			{Name: "frontend", Paths: []string{"web/", "assets"}},
+			{Name: "api", Paths: []string{"web/api"}},
+		},
+	}
+	files := []string{"README.md", "root/setup.sh", "web/index.html", "web/api/routes.go", "assets/logo.svg", "cmd/main.go", "go.mod"}
+	want := []Group{
+		{Name: RootGroup, Files: []string{"README.md", "go.mod"}},
+		{Name: "api", Files: []string{"web/api/routes.go"}},
+		{Name: "cmd", Files: []string{"cmd/main.go"}},
+		{Name: "frontend", Files: []string{"web/index.html", "assets/logo.svg"}},
+		{Name: "root", Files: []string{"root/setup.sh"}},
+	}
+	if got := Split(files, grouping); !reflect.DeepEqual(got, want) {
+		t.Errorf("Split() = %+v, want %+v", got, want)
+	}
+}
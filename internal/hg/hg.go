@@ -0,0 +1,576 @@
+package hg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/vcs"
+)
+
+var _ vcs.Repo = (*Repo)(nil)
+
+// Repo runs Mercurial (hg) in one repository. It covers what the commit
+// cycle needs: status, diffs, commits and pushes. Features built on git
+// internals, such as history rewriting, mirrors and LFS, report
+// vcs.ErrUnsupported or find nothing to do.
+type Repo struct {
+	root        string
+	selected    []string // Files AddAll or AddPaths picked for the next Commit
+	authorName  string   // Replaces the name of ui.username as the author of commits, when set
+	authorEmail string   // Replaces its email likewise
+}
+
+// Open returns the repository whose root is rootPath
+func Open(rootPath string) *Repo {
+	return &Repo{root: rootPath}
+}
+
+// Detect reports whether rootPath is the root of a Mercurial repository
+func Detect(rootPath string) bool {
+	info, err := os.Stat(filepath.Join(rootPath, ".hg"))
+	return err == nil && info.IsDir()
+}
+
+// GetRootPath finds the root of the Mercurial repository of the current
+// directory
+func GetRootPath() (string, error) {
+	return FindRoot("")
+}
+
+// FindRoot finds the root of the Mercurial repository of dir like
+// GetRootPath
+func FindRoot(dir string) (string, error) {
+	cmd := exec.Command("hg", "root")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not a Mercurial repository: %w", err)
+	}
+	return filepath.Abs(strings.TrimSpace(string(output)))
+}
+
+// Version returns the version of the hg on PATH, e.g. "6.7.2"
+func Version() (string, error) {
+	output, err := exec.Command("hg", "version", "--quiet").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run hg: %w", err)
+	}
+	version := strings.TrimSpace(string(output))
+	if _, v, ok := strings.Cut(version, "(version "); ok {
+		version = strings.TrimSuffix(v, ")")
+	}
+	return version, nil
+}
+
+// Root returns the root of the repository
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// UseAuthor attributes subsequent commits on r to name and email. Either
+// may be empty to keep that part of hg's ui.username.
+func (r *Repo) UseAuthor(name, email string) {
+	r.authorName, r.authorEmail = name, email
+}
+
+// command builds an hg command for the repository that never prompts and
+// prints in the stable format scripts rely on
+func (r *Repo) command(args ...string) *exec.Cmd {
+	cmd := exec.Command("hg", append([]string{"--repository", r.root, "--noninteractive"}, args...)...)
+	cmd.Dir = r.root // Patterns and printed paths are relative to it
+	cmd.Env = append(os.Environ(), "HGPLAIN=1")
+	return cmd
+}
+
+// run runs hg with args and returns what it printed
+func (r *Repo) run(args ...string) (string, error) {
+	output, err := r.command(args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("hg %s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// excludes turns exclude paths into hg -X options with the meaning git
+// gives them: a path without wildcards covers that file or directory, and
+// a wildcard pattern without a slash matches in any directory
+func excludes(exclude []string) []string {
+	var args []string
+	for _, p := range exclude {
+		switch {
+		case !strings.ContainsAny(p, "*?["):
+			args = append(args, "--exclude", "path:"+strings.TrimSuffix(p, "/"))
+		case strings.Contains(strings.TrimSuffix(p, "/"), "/"):
+			args = append(args, "--exclude", "glob:"+p)
+		default:
+			args = append(args, "--exclude", "relglob:"+p)
+		}
+	}
+	return args
+}
+
+// files turns paths relative to the root into patterns matching exactly
+// them
+func files(paths []string) []string {
+	args := []string{"--"}
+	for _, p := range paths {
+		args = append(args, "path:"+filepath.ToSlash(p))
+	}
+	return args
+}
+
+// status lists the paths hg status reports with flags, outside exclude
+func (r *Repo) status(flags []string, exclude []string) ([]string, error) {
+	args := append([]string{"status", "--no-status", "--print0"}, flags...)
+	output, err := r.command(append(args, excludes(exclude)...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check hg status: %w", err)
+	}
+	var paths []string
+	for _, p := range strings.Split(string(output), "\x00") {
+		if p != "" {
+			paths = append(paths, filepath.ToSlash(p))
+		}
+	}
+	return paths, nil
+}
+
+// HasChanges checks if there are uncommitted changes outside the excluded
+// paths
+func (r *Repo) HasChanges(exclude ...string) (bool, error) {
+	files, err := r.ChangedFiles(exclude...)
+	return len(files) > 0, err
+}
+
+// HasStaged reports false: Mercurial has no index
+func (r *Repo) HasStaged() (bool, error) {
+	return false, nil
+}
+
+// ChangedFiles returns the paths of all modified, added, removed, missing
+// and unknown files relative to the repository root
+func (r *Repo) ChangedFiles(exclude ...string) ([]string, error) {
+	return r.status([]string{"--modified", "--added", "--removed", "--deleted", "--unknown"}, exclude)
+}
+
+// GetFullDiff returns a diff covering tracked and unknown changes outside
+// the excluded paths, so that new files are visible to the AI as well
+func (r *Repo) GetFullDiff(exclude ...string) (string, error) {
+	unknown, err := r.status([]string{"--unknown"}, exclude)
+	if err != nil {
+		return "", err
+	}
+	return r.fullDiff(excludes(exclude), unknown)
+}
+
+// FullDiffPaths is GetFullDiff limited to paths
+func (r *Repo) FullDiffPaths(paths []string) (string, error) {
+	unknown, err := r.status(append([]string{"--unknown"}, files(paths)...), nil)
+	if err != nil {
+		return "", err
+	}
+	return r.fullDiff(files(paths), unknown)
+}
+
+func (r *Repo) fullDiff(spec []string, unknown []string) (string, error) {
+	output, err := r.command(append([]string{"diff", "--git"}, spec...)...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hg diff: %w", err)
+	}
+	var b strings.Builder
+	b.Write(output)
+	// Unknown files never appear in hg diff, so render them as new files
+	for _, file := range unknown {
+		b.WriteString(git.NewFileDiff(file, filepath.Join(r.root, filepath.FromSlash(file))))
+	}
+	return b.String(), nil
+}
+
+// AddAll adds unknown and forgets missing files outside the excluded
+// paths, and picks the changes the next Commit records
+func (r *Repo) AddAll(exclude ...string) error {
+	files, err := r.ChangedFiles(exclude...)
+	if err != nil {
+		return err
+	}
+	if _, err := r.run(append([]string{"addremove"}, excludes(exclude)...)...); err != nil {
+		return err
+	}
+	r.selected = files
+	return nil
+}
+
+// AddPaths adds or forgets paths as their state in the working directory
+// says, and picks them as the changes the next Commit records
+func (r *Repo) AddPaths(paths []string) error {
+	if _, err := r.run(append([]string{"addremove"}, files(paths)...)...); err != nil {
+		return err
+	}
+	r.selected = append([]string(nil), paths...)
+	return nil
+}
+
+// Commit records the changes AddAll or AddPaths picked, or every change
+// when neither was called
+func (r *Repo) Commit(message string) error {
+	paths := r.selected
+	r.selected = nil
+	return r.CommitPaths(message, paths)
+}
+
+// CommitPaths commits only the given paths, reading the message from
+// stdin so that it reaches hg unchanged
+func (r *Repo) CommitPaths(message string, paths []string) error {
+	args := []string{"commit", "--logfile", "-"}
+	if user := r.user(); user != "" {
+		args = append(args, "--user", user)
+	}
+	if len(paths) > 0 {
+		args = append(args, files(paths)...)
+	}
+	cmd := r.command(args...)
+	cmd.Stdin = strings.NewReader(message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("hg commit failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// user returns the author of commits, "Name <email>", or "" to keep
+// ui.username
+func (r *Repo) user() string {
+	if r.authorName == "" && r.authorEmail == "" {
+		return ""
+	}
+	name, email := r.Identity()
+	if r.authorName != "" {
+		name = r.authorName
+	}
+	if r.authorEmail != "" {
+		email = r.authorEmail
+	}
+	return fmt.Sprintf("%s <%s>", name, email)
+}
+
+// Identity returns the name and email of hg's ui.username
+func (r *Repo) Identity() (name, email string) {
+	output, err := r.command("config", "ui.username").Output()
+	if err != nil {
+		return "", ""
+	}
+	user := strings.TrimSpace(string(output))
+	if open := strings.IndexByte(user, '<'); open >= 0 {
+		return strings.TrimSpace(user[:open]), strings.Trim(strings.TrimSpace(user[open:]), "<>")
+	}
+	return user, ""
+}
+
+// unfinished lists operations that leave state in .hg until they are
+// continued or aborted
+var unfinished = []struct {
+	path, name, resolve string
+}{
+	{"merge/state", "merge", "hg resolve' and 'hg commit', or 'hg merge --abort"},
+	{"rebasestate", "rebase", "hg rebase --continue' or 'hg rebase --abort"},
+	{"histedit-state", "histedit", "hg histedit --continue' or 'hg histedit --abort"},
+	{"graftstate", "graft", "hg graft --continue' or 'hg graft --abort"},
+	{"updatestate", "update", "hg update"},
+}
+
+// CheckState returns an error explaining what to do when the repository
+// is in the middle of a merge, rebase, histedit, graft or update, which a
+// commit would record half-finished
+func (r *Repo) CheckState() error {
+	for _, op := range unfinished {
+		if _, err := os.Stat(filepath.Join(r.root, ".hg", filepath.FromSlash(op.path))); err == nil {
+			return fmt.Errorf("a %s is in progress; finish it with '%s' first", op.name, op.resolve)
+		}
+	}
+	return nil
+}
+
+// Unborn reports whether the repository has no commits yet
+func (r *Repo) Unborn() bool {
+	output, err := r.command("log", "--rev", ".", "--template", "{rev}").Output()
+	return err != nil || strings.TrimSpace(string(output)) == "-1"
+}
+
+// Log returns up to limit ancestors of the working directory's parent,
+// newest first. A repository without commits has an empty log.
+func (r *Repo) Log(limit int) ([]git.LogEntry, error) {
+	if r.Unborn() {
+		return nil, nil
+	}
+	output, err := r.command("log", "--rev", "reverse(::.)", "--limit", strconv.Itoa(limit),
+		"--template", `{node}\x1f{desc|firstline}\x1f{date|hgdate}\x1f{p1rev} {p2rev}\n`).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hg log: %w", err)
+	}
+	
+	var entries []git.LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		date := strings.Fields(fields[2]) // Unix time and offset
+		if len(date) == 0 {
+			continue
+		}
+		unix, err := strconv.ParseInt(date[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		parents := 0
+		for _, rev := range strings.Fields(fields[3]) {
+			if rev != "-1" {
+				parents++
+			}
+		}
+		entries = append(entries, git.LogEntry{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Time:    time.Unix(unix, 0),
+			Parents: parents,
+		})
+	}
+	return entries, nil
+}
+
+// diffstatLine matches the summary hg diff --stat ends with
+var diffstatLine = regexp.MustCompile(`(\d+) insertions?\(\+\)|(\d+) deletions?\(-\)`)
+
+// diffstat returns the lines added and deleted by hg diff --stat args
+func (r *Repo) diffstat(args ...string) (int, int, error) {
+	output, err := r.command(append([]string{"diff", "--stat"}, args...)...).Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read hg diff: %w", err)
+	}
+	added, deleted := 0, 0
+	for _, m := range diffstatLine.FindAllStringSubmatch(string(output), -1) {
+		if m[1] != "" {
+			added, _ = strconv.Atoi(m[1])
+		} else {
+			deleted, _ = strconv.Atoi(m[2])
+		}
+	}
+	return added, deleted, nil
+}
+
+// LastCommitLines returns the lines added and deleted by the commit the
+// working directory is on
+func (r *Repo) LastCommitLines() (int, int, error) {
+	return r.diffstat("--change", ".")
+}
+
+// LinesSince returns the lines added and deleted between commit and the
+// commit the working directory is on
+func (r *Repo) LinesSince(commit string) (int, int, error) {
+	return r.diffstat("--rev", commit, "--rev", ".")
+}
+
+// MergeBase returns the common ancestor of the working directory's parent
+// and ref
+func (r *Repo) MergeBase(ref string) (string, error) {
+	output, err := r.run("log", "--rev", fmt.Sprintf("ancestor(., %s)", revsymbol(ref)), "--template", "{node}")
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base with %s: %w", ref, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// AheadBehind counts the commits on the working directory's parent that
+// ref lacks, and those on ref that it lacks
+func (r *Repo) AheadBehind(ref string) (ahead, behind int, err error) {
+	count := func(revset string) (int, error) {
+		output, err := r.run("log", "--rev", revset, "--template", "x")
+		return len(strings.TrimSpace(output)), err
+	}
+	if ahead, err = count(fmt.Sprintf("only(., %s)", revsymbol(ref))); err != nil {
+		return 0, 0, fmt.Errorf("failed to compare with %s: %w", ref, err)
+	}
+	if behind, err = count(fmt.Sprintf("only(%s, .)", revsymbol(ref))); err != nil {
+		return 0, 0, fmt.Errorf("failed to compare with %s: %w", ref, err)
+	}
+	return ahead, behind, nil
+}
+
+// revsymbol quotes name for use in a revset
+func revsymbol(name string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(name) + "'"
+}
+
+// CurrentBranch returns the active bookmark, or else the named branch of
+// the working directory
+func (r *Repo) CurrentBranch() string {
+	if output, err := r.command("log", "--rev", ".", "--template", "{activebookmark}").Output(); err == nil {
+		if bookmark := strings.TrimSpace(string(output)); bookmark != "" {
+			return bookmark
+		}
+	}
+	output, err := r.command("branch").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// DefaultBranch returns the named branch Mercurial starts with
+func (r *Repo) DefaultBranch() (string, error) {
+	return "default", nil
+}
+
+// BranchExists reports whether a bookmark or named branch called name
+// exists
+func (r *Repo) BranchExists(name string) bool {
+	output, err := r.command("log", "--rev", fmt.Sprintf("bookmark(%s) or branch(%s)", revsymbol(name), revsymbol(name)), "--limit", "1", "--template", "x").Output()
+	return err == nil && len(output) > 0
+}
+
+// Push pushes the commits of the working directory's parent to the
+// default path
+func (r *Repo) Push() error {
+	output, err := r.command("push", "--rev", ".").CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	out := string(output)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && strings.Contains(out, "no changes found") {
+		return nil // Already pushed
+	}
+	if strings.Contains(out, "push creates new remote head") {
+		return fmt.Errorf("hg push failed: %w: %s", git.ErrPushRejected, strings.TrimSpace(out))
+	}
+	return fmt.Errorf("hg push failed: %w: %s", err, strings.TrimSpace(out))
+}
+
+// VerifyPush asks the default path with hg outgoing whether it lacks any
+// commit of the working directory's parent
+func (r *Repo) VerifyPush() error {
+	output, err := r.command("outgoing", "--rev", ".", "--quiet", "--template", "{node|short}\n").CombinedOutput()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return nil // Nothing outgoing
+	case err != nil:
+		return fmt.Errorf("failed to compare with the remote: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return fmt.Errorf("%w: the remote lacks %s", git.ErrPushNotLanded, strings.Join(strings.Fields(string(output)), ", "))
+}
+
+// RepoID returns the autogit ID stored in the repository's .hg/hgrc under
+// autogit.id, or "" when it has none
+func (r *Repo) RepoID() string {
+	output, err := r.command("config", "autogit.id").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// SetRepoID stores id in the repository's .hg/hgrc under autogit.id
+func (r *Repo) SetRepoID(id string) error {
+	path := filepath.Join(r.root, ".hg", "hgrc")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to set autogit.id: %w", err)
+	}
+	
+	// Replace the id of an [autogit] section, or add the section
+	var lines []string
+	section, set := "", false
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = trimmed
+		} else if key, _, ok := strings.Cut(trimmed, "="); ok && section == "[autogit]" && strings.TrimSpace(key) == "id" {
+			line, set = "id = "+id, true
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		lines = nil
+	}
+	if !set {
+		lines = append(lines, "[autogit]", "id = "+id)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to set autogit.id: %w", err)
+	}
+	return nil
+}
+
+// DetectMode finds nothing: sparse and partial clones are git features
+func (r *Repo) DetectMode() (git.Mode, error) {
+	return git.Mode{}, nil
+}
+
+// UseMode does nothing, as DetectMode finds nothing
+func (r *Repo) UseMode(m git.Mode) {}
+
+// UseQuiet does nothing: hg's output is always captured
+func (r *Repo) UseQuiet() {}
+
+// LFSFiles finds none: Git LFS is a git feature
+func (r *Repo) LFSFiles(files []string) ([]string, error) {
+	return nil, nil
+}
+
+// LFSReady reports nothing missing, as LFSFiles finds no LFS files
+func (r *Repo) LFSReady() error {
+	return nil
+}
+
+// SoftReset is not supported: autogit does not rewrite hg history
+func (r *Repo) SoftReset(ref string) error {
+	return fmt.Errorf("%w: squashing commits in Mercurial", vcs.ErrUnsupported)
+}
+
+// PushForce is not supported, as nothing is rewritten
+func (r *Repo) PushForce() error {
+	return fmt.Errorf("%w: pushing rewritten history in Mercurial", vcs.ErrUnsupported)
+}
+
+// CreateBundle is not supported, as nothing is rewritten
+func (r *Repo) CreateBundle(path, base string) error {
+	return fmt.Errorf("%w: backups of Mercurial history", vcs.ErrUnsupported)
+}
+
+// CheckoutNew is not supported: the shared-branch guard is git only
+func (r *Repo) CheckoutNew(name string) error {
+	return fmt.Errorf("%w: moving work to branch %s in Mercurial", vcs.ErrUnsupported, name)
+}
+
+// RewindToUpstream is not supported, like CheckoutNew
+func (r *Repo) RewindToUpstream(branch string) error {
+	return fmt.Errorf("%w: rewinding %s in Mercurial", vcs.ErrUnsupported, branch)
+}
+
+// FetchUpstream is not supported, like CheckoutNew
+func (r *Repo) FetchUpstream() error {
+	return fmt.Errorf("%w: fetching in Mercurial", vcs.ErrUnsupported)
+}
+
+// ForeignAuthors is not supported, like CheckoutNew
+func (r *Repo) ForeignAuthors() ([]string, error) {
+	return nil, fmt.Errorf("%w: finding other authors in Mercurial", vcs.ErrUnsupported)
+}
+
+// Refs is not supported: mirrors are git only
+func (r *Repo) Refs() (string, error) {
+	return "", fmt.Errorf("%w: mirrors of Mercurial repositories", vcs.ErrUnsupported)
+}
+
+// PushMirror is not supported, like Refs
+func (r *Repo) PushMirror(target string, bundle bool) error {
+	return fmt.Errorf("%w: mirrors of Mercurial repositories", vcs.ErrUnsupported)
+}
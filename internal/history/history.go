@@ -0,0 +1,163 @@
+// Package history records the commits made by the daemon in a Store, by
+// default one JSON Lines file per repository. The daemon of the repository
+// is its only writer and appends each entry with a single write. Readers
+// such as 'autogit why' open the file read-only, even while the daemon
+// runs, and take only complete lines, so they never see a partly written
+// entry and need no lock or connection to the daemon.
+//
+// The SQLite backend keeps every repository in one database in WAL mode,
+// which all daemons write to. Each entry is inserted in a transaction of
+// its own, and a daemon waits up to sqliteBusyTimeout while another one
+// writes. Readers open the database with OpenReadOnly, which uses mode=ro:
+// they never take the write lock, are never blocked by the daemons, and
+// see the entries committed when their query started, never part of one.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// What started the cycle that made a commit
+const (
+	TriggerInterval = "interval" // The check interval elapsed
+	TriggerWatch    = "watch"    // Files changed in immediate mode
+	TriggerMarker   = "marker"   // A checkpoint marker was added
+	TriggerResume   = "resume"   // 'autogit resume' ended a pause
+	TriggerRequest  = "trigger"  // 'autogit trigger' asked for a check, e.g. on save
+	TriggerManual   = "manual"   // Committed by the user from the dashboard
+)
+
+// Message sources
+const (
+	SourceAI        = "ai"        // Generated by the AI provider
+	SourceHeuristic = "heuristic" // Generated locally
+	SourceFallback  = "fallback"  // Generated locally after the AI stage ran out of time
+	SourceResumed   = "resumed"   // Reused from a cycle interrupted by a restart
+	SourceMarker    = "marker"    // Written by the user in an autogit: marker
+	SourceManual    = "manual"    // Edited by the user before a manual commit
+)
+
+// Entry records one commit made by the daemon
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Commit   string    `json:"commit"`
+	Message  string    `json:"message"`
+	Files    []string  `json:"files"` // Relative to the repository root, slash-separated
+	Trigger  string    `json:"trigger"`
+	Source   string    `json:"source"`
+	Provider string    `json:"provider,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	Approval string    `json:"approval,omitempty"` // Who approved the sensitive changes it includes
+}
+
+// Files is the Store that keeps the history of each repository in a JSON
+// Lines file in the history directory
+type Files struct{}
+
+// Append adds entry to the history of repoName. The history is a JSON
+// Lines file, so an interrupted write loses at most the last entry.
+func (Files) Append(repoName string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	
+	path := config.GetHistoryPath(repoName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+	
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Load returns the history of repoName, oldest first. A last line without
+// newline is an entry still being written and is left out; other lines
+// that cannot be parsed, such as one cut short by a crash, are skipped.
+func (Files) Load(repoName string) ([]Entry, error) {
+	f, err := os.Open(config.GetHistoryPath(repoName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+	
+	var entries []Entry
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	
+	return entries, nil
+}
+
+// Rename moves the history file of oldName to newName, unless newName
+// already has one
+func (Files) Rename(oldName, newName string) error {
+	oldPath, newPath := config.GetHistoryPath(oldName), config.GetHistoryPath(newName)
+	if _, err := os.Stat(newPath); err == nil || oldPath == newPath {
+		return nil
+	}
+	if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to move history: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the history file of repoName
+func (Files) Remove(repoName string) error {
+	if err := os.Remove(config.GetHistoryPath(repoName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove history: %w", err)
+	}
+	return nil
+}
+
+func (Files) Close() error {
+	return nil
+}
+
+// Touching returns the entries whose commits changed path, or any file
+// below it when path is a directory
+func Touching(entries []Entry, path string) []Entry {
+	path = strings.TrimSuffix(filepath.ToSlash(path), "/")
+	
+	var matches []Entry
+	for _, e := range entries {
+		for _, f := range e.Files {
+			if f == path || path == "." || strings.HasPrefix(f, path+"/") {
+				matches = append(matches, e)
+				break
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,163 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver, without cgo
+)
+
+// sqliteBusyTimeout is how long a write waits for another process that
+// holds the database, such as a second daemon appending at the same time
+const sqliteBusyTimeout = 5 * time.Second
+
+// sqliteSchema keeps each entry as JSON, so that new Entry fields need no
+// migration; time is a column of its own for queries across repositories
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS history (
+	id    INTEGER PRIMARY KEY AUTOINCREMENT,
+	repo  TEXT NOT NULL,
+	time  TEXT NOT NULL,
+	entry TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS history_repo ON history (repo, id);
+`
+
+// errNoDatabase is returned by writes to a read-only store whose database
+// did not exist when it was opened
+var errNoDatabase = errors.New("the history database does not exist")
+
+// SQLite is the Store that keeps the history of every repository in one
+// SQLite database, which all daemons of the config directory share. The
+// database is in WAL mode, so readers do not block the daemons.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database at path, creating it if needed
+func OpenSQLite(path string) (*SQLite, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+	dsn := sqliteDSN(path, fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)", sqliteBusyTimeout.Milliseconds()))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+// OpenSQLiteReadOnly opens the database at path for reading only, with
+// mode=ro, so that readers never create it or change its schema. A
+// database that does not exist yet reads as empty.
+func OpenSQLiteReadOnly(path string) (*SQLite, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &SQLite{}, nil
+	}
+	dsn := sqliteDSN(path, fmt.Sprintf("mode=ro&_pragma=busy_timeout(%d)", sqliteBusyTimeout.Milliseconds()))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+// sqliteDSN returns the URI of the database at path with query. The path is
+// escaped, so that names with ?, # or % open the file they name.
+func sqliteDSN(path, query string) string {
+	uri := filepath.ToSlash(path)
+	if filepath.VolumeName(path) != "" {
+		uri = "/" + uri // file:///C:/...
+	}
+	return (&url.URL{Scheme: "file", Path: uri, RawQuery: query}).String()
+}
+
+func (s *SQLite) Append(repoName string, entry Entry) error {
+	if s.db == nil {
+		return errNoDatabase
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	if _, err := s.db.Exec("INSERT INTO history (repo, time, entry) VALUES (?, ?, ?)", repoName, entry.Time.UTC().Format(time.RFC3339Nano), string(data)); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Load returns the history of repoName, oldest first. Rows that cannot be
+// parsed are skipped, like broken lines of a history file.
+func (s *SQLite) Load(repoName string) ([]Entry, error) {
+	if s.db == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query("SELECT entry FROM history WHERE repo = ? ORDER BY id", repoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer rows.Close()
+	
+	var entries []Entry
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// Rename moves the history of oldName to newName, unless newName already
+// has one
+func (s *SQLite) Rename(oldName, newName string) error {
+	if oldName == newName {
+		return nil
+	}
+	if s.db == nil {
+		return errNoDatabase
+	}
+	if _, err := s.db.Exec("UPDATE history SET repo = ? WHERE repo = ? AND NOT EXISTS (SELECT 1 FROM history WHERE repo = ?)", newName, oldName, newName); err != nil {
+		return fmt.Errorf("failed to move history: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLite) Remove(repoName string) error {
+	if s.db == nil {
+		return errNoDatabase
+	}
+	if _, err := s.db.Exec("DELETE FROM history WHERE repo = ?", repoName); err != nil {
+		return fmt.Errorf("failed to remove history: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLite) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,72 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestSQLiteEscapesPath opens databases whose paths hold characters with a
+// meaning in URIs, and checks that each is created at the path it names
+func TestSQLiteEscapesPath(t *testing.T) {
+	for _, name := range []string{"plain", "what?mode=ro", "50% #1", "with space"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name, "autogit.db")
+			store, err := OpenSQLite(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			
+			entry := Entry{Time: time.Now(), Commit: "abc123", Message: "Add main", Files: []string{"main.go"}}
+			if err := store.Append("app", entry); err != nil {
+				t.Fatal(err)
+			}
+			entries, err := store.Load("app")
+			if err != nil || len(entries) != 1 || entries[0].Commit != "abc123" {
+				t.Errorf("Load() = %+v, %v, want the appended entry", entries, err)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("database is not at %s: %v", path, err)
+			}
+		})
+	}
+}
+
+// TestSQLiteReadOnly checks that a reader neither creates the database nor
+// writes to it, and sees what the daemon appended
+func TestSQLiteReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "autogit.db")
+	reader, err := OpenSQLiteReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := reader.Load("app"); err != nil || len(entries) != 0 {
+		t.Errorf("Load() before the database exists = %+v, %v, want nothing", entries, err)
+	}
+	reader.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("the reader created the database: %v", err)
+	}
+	
+	writer, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if err := writer.Append("app", Entry{Time: time.Now(), Commit: "abc123"}); err != nil {
+		t.Fatal(err)
+	}
+	reader, err = OpenSQLiteReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if entries, err := reader.Load("app"); err != nil || len(entries) != 1 {
+		t.Errorf("Load() = %+v, %v, want the appended entry", entries, err)
+	}
+	if err := reader.Append("app", Entry{Time: time.Now(), Commit: "def456"}); err == nil {
+		t.Errorf("Append() through the reader succeeded")
+	}
+}
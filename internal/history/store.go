@@ -0,0 +1,48 @@
+package history
+
+import (
+	"fmt"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// Store keeps the commit history of every repository. Files and SQLite are
+// built in; another backend, such as a team server that collects the
+// history of several machines, only has to implement Store as well. Only
+// the history goes through a Store: statistics, cycle tokens, approvals,
+// health, pauses, triggers and shared-branch state stay in files of the
+// config directory.
+type Store interface {
+	// Append adds entry to the history of repoName
+	Append(repoName string, entry Entry) error
+	// Load returns the history of repoName, oldest first, or nothing when
+	// none was recorded
+	Load(repoName string) ([]Entry, error)
+	// Rename moves the history of a repository that was relinked to a path
+	// with another name
+	Rename(oldName, newName string) error
+	// Remove deletes the history of repoName
+	Remove(repoName string) error
+	Close() error
+}
+
+// Open returns the store that storage selects
+func Open(storage config.Storage) (Store, error) {
+	switch storage.GetBackend() {
+	case config.StorageFile:
+		return Files{}, nil
+	case config.StorageSQLite:
+		return OpenSQLite(storage.GetPath())
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", storage.Backend)
+}
+
+// OpenReadOnly returns the store that storage selects for readers such as
+// 'autogit why' and 'autogit serve', which never create or change the
+// SQLite database
+func OpenReadOnly(storage config.Storage) (Store, error) {
+	if storage.GetBackend() == config.StorageSQLite {
+		return OpenSQLiteReadOnly(storage.GetPath())
+	}
+	return Open(storage)
+}
@@ -0,0 +1,182 @@
+// Package ignore spots changes that look like build output or caches and
+// keeps the .gitignore entries suggested for them until the user adds or
+// dismisses them.
+package ignore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// Threshold is how many checks must see changes covered by an entry before
+// it is suggested, so that a one-off file is not mistaken for build output
+const Threshold = 3
+
+// generatedDirs hold build output or caches wherever they are in the tree
+var generatedDirs = []string{"dist", "build", "node_modules", "__pycache__", ".pytest_cache", ".mypy_cache", ".gradle", ".next", ".parcel-cache", ".sass-cache"}
+
+// generatedFiles are names of compiled objects and editor or OS leftovers
+var generatedFiles = []string{"*.o", "*.obj", "*.pyc", "*.pyo", "*.class", "*.swp", ".DS_Store", "Thumbs.db"}
+
+// EntryFor returns the .gitignore entry covering file when it looks
+// generated, e.g. "dist/" or "*.pyc", or "" when it does not
+func EntryFor(file string) string {
+	parts := strings.Split(filepath.ToSlash(file), "/")
+	for _, dir := range parts[:len(parts)-1] {
+		for _, d := range generatedDirs {
+			if dir == d {
+				return d + "/"
+			}
+		}
+	}
+	name := parts[len(parts)-1]
+	for _, g := range generatedFiles {
+		if ok, _ := path.Match(g, name); ok {
+			return g
+		}
+	}
+	return ""
+}
+
+// Entries returns the .gitignore entries covering the generated files
+// among files, sorted
+func Entries(files []string) []string {
+	seen := make(map[string]bool)
+	var entries []string
+	for _, file := range files {
+		if e := EntryFor(file); e != "" && !seen[e] {
+			seen[e] = true
+			entries = append(entries, e)
+		}
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// Pathspec returns a git pathspec for the files entry covers anywhere in
+// the tree, to untrack them once they are ignored
+func Pathspec(entry string) string {
+	if dir, ok := strings.CutSuffix(entry, "/"); ok {
+		return ":(top,glob)**/" + dir + "/**"
+	}
+	return ":(top,glob)**/" + entry
+}
+
+// State is what the daemon learned about one repository, kept in the config
+// directory between runs
+type State struct {
+	Seen      map[string]int `json:"seen,omitempty"`      // Checks that saw changes covered by each entry
+	Suggested []string       `json:"suggested,omitempty"` // Entries waiting for the user
+	Dismissed []string       `json:"dismissed,omitempty"` // Entries the user declined, never suggested again
+}
+
+// Load returns the state of repoName, empty when there is none yet
+func Load(repoName string) (*State, error) {
+	data, err := os.ReadFile(config.GetIgnorePath(repoName))
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .gitignore suggestions: %w", err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse .gitignore suggestions: %w", err)
+	}
+	return &s, nil
+}
+
+func (s *State) Save(repoName string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal .gitignore suggestions: %w", err)
+	}
+	path := config.GetIgnorePath(repoName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create suggestion directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write .gitignore suggestions: %w", err)
+	}
+	return nil
+}
+
+// Observe counts one check that saw changes covered by entries and
+// returns the entries that became suggestions with it
+func (s *State) Observe(entries []string) []string {
+	var added []string
+	for _, e := range entries {
+		if contains(s.Suggested, e) || contains(s.Dismissed, e) {
+			continue
+		}
+		if s.Seen == nil {
+			s.Seen = make(map[string]int)
+		}
+		s.Seen[e]++
+		if s.Seen[e] >= Threshold {
+			delete(s.Seen, e)
+			s.Suggested = append(s.Suggested, e)
+			added = append(added, e)
+		}
+	}
+	return added
+}
+
+// Dismiss stops suggesting the current suggestions
+func (s *State) Dismiss() {
+	s.Dismissed = append(s.Dismissed, s.Suggested...)
+	s.Suggested = nil
+}
+
+// Apply appends entries to the .gitignore at the root of a work tree,
+// skipping those it already has
+func Apply(root string, entries []string) error {
+	path := filepath.Join(root, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	existing := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	
+	var b strings.Builder
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		b.WriteString("\n")
+	}
+	for _, e := range entries {
+		if !contains(existing, e) {
+			b.WriteString(e + "\n")
+		}
+	}
+	if b.Len() == 0 || b.String() == "\n" {
+		return nil
+	}
+	
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open .gitignore: %w", err)
+	}
+	_, err = f.WriteString(b.String())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write .gitignore: %w", err)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
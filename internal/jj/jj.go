@@ -0,0 +1,264 @@
+package jj
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/vcs"
+)
+
+// Repo commits through Jujutsu (jj) in a repository colocated with git,
+// where .jj and .git share the work tree. Status, diffs and the log are
+// read through git, which jj keeps at the parent of the working-copy change
+// (@-). Commits and pushes go through jj, so that its working copy and
+// bookmarks stay consistent: a commit describes the changes and starts a
+// new working-copy change, then moves the bookmark of the branch along.
+type Repo struct {
+	*git.Repo
+	selected    []string // Files AddAll or AddPaths picked for the next Commit
+	authorName  string   // Replaces user.name as the author of commits, when set
+	authorEmail string   // Replaces user.email likewise
+}
+
+var _ vcs.Repo = (*Repo)(nil)
+
+// Open returns the Jujutsu repository colocated with repo
+func Open(repo *git.Repo) *Repo {
+	return &Repo{Repo: repo}
+}
+
+// Detect reports whether rootPath is a Jujutsu repository
+func Detect(rootPath string) bool {
+	info, err := os.Stat(filepath.Join(rootPath, ".jj"))
+	return err == nil && info.IsDir()
+}
+
+// Colocated reports whether the Jujutsu repository at rootPath shares its
+// work tree with a git repository, as 'jj git init --colocate' sets up
+func Colocated(rootPath string) bool {
+	_, err := os.Stat(filepath.Join(rootPath, ".git"))
+	return Detect(rootPath) && err == nil
+}
+
+// Version returns the version of the jj on PATH, e.g. "0.25.0"
+func Version() (string, error) {
+	output, err := exec.Command("jj", "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run jj: %w", err)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), "jj "), nil
+}
+
+// UseAuthor attributes subsequent commits on r to name and email. Either
+// may be empty to keep the user.name or user.email of git's config.
+func (r *Repo) UseAuthor(name, email string) {
+	r.authorName, r.authorEmail = name, email
+}
+
+// command builds a jj command for the repository. jj snapshots the work
+// tree into the working-copy change first, so new files need no adding.
+func (r *Repo) command(args ...string) *exec.Cmd {
+	cmd := exec.Command("jj", append([]string{"--repository", r.Root(), "--no-pager", "--color=never"}, args...)...)
+	cmd.Dir = r.Root()
+	return cmd
+}
+
+// run runs jj with args and returns what it printed
+func (r *Repo) run(args ...string) (string, error) {
+	output, err := r.command(args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("jj %s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// AddAll picks the changes the next Commit records, leaving out exclude.
+// Nothing is staged: jj has no index.
+func (r *Repo) AddAll(exclude ...string) error {
+	files, err := r.ChangedFiles(exclude...)
+	if err != nil {
+		return err
+	}
+	r.selected = files
+	return nil
+}
+
+// AddPaths picks paths as the changes the next Commit records
+func (r *Repo) AddPaths(paths []string) error {
+	r.selected = append([]string(nil), paths...)
+	return nil
+}
+
+// Commit records the changes AddAll or AddPaths picked, or the whole
+// working-copy change when neither was called
+func (r *Repo) Commit(message string) error {
+	paths := r.selected
+	r.selected = nil
+	return r.CommitPaths(message, paths)
+}
+
+// CommitPaths records the changes to paths with jj commit, which leaves
+// the other changes in a new working-copy change, and moves the bookmark
+// of the branch onto the commit
+func (r *Repo) CommitPaths(message string, paths []string) error {
+	bookmark := r.CurrentBranch()
+	args := []string{"commit", "--message", message}
+	if author := r.author(); author != "" {
+		args = append(args, "--author", author)
+	}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		for _, p := range paths {
+			args = append(args, fileset(p))
+		}
+	}
+	if _, err := r.run(args...); err != nil {
+		return err
+	}
+	
+	if bookmark == "" {
+		return nil // Nothing to move; Push reports it
+	}
+	if _, err := r.run("bookmark", "set", bookmark, "--revision", "@-"); err != nil {
+		return fmt.Errorf("failed to move bookmark %s to the commit: %w", bookmark, err)
+	}
+	return nil
+}
+
+// author returns the author of commits as jj takes it, "Name <email>", or
+// "" to keep jj's user settings
+func (r *Repo) author() string {
+	if r.authorName == "" && r.authorEmail == "" {
+		return ""
+	}
+	name, email := r.Identity()
+	if r.authorName != "" {
+		name = r.authorName
+	}
+	if r.authorEmail != "" {
+		email = r.authorEmail
+	}
+	return fmt.Sprintf("%s <%s>", name, email)
+}
+
+// fileset matches exactly the file at path, relative to the root, however
+// it is named
+func fileset(path string) string {
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(filepath.ToSlash(path))
+	return `root-file:"` + quoted + `"`
+}
+
+// CurrentBranch returns the bookmark the branch is on: the local bookmark
+// nearest to the parent of the working-copy change, or "" when no
+// ancestor has one. git itself sees a detached HEAD.
+func (r *Repo) CurrentBranch() string {
+	output, err := r.run("log", "--no-graph", "--revisions", "latest(::@- & bookmarks())",
+		"--template", `local_bookmarks.map(|b| b.name()).join("\n")`)
+	if err != nil {
+		return ""
+	}
+	names := strings.Fields(output)
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// CheckState adds the conflicts jj records in the working-copy change to
+// what git's CheckState finds
+func (r *Repo) CheckState() error {
+	if err := r.Repo.CheckState(); err != nil {
+		return err
+	}
+	output, err := r.run("log", "--no-graph", "--revisions", "@", "--template", `if(conflict, "conflict")`)
+	if err != nil {
+		return fmt.Errorf("failed to inspect repository: %w", err)
+	}
+	if strings.TrimSpace(output) == "conflict" {
+		return errors.New("the working-copy change has unresolved conflicts; resolve them with 'jj resolve' first")
+	}
+	return nil
+}
+
+// Push pushes the bookmark of the branch with jj git push, which refuses
+// to overwrite remote changes jj has not seen
+func (r *Repo) Push() error {
+	bookmark := r.CurrentBranch()
+	if bookmark == "" {
+		return errors.New("cannot push: no bookmark to push; create one with 'jj bookmark create <name> --revision @-'")
+	}
+	
+	output, err := r.command("git", "push", "--bookmark", bookmark, "--allow-new").CombinedOutput()
+	if err != nil {
+		out := string(output)
+		if strings.Contains(out, "unexpectedly moved on the remote") || strings.Contains(out, "stale info") {
+			return fmt.Errorf("jj git push failed: %w: %s", git.ErrPushRejected, strings.TrimSpace(out))
+		}
+		return fmt.Errorf("jj git push failed: %w: %s", err, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// PushForce is Push: jj pushes rewritten bookmarks only when the remote
+// is where jj last saw it
+func (r *Repo) PushForce() error {
+	return r.Push()
+}
+
+// VerifyPush fetches the bookmark of the branch from the remote with git
+// and checks that the commit jj pushed is on it, as the tip or under
+// commits pushed since
+func (r *Repo) VerifyPush() error {
+	bookmark := r.CurrentBranch()
+	if bookmark == "" {
+		return fmt.Errorf("no bookmark to verify the push against")
+	}
+	output, err := r.run("log", "--no-graph", "--revisions", fmt.Sprintf("bookmarks(exact:%q)", bookmark), "--template", "commit_id")
+	if err != nil {
+		return fmt.Errorf("failed to read bookmark %s: %w", bookmark, err)
+	}
+	head := strings.TrimSpace(output)
+	
+	remote := "origin"
+	if output, err := r.run("config", "get", "git.push"); err == nil && strings.TrimSpace(output) != "" {
+		remote = strings.TrimSpace(output)
+	}
+	ref := "refs/heads/" + bookmark
+	cmd := exec.Command("git", "-C", r.Root(), "fetch", "-q", "--no-tags", remote, ref)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if strings.Contains(string(out), "couldn't find remote ref") {
+			return fmt.Errorf("%w: %s has no %s", git.ErrPushNotLanded, remote, ref)
+		}
+		return fmt.Errorf("failed to fetch %s from %s: %w: %s", ref, remote, err, strings.TrimSpace(string(out)))
+	}
+	if exec.Command("git", "-C", r.Root(), "merge-base", "--is-ancestor", head, "FETCH_HEAD").Run() == nil {
+		return nil
+	}
+	out, err := exec.Command("git", "-C", r.Root(), "rev-parse", "FETCH_HEAD").Output()
+	if err != nil {
+		return fmt.Errorf("failed to read the fetched %s: %w", ref, err)
+	}
+	return fmt.Errorf("%w: %s %s is at %.7s, not %.7s", git.ErrPushNotLanded, remote, ref, strings.TrimSpace(string(out)), head)
+}
+
+// SoftReset is not supported: squash changes with jj squash instead
+func (r *Repo) SoftReset(ref string) error {
+	return fmt.Errorf("%w: squashing commits; use 'jj squash'", vcs.ErrUnsupported)
+}
+
+// CheckoutNew is not supported: moving work to another branch would leave
+// two bookmarks on one change
+func (r *Repo) CheckoutNew(name string) error {
+	return fmt.Errorf("%w: moving work to branch %s; use 'jj bookmark'", vcs.ErrUnsupported, name)
+}
+
+// RewindToUpstream is not supported, like CheckoutNew
+func (r *Repo) RewindToUpstream(branch string) error {
+	return fmt.Errorf("%w: rewinding %s; use 'jj bookmark set --allow-backwards'", vcs.ErrUnsupported, branch)
+}
@@ -0,0 +1,94 @@
+// Package logdest opens where a daemon writes its log: a file, the local
+// syslog daemon or the systemd journal.
+package logdest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// Identifier names autogit in syslog and the journal
+const Identifier = "autogit"
+
+// journalSocket is where journald accepts native protocol datagrams
+const journalSocket = "/run/systemd/journal/socket"
+
+// Open returns the writer for a daemon log at destination and the flags
+// for its log.Logger. path is used for config.LogToFile and repoName tags
+// the entries elsewhere.
+func Open(destination, path, repoName string) (io.WriteCloser, int, error) {
+	switch destination {
+	case config.LogToSyslog:
+		w, err := openSyslog(repoName)
+		return w, 0, err // syslog adds its own timestamps
+	case config.LogToJournald:
+		w, err := openJournal(repoName)
+		return w, 0, err
+	default:
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, 0, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open log file: %w", err)
+		}
+		return f, log.LstdFlags, nil
+	}
+}
+
+// isError reports whether a log line reports an error, which the daemon
+// prefixes with "ERROR:"
+func isError(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("ERROR:"))
+}
+
+// journal writes each log line as one journal entry
+type journal struct {
+	conn     net.Conn
+	repoName string
+}
+
+func openJournal(repoName string) (io.WriteCloser, error) {
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return &journal{conn: conn, repoName: repoName}, nil
+}
+
+func (j *journal) Write(p []byte) (int, error) {
+	line := bytes.TrimRight(p, "\n")
+	priority := "6" // info
+	if isError(line) {
+		priority = "3" // err
+	}
+	
+	var entry bytes.Buffer
+	fmt.Fprintf(&entry, "PRIORITY=%s\nSYSLOG_IDENTIFIER=%s\nAUTOGIT_REPO=%s\n", priority, Identifier, j.repoName)
+	if bytes.IndexByte(line, '\n') < 0 {
+		fmt.Fprintf(&entry, "MESSAGE=%s\n", line)
+	} else {
+		// Multi-line values are sent with their length instead of "="
+		entry.WriteString("MESSAGE\n")
+		binary.Write(&entry, binary.LittleEndian, uint64(len(line)))
+		entry.Write(line)
+		entry.WriteByte('\n')
+	}
+	
+	if _, err := j.conn.Write(entry.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (j *journal) Close() error {
+	return j.conn.Close()
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logdest
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+func openSyslog(repoName string) (io.WriteCloser, error) {
+	return nil, fmt.Errorf("syslog is not available on %s, use log_destination \"file\"", runtime.GOOS)
+}
@@ -0,0 +1,41 @@
+//go:build !windows && !plan9
+
+package logdest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/syslog"
+)
+
+// syslogWriter sends error lines at error priority and the rest as info
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+func openSyslog(repoName string) (io.WriteCloser, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, Identifier+"/"+repoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &syslogWriter{w: w}, nil
+}
+
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	line := string(bytes.TrimRight(p, "\n"))
+	var err error
+	if isError(p) {
+		err = s.w.Err(line)
+	} else {
+		err = s.w.Info(line)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
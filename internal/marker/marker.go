@@ -0,0 +1,76 @@
+package marker
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Marker kinds
+const (
+	Checkpoint = "checkpoint" // Commit the file now, on its own
+	Message    = "message"    // Use the text as the commit message
+)
+
+// pattern matches a marker after a comment leader, e.g.
+//
+//	// autogit: checkpoint "wip on parser"
+//	# autogit: message "docs: explain the retry loop"
+var pattern = regexp.MustCompile(`(?://|#|--|;|/\*|<!--)\s*autogit:\s*(checkpoint|message)\b(?:\s+"([^"]*)")?`)
+
+// Marker is an instruction found in a line added to a file
+type Marker struct {
+	File string // Relative to the repository root, slash-separated
+	Kind string
+	Text string // Quoted text after the kind, may be empty
+}
+
+// Find returns the markers in lines added by diff, in diff order
+func Find(diff string) []Marker {
+	var markers []Marker
+	file := ""
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "+") && file != "":
+			if m := pattern.FindStringSubmatch(line); m != nil {
+				markers = append(markers, Marker{File: file, Kind: m[1], Text: strings.TrimSpace(m[2])})
+			}
+		}
+	}
+	return markers
+}
+
+// Checkpoints returns the files carrying a checkpoint marker, each once,
+// and the message given for each
+func Checkpoints(markers []Marker) (files []string, messages map[string]string) {
+	messages = make(map[string]string)
+	for _, m := range markers {
+		if m.Kind != Checkpoint {
+			continue
+		}
+		if _, ok := messages[m.File]; !ok {
+			files = append(files, m.File)
+			messages[m.File] = ""
+		}
+		if messages[m.File] == "" {
+			messages[m.File] = m.Text
+		}
+	}
+	return files, messages
+}
+
+// MessageFor returns the text of the first message marker in one of files,
+// or "" when there is none
+func MessageFor(markers []Marker, files []string) string {
+	in := make(map[string]bool, len(files))
+	for _, f := range files {
+		in[f] = true
+	}
+	for _, m := range markers {
+		if m.Kind == Message && m.Text != "" && in[m.File] {
+			return m.Text
+		}
+	}
+	return ""
+}
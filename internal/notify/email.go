@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// Mailer sends error notifications by email, at most one per problem
+// within the throttle period. A nil Mailer sends nothing.
+type Mailer struct {
+	cfg config.Email
+	
+	mu   sync.Mutex
+	sent map[string]time.Time // Last email by problem
+}
+
+// NewMailer returns a mailer for cfg, or nil when email is not configured
+func NewMailer(cfg config.Email) *Mailer {
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &Mailer{cfg: cfg, sent: make(map[string]time.Time)}
+}
+
+// EmailPushFailed reports that the daemon paused after a failed push
+func (m *Mailer) EmailPushFailed(repoName, errorMsg string) (bool, error) {
+	subject := fmt.Sprintf("Autogit paused: push failed in %s", repoName)
+	body := fmt.Sprintf("Pushing %s failed and the daemon has paused until it is restarted.\n\n%s\n", repoName, errorMsg)
+	return m.Send("push", subject, body)
+}
+
+// EmailPushNotLanded reports a push the remote does not have although git
+// reported it as successful
+func (m *Mailer) EmailPushNotLanded(repoName, detail string) (bool, error) {
+	subject := fmt.Sprintf("Autogit: push did not land in %s", repoName)
+	body := fmt.Sprintf("git push reported success for %s, but the remote does not have the commit:\n\n%s\n\nA proxy or server hook may have dropped the update. The daemon pushes again on the next check.\n", repoName, detail)
+	return m.Send("verify", subject, body)
+}
+
+// EmailMirrorFailed reports a failed push to the backup mirror
+func (m *Mailer) EmailMirrorFailed(repoName, target, errorMsg string) (bool, error) {
+	subject := fmt.Sprintf("Autogit: mirror failed in %s", repoName)
+	body := fmt.Sprintf("Autogit could not update the mirror of %s at %s:\n\n%s\n\nThe main remote is not affected. The daemon tries again on the next check.\n", repoName, target, errorMsg)
+	return m.Send("mirror", subject, body)
+}
+
+// EmailAIFailing reports repeated failures to generate a commit message
+func (m *Mailer) EmailAIFailing(repoName string, count int, errorMsg string) (bool, error) {
+	subject := fmt.Sprintf("Autogit: AI provider failing in %s", repoName)
+	body := fmt.Sprintf("The last %d attempts to generate a commit message for %s failed. Changes are not being committed.\n\nLast error: %s\n", count, repoName, errorMsg)
+	return m.Send("ai", subject, body)
+}
+
+// EmailAuthExpired reports that the provider rejected the API key
+func (m *Mailer) EmailAuthExpired(repoName, provider string) (bool, error) {
+	subject := fmt.Sprintf("Autogit: API key rejected in %s", repoName)
+	body := fmt.Sprintf("%s rejected the API key used for %s, which may have expired or been revoked. Commit messages are generated locally until the key is replaced.\n\nRun 'autogit reauth' to set a new key; running daemons pick it up without a restart.\n", provider, repoName)
+	return m.Send("auth", subject, body)
+}
+
+// Send emails subject and body to the recipients, unless an email about
+// the same problem, named by key, was sent within the throttle period. It
+// reports whether an email was sent.
+func (m *Mailer) Send(key, subject, body string) (bool, error) {
+	if m == nil {
+		return false, nil
+	}
+	
+	m.mu.Lock()
+	if last, ok := m.sent[key]; ok && time.Since(last) < m.cfg.GetThrottle() {
+		m.mu.Unlock()
+		return false, nil
+	}
+	m.sent[key] = time.Now()
+	m.mu.Unlock()
+	
+	if err := m.deliver(subject, body); err != nil {
+		// Let the next occurrence try again
+		m.mu.Lock()
+		delete(m.sent, key)
+		m.mu.Unlock()
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *Mailer) deliver(subject, body string) error {
+	host, _ := os.Hostname()
+	from := m.cfg.GetFrom()
+	
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(m.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	fmt.Fprintf(&msg, "\r\n-- \r\nautogit on %s\r\n", host)
+	
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.GetPort()))
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.GetPassword(), m.cfg.Host)
+	}
+	
+	// smtp.SendMail upgrades with STARTTLS when offered; port 465 expects
+	// TLS from the start
+	if m.cfg.GetPort() != 465 {
+		if err := smtp.SendMail(addr, auth, from, m.cfg.To, []byte(msg.String())); err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	}
+	
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: m.cfg.Host})
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer c.Close()
+	
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	for _, to := range m.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to send email to %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write([]byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return c.Quit()
+}
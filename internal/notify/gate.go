@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// Kinds of desktop notification
+const (
+	KindSuccess = "success" // A commit was pushed
+	KindError   = "error"   // Something needs the user's attention
+)
+
+// Gate applies the notification preferences: which kinds are shown, the
+// mute set by 'autogit mute' and the hourly limit
+type Gate struct {
+	prefs config.Notifications
+	
+	mu    sync.Mutex
+	shown []time.Time // Notifications shown within the last hour
+}
+
+func NewGate(prefs config.Notifications) *Gate {
+	return &Gate{prefs: prefs}
+}
+
+// Private reports whether notifications leave out commit messages and
+// file names, e.g. for people who share their screen
+func (g *Gate) Private() bool {
+	return g.prefs.Private
+}
+
+// Allow reports whether a notification of kind may be shown now, counting
+// it against the hourly limit when it may
+func (g *Gate) Allow(kind string) bool {
+	switch kind {
+	case KindSuccess:
+		if !g.prefs.OnSuccess {
+			return false
+		}
+	case KindError:
+		if !g.prefs.OnError {
+			return false
+		}
+	}
+	if mute, err := config.LoadMute(); err == nil && mute != nil {
+		return false
+	}
+	
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	
+	now := time.Now()
+	recent := g.shown[:0]
+	for _, t := range g.shown {
+		if now.Sub(t) < time.Hour {
+			recent = append(recent, t)
+		}
+	}
+	g.shown = recent
+	if g.prefs.MaxPerHour > 0 && len(g.shown) >= g.prefs.MaxPerHour {
+		return false
+	}
+	g.shown = append(g.shown, now)
+	return true
+}
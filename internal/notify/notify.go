@@ -2,6 +2,10 @@ package notify
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/timefmt"
 	"github.com/gen2brain/beeep"
 )
 
@@ -17,10 +21,123 @@ func NotifyError(repoName, errorMsg string) error {
 	return Notify(title, message)
 }
 
-// NotifySuccess sends a success notification
-func NotifySuccess(repoName, commitMsg string) error {
+// NotifySuccess sends a success notification for count new commits. With
+// private, their messages are left out.
+func NotifySuccess(repoName, commitMsg string, count int, private bool) error {
 	title := fmt.Sprintf("Autogit: Committed to %s", repoName)
 	message := fmt.Sprintf("Commit: %s", commitMsg)
+	if private {
+		message = fmt.Sprintf("%d new commit(s)", count)
+	}
+	return Notify(title, message)
+}
+
+
+// NotifyPushNotLanded reports a push git reported as successful that the
+// remote does not have
+func NotifyPushNotLanded(repoName, detail string) error {
+	title := fmt.Sprintf("Autogit: Push did not land in %s", repoName)
+	message := fmt.Sprintf("%s. A proxy or server hook may have dropped it; the daemon pushes again on the next check.", detail)
 	return Notify(title, message)
 }
 
+// NotifyMirrorFailed reports a failed push to the backup mirror
+func NotifyMirrorFailed(repoName, errorMsg string) error {
+	title := fmt.Sprintf("Autogit: Mirror failed in %s", repoName)
+	message := fmt.Sprintf("%s. The daemon tries again on the next check.", errorMsg)
+	return Notify(title, message)
+}
+
+// NotifyApproval reports changes held back until the user approves them.
+// With private, no file is named.
+func NotifyApproval(repoName string, files []string, private bool) error {
+	title := fmt.Sprintf("Autogit: Approval needed in %s", repoName)
+	example := ", e.g. " + files[0]
+	if private {
+		example = ""
+	}
+	message := fmt.Sprintf("%d sensitive change(s)%s. Run 'autogit approve' to commit them.", len(files), example)
+	return Notify(title, message)
+}
+
+// NotifyLFS reports changes to files tracked by Git LFS that are not
+// committed because LFS cannot store them. With private, no file is named.
+func NotifyLFS(repoName string, files []string, reason error, private bool) error {
+	title := fmt.Sprintf("Autogit: Git LFS files held in %s", repoName)
+	example := ", e.g. " + files[0] + ","
+	if private {
+		example = ""
+	}
+	message := fmt.Sprintf("%d change(s)%s are not committed: %v.", len(files), example, reason)
+	return Notify(title, message)
+}
+
+// NotifyIgnore suggests .gitignore entries for changes that keep appearing
+// in what looks like build output or caches. With private, only their
+// number is given.
+func NotifyIgnore(repoName string, entries []string, private bool) error {
+	title := fmt.Sprintf("Autogit: .gitignore suggestion for %s", repoName)
+	paths := strings.Join(entries, ", ")
+	if private {
+		paths = fmt.Sprintf("%d path(s)", len(entries))
+	}
+	message := fmt.Sprintf("Changes to %s look like build output or caches. Add them to .gitignore from 'autogit menu'.", paths)
+	return Notify(title, message)
+}
+
+// NotifyAuthExpired reports that the provider rejected the API key
+func NotifyAuthExpired(repoName, provider string) error {
+	title := fmt.Sprintf("Autogit: API key rejected in %s", repoName)
+	message := fmt.Sprintf("%s rejected the API key. Local messages are used until you run 'autogit reauth'.", provider)
+	return Notify(title, message)
+}
+
+// NotifyModelFallback reports that the provider no longer serves the
+// configured model and its default model is used instead
+func NotifyModelFallback(repoName, model, fallback string) error {
+	title := fmt.Sprintf("Autogit: Model %s unavailable in %s", model, repoName)
+	message := fmt.Sprintf("The AI provider no longer serves %s, using %s instead. Pick another model in 'autogit menu'.", model, fallback)
+	return Notify(title, message)
+}
+
+// NotifyBudget reports that AI calls reached a cap of ai_budget and
+// template messages are used until the period ends
+func NotifyBudget(repoName, limit, until string) error {
+	title := fmt.Sprintf("Autogit: AI budget reached in %s", repoName)
+	message := fmt.Sprintf("Reached the %s. Template messages are used until %s; see 'autogit stats'.", limit, until)
+	return Notify(title, message)
+}
+
+// NotifyCrash reports that the daemon crashed and is started again
+func NotifyCrash(repoName, reason string, restartIn time.Duration) error {
+	title := fmt.Sprintf("Autogit: Daemon crashed in %s", repoName)
+	message := fmt.Sprintf("The daemon died (%s) and restarts in %s. The stack trace is in its log.", reason, timefmt.Duration(restartIn))
+	return Notify(title, message)
+}
+
+// NotifyAhead reminds the user that the branch has drifted far from the
+// base of its pull request
+func NotifyAhead(repoName, base string, commits, lines int) error {
+	title := fmt.Sprintf("Autogit: %s is far ahead of %s", repoName, base)
+	message := fmt.Sprintf("%d commit(s) and %d changed line(s) since %s. Time to push and open a pull request?", commits, lines, base)
+	return Notify(title, message)
+}
+
+// NotifySharedBranch tells the user that teammates push to the same
+// branch, with what autogit did or advises about it
+func NotifySharedBranch(repoName, branch string, authors []string, advice string) error {
+	title := fmt.Sprintf("Autogit: %s in %s is shared", branch, repoName)
+	message := fmt.Sprintf("Pushes keep being rejected because of commits by %s. %s", strings.Join(authors, ", "), advice)
+	return Notify(title, message)
+}
+
+// NotifySecrets warns that a commit was blocked because it contains
+// credentials. With private, the file of the first finding is not named.
+func NotifySecrets(repoName string, count int, first string, private bool) error {
+	title := fmt.Sprintf("Autogit: Commit blocked in %s", repoName)
+	message := fmt.Sprintf("%d possible secret(s) found, e.g. %s", count, first)
+	if private {
+		message = fmt.Sprintf("%d possible secret(s) found. See the daemon log for details.", count)
+	}
+	return Notify(title, message)
+}
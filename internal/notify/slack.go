@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// slackTimeout limits each request to Slack
+const slackTimeout = 10 * time.Second
+
+// slackListed is how many held files a Slack message names
+const slackListed = 10
+
+// Slack button actions
+const (
+	SlackApprove = "approve"
+	SlackReject  = "reject"
+)
+
+// SlackApproval is carried by the buttons of an approval message, so that
+// a click names the changes it approves
+type SlackApproval struct {
+	Repo  string   `json:"repo"`
+	State string   `json:"state,omitempty"` // StateName of the repository, see config.RepoConfig.StateName
+	Hash  string   `json:"hash"`
+	Files []string `json:"files,omitempty"`
+	Count int      `json:"count,omitempty"` // Number of changes, when Files is left out
+}
+
+// StateName returns the name the approval is kept under. Buttons posted
+// before State was added only carry the repository's name.
+func (a SlackApproval) StateName() string {
+	if a.State == "" {
+		return a.Repo
+	}
+	return a.State
+}
+
+// Changes returns the number of changes a stands for
+func (a SlackApproval) Changes() int {
+	if len(a.Files) == 0 {
+		return a.Count
+	}
+	return len(a.Files)
+}
+
+// PostApproval posts changes held for approval to the Slack incoming
+// webhook at webhookURL, with Approve and Reject buttons. With private,
+// the message and its buttons only carry the number of changes, not the
+// files.
+func PostApproval(ctx context.Context, webhookURL string, a SlackApproval, private bool) error {
+	listed := a.Files
+	if len(listed) > slackListed {
+		listed = listed[:slackListed]
+	}
+	text := fmt.Sprintf("*Approval needed in %s*\n%d sensitive change(s):\n• `%s`", a.Repo, len(a.Files), strings.Join(listed, "`\n• `"))
+	if more := len(a.Files) - len(listed); more > 0 {
+		text += fmt.Sprintf("\n… and %d more", more)
+	}
+	held := SlackApproval{Repo: a.Repo, State: a.State, Hash: a.Hash, Files: listed}
+	if private {
+		text = fmt.Sprintf("*Approval needed in %s*\n%d sensitive change(s)", a.Repo, len(a.Files))
+		held = SlackApproval{Repo: a.Repo, State: a.State, Hash: a.Hash, Count: len(a.Files)}
+	}
+	
+	value, err := json.Marshal(held)
+	if err != nil {
+		return err
+	}
+	button := func(action, label, style string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":      "button",
+			"action_id": action,
+			"text":      map[string]string{"type": "plain_text", "text": label},
+			"style":     style,
+			"value":     string(value),
+		}
+	}
+	return postSlack(ctx, webhookURL, map[string]interface{}{
+		"text": fmt.Sprintf("Approval needed in %s", a.Repo),
+		"blocks": []interface{}{
+			map[string]interface{}{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": text}},
+			map[string]interface{}{"type": "actions", "elements": []interface{}{
+				button(SlackApprove, "Approve", "primary"),
+				button(SlackReject, "Reject", "danger"),
+			}},
+		},
+	})
+}
+
+// ReplySlack replaces the message a button belonged to with text, through
+// the response_url of the click
+func ReplySlack(ctx context.Context, responseURL, text string) error {
+	return postSlack(ctx, responseURL, map[string]interface{}{"replace_original": true, "text": text})
+}
+
+func postSlack(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(ctx, slackTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to post to Slack: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package pathutil
+
+import (
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// maxPath is the classic Windows MAX_PATH limit. Directories are limited
+// to MAX_PATH minus room for an 8.3 file name.
+const maxPath = 248
+
+// Long returns a form of path that Windows APIs accept beyond MAX_PATH by
+// adding the \\?\ prefix to long absolute paths. Go's os package already
+// does this internally, but libraries that call the Win32 API directly
+// (such as fsnotify) do not. On other platforms path is returned as-is.
+func Long(path string) string {
+	if runtime.GOOS != "windows" {
+		return path
+	}
+	return longWindows(path)
+}
+
+func longWindows(path string) string {
+	if len(path) < maxPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	
+	// The prefix disables all path normalization, so the path must be
+	// absolute with backslash separators and no . or .. elements
+	path = strings.ReplaceAll(path, "/", `\`)
+	if !isAbsWindows(path) {
+		return path
+	}
+	path = cleanWindows(path)
+	
+	if strings.HasPrefix(path, `\\`) {
+		// \\server\share\dir -> \\?\UNC\server\share\dir
+		return `\\?\UNC\` + path[2:]
+	}
+	return `\\?\` + path
+}
+
+// TrimLong undoes Long, so that paths reported back by the OS can be
+// compared with ordinary paths
+func TrimLong(path string) string {
+	if strings.HasPrefix(path, `\\?\UNC\`) {
+		return `\\` + path[len(`\\?\UNC\`):]
+	}
+	return strings.TrimPrefix(path, `\\?\`)
+}
+
+func isAbsWindows(path string) bool {
+	if strings.HasPrefix(path, `\\`) {
+		return true
+	}
+	return len(path) >= 3 && path[1] == ':' && path[2] == '\\'
+}
+
+// cleanWindows resolves . and .. elements using backslash separators,
+// independent of the host platform
+func cleanWindows(path string) string {
+	prefix := ""
+	rest := path
+	if strings.HasPrefix(path, `\\`) {
+		prefix, rest = `\\`, path[2:]
+	} else {
+		prefix, rest = path[:3], path[3:]
+	}
+	
+	var parts []string
+	for _, p := range strings.Split(rest, `\`) {
+		switch p {
+		case "", ".":
+		case "..":
+			if len(parts) > 0 {
+				parts = parts[:len(parts)-1]
+			}
+		default:
+			parts = append(parts, p)
+		}
+	}
+	return prefix + strings.Join(parts, `\`)
+}
+
+// Same reports whether a and b refer to the same path. Comparison is
+// case-insensitive on Windows and macOS, whose default file systems are.
+func Same(a, b string) bool {
+	return Key(a) == Key(b)
+}
+
+// Key returns a form of path that is equal for all paths Same considers
+// the same, for use as a map key or in hashes
+func Key(path string) string {
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// Within reports whether path is dir or lies below it
+func Within(path, dir string) bool {
+	rel, err := filepath.Rel(Key(dir), Key(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// MatchGlob reports whether file, a slash-separated path relative to the
+// repository root, matches pattern like git's glob pathspecs do: * and ?
+// stay within a directory and ** matches any number of directories
+func MatchGlob(pattern, file string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(filepath.ToSlash(file), "/"))
+}
+
+func matchSegments(pattern, names []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(names); i++ {
+				if matchSegments(pattern[1:], names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], names[0]); !ok {
+			return false
+		}
+		pattern, names = pattern[1:], names[1:]
+	}
+	return len(names) == 0
+}
+
+// QuoteArg quotes s as a single argument for a Windows command line, for
+// places where a full command line is handed to another program (such as
+// schtasks /TR) instead of going through os/exec
+func QuoteArg(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+	
+	// Backslashes are only special when they precede a quote
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\':
+			slashes++
+		case '"':
+			b.WriteString(strings.Repeat(`\`, slashes*2+1))
+			b.WriteByte('"')
+			slashes = 0
+			continue
+		default:
+			b.WriteString(strings.Repeat(`\`, slashes))
+			slashes = 0
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+		}
+	}
+	b.WriteString(strings.Repeat(`\`, slashes*2))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// SafeFileName replaces characters that are invalid in file names on any
+// supported platform
+func SafeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '<', '>', ':', '"', '/', '\\', '|', '?', '*':
+			return '_'
+		}
+		if r < 32 {
+			return '_'
+		}
+		return r
+	}, name)
+	// Windows silently strips trailing dots and spaces
+	return strings.TrimRight(name, ". ")
+}
@@ -0,0 +1,140 @@
+package pathutil
+
+import (
+	"strings"
+	"testing"
+)
+
+// long returns a Windows directory path of at least n characters below root
+func long(root string, n int) string {
+	path := root
+	for len(path) < n {
+		path += `\` + strings.Repeat("d", 20)
+	}
+	return path
+}
+
+func TestLongWindows(t *testing.T) {
+	deep := long(`C:\Users\me`, 300)
+	unc := long(`\\server\share`, 300)
+	tests := []struct {
+		name, in, want string
+	}{
+		{"short drive path", `C:\Users\me\repo`, `C:\Users\me\repo`},
+		{"short forward slashes", `C:/Users/me/repo`, `C:/Users/me/repo`},
+		{"long drive path", deep, `\\?\` + deep},
+		{"long forward slashes", strings.ReplaceAll(deep, `\`, "/"), `\\?\` + deep},
+		{"long mixed separators", strings.Replace(deep, `\`, "/", 2), `\\?\` + deep},
+		{"long with dot elements", `C:\Users\.\me\x\..` + deep[len(`C:\Users\me`):], `\\?\` + deep},
+		{"long with doubled separators", strings.Replace(deep, `\`, `\\`, 1), `\\?\` + deep},
+		{"long UNC path", unc, `\\?\UNC\` + unc[2:]},
+		{"already long", `\\?\` + deep, `\\?\` + deep},
+		{"already long UNC", `\\?\UNC\` + unc[2:], `\\?\UNC\` + unc[2:]},
+		{"long relative path", long(`repo`, 300), long(`repo`, 300)},
+		{"long drive-relative path", long(`C:repo`, 300), long(`C:repo`, 300)},
+	}
+	for _, tt := range tests {
+		if got := longWindows(tt.in); got != tt.want {
+			t.Errorf("%s: longWindows(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTrimLong(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`\\?\C:\Users\me\repo`, `C:\Users\me\repo`},
+		{`\\?\UNC\server\share\repo`, `\\server\share\repo`},
+		{`C:\Users\me\repo`, `C:\Users\me\repo`},
+		{`\\server\share\repo`, `\\server\share\repo`},
+		{`/home/me/repo`, `/home/me/repo`},
+	}
+	for _, tt := range tests {
+		if got := TrimLong(tt.in); got != tt.want {
+			t.Errorf("TrimLong(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	
+	// TrimLong undoes longWindows
+	for _, path := range []string{long(`D:\work`, 300), long(`\\nas\home`, 300)} {
+		if got := TrimLong(longWindows(path)); got != path {
+			t.Errorf("TrimLong(longWindows(%q)) = %q", path, got)
+		}
+	}
+}
+
+func TestIsAbsWindows(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{`C:\repo`, true},
+		{`c:\repo`, true},
+		{`\\server\share`, true},
+		{`C:repo`, false},
+		{`C:`, false},
+		{`\repo`, false},
+		{`repo\sub`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := isAbsWindows(tt.in); got != tt.want {
+			t.Errorf("isAbsWindows(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCleanWindows(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`C:\a\b\..\c`, `C:\a\c`},
+		{`C:\a\.\b\`, `C:\a\b`},
+		{`C:\..\a`, `C:\a`},
+		{`C:\a\\b`, `C:\a\b`},
+		{`\\server\share\a\..\b`, `\\server\share\b`},
+	}
+	for _, tt := range tests {
+		if got := cleanWindows(tt.in); got != tt.want {
+			t.Errorf("cleanWindows(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{``, `""`},
+		{`C:\repo`, `C:\repo`},
+		{`C:\Program Files\autogit.exe`, `"C:\Program Files\autogit.exe"`},
+		{`C:\My Repo\`, `"C:\My Repo\\"`},
+		{`\\server\my share\repo`, `"\\server\my share\repo"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`a\"b c`, `"a\\\"b c"`},
+	}
+	for _, tt := range tests {
+		if got := QuoteArg(tt.in); got != tt.want {
+			t.Errorf("QuoteArg(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSafeFileName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`repo`, `repo`},
+		{`C:\repo`, `C__repo`},
+		{`a/b`, `a_b`},
+		{`what?*`, `what__`},
+		{"tab\there", "tab_here"},
+		{`trailing. `, `trailing`},
+	}
+	for _, tt := range tests {
+		if got := SafeFileName(tt.in); got != tt.want {
+			t.Errorf("SafeFileName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package proc manages daemon processes across platforms: whether one
+// still runs, how to recognize it after its PID was reused, how to stop
+// it and how to start it detached from the terminal.
+package proc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Running reports whether a process with the given PID exists. The PID
+// alone does not identify a process: after a reboot it is often reused,
+// which StartTime and Executable can tell apart.
+func Running(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return running(pid)
+}
+
+// StartTime returns an opaque value that identifies when the process
+// started, for comparison with a value recorded earlier
+func StartTime(pid int) (string, error) {
+	return startTime(pid)
+}
+
+// Executable returns the path of the program the process runs
+func Executable(pid int) (string, error) {
+	return executable(pid)
+}
+
+// Terminate asks the process to stop, giving it the chance to finish
+// cleanly where the platform allows it
+func Terminate(pid int) error {
+	return terminate(pid)
+}
+
+// Wake tells the daemon process to read its pause state again. Windows
+// has no such signal, so daemons there notice within their poll interval.
+func Wake(pid int) error {
+	return wake(pid)
+}
+
+// NotifyWake relays the signals sent by Wake to c
+func NotifyWake(c chan<- os.Signal) {
+	notifyWake(c)
+}
+
+// Find returns the PIDs of other processes running the same program as
+// this one with arg among their arguments, such as daemons whose record
+// was lost or overwritten
+func Find(arg string) ([]int, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	name := programName(self)
+	
+	lines, err := commandLines()
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for pid, args := range lines {
+		if pid == os.Getpid() || len(args) < 2 || programName(args[0]) != name {
+			continue
+		}
+		for _, a := range args[1:] {
+			if a == arg {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	return pids, nil
+}
+
+// programName is the file name of a program without extension, as it
+// appears in a command line
+func programName(path string) string {
+	name := filepath.Base(strings.ReplaceAll(path, `\`, "/"))
+	return strings.TrimSuffix(strings.ToLower(name), ".exe")
+}
+
+// Detach makes cmd start in a session of its own, so that it outlives the
+// terminal or console it was started from
+func Detach(cmd *exec.Cmd) {
+	detach(cmd)
+}
+
+// ps asks ps(1) for a single column of the process
+func ps(pid int, column string) (string, error) {
+	out, err := exec.Command("ps", "-o", column, "-p", fmt.Sprint(pid)).Output()
+	if err != nil {
+		return "", fmt.Errorf("ps failed for PID %d: %w", pid, err)
+	}
+	value := strings.TrimSpace(string(out))
+	if value == "" {
+		return "", fmt.Errorf("no process with PID %d", pid)
+	}
+	return value, nil
+}
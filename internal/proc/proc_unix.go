@@ -0,0 +1,120 @@
+//go:build !windows
+
+package proc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func running(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	
+	// Sending signal 0 checks if process exists
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+func startTime(pid int) (string, error) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "android" {
+		return ps(pid, "lstart=")
+	}
+	
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", err
+	}
+	// The command name may contain spaces and parentheses, so count
+	// fields after its closing parenthesis. starttime is field 22.
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	if len(fields) < 20 {
+		return "", fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+	return fields[19], nil
+}
+
+func executable(pid int) (string, error) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "android" {
+		return ps(pid, "comm=")
+	}
+	
+	path, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return "", err
+	}
+	// A binary replaced by an upgrade is still the same program
+	return strings.TrimSuffix(path, " (deleted)"), nil
+}
+
+func terminate(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Signal(syscall.SIGTERM)
+}
+
+func wake(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Signal(syscall.SIGUSR1)
+}
+
+func notifyWake(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGUSR1)
+}
+
+// commandLines returns the arguments of every process by PID, from /proc
+// on Linux and ps(1) elsewhere, where arguments containing spaces are split
+func commandLines() (map[int][]string, error) {
+	lines := make(map[int][]string)
+	if runtime.GOOS != "linux" && runtime.GOOS != "android" {
+		out, err := exec.Command("ps", "-axo", "pid=,args=").Output()
+		if err != nil {
+			return nil, fmt.Errorf("ps failed: %w", err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			if pid, err := strconv.Atoi(fields[0]); err == nil {
+				lines[pid] = fields[1:]
+			}
+		}
+		return lines, nil
+	}
+	
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", e.Name(), "cmdline"))
+		if err != nil || len(data) == 0 {
+			continue // Exited meanwhile, or a kernel thread
+		}
+		lines[pid] = strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	}
+	return lines, nil
+}
+
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,129 @@
+//go:build windows
+
+package proc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	stillActive = 259             // Exit code of a process that has not exited
+	breakWait   = 3 * time.Second // How long a process may take to stop after CTRL_BREAK
+)
+
+// open opens the process for querying, which fails once it has exited and
+// no handle keeps it around
+func open(pid int) (windows.Handle, error) {
+	return windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+}
+
+func running(pid int) bool {
+	h, err := open(pid)
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(h)
+	
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+func startTime(pid int) (string, error) {
+	h, err := open(pid)
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(h)
+	
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return "", err
+	}
+	return fmt.Sprint(creation.Nanoseconds()), nil
+}
+
+func executable(pid int) (string, error) {
+	h, err := open(pid)
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(h)
+	
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(h, 0, &buf[0], &size); err != nil {
+		return "", err
+	}
+	return windows.UTF16ToString(buf[:size]), nil
+}
+
+// terminate sends CTRL_BREAK, which Go delivers to the daemon as
+// os.Interrupt. That only reaches processes sharing our console, so a
+// daemon started detached is stopped with taskkill once it has not exited
+// after breakWait; the cycle token lets its next start finish an
+// interrupted cycle.
+func terminate(pid int) error {
+	if windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(pid)) == nil {
+		for deadline := time.Now().Add(breakWait); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+			if !running(pid) {
+				return nil
+			}
+		}
+	}
+	
+	out, err := exec.Command("taskkill", "/PID", fmt.Sprint(pid), "/T", "/F").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("taskkill failed: %w: %s", err, out)
+	}
+	return nil
+}
+
+func wake(pid int) error {
+	return nil
+}
+
+func notifyWake(c chan<- os.Signal) {}
+
+// commandLines returns the arguments of every process by PID. Windows only
+// exposes command lines through WMI, which PowerShell queries for us.
+func commandLines() (map[int][]string, error) {
+	script := `Get-CimInstance Win32_Process | ForEach-Object { "$($_.ProcessId)` + "`t" + `$($_.CommandLine)" }`
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing processes failed: %w", err)
+	}
+	
+	lines := make(map[int][]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		id, commandLine, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok || commandLine == "" {
+			continue
+		}
+		pid, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		if args, err := windows.DecomposeCommandLine(commandLine); err == nil {
+			lines[pid] = args
+		}
+	}
+	return lines, nil
+}
+
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS,
+		HideWindow:    true,
+	}
+}
@@ -0,0 +1,326 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lookahead bounds the search for the next active minute
+const lookahead = 8 * 24 * time.Hour
+
+// Schedule restricts when the daemon may commit. A time is active when it
+// falls in one of the hour windows, on one of the days, and matches one of
+// the cron expressions; an empty list does not restrict. Hours and days are
+// read on the clock of the schedule's time zone.
+type Schedule struct {
+	hours []window
+	days  *[7]bool
+	crons []*cron
+	loc   *time.Location // Nil to follow the system zone
+}
+
+// window is a daily time range in minutes since midnight. End is
+// exclusive; a window whose end is before its start wraps past midnight.
+type window struct {
+	start, end int
+}
+
+// New parses the schedule. It returns nil when nothing is restricted.
+//
+//	hours: "09:00-18:00", "22:00-06:00"
+//	days:  "mon-fri", "sat", "weekdays", "weekends"
+//	crons: "* 9-17 * * 1-5" (minute hour day-of-month month day-of-week)
+func New(hours, days, crons []string) (*Schedule, error) {
+	if len(hours) == 0 && len(days) == 0 && len(crons) == 0 {
+		return nil, nil
+	}
+	
+	s := &Schedule{}
+	for _, h := range hours {
+		w, err := parseWindow(h)
+		if err != nil {
+			return nil, err
+		}
+		s.hours = append(s.hours, w)
+	}
+	if len(days) > 0 {
+		s.days = &[7]bool{}
+		for _, d := range days {
+			if err := parseDays(d, s.days); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, c := range crons {
+		expr, err := parseCron(c)
+		if err != nil {
+			return nil, err
+		}
+		s.crons = append(s.crons, expr)
+	}
+	return s, nil
+}
+
+// In returns the schedule read in loc instead of the system zone. A nil
+// loc keeps it following the system zone.
+func (s *Schedule) In(loc *time.Location) *Schedule {
+	if s == nil || loc == nil {
+		return s
+	}
+	in := *s
+	in.loc = loc
+	return &in
+}
+
+// Location returns the time zone the schedule is read in
+func (s *Schedule) Location() *time.Location {
+	if s == nil || s.loc == nil {
+		return Local()
+	}
+	return s.loc
+}
+
+// Active reports whether t is inside the schedule. A nil schedule is
+// always active.
+func (s *Schedule) Active(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	t = t.In(s.Location())
+	if s.days != nil && !s.days[t.Weekday()] {
+		return false
+	}
+	if len(s.hours) > 0 {
+		minute := t.Hour()*60 + t.Minute()
+		inside := false
+		for _, w := range s.hours {
+			if w.contains(minute) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	if len(s.crons) > 0 {
+		for _, c := range s.crons {
+			if c.matches(t) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Next returns the first active minute at or after t, in the schedule's
+// time zone, or the zero time when the schedule is never active. Minutes
+// are counted in real time, so a window is open for as long as the clock
+// shows it, even twice when the clock is set back.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.Location())
+	if s.Active(t) {
+		return t
+	}
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(lookahead); next.Before(limit); next = next.Add(time.Minute) {
+		if s.Active(next) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (w window) contains(minute int) bool {
+	if w.start <= w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+func parseWindow(s string) (window, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return window{}, fmt.Errorf("invalid active hours %q, expected HH:MM-HH:MM", s)
+	}
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return window{}, fmt.Errorf("invalid active hours %q: %v", s, err)
+	}
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return window{}, fmt.Errorf("invalid active hours %q: %v", s, err)
+	}
+	if start == end {
+		return window{}, fmt.Errorf("invalid active hours %q: empty window", s)
+	}
+	return window{start: start, end: end}, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight. "24:00" is
+// accepted as the end of the day.
+func parseClock(s string) (int, error) {
+	t := strings.TrimSpace(s)
+	if t == "24:00" {
+		return 24 * 60, nil
+	}
+	parsed, err := time.Parse("15:04", t)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", t)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// parseDays marks the days named by s, a day, a range such as "mon-fri"
+// (which may wrap, e.g. "fri-mon"), "weekdays" or "weekends"
+func parseDays(s string, days *[7]bool) error {
+	d := strings.ToLower(strings.TrimSpace(s))
+	switch d {
+	case "weekdays":
+		d = "mon-fri"
+	case "weekends":
+		d = "sat-sun"
+	}
+	
+	parts := strings.Split(d, "-")
+	if len(parts) > 2 {
+		return fmt.Errorf("invalid active days %q", s)
+	}
+	var bounds []int
+	for _, p := range parts {
+		day, ok := dayNames[p]
+		if !ok && len(p) > 3 {
+			day, ok = dayNames[p[:3]] // "monday"
+		}
+		if !ok {
+			return fmt.Errorf("invalid active days %q, expected names such as mon or mon-fri", s)
+		}
+		bounds = append(bounds, day)
+	}
+	
+	from, to := bounds[0], bounds[len(bounds)-1]
+	for day := from; ; day = (day + 1) % 7 {
+		days[day] = true
+		if day == to {
+			break
+		}
+	}
+	return nil
+}
+
+// cron is a five-field cron expression matched to the minute
+type cron struct {
+	minute, hour, dom, month, dow []bool
+	domAny, dowAny                bool
+	hourAny                       bool // The hour field starts with "*"
+}
+
+func parseCron(expr string) (*cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q, expected 5 fields", expr)
+	}
+	
+	// As in cron, a day field starting with "*" does not restrict
+	c := &cron{domAny: strings.HasPrefix(fields[2], "*"), dowAny: strings.HasPrefix(fields[4], "*"), hourAny: strings.HasPrefix(fields[1], "*")}
+	var err error
+	specs := []struct {
+		field    string
+		min, max int
+		dst      *[]bool
+	}{
+		{fields[0], 0, 59, &c.minute},
+		{fields[1], 0, 23, &c.hour},
+		{fields[2], 1, 31, &c.dom},
+		{fields[3], 1, 12, &c.month},
+		{fields[4], 0, 7, &c.dow},
+	}
+	for _, spec := range specs {
+		if *spec.dst, err = parseField(spec.field, spec.min, spec.max); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", expr, err)
+		}
+	}
+	if c.dow[7] {
+		c.dow[0] = true // 7 is Sunday too
+	}
+	return c, nil
+}
+
+// parseField parses a comma-separated list of "*", "n", "a-b", each
+// optionally followed by "/step". Days of the week may be named.
+func parseField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rng, step = part[:i], n
+		}
+		
+		lo, hi := min, max
+		if rng != "*" {
+			bounds := strings.Split(rng, "-")
+			if len(bounds) > 2 {
+				return nil, fmt.Errorf("invalid range %q", rng)
+			}
+			var err error
+			if lo, err = fieldValue(bounds[0]); err != nil {
+				return nil, err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = fieldValue(bounds[1]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is out of range %d-%d", rng, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+func fieldValue(s string) (int, error) {
+	if day, ok := dayNames[strings.ToLower(s)]; ok {
+		return day, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return n, nil
+}
+
+func (c *cron) matches(t time.Time) bool {
+	return c.minute[t.Minute()] && c.hour[t.Hour()] && c.month[int(t.Month())] && c.day(t)
+}
+
+// day follows cron semantics: when both day of month and day of week are
+// restricted, either may match
+func (c *cron) day(t time.Time) bool {
+	dom, dow := c.dom[t.Day()], c.dow[int(t.Weekday())]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNewRejectsInvalidSettings checks that malformed hours, days and cron
+// expressions are reported instead of ignored
+func TestNewRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name               string
+		hours, days, crons []string
+	}{
+		{"hours without a range", []string{"09:00"}, nil, nil},
+		{"hours out of range", []string{"09:00-25:00"}, nil, nil},
+		{"empty window", []string{"09:00-09:00"}, nil, nil},
+		{"unknown day", nil, []string{"someday"}, nil},
+		{"three days", nil, []string{"mon-wed-fri"}, nil},
+		{"four cron fields", nil, nil, []string{"* * * *"}},
+		{"cron hour out of range", nil, nil, []string{"0 24 * * *"}},
+		{"cron zero step", nil, nil, []string{"*/0 * * * *"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.hours, tt.days, tt.crons); err == nil {
+				t.Errorf("New(%q, %q, %q) succeeded, want an error", tt.hours, tt.days, tt.crons)
+			}
+		})
+	}
+	if s, err := New(nil, nil, nil); s != nil || err != nil {
+		t.Errorf("New(nil, nil, nil) = %v, %v, want no schedule", s, err)
+	}
+}
+
+// TestActive checks hour windows, including ones past midnight, day names
+// and ranges, and cron expressions, on a clock in UTC
+func TestActive(t *testing.T) {
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2024, time.June, 3, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name               string
+		hours, days, crons []string
+		at                 time.Time
+		want               bool
+	}{
+		{"inside hours", []string{"09:00-18:00"}, nil, nil, monday(9, 0), true},
+		{"end is exclusive", []string{"09:00-18:00"}, nil, nil, monday(18, 0), false},
+		{"second window", []string{"09:00-12:00", "14:00-18:00"}, nil, nil, monday(15, 30), true},
+		{"wraps past midnight", []string{"22:00-06:00"}, nil, nil, monday(2, 0), true},
+		{"outside wrapped window", []string{"22:00-06:00"}, nil, nil, monday(12, 0), false},
+		{"until 24:00", []string{"20:00-24:00"}, nil, nil, monday(23, 59), true},
+		{"weekdays", nil, []string{"weekdays"}, nil, monday(12, 0), true},
+		{"weekends", nil, []string{"weekends"}, nil, monday(12, 0), false},
+		{"full day name", nil, []string{"Monday"}, nil, monday(12, 0), true},
+		{"range wrapping the week", nil, []string{"fri-mon"}, nil, monday(12, 0), true},
+		{"range missing the day", nil, []string{"tue-thu"}, nil, monday(12, 0), false},
+		{"hours and days", []string{"09:00-18:00"}, []string{"mon"}, nil, monday(8, 59), false},
+		{"cron match", nil, nil, []string{"*/15 9-17 * * MON-FRI"}, monday(9, 45), true},
+		{"cron minute mismatch", nil, nil, []string{"*/15 9-17 * * MON-FRI"}, monday(9, 46), false},
+		{"cron Sunday as 7", nil, nil, []string{"* * * * 7"}, monday(12, 0).AddDate(0, 0, 6), true},
+		{"cron day of month or week", nil, nil, []string{"* * 15 * 1"}, monday(12, 0), true},
+		{"second cron", nil, nil, []string{"0 8 * * *", "30 12 * * *"}, monday(12, 30), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.hours, tt.days, tt.crons)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.In(time.UTC).Active(tt.at); got != tt.want {
+				t.Errorf("Active(%s) = %v, want %v", tt.at.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNext checks that Next finds the opening of the next window, and the
+// zero time for a schedule that never opens
+func TestNext(t *testing.T) {
+	s, err := New([]string{"09:00-18:00"}, []string{"weekdays"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = s.In(time.UTC)
+	friday := time.Date(2024, time.June, 7, 18, 30, 0, 0, time.UTC)
+	if got, want := s.Next(friday), time.Date(2024, time.June, 10, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next(%s) = %s, want %s", friday, got, want)
+	}
+	
+	never, err := New(nil, nil, []string{"0 0 30 2 *"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := never.In(time.UTC).Next(friday); !got.IsZero() {
+		t.Errorf("Next of a schedule that never opens = %s, want the zero time", got)
+	}
+}
@@ -0,0 +1,208 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/timefmt"
+)
+
+// cronLookahead bounds the search for the next minute a check expression
+// matches, long enough for a Feb 29 that falls in a leap year
+const cronLookahead = 5 * 366 * 24 * time.Hour
+
+// maxSkips bounds how many check times Within passes over while looking
+// for one the schedule allows
+const maxSkips = 10000
+
+// Timing says when the daemon checks for changes: every interval from the
+// time it started, or at the minutes a cron expression matches
+type Timing struct {
+	Every time.Duration // Zero for a cron expression
+	spec  string
+	cron  *cron
+	loc   *time.Location // Zone of a cron expression, nil to follow the system zone
+}
+
+// ParseTiming parses a Go duration such as "45s" or "2h30m", or a cron
+// expression such as "*/15 9-18 * * MON-FRI" (minute hour day-of-month
+// month day-of-week)
+func ParseTiming(s string) (*Timing, error) {
+	spec := strings.TrimSpace(s)
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid check interval %q, must be positive", s)
+		}
+		return &Timing{Every: d, spec: spec}, nil
+	}
+	if len(strings.Fields(spec)) != 5 {
+		return nil, fmt.Errorf("invalid check interval %q, expected a duration such as \"45s\" or \"2h30m\" or a cron expression such as \"*/15 9-18 * * MON-FRI\"", s)
+	}
+	c, err := parseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+	if c.next(time.Now(), time.Local).IsZero() {
+		return nil, fmt.Errorf("invalid cron expression %q: never matches", s)
+	}
+	return &Timing{spec: spec, cron: c}, nil
+}
+
+// Interval returns the timing of a check every d
+func Interval(d time.Duration) *Timing {
+	return &Timing{Every: d, spec: timefmt.Duration(d)}
+}
+
+// In returns the timing with a cron expression read in loc instead of the
+// system zone. A nil loc keeps it following the system zone.
+func (t *Timing) In(loc *time.Location) *Timing {
+	if loc == nil || t.cron == nil {
+		return t
+	}
+	in := *t
+	in.loc = loc
+	return &in
+}
+
+func (t *Timing) location() *time.Location {
+	if t.loc == nil {
+		return Local()
+	}
+	return t.loc
+}
+
+// String returns the duration or cron expression the timing was parsed from
+func (t *Timing) String() string {
+	return t.spec
+}
+
+// Next returns the first check after now by a daemon started at start, or
+// the zero time when a cron expression stops matching
+func (t *Timing) Next(start, now time.Time) time.Time {
+	if t.cron != nil {
+		return t.cron.next(now, t.location())
+	}
+	return timefmt.NextTick(start, t.Every, now)
+}
+
+// Within returns at when s allows checks then, and otherwise the first
+// later check time it allows, keeping the phase of an interval. It returns
+// the zero time when there is none. A nil schedule allows any time.
+func (t *Timing) Within(s *Schedule, at time.Time) time.Time {
+	next := at
+	for i := 0; i < maxSkips && !next.IsZero(); i++ {
+		if s.Active(next) {
+			return next
+		}
+		open := s.Next(next)
+		if open.IsZero() {
+			return time.Time{}
+		}
+		if t.cron != nil {
+			next = t.cron.next(open.Add(-time.Nanosecond), t.location())
+			continue
+		}
+		ticks := (open.Sub(next) + t.Every - 1) / t.Every
+		if ticks < 1 {
+			ticks = 1
+		}
+		next = next.Add(ticks * t.Every)
+	}
+	return time.Time{}
+}
+
+// Upcoming returns up to n check times that s allows, from first on
+func (t *Timing) Upcoming(s *Schedule, first time.Time, n int) []time.Time {
+	var times []time.Time
+	for next := t.Within(s, first); !next.IsZero() && len(times) < n; {
+		times = append(times, next)
+		next = t.Within(s, t.Next(next, next))
+	}
+	return times
+}
+
+// next returns the first minute after t that c matches on the clock of
+// loc, or the zero time when there is none within cronLookahead.
+//
+// As in cron, an expression with a fixed hour runs once a day across
+// daylight saving changes: a time the clock skips runs when the clock
+// jumps past it, and a time the clock shows twice runs the first time
+// only. Other expressions follow the minutes as they pass.
+func (c *cron) next(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if c.hourAny {
+		return c.nextIn(t)
+	}
+	
+	// Search the clock as it reads, then find the moment it reads that
+	wall := c.nextIn(clockOf(t))
+	if wall.IsZero() {
+		return time.Time{}
+	}
+	return atClock(wall, loc, t)
+}
+
+// nextIn returns the first minute after t that c matches, stepping through
+// the calendar in the location of t
+func (c *cron) nextIn(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(cronLookahead); next.Before(limit); {
+		y, m, d := next.Date()
+		switch {
+		case !c.month[int(m)]:
+			next = time.Date(y, m+1, 1, 0, 0, 0, 0, next.Location())
+		case !c.day(next):
+			next = time.Date(y, m, d+1, 0, 0, 0, 0, next.Location())
+		case !c.hour[next.Hour()]:
+			next = time.Date(y, m, d, next.Hour()+1, 0, 0, 0, next.Location())
+		case !c.minute[next.Minute()]:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// clockOf returns the date and time t shows on its clock, as a time in
+// UTC, where every day has 24 hours
+func clockOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// atClock returns the first moment after t at which the clock of loc shows
+// wall, or when a daylight saving change skips wall, the moment the clock
+// jumps past it
+func atClock(wall time.Time, loc *time.Location, t time.Time) time.Time {
+	y, m, d := wall.Date()
+	at := time.Date(y, m, d, wall.Hour(), wall.Minute(), 0, 0, loc)
+	start, end := at.ZoneBounds()
+	switch shown := clockOf(at); {
+	case shown.Before(wall):
+		return end
+	case shown.After(wall):
+		return start
+	}
+	
+	// A clock set back shows wall twice, in the zones on either side
+	_, offset := at.Zone()
+	showings := []time.Time{at}
+	if !start.IsZero() {
+		_, before := start.Add(-time.Second).Zone()
+		showings = append(showings, at.Add(time.Duration(offset-before)*time.Second))
+	}
+	if !end.IsZero() {
+		_, after := end.Zone()
+		showings = append(showings, at.Add(time.Duration(offset-after)*time.Second))
+	}
+	sort.Slice(showings, func(i, j int) bool { return showings[i].Before(showings[j]) })
+	for _, s := range showings {
+		if s.After(t) && clockOf(s).Equal(wall) {
+			return s
+		}
+	}
+	return at
+}
@@ -0,0 +1,105 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseTiming checks which check intervals are accepted and that they
+// are shown as written
+func TestParseTiming(t *testing.T) {
+	tests := []struct {
+		spec  string
+		every time.Duration // Zero for a cron expression
+		ok    bool
+	}{
+		{"45s", 45 * time.Second, true},
+		{" 2h30m ", 150 * time.Minute, true},
+		{"*/15 9-18 * * MON-FRI", 0, true},
+		{"0 0 29 2 *", 0, true},
+		{"0s", 0, false},
+		{"-5m", 0, false},
+		{"often", 0, false},
+		{"* * * *", 0, false},
+		{"61 * * * *", 0, false},
+		{"0 0 31 2 *", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			timing, err := ParseTiming(tt.spec)
+			if !tt.ok {
+				if err == nil {
+					t.Errorf("ParseTiming(%q) = %s, want an error", tt.spec, timing)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if timing.Every != tt.every || timing.String() != strings.TrimSpace(tt.spec) {
+				t.Errorf("ParseTiming(%q) = %q every %s, want every %s", tt.spec, timing, timing.Every, tt.every)
+			}
+		})
+	}
+}
+
+// TestTimingNext checks that intervals keep the phase of the daemon's start
+// and cron expressions match on the clock of their zone
+func TestTimingNext(t *testing.T) {
+	start := time.Date(2024, time.June, 3, 9, 7, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		now  time.Time
+		want time.Time
+	}{
+		{"10m", start, start.Add(10 * time.Minute)},
+		{"10m", start.Add(25 * time.Minute), start.Add(30 * time.Minute)},
+		{"10m", start.Add(-time.Hour), start.Add(10 * time.Minute)},
+		{"*/15 * * * *", start, time.Date(2024, time.June, 3, 9, 15, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", start, time.Date(2024, time.June, 4, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", start.AddDate(0, 0, 4), time.Date(2024, time.June, 10, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			timing, err := ParseTiming(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := timing.In(time.UTC).Next(start, tt.now); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTimingWithin checks that check times outside the schedule move to
+// the first one inside it
+func TestTimingWithin(t *testing.T) {
+	s, err := New([]string{"09:00-18:00"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = s.In(time.UTC)
+	evening := time.Date(2024, time.June, 3, 17, 50, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		at   time.Time
+		want time.Time
+	}{
+		{"25m", evening, evening},
+		{"25m", evening.Add(25 * time.Minute), time.Date(2024, time.June, 4, 9, 15, 0, 0, time.UTC)},
+		{"0 * * * *", evening.Add(10 * time.Minute), time.Date(2024, time.June, 4, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			timing, err := ParseTiming(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := timing.In(time.UTC).Within(s, tt.at); !got.Equal(tt.want) {
+				t.Errorf("Within(%s) = %s, want %s", tt.at, got, tt.want)
+			}
+		})
+	}
+}
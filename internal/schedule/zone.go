@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localtime is where Linux and macOS keep the system time zone
+const localtime = "/etc/localtime"
+
+// system is the system time zone. Go reads it once at startup, so a
+// laptop that travels keeps the zone it started in until RefreshLocal
+// reads it again.
+var system struct {
+	mu   sync.Mutex
+	loc  *time.Location // Nil until the zone changes, then the new zone
+	name string
+	data []byte
+}
+
+// Local returns the system time zone, as last read by RefreshLocal
+func Local() *time.Location {
+	system.mu.Lock()
+	defer system.mu.Unlock()
+	if system.loc == nil {
+		return time.Local
+	}
+	return system.loc
+}
+
+// LocalName returns the name of the system time zone, e.g. "Europe/Berlin"
+func LocalName() string {
+	system.mu.Lock()
+	defer system.mu.Unlock()
+	if system.name == "" {
+		return time.Local.String()
+	}
+	return system.name
+}
+
+// RefreshLocal reads the system time zone again and reports whether it
+// changed since the last read, with the names of the old and new zone.
+// The first call only records the zone. A TZ variable fixes the zone, as
+// it does for Go; on Windows the zone is the one at startup.
+func RefreshLocal() (changed bool, from, to string) {
+	if _, ok := os.LookupEnv("TZ"); ok || runtime.GOOS == "windows" {
+		return false, "", ""
+	}
+	data, err := os.ReadFile(localtime)
+	if err != nil {
+		return false, "", ""
+	}
+	name := "Local"
+	if target, err := filepath.EvalSymlinks(localtime); err == nil {
+		if i := strings.Index(target, "zoneinfo/"); i >= 0 {
+			name = target[i+len("zoneinfo/"):]
+		}
+	}
+	
+	system.mu.Lock()
+	defer system.mu.Unlock()
+	if system.data == nil {
+		system.name, system.data = name, data
+		return false, "", ""
+	}
+	if name == system.name && string(data) == string(system.data) {
+		return false, "", ""
+	}
+	loc, err := time.LoadLocationFromTZData(name, data)
+	if err != nil {
+		return false, "", ""
+	}
+	from = system.name
+	system.loc, system.name, system.data = loc, name, data
+	return true, from, name
+}
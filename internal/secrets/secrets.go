@@ -0,0 +1,211 @@
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// Finding is a suspected secret in an added line of a diff
+type Finding struct {
+	File  string
+	Line  int    // Line number in the new file, 0 for whole-file findings
+	Rule  string // Name of the rule that matched
+	Match string // Redacted match
+}
+
+func (f Finding) String() string {
+	if f.Line == 0 {
+		return fmt.Sprintf("%s: %s", f.File, f.Rule)
+	}
+	return fmt.Sprintf("%s:%d: %s (%s)", f.File, f.Line, f.Rule, f.Match)
+}
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// builtinRules detect well-known credential formats
+var builtinRules = []config.SecretRule{
+	{Name: "private-key", Pattern: `-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY( BLOCK)?-----`},
+	{Name: "aws-access-key", Pattern: `\b(AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "github-token", Pattern: `\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`},
+	{Name: "openai-key", Pattern: `\bsk-(proj-|ant-)?[A-Za-z0-9_-]{20,}`},
+	{Name: "google-api-key", Pattern: `\bAIza[0-9A-Za-z_-]{35}\b`},
+	{Name: "slack-token", Pattern: `\bxox[baprs]-[0-9A-Za-z-]{10,}`},
+	{Name: "stripe-key", Pattern: `\b[rs]k_live_[0-9A-Za-z]{24,}\b`},
+	{Name: "generic-credential", Pattern: `(?i)\b(api[_-]?key|secret|passw(or)?d|access[_-]?token|auth[_-]?token)\b["']?\s*[:=]\s*["'][^"'\s]{8,}["']`},
+}
+
+// candidate matches tokens long enough to be checked for entropy
+var candidate = regexp.MustCompile(`[A-Za-z0-9+/_=-]+`)
+
+// Scanner checks diffs for credentials before they are committed
+type Scanner struct {
+	rules         []rule
+	allow         []*regexp.Regexp
+	entropy       float64
+	entropyLength int
+}
+
+// New builds a scanner from cfg. It returns nil when scanning is disabled.
+func New(cfg config.SecretScan) (*Scanner, error) {
+	if cfg.Mode == config.SecretScanOff {
+		return nil, nil
+	}
+	
+	s := &Scanner{
+		entropy:       cfg.EntropyThreshold,
+		entropyLength: cfg.GetMinEntropyLength(),
+	}
+	for _, r := range append(append([]config.SecretRule(nil), builtinRules...), cfg.Rules...) {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret rule %q: %w", r.Name, err)
+		}
+		s.rules = append(s.rules, rule{name: r.Name, pattern: re})
+	}
+	for _, a := range cfg.Allow {
+		re, err := regexp.Compile(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret allow pattern %q: %w", a, err)
+		}
+		s.allow = append(s.allow, re)
+	}
+	return s, nil
+}
+
+// Scan checks the added lines of a unified diff. Files named .env (other
+// than examples and templates) are reported as a whole.
+func (s *Scanner) Scan(diff string) []Finding {
+	var findings []Finding
+	file, line := "", 0
+	
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+			}
+			if isEnvFile(file) {
+				findings = append(findings, Finding{File: file, Rule: "env-file"})
+			}
+		case strings.HasPrefix(text, "@@"):
+			line = hunkStart(text)
+		case strings.HasPrefix(text, "+"):
+			if file != "" {
+				findings = append(findings, s.scanLine(file, line, text[1:])...)
+			}
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	
+	return findings
+}
+
+func (s *Scanner) scanLine(file string, line int, text string) []Finding {
+	if s.allowed(text) {
+		return nil
+	}
+	
+	var findings []Finding
+	for _, r := range s.rules {
+		if m := r.pattern.FindString(text); m != "" {
+			findings = append(findings, Finding{File: file, Line: line, Rule: r.name, Match: redact(m)})
+		}
+	}
+	if len(findings) > 0 || s.entropy <= 0 {
+		return findings
+	}
+	
+	for _, token := range candidate.FindAllString(text, -1) {
+		if len(token) < s.entropyLength || !mixed(token) {
+			continue
+		}
+		if e := entropy(token); e >= s.entropy {
+			findings = append(findings, Finding{File: file, Line: line, Rule: fmt.Sprintf("high-entropy (%.1f)", e), Match: redact(token)})
+			break
+		}
+	}
+	return findings
+}
+
+func (s *Scanner) allowed(text string) bool {
+	for _, a := range s.allow {
+		if a.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnvFile reports whether name is a dotenv file that likely holds real
+// values, as opposed to a checked-in example
+func isEnvFile(name string) bool {
+	base := path.Base(name)
+	if base != ".env" && !strings.HasPrefix(base, ".env.") {
+		return false
+	}
+	switch path.Ext(base) {
+	case ".example", ".sample", ".template", ".dist":
+		return false
+	}
+	return true
+}
+
+// hunkStart parses the new-file start line from "@@ -a,b +c,d @@"
+func hunkStart(header string) int {
+	i := strings.Index(header, "+")
+	if i < 0 {
+		return 0
+	}
+	var start int
+	fmt.Sscanf(header[i+1:], "%d", &start)
+	return start
+}
+
+// mixed reports whether token contains both letters and digits, which
+// rules out long words and identifiers
+func mixed(token string) bool {
+	letter, digit := false, false
+	for _, r := range token {
+		switch {
+		case r >= '0' && r <= '9':
+			digit = true
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			letter = true
+		}
+	}
+	return letter && digit
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len(s))
+	var e float64
+	for _, c := range counts {
+		p := float64(c) / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+// redact keeps only the start of a match so it can be logged safely
+func redact(m string) string {
+	if len(m) <= 8 {
+		return "****"
+	}
+	return m[:4] + "****"
+}
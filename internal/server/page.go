@@ -0,0 +1,77 @@
+package server
+
+import (
+	"html/template"
+	"time"
+)
+
+var page = template.Must(template.New("status").Funcs(template.FuncMap{
+	"ago": func(t, now time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return now.Sub(t).Round(time.Second).String() + " ago"
+	},
+	"short": func(hash string) string {
+		if len(hash) > 7 {
+			return hash[:7]
+		}
+		return hash
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="30">
+<title>autogit status</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h2 { margin-bottom: 0.2em; }
+.path { color: #666; font-size: 0.9em; }
+.running { color: #1a7f37; } .error, .unresponsive { color: #cf222e; } .paused, .stopped { color: #9a6700; }
+table { border-collapse: collapse; margin-top: 0.5em; }
+td, th { padding: 0.2em 1em 0.2em 0; text-align: left; vertical-align: top; }
+code { font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>autogit</h1>
+<p class="path">Updated {{.Now.Format "2006-01-02 15:04:05"}}, refreshes every 30s. <a href="status.json?token={{.Token}}">JSON</a></p>
+{{$now := .Now}}
+{{range $name, $state := .Breakers}}
+<p class="{{if $state.Open $now}}error{{else}}paused{{end}}">AI provider {{$name}}: {{if $state.Open $now}}skipped until {{$state.OpenUntil.Format "15:04:05"}}{{else}}{{$state.Failures}} recent failure(s){{end}}{{if $state.LastError}}, last error: {{$state.LastError}}{{end}}</p>
+{{if $state.Recent}}<ul>{{range $state.Recent}}<li>{{ago .At $now}}: {{.Summary}}</li>{{end}}</ul>{{end}}
+{{end}}
+{{range .Repos}}
+<h2>{{.Name}} <span class="{{.Daemon}}">● {{.Daemon}}</span></h2>
+<div class="path">{{.Path}}{{range .Groups}} · <a href="?group={{.}}&amp;token={{$.Token}}">{{.}}</a>{{end}}</div>
+{{with .Health}}
+<table>
+<tr><th>Last check</th><td>{{ago .LastCheck $now}}</td></tr>
+<tr><th>Last commit</th><td>{{ago .LastCommit $now}}</td></tr>
+{{if .LastError}}<tr><th>Last error</th><td class="error">{{ago .LastErrorAt $now}}: {{.LastError}}</td></tr>{{end}}
+{{if .ModelFallback}}<tr><th>Warning</th><td class="paused">{{.ModelFallback}}</td></tr>{{end}}
+{{if .PendingApproval}}<tr><th>Awaiting approval</th><td>{{range .PendingApproval}}<code>{{.}}</code> {{end}}</td></tr>{{end}}
+{{if .HeldLFS}}<tr><th>Held for Git LFS</th><td>{{range .HeldLFS}}<code>{{.}}</code> {{end}}</td></tr>{{end}}
+</table>
+{{end}}
+<table>
+<tr><th></th><th>Commits</th><th>Lines</th><th>AI tokens</th><th>Failures</th></tr>
+<tr><td>Today</td><td>{{.Today.Commits}}</td><td>+{{.Today.LinesAdded}} -{{.Today.LinesDeleted}}</td><td>{{.Today.AITokens}}</td><td>{{.Today.Failures}}</td></tr>
+<tr><td>Last 7 days</td><td>{{.LastWeek.Commits}}</td><td>+{{.LastWeek.LinesAdded}} -{{.LastWeek.LinesDeleted}}</td><td>{{.LastWeek.AITokens}}</td><td>{{.LastWeek.Failures}}</td></tr>
+</table>
+{{if .Commits}}
+<table>
+<tr><th>Commit</th><th>When</th><th>Trigger</th><th>Message</th></tr>
+{{range .Commits}}<tr><td><code>{{short .Commit}}</code></td><td>{{ago .Time $now}}</td><td>{{.Trigger}}</td><td>{{.Message}}</td></tr>
+{{end}}
+</table>
+{{else}}
+<p>No commits recorded.</p>
+{{end}}
+{{else}}
+<p>No repositories configured. Run <code>autogit init</code> in a repository.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/breaker"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/history"
+	"github.com/aadityansha/autogit/internal/stats"
+)
+
+// DefaultAddr is where 'autogit serve' listens unless told otherwise.
+// Only local clients can reach it; bind another address to expose it.
+const DefaultAddr = "127.0.0.1:7420"
+
+// recentCommits is how many commits are listed per repository
+const recentCommits = 5
+
+// Status is everything the status page shows
+type Status struct {
+	Repos    []RepoStatus             `json:"repos"`
+	Breakers map[string]breaker.State `json:"breakers"` // AI providers that failed recently, by name
+}
+
+// RepoStatus is what the status page shows for one repository
+type RepoStatus struct {
+	Name     string          `json:"name"`
+	Path     string          `json:"path"`
+	Groups   []string        `json:"groups,omitempty"`
+	Daemon   string          `json:"daemon"` // "running", "error", "paused", "unresponsive" or "stopped"
+	Health   *config.Health  `json:"health,omitempty"`
+	Commits  []history.Entry `json:"commits"` // Newest first
+	Today    stats.Day       `json:"today"`
+	LastWeek stats.Day       `json:"last_week"`
+}
+
+// New returns a read-only handler for the status page at / and the same
+// data as JSON at /status.json. Every request must carry token, either as
+// "Authorization: Bearer <token>" or as the token query parameter. The
+// group query parameter limits both to the repositories in that group.
+// Clicks on Slack approval buttons are accepted at /slack/actions, signed
+// by Slack instead.
+func New(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		status, err := Collect(time.Now(), r.URL.Query().Get("group"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		page.Execute(w, struct {
+			*Status
+			Now   time.Time
+			Token string
+		}{status, time.Now(), r.URL.Query().Get("token")})
+	})
+	mux.HandleFunc("/status.json", func(w http.ResponseWriter, r *http.Request) {
+		status, err := Collect(time.Now(), r.URL.Query().Get("group"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	})
+	
+	root := http.NewServeMux()
+	root.Handle("/slack/actions", slackActions())
+	root.Handle("/", Authorize(token, readOnly(mux)))
+	return root
+}
+
+// Authorize passes on requests that carry token, either as
+// "Authorization: Bearer <token>" or as the token query parameter, and
+// answers the others with 401. An empty token lets every request through.
+func Authorize(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := r.URL.Query().Get("token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			given = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func readOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Collect gathers the status of every configured repository, of those
+// daemons run for, and of the AI provider circuits. A non-empty group
+// keeps only the repositories in that group.
+func Collect(now time.Time, group string) (*Status, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	store, err := history.OpenReadOnly(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	daemons, _, _ := config.ListLiveDaemonInfo()
+	
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, info := range daemons {
+		add(info.RepoPath)
+	}
+	add(cfg.RootPath)
+	for _, r := range cfg.Repos {
+		add(r.Path)
+	}
+	
+	repos := make([]RepoStatus, 0, len(paths))
+	for _, path := range paths {
+		rc := cfg.ForRepo(path)
+		if group != "" && !rc.InGroup(group) {
+			continue
+		}
+		name := git.GetRepoName(path)
+		repo := RepoStatus{Name: name, Path: path, Groups: rc.Groups, Daemon: "stopped", Commits: []history.Entry{}}
+		
+		if daemonInfo := config.DaemonFor(daemons, path); daemonInfo != nil {
+			repo.Daemon = daemonInfo.Status
+			if health := config.HealthFor(daemonInfo); health != nil {
+				repo.Health = health
+				repo.Daemon = health.Status
+				if health.Stale(now) {
+					repo.Daemon = "unresponsive"
+				}
+			}
+		}
+		
+		if entries, err := store.Load(rc.StateName()); err == nil {
+			for i := len(entries) - 1; i >= 0 && len(repo.Commits) < recentCommits; i-- {
+				repo.Commits = append(repo.Commits, entries[i])
+			}
+		}
+		if s, err := stats.Load(rc.StateName()); err == nil {
+			repo.Today = s.Since(now)
+			repo.LastWeek = s.Since(now.AddDate(0, 0, -6))
+		}
+		repos = append(repos, repo)
+	}
+	
+	breakers, err := breaker.Load()
+	if err != nil {
+		breakers = map[string]breaker.State{}
+	}
+	return &Status{Repos: repos, Breakers: breakers}, nil
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/approval"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/notify"
+)
+
+// slackMaxAge is how old a signed Slack request may be, which keeps
+// recorded requests from being replayed
+const slackMaxAge = 5 * time.Minute
+
+// maxSlackBody limits the size of a Slack request
+const maxSlackBody = 1 << 20
+
+// slackAction is the part of a Slack block action payload that matters
+type slackAction struct {
+	Type        string `json:"type"`
+	ResponseURL string `json:"response_url"`
+	User        struct {
+		Name string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// slackActions handles clicks on the Approve and Reject buttons of
+// approval messages. Slack cannot send the page token, so requests are
+// verified with approval.slack.signing_secret instead.
+func slackActions() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		secret := cfg.Approval.Slack.GetSigningSecret()
+		if secret == "" {
+			http.Error(w, "approval.slack.signing_secret is not set", http.StatusForbidden)
+			return
+		}
+		
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSlackBody))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := verifySlack(secret, r.Header, body, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var payload slackAction
+		if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil || payload.Type != "block_actions" || len(payload.Actions) == 0 {
+			http.Error(w, "expected a block_actions payload", http.StatusBadRequest)
+			return
+		}
+		action := payload.Actions[0]
+		var held notify.SlackApproval
+		if err := json.Unmarshal([]byte(action.Value), &held); err != nil || held.Repo == "" || held.Hash == "" {
+			http.Error(w, "invalid button value", http.StatusBadRequest)
+			return
+		}
+		
+		reply, err := decide(action.ActionID, held, payload.User.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		
+		if payload.ResponseURL != "" {
+			go func() {
+				if err := notify.ReplySlack(context.Background(), payload.ResponseURL, reply); err != nil {
+					log.Printf("Failed to update Slack message: %v", err)
+				}
+			}()
+		}
+	})
+}
+
+// decide records the approval or rejection of held by user and returns
+// the text that replaces the Slack message
+func decide(action string, held notify.SlackApproval, user string) (string, error) {
+	switch action {
+	case notify.SlackApprove:
+		if err := approval.Approve(held.StateName(), held.Files, held.Hash, "@"+user+" on Slack"); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ @%s approved %d change(s) in %s. The next cycle commits them, unless they were edited since.", user, held.Changes(), held.Repo), nil
+	case notify.SlackReject:
+		if approval.Approved(held.StateName(), held.Hash) {
+			if err := approval.Clear(held.StateName()); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("❌ @%s rejected the changes in %s. They stay uncommitted until approved with 'autogit approve' or reverted.", user, held.Repo), nil
+	}
+	return "", fmt.Errorf("unknown action %q", action)
+}
+
+// verifySlack checks the signature Slack puts on every request, an HMAC of
+// the timestamp and body keyed with the app's signing secret
+func verifySlack(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing Slack timestamp")
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > slackMaxAge || age < -slackMaxAge {
+		return fmt.Errorf("Slack request is too old")
+	}
+	
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("invalid Slack signature")
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/pathutil"
+)
+
+// Install registers the daemon for rootPath with the operating system's
+// service manager so that it is started at login and restarted on failure.
+// It returns the path of the generated unit file.
+func Install(rootPath string) (string, error) {
+	execPath, err := executablePath()
+	if err != nil {
+		return "", err
+	}
+	
+	name := serviceName(rootPath)
+	
+	switch runtime.GOOS {
+	case "linux":
+		return installSystemd(name, execPath, rootPath)
+	case "darwin":
+		return installLaunchd(name, execPath, rootPath)
+	case "windows":
+		return installWindows(name, execPath, rootPath)
+	default:
+		return "", fmt.Errorf("service installation is not supported on %s", runtime.GOOS)
+	}
+}
+
+// Uninstall removes a service previously registered with Install, also
+// one installed under the name used before it included a path hash
+func Uninstall(rootPath string) error {
+	if legacy := legacyServiceName(rootPath); registered(legacy) && owns(legacy, rootPath) {
+		if err := Remove(legacy); err != nil {
+			return err
+		}
+		if !registered(serviceName(rootPath)) {
+			return nil
+		}
+	}
+	return Remove(serviceName(rootPath))
+}
+
+// List returns the names of all services registered with Install, for
+// any repository
+func List() ([]string, error) {
+	var pattern string
+	switch runtime.GOOS {
+	case "linux":
+		unitPath, err := systemdUnitPath("autogit-*")
+		if err != nil {
+			return nil, err
+		}
+		pattern = unitPath
+	case "darwin":
+		plistPath, err := launchdPlistPath("autogit-*")
+		if err != nil {
+			return nil, err
+		}
+		pattern = plistPath
+	case "windows":
+		out, err := exec.Command("schtasks", "/Query", "/FO", "CSV", "/NH").Output()
+		if err != nil {
+			return nil, fmt.Errorf("schtasks failed: %w", err)
+		}
+		var names []string
+		for _, line := range strings.Split(string(out), "\n") {
+			// "\autogit-name","next run","status"
+			task, _, _ := strings.Cut(strings.TrimSpace(line), ",")
+			task = strings.TrimPrefix(strings.Trim(task, `"`), `\`)
+			if strings.HasPrefix(task, "autogit-") && !contains(names, task) {
+				names = append(names, task)
+			}
+		}
+		return names, nil
+	default:
+		return nil, nil
+	}
+	
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		names = append(names, strings.TrimPrefix(name, "com.autogit."))
+	}
+	return names, nil
+}
+
+// Remove stops and removes the service with the given name, as returned
+// by List
+func Remove(name string) error {
+	switch runtime.GOOS {
+	case "linux":
+		unitPath, err := systemdUnitPath(name)
+		if err != nil {
+			return err
+		}
+		run("systemctl", "--user", "disable", "--now", name+".service")
+		if err := os.Remove(unitPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove unit file: %w", err)
+		}
+		return run("systemctl", "--user", "daemon-reload")
+	case "darwin":
+		plistPath, err := launchdPlistPath(name)
+		if err != nil {
+			return err
+		}
+		run("launchctl", "unload", "-w", plistPath)
+		if err := os.Remove(plistPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove plist: %w", err)
+		}
+		return nil
+	case "windows":
+		return run("schtasks", "/Delete", "/F", "/TN", name)
+	default:
+		return fmt.Errorf("service installation is not supported on %s", runtime.GOOS)
+	}
+}
+
+// Installed reports whether a service is registered for rootPath
+func Installed(rootPath string) bool {
+	if registered(serviceName(rootPath)) {
+		return true
+	}
+	legacy := legacyServiceName(rootPath)
+	return registered(legacy) && owns(legacy, rootPath)
+}
+
+// registered reports whether a service with the given name exists
+func registered(name string) bool {
+	switch runtime.GOOS {
+	case "linux":
+		unitPath, err := systemdUnitPath(name)
+		return err == nil && exists(unitPath)
+	case "darwin":
+		plistPath, err := launchdPlistPath(name)
+		return err == nil && exists(plistPath)
+	case "windows":
+		return exec.Command("schtasks", "/Query", "/TN", name).Run() == nil
+	default:
+		return false
+	}
+}
+
+// owns reports whether the service with the given name starts the daemon
+// for rootPath. Names without a path hash are shared by repositories with
+// the same directory name, so only the definition tells them apart.
+func owns(name, rootPath string) bool {
+	var definition []byte
+	var err error
+	switch runtime.GOOS {
+	case "linux":
+		var unitPath string
+		if unitPath, err = systemdUnitPath(name); err == nil {
+			definition, err = os.ReadFile(unitPath)
+		}
+	case "darwin":
+		var plistPath string
+		if plistPath, err = launchdPlistPath(name); err == nil {
+			definition, err = os.ReadFile(plistPath)
+		}
+	case "windows":
+		// The task's command line ends with the quoted path
+		var out []byte
+		if out, err = exec.Command("schtasks", "/Query", "/TN", name, "/V", "/FO", "LIST").Output(); err == nil {
+			for _, line := range strings.Split(string(out), "\n") {
+				if strings.HasSuffix(strings.TrimSpace(line), " start-daemon "+pathutil.QuoteArg(rootPath)) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(definition, []byte(`"`+rootPath+`"`)) ||
+		bytes.Contains(definition, []byte("<string>"+escapeXML(rootPath)+"</string>"))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// serviceName derives a stable, filesystem-safe service name for a
+// repository. The hash of its path keeps repositories with the same
+// directory name, such as ~/work/api and ~/oss/api, from replacing each
+// other's service.
+func serviceName(rootPath string) string {
+	return legacyServiceName(rootPath) + "-" + config.PathID(rootPath)
+}
+
+// legacyServiceName is the name services were installed under before it
+// included a hash of the path
+func legacyServiceName(rootPath string) string {
+	repo := git.GetRepoName(rootPath)
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '-'
+		}
+	}, repo)
+	return "autogit-" + safe
+}
+
+func executablePath() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	absExecPath, err := filepath.Abs(execPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve executable path: %w", err)
+	}
+	return absExecPath, nil
+}
+
+const systemdTemplate = `[Unit]
+Description=Autogit daemon for {{systemd .RootPath}}
+After=network-online.target
+
+[Service]
+Type=simple
+ExecStart="{{systemdArg .ExecPath}}" start-daemon "{{systemdArg .RootPath}}"
+WorkingDirectory={{systemd .RootPath}}
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`
+
+func systemdUnitPath(name string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "systemd", "user", name+".service"), nil
+}
+
+func installSystemd(name, execPath, rootPath string) (string, error) {
+	unitPath, err := systemdUnitPath(name)
+	if err != nil {
+		return "", err
+	}
+	if err := writeTemplate(unitPath, systemdTemplate, execPath, rootPath); err != nil {
+		return "", err
+	}
+	if err := run("systemctl", "--user", "daemon-reload"); err != nil {
+		return unitPath, err
+	}
+	if err := run("systemctl", "--user", "enable", "--now", name+".service"); err != nil {
+		return unitPath, err
+	}
+	return unitPath, nil
+}
+
+const launchdTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.autogit.{{.Name}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{xml .ExecPath}}</string>
+		<string>start-daemon</string>
+		<string>{{xml .RootPath}}</string>
+	</array>
+	<key>WorkingDirectory</key>
+	<string>{{xml .RootPath}}</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+</dict>
+</plist>
+`
+
+func launchdPlistPath(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", "com.autogit."+name+".plist"), nil
+}
+
+func installLaunchd(name, execPath, rootPath string) (string, error) {
+	plistPath, err := launchdPlistPath(name)
+	if err != nil {
+		return "", err
+	}
+	if err := writeTemplate(plistPath, launchdTemplate, execPath, rootPath); err != nil {
+		return "", err
+	}
+	if err := run("launchctl", "load", "-w", plistPath); err != nil {
+		return plistPath, err
+	}
+	return plistPath, nil
+}
+
+// installWindows registers a logon task with Task Scheduler. A plain
+// executable cannot act as a native Windows service, so the scheduler is
+// used as the supervisor instead.
+func installWindows(name, execPath, rootPath string) (string, error) {
+	command := pathutil.QuoteArg(execPath) + " start-daemon " + pathutil.QuoteArg(rootPath)
+	if err := run("schtasks", "/Create", "/F", "/SC", "ONLOGON", "/RL", "LIMITED", "/TN", name, "/TR", command); err != nil {
+		return "", err
+	}
+	if err := run("schtasks", "/Run", "/TN", name); err != nil {
+		return name, err
+	}
+	return name, nil
+}
+
+func writeTemplate(path, tmpl, execPath, rootPath string) error {
+	t, err := template.New(filepath.Base(path)).Funcs(template.FuncMap{"xml": escapeXML, "systemd": escapeSystemd, "systemdArg": escapeSystemdArg}).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	
+	data := struct {
+		Name     string
+		ExecPath string
+		RootPath string
+	}{
+		Name:     serviceName(rootPath),
+		ExecPath: execPath,
+		RootPath: rootPath,
+	}
+	if err := t.Execute(f, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// escapeXML escapes s for use as XML character data, e.g. a path with &
+// or < in a plist
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// escapeSystemd escapes s for a value in a systemd unit, where % starts a
+// specifier such as %h, e.g. a path with % in WorkingDirectory=
+func escapeSystemd(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// escapeSystemdArg escapes s for a double-quoted argument of ExecStart=,
+// where backslashes and quotes are escaped as well
+func escapeSystemdArg(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+	return escapeSystemd(s)
+}
+
+func run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSystemdUnitEscapesPaths writes units for paths with characters that
+// systemd would otherwise expand or split, and checks the lines that name
+// them
+func TestSystemdUnitEscapesPaths(t *testing.T) {
+	tests := []struct {
+		root            string
+		execStart, wdir string
+	}{
+		{"/home/me/notes", `ExecStart="/usr/bin/autogit" start-daemon "/home/me/notes"`, "WorkingDirectory=/home/me/notes"},
+		{"/home/me/100% done", `ExecStart="/usr/bin/autogit" start-daemon "/home/me/100%% done"`, "WorkingDirectory=/home/me/100%% done"},
+		{"/home/me/%h", `ExecStart="/usr/bin/autogit" start-daemon "/home/me/%%h"`, "WorkingDirectory=/home/me/%%h"},
+		{`/home/me/say "hi"`, `ExecStart="/usr/bin/autogit" start-daemon "/home/me/say \"hi\""`, `WorkingDirectory=/home/me/say "hi"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.root, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "autogit.service")
+			if err := writeTemplate(path, systemdTemplate, "/usr/bin/autogit", tt.root); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(string(data), "\n")
+			for _, want := range []string{tt.execStart, tt.wdir} {
+				found := false
+				for _, line := range lines {
+					found = found || line == want
+				}
+				if !found {
+					t.Errorf("unit lacks %q:\n%s", want, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package spend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+const (
+	// dateLayout keys daily usage by local calendar day
+	dateLayout = "2006-01-02"
+	// hourLayout keys the request count of the current hour
+	hourLayout = "2006-01-02T15"
+	// retainDays is how many days of usage are kept, enough for the
+	// previous month
+	retainDays = 62
+)
+
+// Usage is what AI calls used, on one day or over a period
+type Usage struct {
+	Requests int     `json:"requests"`
+	Tokens   int64   `json:"tokens"`
+	Cost     float64 `json:"cost"` // Estimated USD
+}
+
+func (u *Usage) add(o Usage) {
+	u.Requests += o.Requests
+	u.Tokens += o.Tokens
+	u.Cost += o.Cost
+}
+
+// Ledger is the AI usage of every daemon, kept in a file they share so
+// that ai_budget caps them together
+type Ledger struct {
+	Days         map[string]map[string]Usage `json:"days"`                    // By local date, then provider
+	Hour         string                      `json:"hour,omitempty"`          // Hour of HourRequests, e.g. "2024-05-01T14"
+	HourRequests int                         `json:"hour_requests,omitempty"` // Requests in that hour
+	Notified     string                      `json:"notified,omitempty"`      // Cap and period last notified, so each is notified once
+}
+
+// Load returns the usage recorded so far
+func Load() (*Ledger, error) {
+	data, err := os.ReadFile(config.GetAIUsagePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Ledger{Days: map[string]map[string]Usage{}}, nil
+		}
+		return nil, fmt.Errorf("failed to read AI usage: %w", err)
+	}
+	
+	var l Ledger
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal AI usage: %w", err)
+	}
+	if l.Days == nil {
+		l.Days = map[string]map[string]Usage{}
+	}
+	return &l, nil
+}
+
+// Request records a request to provider at now
+func Request(provider string, now time.Time) error {
+	return update(func(l *Ledger) {
+		l.add(provider, now, Usage{Requests: 1})
+		if hour := now.Format(hourLayout); l.Hour != hour {
+			l.Hour, l.HourRequests = hour, 0
+		}
+		l.HourRequests++
+	})
+}
+
+// Tokens records tokens used by provider at now, priced at price USD per
+// million tokens
+func Tokens(provider string, tokens int64, price float64, now time.Time) error {
+	return update(func(l *Ledger) {
+		l.add(provider, now, Usage{Tokens: tokens, Cost: float64(tokens) * price / 1e6})
+	})
+}
+
+// Notify reports whether the reached cap has not been notified in its
+// period yet, and records that it now has
+func Notify(limit Limit) bool {
+	key := limit.Cap + " " + limit.Until.Format(time.RFC3339)
+	notify := false
+	update(func(l *Ledger) {
+		notify = l.Notified != key
+		l.Notified = key
+	})
+	return notify
+}
+
+func (l *Ledger) add(provider string, now time.Time, u Usage) {
+	day := now.Format(dateLayout)
+	if l.Days[day] == nil {
+		l.Days[day] = map[string]Usage{}
+	}
+	usage := l.Days[day][strings.ToLower(provider)]
+	usage.add(u)
+	l.Days[day][strings.ToLower(provider)] = usage
+}
+
+// Since returns the usage of each provider from the day of since on
+func (l *Ledger) Since(since time.Time) map[string]Usage {
+	from := since.Format(dateLayout)
+	totals := make(map[string]Usage)
+	for day, providers := range l.Days {
+		if day < from {
+			continue
+		}
+		for provider, u := range providers {
+			total := totals[provider]
+			total.add(u)
+			totals[provider] = total
+		}
+	}
+	return totals
+}
+
+// sum adds up the usage of all providers
+func sum(usage map[string]Usage) Usage {
+	var total Usage
+	for _, u := range usage {
+		total.add(u)
+	}
+	return total
+}
+
+// Limit is a cap of ai_budget that was reached
+type Limit struct {
+	Cap   string    // e.g. "daily cost cap of $1.00"
+	Until time.Time // When the period of the cap ends
+}
+
+// Exceeded returns the first cap of budget the usage has reached at now,
+// or nil when AI calls may go ahead
+func (l *Ledger) Exceeded(budget config.AIBudget, now time.Time) *Limit {
+	y, m, d := now.Date()
+	hour := time.Date(y, m, d, now.Hour(), 0, 0, 0, now.Location())
+	day := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	month := time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	today, thisMonth := sum(l.Since(day)), sum(l.Since(month))
+	
+	hourRequests := 0
+	if l.Hour == now.Format(hourLayout) {
+		hourRequests = l.HourRequests
+	}
+	switch {
+	case budget.RequestsPerHour > 0 && hourRequests >= budget.RequestsPerHour:
+		return &Limit{fmt.Sprintf("limit of %d requests per hour", budget.RequestsPerHour), hour.Add(time.Hour)}
+	case budget.DailyTokens > 0 && today.Tokens >= budget.DailyTokens:
+		return &Limit{fmt.Sprintf("daily cap of %d tokens", budget.DailyTokens), day.AddDate(0, 0, 1)}
+	case budget.DailyCost > 0 && today.Cost >= budget.DailyCost:
+		return &Limit{fmt.Sprintf("daily cost cap of $%.2f", budget.DailyCost), day.AddDate(0, 0, 1)}
+	case budget.MonthlyTokens > 0 && thisMonth.Tokens >= budget.MonthlyTokens:
+		return &Limit{fmt.Sprintf("monthly cap of %d tokens", budget.MonthlyTokens), month.AddDate(0, 1, 0)}
+	case budget.MonthlyCost > 0 && thisMonth.Cost >= budget.MonthlyCost:
+		return &Limit{fmt.Sprintf("monthly cost cap of $%.2f", budget.MonthlyCost), month.AddDate(0, 1, 0)}
+	}
+	return nil
+}
+
+// Report renders the usage of today and this month by provider, and how
+// much of each cap of budget is used
+func (l *Ledger) Report(budget config.AIBudget, now time.Time) string {
+	var b strings.Builder
+	y, m, d := now.Date()
+	today := l.Since(time.Date(y, m, d, 0, 0, 0, 0, now.Location()))
+	thisMonth := l.Since(time.Date(y, m, 1, 0, 0, 0, 0, now.Location()))
+	
+	providers := make([]string, 0, len(thisMonth))
+	for provider := range thisMonth {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	
+	fmt.Fprintf(&b, "%-13s %10s %12s %10s\n", "AI usage", "Requests", "Tokens", "Est. cost")
+	for _, p := range []struct {
+		label string
+		usage map[string]Usage
+	}{{"Today", today}, {"This month", thisMonth}} {
+		fmt.Fprintf(&b, "%-13s %10d %12d %10s\n", p.label, sum(p.usage).Requests, sum(p.usage).Tokens, dollars(sum(p.usage).Cost))
+		if len(providers) > 1 {
+			for _, provider := range providers {
+				u := p.usage[provider]
+				fmt.Fprintf(&b, "  %-11s %10d %12d %10s\n", provider, u.Requests, u.Tokens, dollars(u.Cost))
+			}
+		}
+	}
+	
+	if budget.Enabled() {
+		var caps []string
+		if budget.RequestsPerHour > 0 {
+			requests := 0
+			if l.Hour == now.Format(hourLayout) {
+				requests = l.HourRequests
+			}
+			caps = append(caps, fmt.Sprintf("%d of %d requests this hour", requests, budget.RequestsPerHour))
+		}
+		if budget.DailyTokens > 0 {
+			caps = append(caps, fmt.Sprintf("%d of %d tokens today", sum(today).Tokens, budget.DailyTokens))
+		}
+		if budget.DailyCost > 0 {
+			caps = append(caps, fmt.Sprintf("%s of $%.2f today", dollars(sum(today).Cost), budget.DailyCost))
+		}
+		if budget.MonthlyTokens > 0 {
+			caps = append(caps, fmt.Sprintf("%d of %d tokens this month", sum(thisMonth).Tokens, budget.MonthlyTokens))
+		}
+		if budget.MonthlyCost > 0 {
+			caps = append(caps, fmt.Sprintf("%s of $%.2f this month", dollars(sum(thisMonth).Cost), budget.MonthlyCost))
+		}
+		fmt.Fprintf(&b, "Budget: %s\n", strings.Join(caps, ", "))
+		if limit := l.Exceeded(budget, now); limit != nil {
+			fmt.Fprintf(&b, "Reached the %s; using template messages until %s\n", limit.Cap, limit.Until.Format("Jan 2 15:04"))
+		}
+	}
+	b.WriteString("Costs are estimates from token counts; set ai_budget.prices to match your plan.")
+	return b.String()
+}
+
+// dollars formats an estimated cost, with more digits for the fractions
+// of a cent a few requests cost
+func dollars(cost float64) string {
+	if cost > 0 && cost < 0.01 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// update changes the ledger in place and drops days past retainDays.
+// Daemons may race on the file; the last write wins, which at worst loses
+// one request.
+func update(change func(l *Ledger)) error {
+	l, err := Load()
+	if err != nil {
+		l = &Ledger{Days: map[string]map[string]Usage{}}
+	}
+	change(l)
+	cutoff := time.Now().AddDate(0, 0, -retainDays).Format(dateLayout)
+	for day := range l.Days {
+		if day < cutoff {
+			delete(l.Days, day)
+		}
+	}
+	
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal AI usage: %w", err)
+	}
+	path := config.GetAIUsagePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write AI usage: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write AI usage: %w", err)
+	}
+	return nil
+}
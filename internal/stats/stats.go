@@ -0,0 +1,220 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// dateLayout keys daily buckets by local calendar day
+const dateLayout = "2006-01-02"
+
+// retainDays is how many days of history are kept per repository
+const retainDays = 400
+
+// Day holds the activity of one calendar day
+type Day struct {
+	Date         string `json:"date"` // Local date, e.g. "2024-05-01"
+	Commits      int    `json:"commits"`
+	LinesAdded   int    `json:"lines_added"`
+	LinesDeleted int    `json:"lines_deleted"`
+	AITokens     int64  `json:"ai_tokens"`
+	Failures     int    `json:"failures"`
+}
+
+func (d *Day) add(o Day) {
+	d.Commits += o.Commits
+	d.LinesAdded += o.LinesAdded
+	d.LinesDeleted += o.LinesDeleted
+	d.AITokens += o.AITokens
+	d.Failures += o.Failures
+}
+
+// Stats is the activity history of one repository, oldest day first
+type Stats struct {
+	RepoPath string `json:"repo_path"`
+	Days     []Day  `json:"days"`
+}
+
+// Load reads the statistics for repoName. A repository without history
+// has empty statistics.
+func Load(repoName string) (*Stats, error) {
+	data, err := os.ReadFile(config.GetStatsPath(repoName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Stats{}, nil
+		}
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+	
+	var s Stats
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal stats: %w", err)
+	}
+	
+	return &s, nil
+}
+
+func (s *Stats) save(repoName string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats: %w", err)
+	}
+	
+	path := config.GetStatsPath(repoName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	
+	return nil
+}
+
+// day returns the bucket for t, creating it and dropping expired days
+func (s *Stats) day(t time.Time) *Day {
+	date := t.Format(dateLayout)
+	if n := len(s.Days); n > 0 && s.Days[n-1].Date == date {
+		return &s.Days[n-1]
+	}
+	
+	s.Days = append(s.Days, Day{Date: date})
+	if len(s.Days) > retainDays {
+		s.Days = s.Days[len(s.Days)-retainDays:]
+	}
+	return &s.Days[len(s.Days)-1]
+}
+
+// Since sums the activity of all days from the day of since onwards. A
+// zero since covers the whole history.
+func (s *Stats) Since(since time.Time) Day {
+	from := ""
+	if !since.IsZero() {
+		from = since.Format(dateLayout)
+	}
+	
+	var total Day
+	for _, d := range s.Days {
+		if d.Date >= from {
+			total.add(d)
+		}
+	}
+	return total
+}
+
+// Recent returns the last n days up to now, newest first, including days
+// without activity
+func (s *Stats) Recent(n int, now time.Time) []Day {
+	byDate := make(map[string]Day, len(s.Days))
+	for _, d := range s.Days {
+		byDate[d.Date] = d
+	}
+	
+	days := make([]Day, 0, n)
+	for i := 0; i < n; i++ {
+		date := now.AddDate(0, 0, -i).Format(dateLayout)
+		d, ok := byDate[date]
+		if !ok {
+			d = Day{Date: date}
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+// Recorder adds the daemon's activity to the statistics of one repository
+// and persists every change
+type Recorder struct {
+	mu       sync.Mutex
+	repoName string
+	stats    *Stats
+}
+
+// NewRecorder continues the history of repoName. Unreadable history is
+// started afresh rather than stopping the daemon.
+func NewRecorder(repoName, repoPath string) *Recorder {
+	s, err := Load(repoName)
+	if err != nil {
+		s = &Stats{}
+	}
+	s.RepoPath = repoPath
+	return &Recorder{repoName: repoName, stats: s}
+}
+
+func (r *Recorder) record(change func(d *Day)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	change(r.stats.day(time.Now()))
+	return r.stats.save(r.repoName)
+}
+
+// Commit records a commit and the lines it changed
+func (r *Recorder) Commit(added, deleted int) error {
+	return r.record(func(d *Day) {
+		d.Commits++
+		d.LinesAdded += added
+		d.LinesDeleted += deleted
+	})
+}
+
+// Tokens records AI tokens used
+func (r *Recorder) Tokens(n int64) error {
+	return r.record(func(d *Day) {
+		d.AITokens += n
+	})
+}
+
+// Failure records a failed step of a commit cycle
+func (r *Recorder) Failure() error {
+	return r.record(func(d *Day) {
+		d.Failures++
+	})
+}
+
+// Report renders totals for today, the last 7 and 30 days and all time,
+// followed by one row per day for the last days days when days > 0
+func (s *Stats) Report(now time.Time, days int) string {
+	var b strings.Builder
+	header := fmt.Sprintf("%-13s %8s %16s %10s %9s\n", "", "Commits", "Lines", "AI tokens", "Failures")
+	
+	b.WriteString(header)
+	periods := []struct {
+		label string
+		since time.Time
+	}{
+		{"Today", now},
+		{"Last 7 days", now.AddDate(0, 0, -6)},
+		{"Last 30 days", now.AddDate(0, 0, -29)},
+		{"All time", time.Time{}},
+	}
+	for _, p := range periods {
+		b.WriteString(row(p.label, s.Since(p.since)))
+	}
+	
+	if days > 0 {
+		b.WriteString("\n")
+		b.WriteString(header)
+		for _, d := range s.Recent(days, now) {
+			b.WriteString(row(d.Date, d))
+		}
+	}
+	
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func row(label string, d Day) string {
+	lines := fmt.Sprintf("+%d -%d", d.LinesAdded, d.LinesDeleted)
+	return fmt.Sprintf("%-13s %8d %16s %10d %9d\n", label, d.Commits, lines, d.AITokens, d.Failures)
+}
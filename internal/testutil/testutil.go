@@ -0,0 +1,76 @@
+// Package testutil holds the repository fixtures shared by the tests of
+// the git, go-git and daemon packages
+package testutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GitRepo creates a repository with one commit in a temporary directory,
+// skipping the test when git is not installed
+func GitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	Git(t, root, "init", "-q")
+	Git(t, root, "config", "user.name", "Test")
+	Git(t, root, "config", "user.email", "test@example.com")
+	Git(t, root, "config", "commit.gpgsign", "false")
+	WriteFile(t, root, "README.md", "readme\n")
+	Git(t, root, "add", "README.md")
+	Git(t, root, "commit", "-q", "-m", "Initial commit")
+	return root
+}
+
+// GoGitRepo creates a repository with one commit in a temporary directory
+// through go-git, without the git binary
+func GoGitRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	repo, err := gogit.PlainInit(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WriteFile(t, root, "README.md", "readme\n")
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	author := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()}
+	if _, err := wt.Commit("Initial commit", &gogit.CommitOptions{Author: author}); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// Git runs git in root and returns its output, failing the test when it
+// fails
+func Git(t *testing.T, root string, args ...string) string {
+	t.Helper()
+	output, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return string(output)
+}
+
+// WriteFile writes content to the file name under root
+func WriteFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,90 @@
+package timefmt
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock formats
+const (
+	Clock24h = "24h" // 14:05 (default)
+	Clock12h = "12h" // 2:05 PM
+)
+
+// Clock renders wall-clock times in the user's preferred format
+type Clock string
+
+// Time formats t in local time. Times on another day include the date.
+func (c Clock) Time(t time.Time, now time.Time) string {
+	t = t.Local()
+	layout := "15:04"
+	if c == Clock12h {
+		layout = "3:04 PM"
+	}
+	
+	now = now.Local()
+	if t.Year() != now.Year() {
+		layout = "2006-01-02 " + layout
+	} else if t.YearDay() != now.YearDay() {
+		layout = "Jan 2 " + layout
+	}
+	return t.Format(layout)
+}
+
+// Stamp formats t in local time including seconds, for log lines
+func (c Clock) Stamp(t time.Time) string {
+	if c == Clock12h {
+		return t.Local().Format("Jan 2 3:04:05 PM")
+	}
+	return t.Local().Format("Jan 2 15:04:05")
+}
+
+// Both renders t as absolute and relative time, e.g. "14:05 (3m ago)"
+func (c Clock) Both(t time.Time, now time.Time) string {
+	return fmt.Sprintf("%s (%s)", c.Time(t, now), Relative(t, now))
+}
+
+// Relative renders the distance between t and now, e.g. "3m ago" or "in 7m"
+func Relative(t time.Time, now time.Time) string {
+	d := t.Sub(now)
+	if d > -time.Second && d < time.Second {
+		return "now"
+	}
+	if d < 0 {
+		return Duration(-d) + " ago"
+	}
+	return "in " + Duration(d)
+}
+
+// Duration renders d compactly using its two largest units, e.g. "1h 5m"
+func Duration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		h, m := int(d.Hours()), int(d.Minutes())%60
+		if m == 0 {
+			return fmt.Sprintf("%dh", h)
+		}
+		return fmt.Sprintf("%dh %dm", h, m)
+	default:
+		days, h := int(d.Hours())/24, int(d.Hours())%24
+		if h == 0 {
+			return fmt.Sprintf("%dd", days)
+		}
+		return fmt.Sprintf("%dd %dh", days, h)
+	}
+}
+
+// NextTick returns the next time a ticker with interval started at start
+// fires after now
+func NextTick(start time.Time, interval time.Duration, now time.Time) time.Time {
+	if interval <= 0 || now.Before(start) {
+		return start.Add(interval)
+	}
+	elapsed := now.Sub(start)
+	return start.Add((elapsed/interval + 1) * interval)
+}
@@ -0,0 +1,324 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/breaker"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/daemon"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/stats"
+)
+
+// basic is a line-oriented alternative to the full-screen model. It never
+// redraws or moves the cursor, so screen readers announce every line and
+// it works in terminals without alt-screen support.
+type basic struct {
+	in     *bufio.Scanner
+	out    io.Writer
+	config *config.Config
+	root   string // Repository the prompts were opened for
+}
+
+// RunBasic runs the dashboard, logs, settings and stats as sequential prompts
+// reading from in and writing plain text to out, for the repository at root
+// or without root the one of the working directory
+func RunBasic(in io.Reader, out io.Writer, root string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	
+	if root == "" {
+		root, _ = git.GetRootPath()
+	}
+	b := &basic{in: bufio.NewScanner(in), out: out, config: cfg, root: root}
+	for {
+		b.println("")
+		b.println("Main menu:")
+		b.println("  1. Dashboard")
+		b.println("  2. Logs")
+		b.println("  3. Settings")
+		b.println("  4. Stats")
+		b.println("  q. Quit")
+		
+		choice, ok := b.prompt("Choose an option")
+		if !ok {
+			return nil
+		}
+		switch strings.ToLower(choice) {
+		case "1", "dashboard":
+			b.dashboard()
+		case "2", "logs":
+			b.logs()
+		case "3", "settings":
+			b.settings()
+		case "4", "stats":
+			b.stats()
+		case "q", "quit", "exit":
+			return nil
+		default:
+			b.println("Unknown option: " + choice)
+		}
+	}
+}
+
+func (b *basic) println(line string) {
+	fmt.Fprintln(b.out, line)
+}
+
+// prompt asks for a line of input. It returns false at end of input.
+func (b *basic) prompt(label string) (string, bool) {
+	fmt.Fprintf(b.out, "%s: ", label)
+	if !b.in.Scan() {
+		b.println("")
+		return "", false
+	}
+	return strings.TrimSpace(b.in.Text()), true
+}
+
+// daemon returns the daemon of the current repository, or outside of one
+// the first that runs
+func (b *basic) daemon() *config.DaemonInfo {
+	daemons, _, _ := config.ListLiveDaemonInfo()
+	if b.root != "" {
+		return config.DaemonFor(daemons, b.root)
+	}
+	if len(daemons) > 0 {
+		return daemons[0]
+	}
+	return nil
+}
+
+func (b *basic) dashboard() {
+	daemonInfo := b.daemon()
+	
+	b.println("")
+	b.println("Dashboard")
+	if daemonInfo == nil {
+		b.println("Status: stopped")
+		b.println("Repository: not initialized")
+		return
+	}
+	
+	clock, now := b.config.Clock(), time.Now()
+	health := config.HealthFor(daemonInfo)
+	
+	status := "error"
+	switch {
+	case health != nil && health.Stale(now):
+		status = "unresponsive"
+	case health != nil:
+		status = health.Status
+	case daemonInfo.Status == daemon.StatusRunning, daemonInfo.Status == daemon.StatusPaused:
+		status = daemonInfo.Status
+	}
+	if status == daemon.StatusPaused {
+		status += " (run 'autogit resume' to continue)"
+	}
+	b.println("Status: " + status)
+	b.println("Repository: " + daemonInfo.RepoPath)
+	if groups := b.config.ForRepo(daemonInfo.RepoPath).Groups; len(groups) > 0 {
+		b.println("Groups: " + strings.Join(groups, ", "))
+	}
+	
+	if samples := aiErrorLines(breaker.Get(b.config.AIProvider), now); samples != "" {
+		b.println(fmt.Sprintf("AI provider (%s):\n%s", b.config.AIProvider, samples))
+	}
+	
+	if health != nil {
+		b.println(healthLines(health, daemonInfo, clock, now))
+		return
+	}
+	
+	timing := b.config.ForRepo(daemonInfo.RepoPath).GetCheckTiming()
+	if daemonInfo.StartedAt.IsZero() {
+		b.println("Check interval: " + timing.String())
+		return
+	}
+	b.println("Started: " + clock.Both(daemonInfo.StartedAt, now))
+	b.println("Next check: " + clock.Both(timing.Next(daemonInfo.StartedAt, now), now))
+}
+
+func (b *basic) stats() {
+	daemonInfo := b.daemon()
+	
+	b.println("")
+	if daemonInfo == nil {
+		b.println("No daemon running. No statistics available.")
+		return
+	}
+	
+	s, err := stats.Load(b.config.ForRepo(daemonInfo.RepoPath).StateName())
+	if err != nil {
+		b.println(fmt.Sprintf("Failed to load statistics: %v", err))
+		return
+	}
+	b.println("Statistics")
+	b.println(s.Report(time.Now(), 7))
+}
+
+func (b *basic) logs() {
+	daemonInfo := b.daemon()
+	
+	b.println("")
+	if daemonInfo == nil {
+		b.println("No daemon running. No logs available.")
+		return
+	}
+	
+	lines, err := recentLogLines(b.config.ForRepo(daemonInfo.RepoPath), 20)
+	if os.IsNotExist(err) {
+		b.println("No log file found.")
+		return
+	}
+	if err != nil {
+		b.println(err.Error())
+		return
+	}
+	
+	b.println(fmt.Sprintf("Last %d log lines, oldest first:", len(lines)))
+	clock, now := b.config.Clock(), time.Now()
+	for _, line := range lines {
+		b.println(formatLogTime(line, clock, now))
+	}
+	b.println("End of logs.")
+}
+
+func (b *basic) settings() {
+	// Edit a copy so that leaving without saving discards changes
+	cfg := *b.config
+	
+	for {
+		b.println("")
+		b.println("Settings:")
+		b.println("  1. AI provider: " + cfg.AIProvider)
+		b.println("  2. API key: " + maskKey(cfg.APIKey))
+		b.println("  3. Base URL: " + orNotSet(cfg.BaseURL))
+		if cfg.CheckInterval != "" {
+			b.println("  4. Check interval: " + cfg.CheckInterval)
+		} else {
+			b.println(fmt.Sprintf("  4. Check interval: %d minutes", cfg.CheckIntervalMinutes))
+		}
+		b.println("  s. Save and return")
+		b.println("  b. Return without saving")
+		
+		choice, ok := b.prompt("Choose a setting")
+		if !ok {
+			return
+		}
+		switch strings.ToLower(choice) {
+		case "1":
+			b.println("Providers: " + strings.Join(providers, ", "))
+			if v, ok := b.prompt("AI provider"); ok && v != "" {
+				if !isProvider(v) {
+					b.println("Error: unknown provider " + v)
+					continue
+				}
+				cfg.AIProvider = v
+				if cfg.APIKeyEnvOnly || cfg.APIKeyEnv != "" {
+					cfg.APIKey, cfg.APIKeyEnv = config.EnvAPIKey(v)
+				}
+			}
+		case "2":
+			if cfg.APIKeyEnvOnly {
+				b.println("The API key is read from the environment: " + strings.Join(config.APIKeyVars(cfg.AIProvider), ", "))
+				continue
+			}
+			if v, ok := b.prompt("API key (input is shown)"); ok && v != "" {
+				if err := validateAPIKey(cfg.AIProvider, v); err != nil {
+					b.println(fmt.Sprintf("Error: %v", err))
+					continue
+				}
+				cfg.APIKey, cfg.APIKeyEnv = v, ""
+			}
+		case "3":
+			if v, ok := b.prompt("Base URL, or - to clear"); ok && v != "" {
+				if v == "-" {
+					v = ""
+				}
+				if err := validateBaseURL(v); err != nil {
+					b.println(fmt.Sprintf("Error: %v", err))
+					continue
+				}
+				cfg.BaseURL = v
+			}
+		case "4":
+			if v, ok := b.prompt("Check interval in minutes"); ok && v != "" {
+				interval, err := validateInterval(v)
+				if err != nil {
+					b.println(fmt.Sprintf("Error: Check interval %v", err))
+					continue
+				}
+				cfg.CheckIntervalMinutes = interval
+				cfg.CheckInterval = ""
+			}
+		case "s", "save":
+			b.println("Validating API key...")
+			if err := ai.ValidateAPIKey(cfg.AIProvider, cfg.APIKey, cfg.BaseURL); err != nil {
+				b.println(fmt.Sprintf("Error: %v", err))
+				continue
+			}
+			if model := cfg.GetAIModel(); model != "" {
+				b.println("Checking model " + model + "...")
+				transport, err := ai.TransportFor(cfg.Network)
+				if err != nil {
+					b.println(fmt.Sprintf("Error: %v", err))
+					continue
+				}
+				if err := ai.ValidateModel(cfg.AIProvider, cfg.APIKey, cfg.BaseURL, model, transport); errors.Is(err, ai.ErrModelsUnavailable) {
+					b.println(fmt.Sprintf("Warning: %v; saving the model unchecked", err))
+				} else if err != nil {
+					b.println(fmt.Sprintf("Error: %v", err))
+					continue
+				}
+			}
+			if err := config.SaveConfig(&cfg); err != nil {
+				b.println(fmt.Sprintf("Error saving config: %v", err))
+				continue
+			}
+			*b.config = cfg
+			b.println("Settings saved successfully.")
+			return
+		case "b", "back":
+			return
+		default:
+			b.println("Unknown option: " + choice)
+		}
+	}
+}
+
+func isProvider(name string) bool {
+	for _, p := range providers {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maskKey shows only the ends of an API key
+func maskKey(key string) string {
+	switch {
+	case key == "":
+		return "Not set"
+	case len(key) > 8:
+		return key[:4] + "..." + key[len(key)-4:]
+	default:
+		return "***"
+	}
+}
+
+func orNotSet(s string) string {
+	if s == "" {
+		return "Not set"
+	}
+	return s
+}
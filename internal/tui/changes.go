@@ -0,0 +1,242 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/daemon"
+	"github.com/aadityansha/autogit/internal/git"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// loadChanges shows the pending changes of the selected repository: its
+// status and the diff the next commit would be made from, in the staging
+// mode and with the exclusions the daemon uses
+func (m *model) loadChanges() {
+	m.changesViewport.SetContent(m.changesContent())
+}
+
+func (m *model) changesContent() string {
+	if m.repoPath == "" {
+		return "No repository selected. No changes to show."
+	}
+	
+	var result string
+	if status := m.commitStatus(); status != "" {
+		result = status + "\n\n"
+	}
+	rc := m.config.ForRepo(m.repoPath)
+	repo := daemon.OpenRepo(rc)
+	status, err := repo.Status(rc.Exclude...)
+	if err != nil {
+		return result + err.Error()
+	}
+	if strings.TrimSpace(status) == "" {
+		return result + "No pending changes."
+	}
+	diff, err := repo.GetFullDiff(rc.Exclude...)
+	if err != nil {
+		return result + err.Error()
+	}
+	
+	return fmt.Sprintf("%s%s\n%s\n\n%s\n\n%s",
+		result,
+		lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("Pending changes (staging: %s)", rc.GetStagingMode())),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Press 'c' to commit them now with a suggested message you can edit"),
+		colorizeStatus(status),
+		colorizeDiff(diff))
+}
+
+// colorizeStatus colors the two status letters of each porcelain line:
+// green for staged changes, red for unstaged ones and untracked files
+func colorizeStatus(status string) string {
+	green := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(status, "\n"), "\n") {
+		if len(line) < 3 {
+			lines = append(lines, line)
+			continue
+		}
+		index, worktree := string(line[0]), string(line[1])
+		if line[:2] == "??" {
+			index = red.Render(index)
+		} else {
+			index = green.Render(index)
+		}
+		lines = append(lines, index+red.Render(worktree)+line[2:])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// colorizeDiff colors a unified diff the way git does on a terminal
+func colorizeDiff(diff string) string {
+	header := lipgloss.NewStyle().Bold(true)
+	hunk := lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	added := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	removed := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		switch {
+		case strings.HasPrefix(line, "diff --git"), strings.HasPrefix(line, "index "),
+			strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"),
+			strings.HasPrefix(line, "new file"), strings.HasPrefix(line, "deleted file"),
+			strings.HasPrefix(line, "similarity"), strings.HasPrefix(line, "rename "), strings.HasPrefix(line, "copy "):
+			line = header.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			line = hunk.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = added.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = removed.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// suggestedMsg carries the drafted message of a manual commit
+type suggestedMsg struct {
+	msg string
+	err error
+}
+
+// committedMsg reports the result of a manual commit
+type committedMsg struct {
+	subject string
+	push    bool // Pushing was asked for
+	pushed  bool
+	err     error
+}
+
+// updateChanges scrolls the changes and starts a manual commit on 'c'
+func (m *model) updateChanges(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "c" {
+		var cmd tea.Cmd
+		m.changesViewport, cmd = m.changesViewport.Update(msg)
+		return m, cmd
+	}
+	if m.repoPath == "" || m.commitBusy != "" {
+		return m, nil
+	}
+	
+	cfg, rc := m.config, m.config.ForRepo(m.repoPath)
+	m.commitRepo = m.repoPath
+	m.commitBusy = "Drafting a commit message..."
+	m.commitResult = ""
+	m.loadChanges()
+	if m.demo {
+		return m, func() tea.Msg { return suggestedMsg{msg: demoMessage} }
+	}
+	return m, func() tea.Msg {
+		msg, err := daemon.SuggestMessage(context.Background(), cfg, rc)
+		return suggestedMsg{msg: msg, err: err}
+	}
+}
+
+// messageSuggested opens the editor on the drafted message
+func (m *model) messageSuggested(msg suggestedMsg) {
+	m.commitBusy = ""
+	if msg.err != nil {
+		m.commitResult = "Error: " + msg.err.Error()
+		m.loadChanges()
+		return
+	}
+	m.commitEditor.SetValue(msg.msg)
+	m.commitEditor.Focus()
+	m.editing = true
+}
+
+// updateCommitEditor edits the message, then commits it on ctrl+s, also
+// pushing on ctrl+g, or gives up on esc
+func (m *model) updateCommitEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case "esc":
+		m.editing = false
+		m.commitEditor.Blur()
+		m.commitResult = "Commit cancelled"
+		m.loadChanges()
+		return m, nil
+	case "ctrl+s", "ctrl+g":
+		text := strings.TrimSpace(m.commitEditor.Value())
+		if text == "" {
+			m.commitResult = "Error: the commit message is empty"
+			return m, nil
+		}
+		push := msg.String() == "ctrl+g"
+		m.editing = false
+		m.commitEditor.Blur()
+		m.commitBusy = "Committing..."
+		if push {
+			m.commitBusy = "Committing and pushing..."
+		}
+		m.commitResult = ""
+		m.loadChanges()
+		
+		cfg, rc := m.config, m.config.ForRepo(m.commitRepo)
+		return m, func() tea.Msg {
+			pushed, err := daemon.CommitManual(cfg, rc, text, push)
+			return committedMsg{subject: strings.SplitN(text, "\n", 2)[0], push: push, pushed: pushed, err: err}
+		}
+	}
+	
+	var cmd tea.Cmd
+	m.commitEditor, cmd = m.commitEditor.Update(msg)
+	return m, cmd
+}
+
+// committed shows the result of a manual commit
+func (m *model) committed(msg committedMsg) {
+	m.commitBusy = ""
+	switch {
+	case msg.err != nil:
+		m.commitResult = "Error: " + msg.err.Error()
+	case msg.push && !msg.pushed:
+		m.commitResult = fmt.Sprintf("✓ Committed %q; push is off for this repository", msg.subject)
+	case msg.pushed:
+		m.commitResult = fmt.Sprintf("✓ Committed and pushed %q", msg.subject)
+	default:
+		m.commitResult = fmt.Sprintf("✓ Committed %q", msg.subject)
+	}
+	m.loadChanges()
+}
+
+// viewChanges shows the pending changes, or the commit editor
+func (m *model) viewChanges() string {
+	if !m.editing {
+		return m.changesViewport.View()
+	}
+	
+	keys := "ctrl+s to commit | ctrl+g to commit and push | esc to cancel"
+	if m.config.ForRepo(m.commitRepo).PushDisabled() {
+		keys = "ctrl+s to commit | esc to cancel"
+	}
+	content := fmt.Sprintf("%s\n\n%s\n\n%s",
+		lipgloss.NewStyle().Bold(true).Render("Commit message for "+git.GetRepoName(m.commitRepo)),
+		m.commitEditor.View(),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(keys))
+	if strings.HasPrefix(m.commitResult, "Error") {
+		content += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.commitResult)
+	}
+	return content
+}
+
+// commitStatus renders what a manual commit is doing or how it ended
+func (m *model) commitStatus() string {
+	switch {
+	case m.commitBusy != "":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(m.commitBusy)
+	case strings.HasPrefix(m.commitResult, "✓"):
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true).Render(m.commitResult)
+	case m.commitResult != "":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.commitResult)
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/timefmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// connectivityInterval is how often the dashboard re-checks endpoints
+	connectivityInterval = time.Minute
+	// slowLatency marks a reachable endpoint as degraded
+	slowLatency = 2 * time.Second
+	// remoteTimeout bounds the git remote check
+	remoteTimeout = 15 * time.Second
+)
+
+// probe is the result of the last check of one endpoint
+type probe struct {
+	checking bool
+	checked  time.Time
+	latency  time.Duration
+	err      error
+}
+
+// startConnectivityCheck marks both probes as in progress and returns the
+// command that checks them, or nil while a check is already running
+func (m *model) startConnectivityCheck() tea.Cmd {
+	if m.remoteProbe.checking {
+		return nil
+	}
+	if m.demo {
+		m.remoteProbe.checking, m.aiProbe.checking = true, true
+		return demoConnectivity()
+	}
+	
+	var repoPath string
+	useAI := ai.NeedsAPIKey(m.config.AIProvider)
+	if info := m.selectedDaemon(); info != nil && useAI {
+		repoPath = info.RepoPath
+		rc := m.config.ForRepo(repoPath)
+		useAI = rc.MessageSource != config.MessageHeuristic && !rc.AIDisabled()
+	}
+	
+	m.remoteProbe.checking = true
+	if useAI {
+		m.aiProbe.checking = true
+	}
+	return checkConnectivity(*m.config, m.transport, repoPath, useAI)
+}
+
+// connectivityMsg carries the results of a connectivity check
+type connectivityMsg struct {
+	ai     probe
+	remote probe
+}
+
+type connectivityTickMsg struct{}
+
+func connectivityTick() tea.Cmd {
+	return tea.Tick(connectivityInterval, func(time.Time) tea.Msg {
+		return connectivityTickMsg{}
+	})
+}
+
+// checkConnectivity pings the AI provider and the git remote concurrently
+func checkConnectivity(cfg config.Config, transport http.RoundTripper, repoPath string, useAI bool) tea.Cmd {
+	return func() tea.Msg {
+		var msg connectivityMsg
+		done := make(chan struct{})
+		
+		go func() {
+			defer close(done)
+			if repoPath == "" {
+				msg.remote = probe{checked: time.Now(), err: fmt.Errorf("no repository")}
+				return
+			}
+			latency, err := git.Open(repoPath).PingRemote(remoteTimeout)
+			msg.remote = probe{checked: time.Now(), latency: latency, err: err}
+		}()
+		
+		if useAI {
+			latency, err := ai.Ping(cfg.AIProvider, cfg.APIKey, cfg.BaseURL, transport)
+			msg.ai = probe{checked: time.Now(), latency: latency, err: err}
+		}
+		
+		<-done
+		return msg
+	}
+}
+
+// render shows the probe as a coloured dot with latency and check time:
+// green when healthy, yellow when slow or not yet checked, red on error
+func (p probe) render(label string, now time.Time) string {
+	var color lipgloss.Color
+	var detail string
+	switch {
+	case p.checking && p.checked.IsZero():
+		color, detail = lipgloss.Color("3"), "checking..."
+	case p.checked.IsZero():
+		color, detail = lipgloss.Color("3"), "not checked"
+	case p.err != nil:
+		color, detail = lipgloss.Color("9"), p.err.Error()
+	case p.latency >= slowLatency:
+		color, detail = lipgloss.Color("3"), fmt.Sprintf("slow, %s", p.latency.Round(time.Millisecond))
+	default:
+		color, detail = lipgloss.Color("2"), p.latency.Round(time.Millisecond).String()
+	}
+	
+	line := fmt.Sprintf("%s %s: %s", lipgloss.NewStyle().Foreground(color).Render("●"), label, detail)
+	if !p.checked.IsZero() {
+		line += fmt.Sprintf(" (checked %s)", timefmt.Relative(p.checked, now))
+		if p.checking {
+			line += ", re-checking..."
+		}
+	}
+	return line
+}
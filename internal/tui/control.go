@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/daemon"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/pathutil"
+	"github.com/aadityansha/autogit/internal/service"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Daemon actions offered on the dashboard
+const (
+	actionStart   = "start"
+	actionStop    = "stop"
+	actionRestart = "restart"
+)
+
+// actionLabels holds the confirmation verb and progress text of each action
+var actionLabels = map[string][2]string{
+	actionStart:   {"Start", "Starting"},
+	actionStop:    {"Stop", "Stopping"},
+	actionRestart: {"Restart", "Restarting"},
+}
+
+// daemonActionMsg reports the result of a daemon action
+type daemonActionMsg struct {
+	action string
+	repo   string
+	err    error
+}
+
+// requestDaemonAction asks to confirm action for the selected repository,
+// or explains why it cannot be taken
+func (m *model) requestDaemonAction(action string) {
+	m.daemonMessage = ""
+	info := m.selectedDaemon()
+	switch {
+	case m.repoPath == "" || m.daemonBusy != "":
+		return
+	case m.demo:
+		m.daemonMessage = "The daemon of the demo is simulated and cannot be started or stopped"
+	case service.Installed(m.repoPath):
+		m.daemonMessage = "This daemon is run by the service manager; start and stop it there, or run 'autogit uninstall-service' first"
+	case action == actionStart && info != nil:
+		m.daemonMessage = "The daemon is already running; press 'R' to restart it"
+	case action != actionStart && info == nil:
+		m.daemonMessage = "The daemon is not running; press 's' to start it"
+	default:
+		m.confirming = action
+	}
+}
+
+// updateConfirm handles the y/n answer to a pending daemon action
+func (m *model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	action := m.confirming
+	switch msg.String() {
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case "y", "Y", "enter":
+		m.confirming = ""
+		m.daemonBusy = action
+		m.updateDashboard()
+		return m, runDaemonAction(action, m.selectedDaemon(), m.repoPath)
+	case "n", "N", "esc":
+		m.confirming = ""
+		m.updateDashboard()
+	}
+	return m, nil
+}
+
+// runDaemonAction starts, stops or restarts the daemon through the
+// daemon package, as the CLI does
+func runDaemonAction(action string, info *config.DaemonInfo, repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		switch action {
+		case actionStart:
+			err = daemon.StartDaemonProcess(repoPath)
+		case actionStop:
+			err = daemon.StopDaemonProcess(info)
+		case actionRestart:
+			err = daemon.RestartDaemonProcess(info)
+		}
+		return daemonActionMsg{action: action, repo: repoPath, err: err}
+	}
+}
+
+// daemonActionDone shows the result of a daemon action
+func (m *model) daemonActionDone(msg daemonActionMsg) {
+	m.daemonBusy = ""
+	name := git.GetRepoName(msg.repo)
+	switch {
+	case msg.err != nil:
+		m.daemonMessage = fmt.Sprintf("✗ Failed to %s the daemon for %s: %v", msg.action, name, msg.err)
+	case msg.action == actionStart:
+		m.daemonMessage = fmt.Sprintf("✓ Daemon started for %s", name)
+	case msg.action == actionStop:
+		m.daemonMessage = fmt.Sprintf("✓ Daemon stopped for %s", name)
+	default:
+		m.daemonMessage = fmt.Sprintf("✓ Daemon restarted for %s with the current config", name)
+	}
+	if !pathutil.Same(msg.repo, m.repoPath) {
+		m.daemonMessage = ""
+	}
+}
+
+// daemonControls is the dashboard line for the pending confirmation, the
+// running action or the result of the last one
+func (m *model) daemonControls() string {
+	switch {
+	case m.confirming != "":
+		prompt := fmt.Sprintf("%s the daemon for %s? (y/n)", actionLabels[m.confirming][0], git.GetRepoName(m.repoPath))
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true).Render(prompt)
+	case m.daemonBusy != "":
+		return actionLabels[m.daemonBusy][1] + " the daemon..."
+	}
+	return m.daemonMessage
+}
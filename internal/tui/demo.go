@@ -0,0 +1,384 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/breaker"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/daemon"
+	"github.com/aadityansha/autogit/internal/ignore"
+	"github.com/aadityansha/autogit/internal/stats"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// demoAPIKey fills the settings of the demo; it is never sent anywhere
+const demoAPIKey = "demo-key-not-a-real-key"
+
+// demoInterval is the check interval of the simulated daemon
+const demoInterval = 10 * time.Minute
+
+// demoEvent is a line of a demo log, written ago before the demo starts
+type demoEvent struct {
+	ago  time.Duration
+	text string
+}
+
+// demoRepo is a synthetic repository of the demo
+type demoRepo struct {
+	name      string
+	groups    []string
+	files     map[string]string // Committed first
+	commits   []string          // Later commits, oldest first
+	edits     map[string]string // Left uncommitted for the Changes tab
+	log       []demoEvent
+	suggested []string // .gitignore suggestions
+}
+
+// demoRepos are shown in the demo; the daemon runs for the first
+var demoRepos = []demoRepo{
+	{
+		name:   "api-server",
+		groups: []string{"work"},
+		files: map[string]string{
+			"README.md":         "# api-server\n\nREST API for the shop.\n",
+			"main.go":           "package main\n\nfunc main() {\n\tserve(\":8080\")\n}\n",
+			"handlers/users.go": "package handlers\n\n// ListUsers returns every user\nfunc ListUsers() []User {\n\treturn store.Users()\n}\n",
+		},
+		commits: []string{
+			"fix(auth): refresh tokens before they expire",
+			"docs: describe the rate limits",
+			"feat(users): add pagination to the list endpoint",
+		},
+		edits: map[string]string{
+			"handlers/users.go":   "package handlers\n\n// ListUsers returns one page of users\nfunc ListUsers(page, size int) []User {\n\treturn store.Users()[page*size : (page+1)*size]\n}\n",
+			"handlers/orders.go":  "package handlers\n\n// ListOrders returns the orders of a user\nfunc ListOrders(user string) []Order {\n\treturn store.Orders(user)\n}\n",
+			"config/secrets.yaml": "database_url: postgres://shop@db/shop\n",
+		},
+		log: []demoEvent{
+			{3 * time.Hour, "Daemon started for repository: {path}"},
+			{3 * time.Hour, "Using git 2.43.0"},
+			{170 * time.Minute, "Checking for changes..."},
+			{170 * time.Minute, "Changes detected, generating commit message..."},
+			{170 * time.Minute, "Changes detected, using message: fix(auth): refresh tokens before they expire"},
+			{170 * time.Minute, "Committed successfully"},
+			{170 * time.Minute, "Pushed successfully"},
+			{2 * time.Hour, "Checking for changes..."},
+			{2 * time.Hour, "No changes detected"},
+			{100 * time.Minute, "Checking for changes..."},
+			{100 * time.Minute, "Changes detected, generating commit message..."},
+			{100 * time.Minute, "Changes detected, using message: docs: describe the rate limits"},
+			{100 * time.Minute, "Committed successfully"},
+			{100 * time.Minute, "Pushed successfully"},
+			{40 * time.Minute, "Checking for changes..."},
+			{40 * time.Minute, "Changes detected, generating commit message..."},
+			{40 * time.Minute, "ERROR: Failed to check changes: failed to generate commit message: API error (status 429): Resource has been exhausted (e.g. check quota)."},
+			{27 * time.Minute, "Checking for changes..."},
+			{27 * time.Minute, "Changes detected, using message: feat(users): add pagination to the list endpoint"},
+			{27 * time.Minute, "Committed successfully"},
+			{27 * time.Minute, "Pushed successfully"},
+			{12 * time.Minute, "Checking for changes..."},
+			{12 * time.Minute, "Changes detected, generating commit message..."},
+			{12 * time.Minute, "ERROR: Failed to check changes: failed to generate commit message: API error (status 503): The model is overloaded. Please try again later."},
+			{4 * time.Minute, "Checking for changes..."},
+			{4 * time.Minute, "Holding 1 changes for approval: config/secrets.yaml"},
+			{4 * time.Minute, "Changes keep appearing in generated files, suggesting .gitignore entries: dist/, .cache/"},
+		},
+		suggested: []string{"dist/", ".cache/"},
+	},
+	{
+		name:   "notes",
+		groups: []string{"notes"},
+		files: map[string]string{
+			"journal/2026-10-14.md": "# Tuesday\n\n- Finished the quarterly report\n",
+		},
+		commits: []string{
+			"Add notes on the planning meeting",
+			"Add reading list for November",
+		},
+		edits: map[string]string{
+			"journal/2026-10-15.md": "# Wednesday\n\n- Paired on the release checklist\n- Ideas for the offsite\n",
+		},
+		log: []demoEvent{
+			{26 * time.Hour, "Daemon started for repository: {path}"},
+			{25 * time.Hour, "Checking for changes..."},
+			{25 * time.Hour, "Changes detected, using message: Add notes on the planning meeting"},
+			{25 * time.Hour, "Committed successfully"},
+			{25 * time.Hour, "Pushed successfully"},
+			{24 * time.Hour, "Checking for changes..."},
+			{24 * time.Hour, "Changes detected, using message: Add reading list for November"},
+			{24 * time.Hour, "Committed successfully"},
+			{24 * time.Hour, "Pushed successfully"},
+		},
+	},
+	{
+		name: "dotfiles",
+		files: map[string]string{
+			".zshrc":     "export EDITOR=vim\nalias ll='ls -la'\n",
+			".gitconfig": "[user]\n\tname = Demo User\n",
+		},
+		commits: []string{
+			"Use delta as the git pager",
+		},
+		edits: map[string]string{
+			".zshrc": "export EDITOR=vim\nalias ll='ls -la'\nalias gs='git status'\n",
+		},
+		log: []demoEvent{
+			{50 * time.Hour, "Daemon started for repository: {path}"},
+			{49 * time.Hour, "Checking for changes..."},
+			{49 * time.Hour, "Changes detected, using message: Use delta as the git pager"},
+			{49 * time.Hour, "Committed successfully"},
+			{49 * time.Hour, "Pushed successfully"},
+		},
+	},
+}
+
+// NewDemoModel returns a dashboard filled with synthetic repositories,
+// logs, statistics and approvals, kept in a temporary directory that
+// cleanup removes. The real config, repositories and API key are not
+// read or changed.
+func NewDemoModel() (m *model, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "autogit-demo-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	
+	if err := setupDemo(dir, time.Now()); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to prepare the demo: %w", err)
+	}
+	if m, err = NewModel(""); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	m.demo = true
+	m.updateDashboard()
+	return m, cleanup, nil
+}
+
+// setupDemo points the config directory at dir and fills it, and creates
+// the demo repositories in it
+func setupDemo(dir string, now time.Time) error {
+	// Keys and settings from the environment would show up in the demo
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, "AUTOGIT_") {
+			os.Unsetenv(name)
+		}
+	}
+	for _, provider := range providers {
+		for _, name := range config.APIKeyVars(provider) {
+			os.Unsetenv(name)
+		}
+	}
+	config.DisableKeyring()
+	if err := config.UseConfigDir(filepath.Join(dir, "config")); err != nil {
+		return err
+	}
+	
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.AIProvider = "gemini"
+	cfg.APIKey = demoAPIKey
+	cfg.CheckIntervalMinutes = int(demoInterval / time.Minute)
+	
+	for _, r := range demoRepos {
+		path := filepath.Join(dir, "repos", r.name)
+		if err := r.create(path); err != nil {
+			return fmt.Errorf("%s: %w", r.name, err)
+		}
+		cfg.Repos = append(cfg.Repos, config.RepoConfig{Path: path, Groups: r.groups})
+	}
+	if err := config.SaveConfig(cfg); err != nil {
+		return err
+	}
+	
+	for _, r := range demoRepos {
+		rc := cfg.ForRepo(filepath.Join(dir, "repos", r.name))
+		if err := r.writeLog(rc, now); err != nil {
+			return err
+		}
+		if err := r.writeStats(rc, now); err != nil {
+			return err
+		}
+		if len(r.suggested) > 0 {
+			state := &ignore.State{Suggested: r.suggested}
+			if err := state.Save(rc.StateName()); err != nil {
+				return err
+			}
+		}
+	}
+	
+	// The daemon is this process, so that it counts as running
+	running := cfg.Repos[0]
+	info := &config.DaemonInfo{Name: cfg.ForRepo(running.Path).StateName(), PID: os.Getpid(), RepoPath: running.Path, Status: daemon.StatusRunning, StartedAt: now.Add(-3 * time.Hour)}
+	if err := config.SaveDaemonInfo(info); err != nil {
+		return err
+	}
+	health := &config.Health{
+		PID:             info.PID,
+		RepoPath:        running.Path,
+		Status:          daemon.StatusRunning,
+		Heartbeat:       now,
+		LastCheck:       now.Add(-4 * time.Minute),
+		LastCommit:      now.Add(-27 * time.Minute),
+		LastCommitMsg:   demoRepos[0].commits[len(demoRepos[0].commits)-1],
+		NextRun:         now.Add(demoInterval - 4*time.Minute),
+		PendingApproval: []string{"config/secrets.yaml"},
+	}
+	if err := config.SaveHealth(info.Name, health); err != nil {
+		return err
+	}
+	
+	b := breaker.New(cfg.AIBreaker)
+	failures := []struct {
+		ago time.Duration
+		err error
+	}{
+		{40 * time.Minute, &ai.APIError{Status: 429, Body: `{"error":{"code":429,"message":"Resource has been exhausted (e.g. check quota).","status":"RESOURCE_EXHAUSTED"}}`}},
+		{12 * time.Minute, &ai.APIError{Status: 503, Body: `{"error":{"code":503,"message":"The model is overloaded. Please try again later.","status":"UNAVAILABLE"}}`}},
+	}
+	for _, f := range failures {
+		if _, err := b.Failure(cfg.AIProvider, f.err, now.Add(-f.ago)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create makes the repository at path with its commits and leaves its
+// edits uncommitted
+func (r demoRepo) create(path string) error {
+	if err := writeDemoFiles(path, r.files); err != nil {
+		return err
+	}
+	if err := demoGit(path, "init", "-q"); err != nil {
+		return err
+	}
+	// An identity of its own lets commits from the Changes tab work too
+	for key, value := range map[string]string{"user.name": "Demo User", "user.email": "demo@example.com", "commit.gpgsign": "false"} {
+		if err := demoGit(path, "config", key, value); err != nil {
+			return err
+		}
+	}
+	if err := demoGit(path, "add", "-A"); err != nil {
+		return err
+	}
+	if err := demoGit(path, "commit", "-q", "-m", "Initial commit"); err != nil {
+		return err
+	}
+	for _, subject := range r.commits {
+		if err := demoGit(path, "commit", "-q", "--allow-empty", "-m", subject); err != nil {
+			return err
+		}
+	}
+	return writeDemoFiles(path, r.edits)
+}
+
+func writeDemoFiles(root string, files map[string]string) error {
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// demoGit runs git in the demo repository at path
+func demoGit(path string, args ...string) error {
+	output, err := exec.Command("git", append([]string{"-C", path}, args...)...).CombinedOutput()
+	if err != nil && len(output) > 0 {
+		return fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	if err != nil {
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// writeLog writes the events of the repository to its daemon log
+func (r demoRepo) writeLog(rc config.RepoConfig, now time.Time) error {
+	var lines []string
+	for _, e := range r.log {
+		text := strings.ReplaceAll(e.text, "{path}", rc.Path)
+		lines = append(lines, now.Add(-e.ago).Format(logTimeLayout)+" "+text)
+	}
+	path := rc.LogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// writeStats makes up a month of activity for the repository
+func (r demoRepo) writeStats(rc config.RepoConfig, now time.Time) error {
+	s := stats.Stats{RepoPath: rc.Path}
+	for i := 30; i >= 0; i-- {
+		commits := (i*7 + len(r.name)) % 6
+		day := stats.Day{
+			Date:         now.AddDate(0, 0, -i).Format("2006-01-02"),
+			Commits:      commits,
+			LinesAdded:   commits*23 + i%5*4,
+			LinesDeleted: commits * 9,
+			AITokens:     int64(commits) * 1800,
+		}
+		if i%9 == 0 {
+			day.Failures = 1
+		}
+		s.Days = append(s.Days, day)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := config.GetStatsPath(rc.StateName())
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// demoHeartbeat keeps the simulated daemon alive: it refreshes the
+// heartbeat and, once the next check is due, "runs" it
+func demoHeartbeat(now time.Time) {
+	infos, _ := config.ListDaemonInfo()
+	for _, info := range infos {
+		health, err := config.LoadHealth(info.Name)
+		if err != nil || health == nil {
+			continue
+		}
+		health.Heartbeat = now
+		for !health.NextRun.After(now) {
+			health.LastCheck = health.NextRun
+			health.NextRun = health.NextRun.Add(demoInterval)
+		}
+		config.SaveHealth(info.Name, health)
+	}
+}
+
+// demoConnectivity reports both endpoints as reachable without contacting
+// either
+func demoConnectivity() tea.Cmd {
+	return func() tea.Msg {
+		now := time.Now()
+		return connectivityMsg{
+			ai:     probe{checked: now, latency: 240 * time.Millisecond},
+			remote: probe{checked: now, latency: 85 * time.Millisecond},
+		}
+	}
+}
+
+// demoMessage stands in for the AI's suggestion in the Changes tab
+const demoMessage = "feat(orders): add an endpoint listing a user's orders\n\nUsers can now page through their orders."
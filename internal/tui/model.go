@@ -3,16 +3,23 @@ package tui
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/breaker"
 	"github.com/aadityansha/autogit/internal/config"
 	"github.com/aadityansha/autogit/internal/daemon"
 	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/ignore"
+	"github.com/aadityansha/autogit/internal/logdest"
+	"github.com/aadityansha/autogit/internal/spend"
+	"github.com/aadityansha/autogit/internal/stats"
+	"github.com/aadityansha/autogit/internal/timefmt"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -23,61 +30,115 @@ const (
 	tabDashboard = iota
 	tabLogs
 	tabSettings
+	tabStats
+	tabChanges
 )
 
+// providers lists the AI providers offered in settings
+var providers = []string{"gemini", "openai", "openrouter", "anthropic", "none"}
+
 type model struct {
 	width      int
 	height     int
 	activeTab  int
 	config     *config.Config
-	daemonInfo *config.DaemonInfo
+	daemons    []*config.DaemonInfo // Running daemons, at most one per repository
+	repos      []string // Repositories to switch between
+	repoPath   string   // Repository whose dashboard, logs and stats are shown
+	root       string   // Repository the dashboard was opened for, selected first
+	transport  http.RoundTripper // Carries pings and model lists, see ai.TransportFor
 	
 	// Dashboard
 	dashboardViewport viewport.Model
+	aiProbe           probe // Last AI provider connectivity check
+	remoteProbe       probe // Last git remote connectivity check
+	ignoreSuggested   []string // .gitignore entries the daemon suggests
+	ignoreMessage     string   // Result of adding or dismissing them
+	confirming        string   // Daemon action awaiting y/n
+	daemonBusy        string   // Daemon action in progress
+	daemonMessage     string   // Result of the last daemon action
 	
 	// Logs
 	logsViewport viewport.Model
 	logLines     []string
 	
+	// Stats
+	statsViewport viewport.Model
+	
+	// Changes
+	changesViewport viewport.Model
+	commitEditor    textarea.Model // Message of a manual commit, while editing
+	editing         bool           // The commit editor has focus
+	commitRepo      string         // Repository the commit is for
+	commitBusy      string         // What a manual commit is waiting for
+	commitResult    string         // Result of the last manual commit
+	
 	// Settings
 	settingsList     list.Model
 	apiKeyInput      textinput.Model
 	baseURLInput     textinput.Model
 	intervalInput    textinput.Model
 	selectedProvider string
+	selectedModel    string     // Model picked for selectedProvider, "" for its default
+	modelPicker      list.Model // Models fetched from the provider
+	pickingModel     bool       // The model picker is shown
+	modelsLoading    bool       // Models are being fetched
+	modelLists       map[string][]string // Models fetched per provider and base URL, see modelListKey
 	showAPIKey       bool
 	showBaseURL      bool
 	focusedInput     int // 0: provider, 1: apiKey, 2: baseURL, 3: interval
 	saveMessage      string // Message to show after saving
+	fieldErrors      map[string]string // Validation errors by settings field
 	
 	// Common
 	quitting bool
+	readOnly bool // Shown to guests, e.g. over SSH: settings are hidden and nothing can be changed
+	demo     bool // Shows synthetic data from a temporary directory, see NewDemoModel
 }
 
 type tickMsg time.Time
 type clearSaveMsg struct{}
 
-func NewModel() (*model, error) {
+// NewModel returns the dashboard, showing the repository at root first, or
+// without root the one of the working directory
+func NewModel(root string) (*model, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, err
 	}
+	transport, err := ai.TransportFor(cfg.Network)
+	if err != nil {
+		return nil, err
+	}
 	
-	daemonInfo, _ := config.LoadDaemonInfo()
+	daemons, _, _ := config.ListLiveDaemonInfo()
+	if root == "" {
+		root, _ = git.GetRootPath()
+	}
 	
 	m := &model{
 		activeTab:  tabDashboard,
 		config:     cfg,
-		daemonInfo: daemonInfo,
+		daemons:    daemons,
+		root:       root,
+		transport:  transport,
 		selectedProvider: cfg.AIProvider,
+		selectedModel: cfg.AIModels[cfg.AIProvider],
 		showAPIKey: false,
 		showBaseURL: false,
 		focusedInput: 0,
+		fieldErrors: make(map[string]string),
+		modelLists:  make(map[string][]string),
 	}
 	
 	// Initialize viewports
 	m.dashboardViewport = viewport.New(0, 0)
 	m.logsViewport = viewport.New(0, 0)
+	m.statsViewport = viewport.New(0, 0)
+	m.changesViewport = viewport.New(0, 0)
+	m.commitEditor = textarea.New()
+	m.commitEditor.ShowLineNumbers = false
+	m.commitEditor.CharLimit = 0
 	
 	// Initialize settings inputs
 	m.apiKeyInput = textinput.New()
@@ -109,6 +170,7 @@ func NewModel() (*model, error) {
 		item{title: "AI Provider", desc: fmt.Sprintf("Current: %s", cfg.AIProvider)},
 		item{title: "API Key", desc: "Click to edit"},
 		item{title: "Base URL", desc: "Click to edit (for OpenRouter)"},
+		item{title: "Model", desc: "Click to pick from the provider's models"},
 		item{title: "Check Interval", desc: fmt.Sprintf("Current: %d minutes", cfg.CheckIntervalMinutes)},
 		item{title: "Save", desc: "Save settings"},
 	}
@@ -118,16 +180,35 @@ func NewModel() (*model, error) {
 	m.settingsList.SetShowStatusBar(false)
 	m.settingsList.SetFilteringEnabled(false)
 	
-	m.loadLogs()
+	m.modelPicker = list.New(nil, modelDelegate{}, 50, 20)
+	m.modelPicker.SetShowStatusBar(false)
+	m.modelPicker.SetShowHelp(false)
+	
 	m.updateDashboard()
+	m.loadLogs()
 	
 	return m, nil
 }
 
+// NewReadOnlyModel returns the dashboard for guests, which shows the
+// daemon, logs and stats but neither settings nor the API key
+func NewReadOnlyModel() (*model, error) {
+	m, err := NewModel("")
+	if err != nil {
+		return nil, err
+	}
+	m.readOnly = true
+	m.apiKeyInput.SetValue("")
+	m.updateDashboard()
+	return m, nil
+}
+
 func (m *model) Init() tea.Cmd {
 	return tea.Batch(
 		tea.EnterAltScreen,
 		tick(),
+		m.startConnectivityCheck(),
+		connectivityTick(),
 	)
 }
 
@@ -136,15 +217,19 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.dashboardViewport.Width = msg.Width - 4
-		m.dashboardViewport.Height = msg.Height - 8
-		m.logsViewport.Width = msg.Width - 4
-		m.logsViewport.Height = msg.Height - 8
-		m.settingsList.SetWidth(msg.Width - 4)
-		m.settingsList.SetHeight(msg.Height - 8)
+		m.resize()
 		return m, nil
 		
 	case tea.KeyMsg:
+		if m.editing {
+			return m.updateCommitEditor(msg)
+		}
+		if m.pickingModel {
+			return m.updateModelPicker(msg)
+		}
+		if m.confirming != "" {
+			return m.updateConfirm(msg)
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			m.quitting = true
@@ -158,8 +243,29 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.loadLogs()
 			return m, nil
 		case "3":
-			m.activeTab = tabSettings
+			if !m.readOnly {
+				m.activeTab = tabSettings
+			}
+			return m, nil
+		case "4":
+			m.activeTab = tabStats
+			m.loadStats()
+			return m, nil
+		case "5":
+			if !m.readOnly {
+				m.activeTab = tabChanges
+				m.loadChanges()
+			}
 			return m, nil
+		case "[", "]":
+			if m.focusedInput == 0 {
+				if msg.String() == "[" {
+					m.switchRepo(-1)
+				} else {
+					m.switchRepo(1)
+				}
+				return m, nil
+			}
 		}
 		
 		// Tab-specific key handling
@@ -170,17 +276,62 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateLogs(msg)
 		case tabSettings:
 			return m.updateSettings(msg)
+		case tabStats:
+			var cmd tea.Cmd
+			m.statsViewport, cmd = m.statsViewport.Update(msg)
+			return m, cmd
+		case tabChanges:
+			return m.updateChanges(msg)
 		}
 		
 	case tickMsg:
+		if m.demo {
+			demoHeartbeat(time.Time(msg))
+		}
 		m.updateDashboard()
 		m.loadLogs()
+		if m.activeTab == tabStats {
+			m.loadStats()
+		}
+		if m.activeTab == tabChanges {
+			m.loadChanges()
+		}
 		return m, tick()
 	case clearSaveMsg:
 		m.saveMessage = ""
 		return m, nil
+	case suggestedMsg:
+		m.messageSuggested(msg)
+		return m, nil
+	case committedMsg:
+		m.committed(msg)
+		return m, nil
+	case daemonActionMsg:
+		m.daemonActionDone(msg)
+		m.updateDashboard()
+		m.loadLogs()
+		return m, nil
+	case modelsMsg:
+		cmd := m.modelsFetched(msg)
+		m.updateSettingsList()
+		return m, cmd
+	case connectivityTickMsg:
+		return m, tea.Batch(m.startConnectivityCheck(), connectivityTick())
+	case connectivityMsg:
+		if m.aiProbe.checking {
+			m.aiProbe = msg.ai
+		}
+		m.remoteProbe = msg.remote
+		m.updateDashboard()
+		return m, nil
 	}
 	
+	if m.pickingModel {
+		// Filter matches arrive as messages of their own
+		var cmd tea.Cmd
+		m.modelPicker, cmd = m.modelPicker.Update(msg)
+		return m, cmd
+	}
 	return m, nil
 }
 
@@ -195,14 +346,32 @@ func (m *model) View() string {
 		content = m.dashboardViewport.View()
 	case tabLogs:
 		content = m.logsViewport.View()
+	case tabStats:
+		content = m.statsViewport.View()
+	case tabChanges:
+		content = m.viewChanges()
 	case tabSettings:
 		content = m.settingsList.View()
+		if m.pickingModel {
+			content = m.modelPicker.View() + "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("enter to pick | / to filter | esc to go back")
+		}
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+		var field string
 		if m.focusedInput == 1 {
 			content += "\n\n" + m.apiKeyInput.View()
+			field = fieldAPIKey
 		} else if m.focusedInput == 2 {
 			content += "\n\n" + m.baseURLInput.View()
+			field = fieldBaseURL
 		} else if m.focusedInput == 3 {
 			content += "\n\n" + m.intervalInput.View()
+			field = fieldInterval
+		}
+		if msg, ok := m.fieldErrors[field]; ok {
+			content += "\n" + errorStyle.Render("✗ "+msg)
+		}
+		if summary := m.repoSummary(); summary != "" {
+			content += "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(summary)
 		}
 		if m.saveMessage != "" {
 			var style lipgloss.Style
@@ -215,14 +384,44 @@ func (m *model) View() string {
 		}
 	}
 	
+	if m.showSidebar() {
+		content = lipgloss.JoinHorizontal(lipgloss.Top, m.renderSidebar(m.dashboardViewport.Height), content)
+	}
+	
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
-		renderTabs(m.activeTab),
+		renderTabs(m.activeTab, m.readOnly),
 		content,
-		renderHelp(),
+		renderHelp(m.readOnly),
 	)
 }
 
+// resize fits the views to the window, next to the repository switcher
+// when it is shown
+func (m *model) resize() {
+	if m.width == 0 {
+		return // Until the window size is known
+	}
+	width := m.width - 4
+	if m.showSidebar() {
+		width -= sidebarWidth
+	}
+	m.dashboardViewport.Width = width
+	m.dashboardViewport.Height = m.height - 8
+	m.logsViewport.Width = width
+	m.logsViewport.Height = m.height - 8
+	m.statsViewport.Width = width
+	m.statsViewport.Height = m.height - 8
+	m.changesViewport.Width = width
+	m.changesViewport.Height = m.height - 8
+	m.commitEditor.SetWidth(width)
+	m.commitEditor.SetHeight(m.height - 14)
+	m.settingsList.SetWidth(width)
+	m.settingsList.SetHeight(m.height - 8)
+	m.modelPicker.SetWidth(width)
+	m.modelPicker.SetHeight(m.height - 9)
+}
+
 func tick() tea.Cmd {
 	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
 		return tickMsg(t)
@@ -230,44 +429,102 @@ func tick() tea.Cmd {
 }
 
 func (m *model) updateDashboard() {
-	daemonInfo, _ := config.LoadDaemonInfo()
-	m.daemonInfo = daemonInfo
-	
-	var status string
-	var statusColor lipgloss.Color
-	if daemonInfo == nil {
-		status = "● Stopped"
-		statusColor = lipgloss.Color("9")
-	} else if daemonInfo.Status == daemon.StatusRunning {
-		status = "● Running"
-		statusColor = lipgloss.Color("2")
-	} else {
-		status = "● Error"
-		statusColor = lipgloss.Color("9")
+	m.daemons, _, _ = config.ListLiveDaemonInfo()
+	sidebar := m.showSidebar()
+	m.refreshRepos()
+	if sidebar != m.showSidebar() {
+		m.resize()
 	}
 	
+	// Only the selected repository's daemon is shown
+	daemonInfo := m.selectedDaemon()
+	health := config.HealthFor(daemonInfo)
+	now := time.Now()
+	
+	state, statusColor := daemonState(daemonInfo, health, now)
+	status := "● " + state
+	if state == "Paused" {
+		status += " (run 'autogit resume' to continue)"
+	}
 	statusStyle := lipgloss.NewStyle().Foreground(statusColor).Bold(true)
 	
-	var repoPath string
-	if daemonInfo != nil {
-		repoPath = daemonInfo.RepoPath
+	repoPath := m.repoPath
+	if repoPath != "" {
+		if groups := m.config.ForRepo(repoPath).Groups; len(groups) > 0 {
+			repoPath += " [" + strings.Join(groups, ", ") + "]"
+		}
 	} else {
 		repoPath = "Not initialized"
 	}
 	
 	var nextCheck string
 	if daemonInfo != nil && m.config != nil {
-		interval := m.config.GetCheckInterval()
-		nextCheck = fmt.Sprintf("Next check in: %s", interval.String())
+		timing := m.config.ForRepo(daemonInfo.RepoPath).GetCheckTiming()
+		clock := m.config.Clock()
+		switch {
+		case health != nil:
+			nextCheck = healthLines(health, daemonInfo, clock, now)
+		case daemonInfo.StartedAt.IsZero():
+			nextCheck = fmt.Sprintf("Check interval: %s", timing)
+		default:
+			nextCheck = fmt.Sprintf("Started: %s\nNext check: %s",
+				clock.Both(daemonInfo.StartedAt, now),
+				clock.Both(timing.Next(daemonInfo.StartedAt, now), now))
+		}
 	} else {
 		nextCheck = "N/A"
 	}
 	
+	aiLine := m.aiProbe.render(fmt.Sprintf("AI provider (%s)", m.config.AIProvider), now)
+	if samples := aiErrorLines(breaker.Get(m.config.AIProvider), now); samples != "" {
+		aiLine += "\n" + samples
+	}
+	if m.repoPath != "" {
+		switch rc := m.config.ForRepo(m.repoPath); {
+		case rc.MessageSource == config.MessageHeuristic:
+			aiLine = "● AI provider: not used (heuristic messages)"
+		case rc.AIDisabled():
+			aiLine = "● AI provider: disabled for this repository (local messages)"
+		}
+	}
+	
+	keys := "Press 'r' to run check now | 'p' to check connectivity | 's' to start or stop the daemon | 'R' to restart it"
+	if m.readOnly {
+		keys = "Read-only view | 'p' to check connectivity"
+	}
+	if m.demo {
+		keys = "Demo with synthetic data | " + keys
+	}
+	
+	m.ignoreSuggested = nil
+	if m.repoPath != "" {
+		if state, err := ignore.Load(m.stateName()); err == nil {
+			m.ignoreSuggested = state.Suggested
+		}
+	}
+	var suggestion string
+	if len(m.ignoreSuggested) > 0 {
+		suggestion = fmt.Sprintf("\n\nChanges keep appearing in what looks like build output or caches.\nSuggested for .gitignore: %s", strings.Join(m.ignoreSuggested, ", "))
+		if !m.readOnly {
+			keys += " | 'i' to add to .gitignore | 'x' to dismiss"
+		}
+	}
+	if m.ignoreMessage != "" {
+		suggestion += "\n\n" + m.ignoreMessage
+	}
+	if controls := m.daemonControls(); controls != "" {
+		suggestion += "\n\n" + controls
+	}
+	
 	content := fmt.Sprintf(
-		"\n%s\n\nRepository: %s\n%s\n\nPress 'r' to run check now\n",
+		"\n%s\n\nRepository: %s\n%s\n\n%s\n%s%s\n\n%s\n",
 		statusStyle.Render(status),
 		repoPath,
 		nextCheck,
+		aiLine,
+		m.remoteProbe.render("Git remote", now),
+		suggestion,
+		keys,
 	)
 	
 	m.dashboardViewport.SetContent(content)
@@ -279,42 +536,96 @@ func (m *model) handleDashboardKeys(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "r":
 			// Run check now
-			if m.daemonInfo != nil {
+			if m.selectedDaemon() != nil {
 				// Trigger immediate check (this would need daemon integration)
 				m.updateDashboard()
 			}
+		case "p":
+			cmd := m.startConnectivityCheck()
+			m.updateDashboard()
+			return m, cmd
+		case "s", "R":
+			if m.readOnly {
+				break
+			}
+			switch {
+			case msg.String() == "R":
+				m.requestDaemonAction(actionRestart)
+			case m.selectedDaemon() != nil:
+				m.requestDaemonAction(actionStop)
+			default:
+				m.requestDaemonAction(actionStart)
+			}
+			m.updateDashboard()
+		case "i", "x":
+			if !m.readOnly && m.repoPath != "" && len(m.ignoreSuggested) > 0 {
+				m.ignoreMessage = m.resolveIgnores(msg.String() == "i")
+				m.updateDashboard()
+			}
 		}
 	}
 	return m, nil
 }
 
+// resolveIgnores adds the suggested entries to the repository's .gitignore
+// and stops tracking the files they cover, or dismisses them, and returns
+// what happened
+func (m *model) resolveIgnores(add bool) string {
+	rc := m.config.ForRepo(m.repoPath)
+	name := rc.StateName()
+	state, err := ignore.Load(name)
+	if err != nil {
+		return err.Error()
+	}
+	entries := state.Suggested
+	
+	if add {
+		if err := ignore.Apply(rc.Path, entries); err != nil {
+			return err.Error()
+		}
+		specs := make([]string, len(entries))
+		for i, e := range entries {
+			specs[i] = ignore.Pathspec(e)
+		}
+		if err := daemon.OpenRepo(rc).Untrack(specs); err != nil {
+			return err.Error()
+		}
+		state.Suggested = nil
+	} else {
+		state.Dismiss()
+	}
+	if err := state.Save(name); err != nil {
+		return err.Error()
+	}
+	
+	if !add {
+		return fmt.Sprintf("✓ Dismissed %s; they will not be suggested again", strings.Join(entries, ", "))
+	}
+	return fmt.Sprintf("✓ Added %s to .gitignore; the next commit stops tracking the files", strings.Join(entries, ", "))
+}
+
 func (m *model) loadLogs() {
-	if m.daemonInfo == nil {
+	if m.repoPath == "" {
 		m.logsViewport.SetContent("No daemon running. No logs available.")
 		return
 	}
 	
-	logDir := filepath.Join(config.GetConfigDir(), "logs")
-	repoName := git.GetRepoName(m.daemonInfo.RepoPath)
-	logPath := filepath.Join(logDir, fmt.Sprintf("%s.log", repoName))
-	
-	data, err := os.ReadFile(logPath)
-	if err != nil {
+	lines, err := recentLogLines(m.config.ForRepo(m.repoPath), 50)
+	if os.IsNotExist(err) {
 		m.logsViewport.SetContent("No log file found.")
 		return
 	}
-	
-	lines := strings.Split(string(data), "\n")
-	// Get last 50 lines
-	start := len(lines) - 50
-	if start < 0 {
-		start = 0
+	if err != nil {
+		m.logsViewport.SetContent(err.Error())
+		return
 	}
-	m.logLines = lines[start:]
+	m.logLines = lines
 	
 	// Style the log lines
+	clock, now := m.config.Clock(), time.Now()
 	var styledLines []string
 	for _, line := range m.logLines {
+		line = formatLogTime(line, clock, now)
 		if strings.Contains(line, "ERROR") {
 			styledLines = append(styledLines, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(line))
 		} else if strings.Contains(line, "successfully") || strings.Contains(line, "Committed") {
@@ -328,6 +639,72 @@ func (m *model) loadLogs() {
 	m.logsViewport.GotoBottom()
 }
 
+// loadStats shows the activity statistics of the selected repository
+// stateName returns the name the shown repository's state is kept under
+func (m *model) stateName() string {
+	if m.config == nil {
+		return config.StateName(m.repoPath)
+	}
+	return m.config.ForRepo(m.repoPath).StateName()
+}
+
+func (m *model) loadStats() {
+	if m.repoPath == "" {
+		m.statsViewport.SetContent("No daemon running. No statistics available.")
+		return
+	}
+	
+	s, err := stats.Load(m.stateName())
+	if err != nil {
+		m.statsViewport.SetContent(fmt.Sprintf("Failed to load statistics: %v", err))
+		return
+	}
+	report := s.Report(time.Now(), 14)
+	if ledger, err := spend.Load(); err == nil && m.config != nil {
+		report += "\n\n" + ledger.Report(m.config.AIBudget, time.Now())
+	}
+	m.statsViewport.SetContent(report)
+}
+
+// recentLogLines returns the last n lines of the daemon log of the
+// repository rc describes
+func recentLogLines(rc config.RepoConfig, n int) ([]string, error) {
+	switch rc.GetLogDestination() {
+	case config.LogToSyslog:
+		return nil, fmt.Errorf("logs are sent to syslog with tag %s/%s", logdest.Identifier, git.GetRepoName(rc.Path))
+	case config.LogToJournald:
+		return nil, fmt.Errorf("logs are sent to the journal; view them with 'journalctl -t %s'", logdest.Identifier)
+	}
+	
+	data, err := os.ReadFile(rc.LogPath())
+	if err != nil {
+		return nil, err
+	}
+	
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	start := len(lines) - n
+	if start < 0 {
+		start = 0
+	}
+	return lines[start:], nil
+}
+
+// logTimeLayout matches the timestamp written by log.LstdFlags
+const logTimeLayout = "2006/01/02 15:04:05"
+
+// formatLogTime rewrites the leading log timestamp of line in the
+// configured clock format, followed by the relative time
+func formatLogTime(line string, clock timefmt.Clock, now time.Time) string {
+	if len(line) < len(logTimeLayout) {
+		return line
+	}
+	t, err := time.ParseInLocation(logTimeLayout, line[:len(logTimeLayout)], time.Local)
+	if err != nil {
+		return line
+	}
+	return fmt.Sprintf("%s (%s)%s", clock.Stamp(t), timefmt.Relative(t, now), line[len(logTimeLayout):])
+}
+
 func (m *model) updateLogs(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	m.logsViewport, cmd = m.logsViewport.Update(msg)
@@ -339,6 +716,7 @@ func (m *model) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.focusedInput == 1 {
 		var cmd tea.Cmd
 		m.apiKeyInput, cmd = m.apiKeyInput.Update(msg)
+		m.validateField(fieldAPIKey)
 		// If Enter is pressed in input, blur it and return to list
 		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "enter" {
 			m.focusedInput = 0
@@ -350,6 +728,8 @@ func (m *model) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 	} else if m.focusedInput == 2 {
 		var cmd tea.Cmd
 		m.baseURLInput, cmd = m.baseURLInput.Update(msg)
+		m.validateField(fieldBaseURL)
+		m.validateField(fieldModel) // Models differ per base URL
 		// If Enter is pressed in input, blur it and return to list
 		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "enter" {
 			m.focusedInput = 0
@@ -361,6 +741,7 @@ func (m *model) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 	} else if m.focusedInput == 3 {
 		var cmd tea.Cmd
 		m.intervalInput, cmd = m.intervalInput.Update(msg)
+		m.validateField(fieldInterval)
 		// If Enter is pressed in input, blur it and return to list
 		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "enter" {
 			m.focusedInput = 0
@@ -380,7 +761,6 @@ func (m *model) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch selected.title {
 			case "AI Provider":
 				// Cycle through providers
-				providers := []string{"gemini", "openai", "openrouter", "anthropic"}
 				currentIdx := -1
 				for i, p := range providers {
 					if p == m.selectedProvider {
@@ -391,57 +771,62 @@ func (m *model) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 				nextIdx := (currentIdx + 1) % len(providers)
 				m.selectedProvider = providers[nextIdx]
 				m.config.AIProvider = m.selectedProvider
+				// A key from the environment depends on the provider
+				if m.config.APIKeyEnvOnly || m.config.APIKeyEnv != "" {
+					key, name := config.EnvAPIKey(m.selectedProvider)
+					m.config.APIKey, m.config.APIKeyEnv = key, name
+					m.apiKeyInput.SetValue(key)
+				}
+				// Key formats differ per provider
+				if m.apiKeyInput.Value() != "" {
+					m.validateField(fieldAPIKey)
+				}
+				m.selectedModel = m.config.AIModels[m.selectedProvider]
+				m.validateField(fieldModel)
 				m.updateSettingsList()
 			case "API Key":
+				if m.config.APIKeyEnvOnly {
+					m.saveMessage = "Error: the API key is read from the environment (" + strings.Join(config.APIKeyVars(m.selectedProvider), ", ") + ")"
+					return m, nil
+				}
 				m.focusedInput = 1
 				m.apiKeyInput.Focus()
 			case "Base URL":
 				m.focusedInput = 2
 				m.baseURLInput.Focus()
+			case "Model":
+				if !ai.NeedsAPIKey(m.selectedProvider) {
+					m.saveMessage = fmt.Sprintf("Error: provider %s does not use a model", m.selectedProvider)
+					return m, nil
+				}
+				if m.modelsLoading {
+					return m, nil
+				}
+				cmd := m.fetchModels(false)
+				m.updateSettingsList()
+				return m, cmd
 			case "Check Interval":
 				m.focusedInput = 3
 				m.intervalInput.Focus()
 			case "Save":
-				// Validate and save settings
-				m.config.AIProvider = m.selectedProvider
-				m.config.APIKey = m.apiKeyInput.Value()
-				m.config.BaseURL = m.baseURLInput.Value()
-				
-				// Parse interval
-				var interval int
-				if _, err := fmt.Sscanf(m.intervalInput.Value(), "%d", &interval); err != nil || interval <= 0 {
-					m.saveMessage = "Error: Check interval must be a positive number"
-					m.focusedInput = 0
-					m.updateSettingsList()
-					return m, nil
-				}
-				m.config.CheckIntervalMinutes = interval
-				
-				// Validate API key
-				if err := ai.ValidateAPIKey(m.config.AIProvider, m.config.APIKey, m.config.BaseURL); err != nil {
-					m.saveMessage = fmt.Sprintf("Error: %v", err)
+				// Validate every field and refuse to save while any is invalid
+				if !m.validateAll() {
+					m.saveMessage = fmt.Sprintf("Error: fix %d highlighted field(s) before saving", len(m.fieldErrors))
 					m.focusedInput = 0
 					m.updateSettingsList()
 					return m, nil
 				}
 				
-				// Save config
-				if err := config.SaveConfig(m.config); err != nil {
-					m.saveMessage = fmt.Sprintf("Error saving config: %v", err)
-					m.focusedInput = 0
-					m.updateSettingsList()
-					return m, nil
+				// Check a picked model against the provider's list first,
+				// unless the picker fetched it already
+				if m.selectedModel != "" && ai.NeedsAPIKey(m.selectedProvider) && m.modelLists[m.modelListKey()] == nil && !m.demo {
+					if m.modelsLoading {
+						return m, nil
+					}
+					m.saveMessage = fmt.Sprintf("Checking model %s with %s...", m.selectedModel, m.selectedProvider)
+					return m, m.fetchModels(true)
 				}
-				
-				// Success
-				m.saveMessage = "✓ Settings saved successfully!"
-				m.focusedInput = 0
-				m.updateSettingsList()
-				
-				// Clear message after 3 seconds
-				return m, tea.Tick(3*time.Second, func(time.Time) tea.Msg {
-					return clearSaveMsg{}
-				})
+				return m, m.saveSettings()
 			}
 		case "esc":
 			m.focusedInput = 0
@@ -456,40 +841,87 @@ func (m *model) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func (m *model) updateSettingsList() {
-	// Update settings list items to reflect current values
-	apiKeyDisplay := m.apiKeyInput.Value()
-	if apiKeyDisplay != "" {
-		// Mask API key for display
-		if len(apiKeyDisplay) > 8 {
-			apiKeyDisplay = apiKeyDisplay[:4] + "..." + apiKeyDisplay[len(apiKeyDisplay)-4:]
-		} else {
-			apiKeyDisplay = "***"
+// saveSettings saves the settings as edited, which the caller has
+// validated
+func (m *model) saveSettings() tea.Cmd {
+	interval, _ := validateInterval(m.intervalInput.Value())
+	m.config.AIProvider = m.selectedProvider
+	if m.apiKeyInput.Value() != m.config.APIKey {
+		// Typed in by the user, so no longer from the environment
+		m.config.APIKeyEnv = ""
+	}
+	m.config.APIKey = m.apiKeyInput.Value()
+	m.config.BaseURL = strings.TrimSpace(m.baseURLInput.Value())
+	if interval != m.config.CheckIntervalMinutes {
+		// Typed in by the user, so it replaces check_interval
+		m.config.CheckInterval = ""
+	}
+	m.config.CheckIntervalMinutes = interval
+	if m.selectedModel != "" {
+		if m.config.AIModels == nil {
+			m.config.AIModels = make(map[string]string)
 		}
+		m.config.AIModels[m.selectedProvider] = m.selectedModel
 	} else {
-		apiKeyDisplay = "Not set"
+		delete(m.config.AIModels, m.selectedProvider)
+	}
+	
+	// Save config
+	if err := config.SaveConfig(m.config); err != nil {
+		m.saveMessage = fmt.Sprintf("Error saving config: %v", err)
+		m.focusedInput = 0
+		m.updateSettingsList()
+		return nil
+	}
+	
+	// Success
+	m.saveMessage = "✓ Settings saved successfully!"
+	m.focusedInput = 0
+	m.updateSettingsList()
+	
+	// Clear message after 3 seconds
+	return tea.Tick(3*time.Second, func(time.Time) tea.Msg {
+		return clearSaveMsg{}
+	})
+}
+
+func (m *model) updateSettingsList() {
+	// Update settings list items to reflect current values
+	apiKeyDisplay := maskKey(m.apiKeyInput.Value())
+	if m.config.APIKeyEnv != "" && m.apiKeyInput.Value() == m.config.APIKey {
+		apiKeyDisplay += " (from $" + m.config.APIKeyEnv + ")"
+	} else if m.config.APIKeyEnvOnly {
+		apiKeyDisplay += " (environment only)"
 	}
+	baseURLDisplay := orNotSet(m.baseURLInput.Value())
 	
-	baseURLDisplay := m.baseURLInput.Value()
-	if baseURLDisplay == "" {
-		baseURLDisplay = "Not set"
+	intervalDisplay := fmt.Sprintf("%d minutes", m.config.CheckIntervalMinutes)
+	if m.config.CheckInterval != "" {
+		intervalDisplay = m.config.CheckInterval + " (check_interval)"
+	}
+	if m.intervalInput.Value() != fmt.Sprintf("%d", m.config.CheckIntervalMinutes) {
+		intervalDisplay = m.intervalInput.Value() + " minutes (unsaved)"
 	}
 	
 	items := []list.Item{
 		item{title: "AI Provider", desc: fmt.Sprintf("Current: %s", m.selectedProvider)},
-		item{title: "API Key", desc: fmt.Sprintf("Current: %s", apiKeyDisplay)},
-		item{title: "Base URL", desc: fmt.Sprintf("Current: %s", baseURLDisplay)},
-		item{title: "Check Interval", desc: fmt.Sprintf("Current: %d minutes", m.config.CheckIntervalMinutes)},
+		item{title: fieldAPIKey, desc: fmt.Sprintf("Current: %s", apiKeyDisplay), err: m.fieldErrors[fieldAPIKey]},
+		item{title: fieldBaseURL, desc: fmt.Sprintf("Current: %s", baseURLDisplay), err: m.fieldErrors[fieldBaseURL]},
+		item{title: fieldModel, desc: fmt.Sprintf("Current: %s", m.modelDisplay()), err: m.fieldErrors[fieldModel]},
+		item{title: fieldInterval, desc: fmt.Sprintf("Current: %s", intervalDisplay), err: m.fieldErrors[fieldInterval]},
 		item{title: "Save", desc: "Save settings"},
 	}
 	m.settingsList.SetItems(items)
 }
 
-func renderTabs(activeTab int) string {
-	tabs := []string{"Dashboard", "Logs", "Settings"}
+func renderTabs(activeTab int, readOnly bool) string {
+	tabs := []string{"Dashboard", "Logs", "Settings", "Stats", "Changes"}
 	var rendered []string
 	
 	for i, tab := range tabs {
+		if readOnly && (i == tabSettings || i == tabChanges) {
+			continue
+		}
 		if i == activeTab {
 			rendered = append(rendered, lipgloss.NewStyle().
 				Foreground(lipgloss.Color("6")).
@@ -505,15 +937,20 @@ func renderTabs(activeTab int) string {
 	return lipgloss.JoinHorizontal(lipgloss.Left, rendered...)
 }
 
-func renderHelp() string {
+func renderHelp(readOnly bool) string {
+	help := "Press [1-5] to switch tabs | [q] to quit"
+	if readOnly {
+		help = "Press [1], [2] or [4] to switch tabs | [q] to quit"
+	}
 	return lipgloss.NewStyle().
 		Foreground(lipgloss.Color("8")).
-		Render("Press [1-3] to switch tabs | [q] to quit")
+		Render(help)
 }
 
 // List items for settings
 type item struct {
 	title, desc string
+	err         string // Validation error shown next to the value
 }
 
 func (i item) FilterValue() string { return i.title }
@@ -537,5 +974,61 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	}
 	
 	fmt.Fprint(w, style.Render(fmt.Sprintf("%s - %s", i.title, i.desc)))
+	if i.err != "" {
+		fmt.Fprint(w, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("  ✗ "+i.err))
+	}
 }
 
+
+// healthLines renders the state a daemon reports in its health file
+func healthLines(health *config.Health, daemonInfo *config.DaemonInfo, clock timefmt.Clock, now time.Time) string {
+	var lines []string
+	if !daemonInfo.StartedAt.IsZero() {
+		lines = append(lines, "Started: "+clock.Both(daemonInfo.StartedAt, now))
+	}
+	if health.Stale(now) {
+		lines = append(lines, "Last heartbeat: "+clock.Both(health.Heartbeat, now))
+	}
+	if !health.LastCheck.IsZero() {
+		lines = append(lines, "Last check: "+clock.Both(health.LastCheck, now))
+	}
+	if !health.LastCommit.IsZero() {
+		subject := strings.SplitN(health.LastCommitMsg, "\n", 2)[0]
+		lines = append(lines, fmt.Sprintf("Last commit: %s (%s)", clock.Both(health.LastCommit, now), subject))
+	}
+	if health.LastError != "" {
+		problem := strings.SplitN(health.LastError, "\n", 2)[0]
+		lines = append(lines, fmt.Sprintf("Last error: %s (%s)", clock.Both(health.LastErrorAt, now), problem))
+	}
+	if health.ModelFallback != "" {
+		lines = append(lines, "Warning: "+health.ModelFallback)
+	}
+	if len(health.PendingApproval) > 0 {
+		lines = append(lines, fmt.Sprintf("Awaiting approval: %s (run 'autogit approve')", strings.Join(health.PendingApproval, ", ")))
+	}
+	if health.NextRun.IsZero() {
+		lines = append(lines, "Next check: not scheduled")
+	} else {
+		lines = append(lines, "Next check: "+clock.Both(health.NextRun, now))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// aiErrorLines lists the summaries of the provider's recent failures,
+// newest first, or returns "" when it has not failed since it last
+// succeeded
+func aiErrorLines(state breaker.State, now time.Time) string {
+	if len(state.Recent) == 0 {
+		return ""
+	}
+	
+	lines := []string{"  Recent errors:"}
+	if state.Open(now) {
+		lines[0] = fmt.Sprintf("  Skipped until %s after repeated failures. Recent errors:", state.OpenUntil.Format("15:04"))
+	}
+	for i := len(state.Recent) - 1; i >= 0; i-- {
+		sample := state.Recent[i]
+		lines = append(lines, fmt.Sprintf("    %s: %s", timefmt.Relative(sample.At, now), sample.Summary))
+	}
+	return strings.Join(lines, "\n")
+}
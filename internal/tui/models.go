@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// modelsMsg carries the models a provider offers
+type modelsMsg struct {
+	provider string
+	key      string // modelListKey when the models were requested
+	models   []string
+	err      error
+	save     bool // Fetched to check the model before saving
+}
+
+// modelItem is a model in the picker; the empty one stands for the
+// provider's default
+type modelItem string
+
+func (i modelItem) FilterValue() string {
+	if i == "" {
+		return "default"
+	}
+	return string(i)
+}
+
+type modelDelegate struct {
+	fallback string // The provider's default model
+}
+
+func (d modelDelegate) Height() int                             { return 1 }
+func (d modelDelegate) Spacing() int                            { return 0 }
+func (d modelDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+func (d modelDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	i, ok := listItem.(modelItem)
+	if !ok {
+		return
+	}
+	name := string(i)
+	if i == "" {
+		name = fmt.Sprintf("Default (%s)", d.fallback)
+	}
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
+	if index == m.Index() {
+		style = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
+	}
+	fmt.Fprint(w, style.Render(name))
+}
+
+// fetchModels lists the models of the selected provider with the key and
+// base URL typed in settings, which need not be saved yet
+func (m *model) fetchModels(save bool) tea.Cmd {
+	provider, key, baseURL := m.selectedProvider, m.apiKeyInput.Value(), strings.TrimSpace(m.baseURLInput.Value())
+	transport := m.transport
+	listKey := m.modelListKey()
+	m.modelsLoading = true
+	if !save {
+		m.saveMessage = ""
+	}
+	if m.demo {
+		models := []string{m.defaultModel()} // The demo key is never sent
+		return func() tea.Msg { return modelsMsg{provider: provider, key: listKey, models: models, save: save} }
+	}
+	return func() tea.Msg {
+		models, err := ai.ListModels(provider, key, baseURL, transport)
+		return modelsMsg{provider: provider, key: listKey, models: models, err: err, save: save}
+	}
+}
+
+// modelListKey tells apart the model lists of providers and, for
+// OpenAI-compatible APIs, their base URLs
+func (m *model) modelListKey() string {
+	return m.selectedProvider + " " + strings.TrimSpace(m.baseURLInput.Value())
+}
+
+// modelsFetched opens the picker on the models, with the selected one
+// highlighted, or saves the settings once the picked model is found among
+// them when they were fetched to check it
+func (m *model) modelsFetched(msg modelsMsg) tea.Cmd {
+	m.modelsLoading = false
+	if msg.key != m.modelListKey() {
+		return nil // The provider or base URL was changed meanwhile
+	}
+	if msg.err == nil {
+		m.modelLists[msg.key] = msg.models
+	}
+	if msg.save {
+		return m.checkedSave(msg.err)
+	}
+	if msg.err != nil {
+		m.saveMessage = fmt.Sprintf("Error: cannot list the models of %s: %v", msg.provider, msg.err)
+		return nil
+	}
+	
+	items := []list.Item{modelItem("")}
+	selected := 0
+	for _, id := range msg.models {
+		if id == m.selectedModel {
+			selected = len(items)
+		}
+		items = append(items, modelItem(id))
+	}
+	m.modelPicker.SetDelegate(modelDelegate{fallback: m.defaultModel()})
+	m.modelPicker.SetItems(items)
+	m.modelPicker.ResetFilter()
+	m.modelPicker.Select(selected)
+	m.modelPicker.Title = fmt.Sprintf("Models of %s (%d)", msg.provider, len(msg.models))
+	m.pickingModel = true
+	return nil
+}
+
+// checkedSave saves the settings after the provider's models were fetched
+// to check the picked model, unless it is not among them. When the list
+// could not be fetched, as while offline, the model is saved unchecked.
+func (m *model) checkedSave(listErr error) tea.Cmd {
+	m.validateField(fieldModel)
+	if len(m.fieldErrors) > 0 {
+		m.saveMessage = fmt.Sprintf("Error: fix %d highlighted field(s) before saving", len(m.fieldErrors))
+		return nil
+	}
+	cmd := m.saveSettings()
+	if listErr != nil && strings.HasPrefix(m.saveMessage, "✓") {
+		m.saveMessage = fmt.Sprintf("✓ Settings saved; model %s could not be checked: %v", m.selectedModel, listErr)
+	}
+	return cmd
+}
+
+// updateModelPicker moves through and filters the models, picks one on
+// enter and closes the picker on esc
+func (m *model) updateModelPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	filtering := m.modelPicker.FilterState() == list.Filtering
+	switch {
+	case msg.String() == "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case msg.String() == "esc" && m.modelPicker.FilterState() == list.Unfiltered:
+		m.pickingModel = false
+		return m, nil
+	case msg.String() == "enter" && !filtering:
+		if i, ok := m.modelPicker.SelectedItem().(modelItem); ok {
+			m.selectedModel = string(i)
+		}
+		m.validateField(fieldModel)
+		m.pickingModel = false
+		m.updateSettingsList()
+		return m, nil
+	}
+	
+	var cmd tea.Cmd
+	m.modelPicker, cmd = m.modelPicker.Update(msg)
+	return m, cmd
+}
+
+// defaultModel returns the model the selected provider uses when none is
+// picked
+func (m *model) defaultModel() string {
+	return ai.DefaultModel(m.selectedProvider, strings.TrimSpace(m.baseURLInput.Value()))
+}
+
+// modelDisplay describes the picked model for the settings list
+func (m *model) modelDisplay() string {
+	if !ai.NeedsAPIKey(m.selectedProvider) {
+		return "not used"
+	}
+	display := m.selectedModel
+	if display == "" {
+		display = fmt.Sprintf("default (%s)", m.defaultModel())
+	}
+	if m.selectedModel != m.config.AIModels[m.selectedProvider] {
+		display += " (unsaved)"
+	}
+	if m.modelsLoading {
+		display += ", fetching models..."
+	}
+	return display
+}
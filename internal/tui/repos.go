@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/daemon"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/aadityansha/autogit/internal/pathutil"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sidebarWidth is the width taken by the repository switcher, which is
+// only shown when there is more than one repository to pick from
+const sidebarWidth = 28
+
+// repoPaths lists the registered repositories in the order of the config
+// file, followed by any other that a daemon runs for
+func repoPaths(cfg *config.Config, daemons []*config.DaemonInfo) []string {
+	var paths []string
+	for _, r := range cfg.Repos {
+		if r.Path != "" && !containsPath(paths, r.Path) {
+			paths = append(paths, r.Path)
+		}
+	}
+	for _, info := range daemons {
+		if !containsPath(paths, info.RepoPath) {
+			paths = append(paths, info.RepoPath)
+		}
+	}
+	return paths
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if pathutil.Same(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// daemonState names the state of a daemon and its color, "Stopped" when
+// info is nil
+func daemonState(info *config.DaemonInfo, health *config.Health, now time.Time) (string, lipgloss.Color) {
+	status := ""
+	if info != nil {
+		status = info.Status
+	}
+	if health != nil {
+		status = health.Status
+	}
+	switch {
+	case info == nil:
+		return "Stopped", lipgloss.Color("9")
+	case health != nil && health.Stale(now):
+		return "Unresponsive", lipgloss.Color("3")
+	case status == daemon.StatusRunning:
+		return "Running", lipgloss.Color("2")
+	case status == daemon.StatusPaused:
+		return "Paused", lipgloss.Color("3")
+	case status == daemon.StatusRestarting:
+		return "Restarting after a crash", lipgloss.Color("3")
+	}
+	return "Error", lipgloss.Color("9")
+}
+
+// refreshRepos updates the repositories of the switcher and keeps the
+// selected one, or selects the current one, the first a daemon runs for or
+// the first, in that order
+func (m *model) refreshRepos() {
+	m.repos = repoPaths(m.config, m.daemons)
+	if m.repoPath != "" && containsPath(m.repos, m.repoPath) {
+		return
+	}
+	m.repoPath = ""
+	if m.root != "" && containsPath(m.repos, m.root) {
+		m.repoPath = m.root
+	} else if len(m.daemons) > 0 {
+		m.repoPath = m.daemons[0].RepoPath
+	} else if len(m.repos) > 0 {
+		m.repoPath = m.repos[0]
+	}
+}
+
+// switchRepo selects the repository offset places away in the switcher and
+// shows its dashboard, logs, stats and changes
+func (m *model) switchRepo(offset int) {
+	if len(m.repos) < 2 {
+		return
+	}
+	current := 0
+	for i, p := range m.repos {
+		if pathutil.Same(p, m.repoPath) {
+			current = i
+			break
+		}
+	}
+	m.repoPath = m.repos[(current+offset+len(m.repos))%len(m.repos)]
+	m.ignoreMessage = ""
+	m.updateDashboard()
+	m.loadLogs()
+	m.loadStats()
+	m.changesViewport.GotoTop()
+	if m.activeTab == tabChanges {
+		m.loadChanges()
+	}
+}
+
+// selectedDaemon returns the daemon of the selected repository, or nil
+// when it does not run
+func (m *model) selectedDaemon() *config.DaemonInfo {
+	return config.DaemonFor(m.daemons, m.repoPath)
+}
+
+// showSidebar reports whether there is more than one repository to switch
+// between
+func (m *model) showSidebar() bool {
+	return len(m.repos) > 1
+}
+
+// renderSidebar lists the repositories with the state of their daemon,
+// marking the selected one
+func (m *model) renderSidebar(height int) string {
+	now := time.Now()
+	lines := []string{lipgloss.NewStyle().Bold(true).Render("Repositories"), ""}
+	for _, path := range m.repos {
+		info := config.DaemonFor(m.daemons, path)
+		state, color := daemonState(info, config.HealthFor(info), now)
+		
+		name := git.GetRepoName(path)
+		if limit := sidebarWidth - 6; len(name) > limit {
+			name = name[:limit-1] + "…"
+		}
+		marker, style := "  ", lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
+		if pathutil.Same(path, m.repoPath) {
+			marker, style = "> ", lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
+		}
+		lines = append(lines,
+			style.Render(marker+name),
+			"    "+lipgloss.NewStyle().Foreground(color).Render("● "+strings.ToLower(state)))
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("[ and ] to switch"))
+	
+	return lipgloss.NewStyle().
+		Width(sidebarWidth-2).
+		Height(height).
+		MarginRight(2).
+		Render(strings.Join(lines, "\n"))
+}
+
+// repoSummary describes the settings in effect for the selected repository,
+// which the global settings above may be overridden for
+func (m *model) repoSummary() string {
+	if m.repoPath == "" {
+		return ""
+	}
+	rc := m.config.ForRepo(m.repoPath)
+	trigger := rc.Trigger
+	if trigger == "" {
+		trigger = config.TriggerInterval
+	}
+	return fmt.Sprintf("In effect for %s:\n  Check interval: %s\n  Trigger: %s\n  Staging: %s\nRun 'autogit config set --local' in the repository to change them there.",
+		git.GetRepoName(m.repoPath), rc.GetCheckTiming(), trigger, rc.GetStagingMode())
+}
@@ -0,0 +1,446 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/config"
+	"github.com/aadityansha/autogit/internal/git"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Steps of the setup wizard, in order
+const (
+	stepProvider = iota
+	stepKey
+	stepInterval
+	stepStaging
+	stepInit
+	stepSummary
+)
+
+// choice is an option of a setup step
+type choice struct {
+	value, label, desc string
+}
+
+var providerChoices = []choice{
+	{"gemini", "Gemini", "Google's models, with a free tier"},
+	{"openai", "OpenAI", "Or any OpenAI-compatible API, set its base URL in 'autogit menu'"},
+	{"openrouter", "OpenRouter", "Models of many vendors with one key"},
+	{"anthropic", "Anthropic", "Claude models"},
+	{"none", "None", "Local messages derived from the changed files, no API key"},
+}
+
+var stagingChoices = []choice{
+	{config.StagingAll, "All changes", "New files included, like git add -A"},
+	{config.StagingTracked, "Tracked files", "Only files git already tracks, like git add -u"},
+	{config.StagingStaged, "Staged only", "Nothing is staged for you; only what you stage is committed"},
+}
+
+var initChoices = []choice{
+	{"yes", "Yes", "Start the daemon for this repository now"},
+	{"no", "No", "Run 'autogit init' in a repository later"},
+}
+
+// SetupResult is what the user decided in the setup wizard
+type SetupResult struct {
+	Saved bool // The settings were written to the config file
+	Init  bool // The user asked to start autogit for the repository
+}
+
+// keyCheckMsg carries the result of checking the API key with the provider
+type keyCheckMsg struct {
+	key string
+	err error
+}
+
+// setupModel walks a new user through the settings autogit needs before
+// its first commit, one step at a time. esc goes back a step.
+type setupModel struct {
+	config    *config.Config
+	transport http.RoundTripper // Carries the key check, see ai.TransportFor
+	repoPath  string // Repository that may be initialized, "" for none
+	step     int
+	cursor   int // Highlighted choice of the current step
+	
+	provider      string
+	keyInput      textinput.Model
+	keyChecking   bool
+	keyUnverified string // Key that could not be checked, accepted on a second enter
+	intervalInput textinput.Model
+	staging       string
+	init          bool
+	
+	err    string // Problem with the current step
+	result SetupResult
+}
+
+// RunSetup runs the setup wizard. repoPath is the repository offered for
+// initialization, "" to skip that step.
+func RunSetup(repoPath string) (SetupResult, error) {
+	m, err := newSetupModel(repoPath)
+	if err != nil {
+		return SetupResult{}, err
+	}
+	final, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return SetupResult{}, fmt.Errorf("TUI error: %w", err)
+	}
+	return final.(*setupModel).result, nil
+}
+
+func newSetupModel(repoPath string) (*setupModel, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport, err := ai.TransportFor(cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+	
+	m := &setupModel{config: cfg, transport: transport, repoPath: repoPath, provider: cfg.AIProvider, staging: cfg.StagingMode, init: repoPath != ""}
+	if m.staging == "" || m.staging == config.StagingPatterns {
+		m.staging = config.StagingAll
+	}
+	
+	m.keyInput = textinput.New()
+	m.keyInput.Placeholder = "Paste your API key"
+	m.keyInput.EchoMode = textinput.EchoPassword
+	m.keyInput.CharLimit = 200
+	m.keyInput.Width = 50
+	m.keyInput.SetValue(cfg.APIKey)
+	
+	m.intervalInput = textinput.New()
+	m.intervalInput.Placeholder = strconv.Itoa(int(config.DefaultCheckInterval.Minutes()))
+	m.intervalInput.CharLimit = 10
+	m.intervalInput.Width = 20
+	m.intervalInput.SetValue(strconv.Itoa(int(cfg.GetCheckInterval().Minutes())))
+	
+	m.enter(stepProvider)
+	return m, nil
+}
+
+func (m *setupModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *setupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case keyCheckMsg:
+		return m, m.keyChecked(msg)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			if m.step == stepProvider {
+				return m, tea.Quit
+			}
+			m.back()
+			return m, nil
+		}
+		
+		switch m.step {
+		case stepKey:
+			return m.updateKey(msg)
+		case stepInterval:
+			return m.updateInterval(msg)
+		case stepSummary:
+			if msg.String() == "enter" {
+				return m, m.save()
+			}
+			return m, nil
+		}
+		return m.updateChoice(msg)
+	}
+	return m, nil
+}
+
+// choices returns the options of the current step, nil for a step with
+// an input field
+func (m *setupModel) choices() []choice {
+	switch m.step {
+	case stepProvider:
+		return providerChoices
+	case stepStaging:
+		return stagingChoices
+	case stepInit:
+		return initChoices
+	}
+	return nil
+}
+
+// selected returns the value the current choice step starts from
+func (m *setupModel) selected() string {
+	switch m.step {
+	case stepProvider:
+		return m.provider
+	case stepStaging:
+		return m.staging
+	case stepInit:
+		if !m.init {
+			return "no"
+		}
+	}
+	return "yes"
+}
+
+// enter moves to step, focusing its input or highlighting its current
+// choice
+func (m *setupModel) enter(step int) tea.Cmd {
+	m.step, m.err, m.cursor = step, "", 0
+	m.keyInput.Blur()
+	m.intervalInput.Blur()
+	for i, c := range m.choices() {
+		if c.value == m.selected() {
+			m.cursor = i
+		}
+	}
+	switch step {
+	case stepKey:
+		return m.keyInput.Focus()
+	case stepInterval:
+		return m.intervalInput.Focus()
+	}
+	return nil
+}
+
+// next moves past the current step, skipping steps that do not apply
+func (m *setupModel) next() tea.Cmd {
+	step := m.step + 1
+	if step == stepKey && !ai.NeedsAPIKey(m.provider) {
+		step++
+	}
+	if step == stepInit && m.repoPath == "" {
+		step++
+	}
+	return m.enter(step)
+}
+
+// back returns to the previous step, skipping steps that do not apply
+func (m *setupModel) back() {
+	step := m.step - 1
+	if step == stepInit && m.repoPath == "" {
+		step--
+	}
+	if step == stepKey && !ai.NeedsAPIKey(m.provider) {
+		step--
+	}
+	m.enter(step)
+}
+
+func (m *setupModel) updateChoice(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	choices := m.choices()
+	switch msg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(choices)-1 {
+			m.cursor++
+		}
+	case "enter":
+		value := choices[m.cursor].value
+		switch m.step {
+		case stepProvider:
+			if value != m.provider {
+				m.provider = value
+				m.keyUnverified = ""
+				// A key from the environment depends on the provider
+				if m.config.APIKeyEnvOnly || m.config.APIKeyEnv != "" {
+					key, _ := config.EnvAPIKey(value)
+					m.keyInput.SetValue(key)
+				}
+			}
+		case stepStaging:
+			m.staging = value
+		case stepInit:
+			m.init = value == "yes"
+		}
+		return m, m.next()
+	}
+	return m, nil
+}
+
+func (m *setupModel) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.keyChecking {
+		return m, nil
+	}
+	if msg.String() != "enter" {
+		if m.config.APIKeyEnvOnly {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.keyInput, cmd = m.keyInput.Update(msg)
+		m.err = ""
+		return m, cmd
+	}
+	
+	key := strings.TrimSpace(m.keyInput.Value())
+	if err := validateAPIKey(m.provider, key); err != nil {
+		m.err = err.Error()
+		if m.config.APIKeyEnvOnly && key == "" {
+			m.err = fmt.Sprintf("set %s and run 'autogit setup' again", strings.Join(config.APIKeyVars(m.provider), " or "))
+		}
+		return m, nil
+	}
+	if key == m.keyUnverified {
+		return m, m.next()
+	}
+	
+	// Ask the provider, which costs no tokens
+	m.keyChecking = true
+	provider, baseURL, transport := m.provider, m.config.BaseURL, m.transport
+	return m, func() tea.Msg {
+		_, err := ai.Ping(provider, key, baseURL, transport)
+		return keyCheckMsg{key: key, err: err}
+	}
+}
+
+// keyChecked moves on once the provider accepted the key. A key that
+// could not be checked, e.g. while offline, is kept on a second enter.
+func (m *setupModel) keyChecked(msg keyCheckMsg) tea.Cmd {
+	m.keyChecking = false
+	if m.step != stepKey || msg.key != strings.TrimSpace(m.keyInput.Value()) {
+		return nil // Edited meanwhile
+	}
+	switch {
+	case msg.err == nil:
+		return m.next()
+	case errors.Is(msg.err, ai.ErrUnauthorized):
+		m.err = fmt.Sprintf("%s rejected the key: %v", m.provider, msg.err)
+	default:
+		m.keyUnverified = msg.key
+		m.err = fmt.Sprintf("Could not check the key with %s: %v\nPress enter again to keep it anyway.", m.provider, msg.err)
+	}
+	return nil
+}
+
+func (m *setupModel) updateInterval(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		m.intervalInput, cmd = m.intervalInput.Update(msg)
+		m.err = ""
+		return m, cmd
+	}
+	if _, err := validateInterval(m.intervalInput.Value()); err != nil {
+		m.err = err.Error()
+		return m, nil
+	}
+	return m, m.next()
+}
+
+// save writes the choices to the config file and ends the wizard
+func (m *setupModel) save() tea.Cmd {
+	interval, _ := validateInterval(m.intervalInput.Value())
+	cfg := m.config
+	cfg.AIProvider = m.provider
+	if ai.NeedsAPIKey(m.provider) && !cfg.APIKeyEnvOnly {
+		key := strings.TrimSpace(m.keyInput.Value())
+		if key != cfg.APIKey {
+			// Typed in by the user, so no longer from the environment
+			cfg.APIKeyEnv = ""
+		}
+		cfg.APIKey = key
+	}
+	if interval != cfg.CheckIntervalMinutes {
+		// Typed in by the user, so it replaces check_interval
+		cfg.CheckInterval = ""
+	}
+	cfg.CheckIntervalMinutes = interval
+	cfg.StagingMode = m.staging
+	if m.staging == config.StagingAll {
+		cfg.StagingMode = ""
+	}
+	
+	if err := config.SaveConfig(cfg); err != nil {
+		m.err = fmt.Sprintf("Error saving config: %v", err)
+		return nil
+	}
+	m.result = SetupResult{Saved: true, Init: m.init}
+	return tea.Quit
+}
+
+func (m *setupModel) View() string {
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	faint := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	
+	var b strings.Builder
+	b.WriteString(title.Render("autogit setup") + faint.Render(fmt.Sprintf("  step %d of %d", m.step+1, stepSummary+1)) + "\n\n")
+	
+	switch m.step {
+	case stepProvider:
+		b.WriteString("Which AI provider should write your commit messages?\n\n")
+	case stepKey:
+		b.WriteString(fmt.Sprintf("API key for %s:\n\n", m.provider))
+		if m.config.APIKeyEnvOnly {
+			b.WriteString(faint.Render(fmt.Sprintf("Read from %s, as api_key_env_only is set", strings.Join(config.APIKeyVars(m.provider), " or "))) + "\n")
+		} else {
+			b.WriteString(m.keyInput.View() + "\n")
+		}
+		if m.keyChecking {
+			b.WriteString(faint.Render(fmt.Sprintf("Checking the key with %s...", m.provider)) + "\n")
+		}
+	case stepInterval:
+		b.WriteString("Check for changes every how many minutes?\n\n")
+		b.WriteString(m.intervalInput.View() + "\n")
+		b.WriteString(faint.Render(fmt.Sprintf("Between %d and %d", config.MinCheckIntervalMinutes, config.MaxCheckIntervalMinutes)) + "\n")
+	case stepStaging:
+		b.WriteString("Which changes should be committed?\n\n")
+	case stepInit:
+		b.WriteString(fmt.Sprintf("Start autogit for %s now?\n\n", m.repoPath))
+	case stepSummary:
+		b.WriteString(m.summary())
+	}
+	
+	for i, c := range m.choices() {
+		line := fmt.Sprintf("  %-12s %s", c.label, faint.Render(c.desc))
+		if i == m.cursor {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true).Render(fmt.Sprintf("> %-12s", c.label)) + " " + faint.Render(c.desc)
+		}
+		b.WriteString(line + "\n")
+	}
+	
+	if m.err != "" {
+		b.WriteString("\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.err) + "\n")
+	}
+	
+	help := "enter to continue | esc to go back | ctrl+c to quit without saving"
+	if m.choices() != nil {
+		help = "↑/↓ to choose | " + help
+	}
+	if m.step == stepSummary {
+		help = "enter to save | esc to go back | ctrl+c to quit without saving"
+	}
+	b.WriteString("\n" + faint.Render(help) + "\n")
+	return b.String()
+}
+
+// summary lists the choices before they are saved
+func (m *setupModel) summary() string {
+	lines := []string{"Ready to save:", "", "  AI provider:    " + m.provider}
+	if ai.NeedsAPIKey(m.provider) {
+		lines = append(lines, "  API key:        "+maskKey(strings.TrimSpace(m.keyInput.Value())))
+	}
+	lines = append(lines,
+		"  Check interval: "+strings.TrimSpace(m.intervalInput.Value())+" minutes",
+		"  Staging:        "+m.staging)
+	if m.repoPath != "" {
+		answer := "no"
+		if m.init {
+			answer = "yes, " + git.GetRepoName(m.repoPath)
+		}
+		lines = append(lines, "  Start autogit:  "+answer)
+	}
+	lines = append(lines, "", "The model, base URL and everything else can be changed later in 'autogit menu' or with 'autogit config'.", "")
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aadityansha/autogit/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/activeterm"
+	bm "github.com/charmbracelet/wish/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// DefaultSSHAddr is where 'autogit status --serve-ssh' listens by default
+const DefaultSSHAddr = "127.0.0.1:2222"
+
+// GetHostKeyPath returns the SSH host key of the guest view, created on
+// first use
+func GetHostKeyPath() string {
+	return filepath.Join(config.GetConfigDir(), "ssh_host_ed25519")
+}
+
+// NewSSHServer returns a server that shows every SSH session the read-only
+// dashboard. Only the keys listed in authorizedKeys may connect, and the
+// sessions can neither open the settings nor change anything, so the view
+// can be reached from anywhere without exposing the status page's API.
+func NewSSHServer(addr, authorizedKeys string) (*ssh.Server, error) {
+	if _, err := os.Stat(authorizedKeys); err != nil {
+		return nil, fmt.Errorf("no authorized keys to let in: %w", err)
+	}
+	
+	// The styles are rendered for the session's terminal, not the one the
+	// server was started from
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	
+	server, err := wish.NewServer(
+		wish.WithAddress(addr),
+		wish.WithHostKeyPath(GetHostKeyPath()),
+		wish.WithAuthorizedKeys(authorizedKeys),
+		wish.WithMiddleware(
+			bm.Middleware(guestHandler),
+			activeterm.Middleware(),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH server: %w", err)
+	}
+	return server, nil
+}
+
+func guestHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+	m, err := NewReadOnlyModel()
+	if err != nil {
+		wish.Fatalln(s, "autogit:", err)
+		return nil, nil
+	}
+	return m, []tea.ProgramOption{tea.WithAltScreen()}
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aadityansha/autogit/internal/ai"
+	"github.com/aadityansha/autogit/internal/config"
+)
+
+// Settings fields that are validated, named as in the settings list
+const (
+	fieldAPIKey   = "API Key"
+	fieldBaseURL  = "Base URL"
+	fieldModel    = "Model"
+	fieldInterval = "Check Interval"
+)
+
+// validateAPIKey checks the key format for provider without contacting it
+func validateAPIKey(provider, key string) error {
+	return ai.ValidateAPIKey(provider, key, "")
+}
+
+// validateBaseURL requires an absolute http(s) URL when one is given
+func validateBaseURL(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("not a valid URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL must start with http:// or https://")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL must include a host")
+	}
+	return nil
+}
+
+// validateInterval parses a check interval in minutes
+func validateInterval(raw string) (int, error) {
+	interval, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("must be a whole number of minutes")
+	}
+	if interval < config.MinCheckIntervalMinutes || interval > config.MaxCheckIntervalMinutes {
+		return 0, fmt.Errorf("must be between %d and %d minutes", config.MinCheckIntervalMinutes, config.MaxCheckIntervalMinutes)
+	}
+	return interval, nil
+}
+
+// validateField re-checks a single field and records or clears its error
+func (m *model) validateField(field string) {
+	var err error
+	switch field {
+	case fieldAPIKey:
+		err = validateAPIKey(m.selectedProvider, m.apiKeyInput.Value())
+		if err != nil && m.config.APIKeyEnvOnly && m.apiKeyInput.Value() == "" {
+			err = fmt.Errorf("set %s", strings.Join(config.APIKeyVars(m.selectedProvider), " or "))
+		}
+	case fieldBaseURL:
+		err = validateBaseURL(m.baseURLInput.Value())
+	case fieldModel:
+		// Checked against the provider's list once it was fetched
+		if models, ok := m.modelLists[m.modelListKey()]; ok && m.selectedModel != "" && ai.NeedsAPIKey(m.selectedProvider) {
+			err = ai.CheckModel(m.selectedProvider, m.selectedModel, models)
+		}
+	case fieldInterval:
+		_, err = validateInterval(m.intervalInput.Value())
+	}
+	
+	if err != nil {
+		m.fieldErrors[field] = err.Error()
+	} else {
+		delete(m.fieldErrors, field)
+	}
+}
+
+// validateAll checks every field and reports whether all are valid
+func (m *model) validateAll() bool {
+	for _, field := range []string{fieldAPIKey, fieldBaseURL, fieldModel, fieldInterval} {
+		m.validateField(field)
+	}
+	return len(m.fieldErrors) == 0
+}
@@ -0,0 +1,70 @@
+package vcs
+
+import (
+	"errors"
+
+	"github.com/aadityansha/autogit/internal/git"
+)
+
+// ErrUnsupported is returned for operations a version control system
+// cannot do through autogit yet
+var ErrUnsupported = errors.New("not supported by this version control system")
+
+// Repo is what the daemon needs of the version control system of a
+// repository: reading its changes, committing and pushing them, and the
+// history and remote state its features inspect. *git.Repo implements it
+// for git repositories, *jj.Repo for Jujutsu repositories colocated with
+// git and *hg.Repo for Mercurial repositories. *gogit.Repo stands in for
+// *git.Repo where the git binary is not installed.
+type Repo interface {
+	// Identity
+	Root() string
+	RepoID() string
+	SetRepoID(id string) error
+	
+	// Changes
+	DetectMode() (git.Mode, error)
+	UseMode(m git.Mode)
+	UseQuiet()
+	CheckState() error
+	HasChanges(exclude ...string) (bool, error)
+	HasStaged() (bool, error)
+	ChangedFiles(exclude ...string) ([]string, error)
+	GetFullDiff(exclude ...string) (string, error)
+	FullDiffPaths(paths []string) (string, error)
+	LFSFiles(files []string) ([]string, error)
+	LFSReady() error
+	
+	// Committing
+	AddAll(exclude ...string) error
+	AddPaths(paths []string) error
+	Commit(message string) error
+	CommitPaths(message string, paths []string) error
+	SoftReset(ref string) error
+	Identity() (name, email string)
+	
+	// History
+	Unborn() bool
+	Log(limit int) ([]git.LogEntry, error)
+	LastCommitLines() (int, int, error)
+	LinesSince(commit string) (int, int, error)
+	MergeBase(ref string) (string, error)
+	AheadBehind(ref string) (ahead, behind int, err error)
+	CreateBundle(path, base string) error
+	
+	// Branches and remotes
+	CurrentBranch() string
+	DefaultBranch() (string, error)
+	BranchExists(name string) bool
+	CheckoutNew(name string) error
+	RewindToUpstream(branch string) error
+	FetchUpstream() error
+	ForeignAuthors() ([]string, error)
+	Push() error
+	PushForce() error
+	VerifyPush() error
+	Refs() (string, error)
+	PushMirror(target string, bundle bool) error
+}
+
+var _ Repo = (*git.Repo)(nil)
@@ -0,0 +1,116 @@
+package wsl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Info describes how a repository path relates to the WSL boundary
+type Info struct {
+	InWSL         bool   // autogit itself runs inside a WSL distribution
+	Distro        string // WSL distribution name, if known
+	CrossBoundary bool   // The path lives on the other side of the Linux/Windows boundary
+	Mount         string // Mount point or share the path was found under
+	FSType        string // File system type of the mount (e.g. 9p, drvfs)
+}
+
+// Detect reports whether autogit runs under WSL and whether path is on a
+// file system shared across the WSL boundary. On such paths inotify (and
+// ReadDirectoryChangesW from the Windows side) misses most changes, and
+// every git call crosses the 9p file server, which is very slow.
+func Detect(path string) Info {
+	var info Info
+	
+	if runtime.GOOS == "windows" {
+		// \\wsl$\Distro\... or \\wsl.localhost\Distro\...
+		p := strings.ToLower(strings.ReplaceAll(path, "/", `\`))
+		for _, prefix := range []string{`\\wsl$\`, `\\wsl.localhost\`} {
+			if strings.HasPrefix(p, prefix) {
+				info.CrossBoundary = true
+				info.Mount = path[:len(prefix)]
+				info.FSType = "9p"
+				if rest := path[len(prefix):]; rest != "" {
+					info.Distro = strings.SplitN(strings.ReplaceAll(rest, "/", `\`), `\`, 2)[0]
+				}
+			}
+		}
+		return info
+	}
+	
+	if runtime.GOOS != "linux" || !inWSL() {
+		return info
+	}
+	info.InWSL = true
+	info.Distro = os.Getenv("WSL_DISTRO_NAME")
+	
+	mount, fsType := mountFor(path)
+	info.Mount = mount
+	info.FSType = fsType
+	switch fsType {
+	case "9p", "drvfs", "v9fs":
+		info.CrossBoundary = true
+	}
+	return info
+}
+
+func inWSL() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" || os.Getenv("WSL_INTEROP") != "" {
+		return true
+	}
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(data)), "microsoft")
+}
+
+// mountFor returns the longest mount point in /proc/mounts that contains
+// path, along with its file system type
+func mountFor(path string) (string, string) {
+	f, err := os.Open("/proc/mounts")
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+	
+	path = filepath.Clean(path)
+	best, bestType := "", ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		// Spaces in mount points are escaped as \040
+		mount := strings.ReplaceAll(fields[1], `\040`, " ")
+		if !under(path, mount) || len(mount) <= len(best) {
+			continue
+		}
+		best, bestType = mount, fields[2]
+	}
+	return best, bestType
+}
+
+func under(path, mount string) bool {
+	if mount == "/" {
+		return true
+	}
+	return path == mount || strings.HasPrefix(path, mount+"/")
+}
+
+// Describe returns a one-line human readable summary of info
+func (i Info) Describe() string {
+	switch {
+	case i.CrossBoundary && i.InWSL:
+		return "repository is on a Windows drive (" + i.Mount + ", " + i.FSType + ") accessed from WSL"
+	case i.CrossBoundary:
+		return "repository is inside WSL (" + i.Mount + ") accessed from Windows"
+	case i.InWSL:
+		return "running in WSL, repository is on the Linux file system"
+	default:
+		return "not running in WSL"
+	}
+}